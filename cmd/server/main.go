@@ -2,31 +2,50 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+	_ "time/tzdata"
 
+	"github.com/redis/go-redis/v9"
+
+	"ling/internal/delivery"
 	"ling/internal/httpapi"
 	"ling/internal/knowledge"
 	"ling/internal/llm"
+	"ling/internal/logging"
+	"ling/internal/metrics"
+	"ling/internal/ratelimit"
+	"ling/internal/report"
 	"ling/internal/service"
 	"ling/internal/store"
+	"ling/internal/tracing"
 )
 
 func main() {
-	if err := loadConfigFile("ling.ini"); err != nil {
-		log.Printf("load ling.ini failed: %v", err)
-	}
+	iniErr := loadConfigFile("ling.ini")
 	// Backward compatibility: still accept .env when present.
-	if err := loadConfigFile(".env"); err != nil {
-		log.Printf("load .env failed: %v", err)
+	envErr := loadConfigFile(".env")
+	// 日志配置可能来自配置文件，因此在加载配置文件之后再初始化。
+	if err := logging.Setup(os.Stderr, os.Getenv("CITYLING_LOG_LEVEL"), envOrDefault("CITYLING_LOG_FORMAT", logging.FormatJSON)); err != nil {
+		slog.Warn("invalid CITYLING_LOG_LEVEL, using info", "err", err)
+	}
+	if iniErr != nil {
+		slog.Warn("load ling.ini failed", "err", iniErr)
+	}
+	if envErr != nil {
+		slog.Warn("load .env failed", "err", envErr)
 	}
 
 	addr := resolveListenAddr()
@@ -35,24 +54,86 @@ func main() {
 
 	st, err := store.NewByEngine(storeEngine, dataFile)
 	if err != nil {
-		log.Fatalf("init store failed: %v", err)
+		slog.Error("init store failed", "engine", storeEngine, "err", err)
+		os.Exit(1)
 	}
+	// 之后的失败通过 exitCode 退出，先让下面的 defer 关闭存储、刷出链路数据。
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
 	if closer, ok := st.(io.Closer); ok {
 		defer func() {
 			if err := closer.Close(); err != nil {
-				log.Printf("store close failed: %v", err)
+				slog.Error("store close failed", "err", err)
 			}
 		}()
 	}
 
-	svc := service.New(st, knowledge.BaseKnowledge)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    os.Getenv("CITYLING_TRACE_EXPORTER"),
+		ServiceName: envOrDefault("CITYLING_TRACE_SERVICE_NAME", "cityling-backend"),
+		SampleRatio: parseEnvFloat("CITYLING_TRACE_SAMPLE_RATIO", 1),
+	})
+	if err != nil {
+		slog.Error("init tracing failed, tracing disabled", "err", err)
+	} else {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				slog.Error("tracing shutdown failed", "err", err)
+			}
+		}()
+	}
+
+	// 服务使用带耗时统计与链路追踪的存储；关闭时仍通过原始实例。
+	observed := store.Observe(st, func(ctx context.Context, operation string, start time.Time, err error) {
+		metrics.ObserveStore(operation, start, err)
+		tracing.ObserveStore(ctx, operation, start, err)
+	})
+	svc := service.New(observed, knowledge.BaseKnowledge)
 	if llmClient := initLLMClientFromEnv(); llmClient != nil {
 		svc.SetLLMClient(llmClient)
-		log.Printf("llm integration enabled")
+		slog.Info("llm integration enabled")
 	} else {
-		log.Printf("llm integration disabled, using local knowledge fallback only")
+		slog.Warn("llm integration disabled, using local knowledge fallback only")
+	}
+	svc.SetUpstreamProbeTTL(time.Duration(parseEnvInt("CITYLING_READYZ_PROBE_TTL_SECONDS", 0)) * time.Second)
+	svc.SetLocationPrecision(parseEnvInt("CITYLING_LOCATION_PRECISION", 3))
+	badgeRulesFile := envOrDefault("CITYLING_BADGE_RULES_FILE", "data/badge_rules.json")
+	if status, err := svc.SetBadgeRulesFile(context.Background(), badgeRulesFile); err != nil {
+		slog.Warn("load badge rules failed, keep built-in rules", "path", badgeRulesFile, "err", err)
+	} else {
+		slog.Info("badge rules loaded", "source", status.Source, "badges", status.Badges)
 	}
 	handler := httpapi.NewHandler(svc)
+	handler.SetAdminToken(os.Getenv("CITYLING_ADMIN_TOKEN"))
+	if closeRateLimit := configureRateLimit(handler); closeRateLimit != nil {
+		defer closeRateLimit()
+	}
+	palettePath := envOrDefault("CITYLING_BRAND_PALETTE", report.DefaultPalettePath)
+	palette, err := report.LoadPalette(palettePath)
+	if err != nil {
+		slog.Warn("load brand palette failed, using defaults", "path", palettePath, "err", err)
+	}
+	renderer := report.NewRenderer(palette)
+	handler.SetReportRenderer(renderer)
+	svc.SetReportRenderer(renderer)
+	// 投递调度在 HTTP 请求排空后才停止，让进行中的投递尽量完成。
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	var scheduler sync.WaitGroup
+	if configureDeliveryChannels(svc) {
+		interval := time.Duration(parseEnvInt("CITYLING_DELIVERY_INTERVAL_SECONDS", 60)) * time.Second
+		scheduler.Add(1)
+		go func() {
+			defer scheduler.Done()
+			svc.RunDeliveryScheduler(schedulerCtx, interval)
+		}()
+		slog.Info("report delivery scheduler started", "channels", svc.DeliveryChannels(), "interval", interval.String())
+	}
 	router := httpapi.NewRouter(handler)
 
 	server := &http.Server{
@@ -61,10 +142,134 @@ func main() {
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	log.Printf("city ling backend listening on %s", addr)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server failed: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	slog.Info("city ling backend listening", "addr", addr)
+	err = serve(ctx, server, svc, shutdownConfig{
+		delay:   time.Duration(parseEnvInt("CITYLING_SHUTDOWN_DELAY_SECONDS", 0)) * time.Second,
+		timeout: time.Duration(parseEnvInt("CITYLING_SHUTDOWN_TIMEOUT_SECONDS", 30)) * time.Second,
+	})
+	if err != nil {
+		slog.Error("server failed", "err", err)
+		exitCode = 1
 	}
+	stopScheduler()
+	scheduler.Wait()
+	slog.Info("city ling backend stopped")
+}
+
+type shutdownConfig struct {
+	// delay 为收到信号后、停止接受连接前的等待时间，期间 /readyz 返回 503，便于负载均衡先摘除实例。
+	delay time.Duration
+	// timeout 为等待进行中请求结束的上限，到期后强制关闭剩余连接。
+	timeout time.Duration
+}
+
+// serve 运行 server 直到 ctx 结束（收到 SIGINT/SIGTERM），然后按 cfg 优雅退出：先标记服务不就绪，
+// 再停止接受新连接并排空进行中的请求。
+func serve(ctx context.Context, server *http.Server, svc *service.Service, cfg shutdownConfig) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutdown signal received, draining requests", "delay", cfg.delay.String(), "timeout", cfg.timeout.String())
+	svc.BeginShutdown()
+	if cfg.delay > 0 {
+		time.Sleep(cfg.delay)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.timeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		_ = server.Close()
+		return fmt.Errorf("drain requests: %w", err)
+	}
+	return nil
+}
+
+// configureRateLimit 根据环境变量开启限流，返回需要在退出时调用的清理函数（共享后端的连接）。
+// 配置有误时记录日志并按默认值继续，不阻止服务启动。
+func configureRateLimit(handler *httpapi.Handler) func() {
+	limits, err := ratelimit.ParseLimits(os.Getenv("CITYLING_RATE_LIMITS"))
+	if err != nil {
+		slog.Warn("invalid CITYLING_RATE_LIMITS, using defaults", "err", err)
+		limits = nil
+	}
+	childLimits, err := ratelimit.ParseLimits(os.Getenv("CITYLING_RATE_LIMITS_PER_CHILD"))
+	if err != nil {
+		slog.Warn("invalid CITYLING_RATE_LIMITS_PER_CHILD, per-child limits disabled", "err", err)
+		childLimits = nil
+	}
+	cfg := httpapi.RateLimitConfig{
+		Limits:      limits,
+		ChildLimits: childLimits,
+		TrustProxy:  strings.EqualFold(strings.TrimSpace(os.Getenv("CITYLING_RATE_LIMIT_TRUST_PROXY")), "true"),
+	}
+	var cleanup func()
+	backend := strings.ToLower(strings.TrimSpace(envOrDefault("CITYLING_RATE_LIMIT_BACKEND", "memory")))
+	switch backend {
+	case "off":
+		slog.Warn("rate limiting disabled")
+		return nil
+	case "redis":
+		opts, err := redis.ParseURL(os.Getenv("CITYLING_RATE_LIMIT_REDIS_URL"))
+		if err != nil {
+			slog.Warn("invalid CITYLING_RATE_LIMIT_REDIS_URL, falling back to in-memory rate limiting", "err", err)
+			backend = "memory"
+			cfg.Backend = ratelimit.NewMemoryBackend()
+			break
+		}
+		client := redis.NewClient(opts)
+		cfg.Backend = ratelimit.NewRedisBackend(client, "cityling:ratelimit:")
+		cleanup = func() {
+			if err := client.Close(); err != nil {
+				slog.Error("rate limit redis close failed", "err", err)
+			}
+		}
+	default:
+		if backend != "memory" {
+			slog.Warn("unknown CITYLING_RATE_LIMIT_BACKEND, using memory", "backend", backend)
+			backend = "memory"
+		}
+		cfg.Backend = ratelimit.NewMemoryBackend()
+	}
+	handler.SetRateLimit(cfg)
+	slog.Info("rate limiting enabled", "backend", backend, "overrides", len(limits), "per_child", len(childLimits), "trust_proxy", cfg.TrustProxy)
+	return cleanup
+}
+
+// configureDeliveryChannels 根据环境变量启用日报推送方式，返回是否至少启用了一种。
+func configureDeliveryChannels(svc *service.Service) bool {
+	enabled := false
+	if addr := strings.TrimSpace(os.Getenv("CITYLING_SMTP_ADDR")); addr != "" {
+		channel, err := delivery.NewSMTPChannel(delivery.SMTPConfig{
+			Addr:     addr,
+			Username: os.Getenv("CITYLING_SMTP_USERNAME"),
+			Password: os.Getenv("CITYLING_SMTP_PASSWORD"),
+			From:     os.Getenv("CITYLING_SMTP_FROM"),
+		})
+		if err != nil {
+			slog.Warn("smtp delivery disabled", "err", err)
+		} else {
+			svc.SetDeliveryChannel(channel)
+			enabled = true
+		}
+	}
+	if secret := strings.TrimSpace(os.Getenv("CITYLING_WEBHOOK_SECRET")); secret != "" {
+		channel, err := delivery.NewWebhookChannel(secret)
+		if err != nil {
+			slog.Warn("webhook delivery disabled", "err", err)
+		} else {
+			svc.SetDeliveryChannel(channel)
+			enabled = true
+		}
+	}
+	return enabled
 }
 
 func resolveListenAddr() string {
@@ -139,7 +344,7 @@ func firstNonEmpty(values ...string) string {
 func initLLMClientFromEnv() *llm.Client {
 	apiKey := strings.TrimSpace(os.Getenv("CITYLING_DASHSCOPE_API_KEY"))
 	if apiKey == "" {
-		log.Printf("llm key missing: CITYLING_DASHSCOPE_API_KEY is empty")
+		slog.Warn("llm key missing: CITYLING_DASHSCOPE_API_KEY is empty")
 		return nil
 	}
 	legacyVoiceKey := strings.TrimSpace(os.Getenv("CITYLING_LLM_API_KEY"))
@@ -147,10 +352,10 @@ func initLLMClientFromEnv() *llm.Client {
 	forcedChatModel := "qwen3.5-flash"
 	companionModel := envOrDefault("CITYLING_COMPANION_MODEL", "qwen-plus")
 	if raw := strings.TrimSpace(os.Getenv("CITYLING_LLM_BASE_URL")); raw != "" && !strings.EqualFold(strings.TrimRight(raw, "/"), forcedChatBaseURL) {
-		log.Printf("llm chat base forced to %s, ignored CITYLING_LLM_BASE_URL=%s", forcedChatBaseURL, raw)
+		slog.Warn("llm chat base forced, ignored CITYLING_LLM_BASE_URL", "base", forcedChatBaseURL, "ignored", raw)
 	}
 	if raw := strings.TrimSpace(os.Getenv("CITYLING_LLM_MODEL")); raw != "" && !strings.EqualFold(raw, forcedChatModel) {
-		log.Printf("llm chat model forced to %s, ignored CITYLING_LLM_MODEL=%s", forcedChatModel, raw)
+		slog.Warn("llm chat model forced, ignored CITYLING_LLM_MODEL", "model", forcedChatModel, "ignored", raw)
 	}
 
 	cfg := llm.Config{
@@ -179,23 +384,23 @@ func initLLMClientFromEnv() *llm.Client {
 		COSBucketName:        os.Getenv("CITYLING_COS_BUCKET_NAME"),
 		COSPublicDomain:      envOrDefault("CITYLING_COS_PUBLIC_DOMAIN", ""),
 	}
-	log.Printf(
-		"llm init config: base=%s model=%s companion_model=%s timeout=%s companion_chat_timeout=%s key_meta={%s} image_base=%s image_key_meta={%s} voice_base=%s voice_key_meta={%s}",
-		cfg.BaseURL,
-		cfg.ChatModel,
-		cfg.CompanionModel,
-		cfg.Timeout.String(),
-		cfg.CompanionChatTimeout.String(),
-		safeKeyMeta(cfg.APIKey),
-		cfg.ImageBaseURL,
-		safeKeyMeta(cfg.ImageAPIKey),
-		cfg.VoiceBaseURL,
-		safeKeyMeta(cfg.VoiceAPIKey),
+	slog.Info(
+		"llm init config",
+		"base", cfg.BaseURL,
+		"model", cfg.ChatModel,
+		"companion_model", cfg.CompanionModel,
+		"timeout", cfg.Timeout.String(),
+		"companion_chat_timeout", cfg.CompanionChatTimeout.String(),
+		"key_meta", safeKeyMeta(cfg.APIKey),
+		"image_base", cfg.ImageBaseURL,
+		"image_key_meta", safeKeyMeta(cfg.ImageAPIKey),
+		"voice_base", cfg.VoiceBaseURL,
+		"voice_key_meta", safeKeyMeta(cfg.VoiceAPIKey),
 	)
 
 	client, err := llm.NewClient(cfg)
 	if err != nil {
-		log.Printf("init llm client failed: %v", err)
+		slog.Error("init llm client failed", "err", err)
 		return nil
 	}
 	return client
@@ -209,6 +414,18 @@ func parseEnvInt(key string, fallback int) int {
 	return parseEnvIntValue(raw, fallback)
 }
 
+func parseEnvFloat(key string, fallback float64) float64 {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
 func parseEnvIntValue(raw string, fallback int) int {
 	value, err := strconv.Atoi(strings.TrimSpace(raw))
 	if err != nil {
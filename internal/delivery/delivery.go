@@ -0,0 +1,28 @@
+// Package delivery 负责把生成好的报告推送给家长，每种推送方式实现为一个 Channel。
+package delivery
+
+import "context"
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message 是一次推送的内容。邮件使用 Subject/Text/HTML/Attachments，Webhook 把 Payload 序列化为 JSON 请求体。
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+
+	Event   string
+	Payload any
+}
+
+type Channel interface {
+	// Name 对应订阅中的 channel 字段，例如 "email"、"webhook"。
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
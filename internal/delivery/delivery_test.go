@@ -0,0 +1,180 @@
+package delivery
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer 是一个最小的 SMTP 服务端，记录收到的信封与邮件内容。
+type fakeSMTPServer struct {
+	listener net.Listener
+	from     string
+	rcpt     string
+	data     chan string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	srv := &fakeSMTPServer{listener: listener, data: make(chan string, 1)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go srv.serve()
+	return srv
+}
+
+func (s *fakeSMTPServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		switch upper := strings.ToUpper(cmd); {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.from = cmd[len("MAIL FROM:"):]
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			s.rcpt = cmd[len("RCPT TO:"):]
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var body strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				body.WriteString(dataLine)
+			}
+			s.data <- body.String()
+			reply("250 OK")
+		case upper == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPChannelSendsMultipartMail(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+	channel, err := NewSMTPChannel(SMTPConfig{Addr: srv.listener.Addr().String(), From: "城市灵 <noreply@cityling.test>"})
+	if err != nil {
+		t.Fatalf("NewSMTPChannel() error = %v", err)
+	}
+	err = channel.Send(context.Background(), Message{
+		To:          "parent@example.com",
+		Subject:     "城市灵探索日报",
+		Text:        "今天收集了 1 个精灵。",
+		HTML:        "<p>今天收集了 1 个精灵。</p>",
+		Attachments: []Attachment{{Filename: "report.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")}},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if srv.from != "<noreply@cityling.test>" || srv.rcpt != "<parent@example.com>" {
+		t.Fatalf("unexpected envelope from=%s rcpt=%s", srv.from, srv.rcpt)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-srv.data))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "城市灵探索日报" {
+		t.Fatalf("unexpected subject %q", subject)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("ParseMediaType() error = %v", err)
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var types []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart() error = %v", err)
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "multipart/alternative") || types[1] != "application/pdf" {
+		t.Fatalf("unexpected parts %v", types)
+	}
+}
+
+func TestWebhookChannelSignsPayload(t *testing.T) {
+	var gotBody []byte
+	var gotSignature, gotTimestamp, gotEvent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(HeaderSignature)
+		gotTimestamp = r.Header.Get(HeaderTimestamp)
+		gotEvent = r.Header.Get(HeaderEvent)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	channel, err := NewWebhookChannel("s3cret")
+	if err != nil {
+		t.Fatalf("NewWebhookChannel() error = %v", err)
+	}
+	err = channel.Send(context.Background(), Message{
+		To:      server.URL,
+		Event:   "daily_report",
+		Payload: map[string]any{"child_id": "kid_1"},
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(gotBody, &payload); err != nil || payload["child_id"] != "kid_1" {
+		t.Fatalf("unexpected body %s err=%v", gotBody, err)
+	}
+	if gotEvent != "daily_report" || !hmac.Equal([]byte(gotSignature), []byte(Sign([]byte("s3cret"), gotTimestamp, gotBody))) {
+		t.Fatalf("signature mismatch: event=%s signature=%s", gotEvent, gotSignature)
+	}
+}
+
+func TestWebhookChannelReportsNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	channel, _ := NewWebhookChannel("s3cret")
+	err := channel.Send(context.Background(), Message{To: server.URL, Payload: map[string]any{}})
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("expected 502 error, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const smtpDialTimeout = 10 * time.Second
+
+type SMTPConfig struct {
+	// Addr 为 host:port，例如 smtp.example.com:587。
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPChannel 通过 SMTP 发送邮件；服务器支持时自动升级 STARTTLS，配置了用户名时使用 PLAIN 认证。
+type SMTPChannel struct {
+	cfg  SMTPConfig
+	host string
+	from *mail.Address
+}
+
+func NewSMTPChannel(cfg SMTPConfig) (*SMTPChannel, error) {
+	cfg.Addr = strings.TrimSpace(cfg.Addr)
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp addr %q: %w", cfg.Addr, err)
+	}
+	from, err := mail.ParseAddress(strings.TrimSpace(cfg.From))
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from %q: %w", cfg.From, err)
+	}
+	return &SMTPChannel{cfg: cfg, host: host, from: from}, nil
+}
+
+func (c *SMTPChannel) Name() string {
+	return "email"
+}
+
+func (c *SMTPChannel) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(strings.TrimSpace(msg.To))
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+	body, err := buildMIMEMessage(c.from, to, msg)
+	if err != nil {
+		return err
+	}
+
+	dialer := net.Dialer{Timeout: smtpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return err
+		}
+	}
+	if c.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMIMEMessage 组装 multipart/mixed 邮件：正文为 text/plain 与 text/html 的 multipart/alternative，其后是附件。
+func buildMIMEMessage(from *mail.Address, to *mail.Address, msg Message) ([]byte, error) {
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, errors.New("empty email body")
+	}
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.BEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	buf.WriteString("\r\n")
+
+	var alt bytes.Buffer
+	alternative := multipart.NewWriter(&alt)
+	if msg.Text != "" {
+		if err := writeBase64Part(alternative, "text/plain; charset=utf-8", "", []byte(msg.Text)); err != nil {
+			return nil, err
+		}
+	}
+	if msg.HTML != "" {
+		if err := writeBase64Part(alternative, "text/html; charset=utf-8", "", []byte(msg.HTML)); err != nil {
+			return nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+	altPart, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := altPart.Write(alt.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range msg.Attachments {
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+		if err := writeBase64Part(mixed, attachment.ContentType, disposition, attachment.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeBase64Part(w *multipart.Writer, contentType string, disposition string, data []byte) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if disposition != "" {
+		header.Set("Content-Disposition", disposition)
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = fmt.Fprintf(part, "%s\r\n", encoded)
+	return err
+}
@@ -0,0 +1,82 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	webhookTimeout = 15 * time.Second
+
+	HeaderEvent     = "X-Cityling-Event"
+	HeaderTimestamp = "X-Cityling-Timestamp"
+	HeaderSignature = "X-Cityling-Signature"
+)
+
+// WebhookChannel 以 JSON POST 推送报告，并附带 HMAC-SHA256 签名，接收方可据此校验来源与防重放。
+type WebhookChannel struct {
+	secret     []byte
+	httpClient *http.Client
+}
+
+func NewWebhookChannel(secret string) (*WebhookChannel, error) {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return nil, errors.New("webhook secret is required")
+	}
+	return &WebhookChannel{
+		secret:     []byte(secret),
+		httpClient: &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+func (c *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.To, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, msg.Event)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(c.secret, timestamp, body))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("webhook responded %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
+
+// Sign 计算 "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))。
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,103 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"ling/internal/service"
+)
+
+// SetAdminToken 配置管理接口令牌；为空时管理接口一律拒绝访问。
+func (h *Handler) SetAdminToken(token string) {
+	h.adminToken = strings.TrimSpace(token)
+}
+
+func (h *Handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.adminToken == "" {
+			writeError(w, r, service.ErrAdminDisabled)
+			return
+		}
+		if !h.adminAuthorized(r) {
+			slog.WarnContext(r.Context(), "admin unauthorized", "method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+			writeError(w, r, service.ErrAdminUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// adminAuthorized 报告请求是否带有有效的管理令牌（X-Admin-Token 或 Authorization: Bearer）。
+func (h *Handler) adminAuthorized(r *http.Request) bool {
+	if h.adminToken == "" {
+		return false
+	}
+	token := strings.TrimSpace(r.Header.Get("X-Admin-Token"))
+	if auth := strings.TrimSpace(r.Header.Get("Authorization")); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
+func (h *Handler) adminListBadges(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, BadgeRuleListResponse{Badges: h.svc.BadgeDefinitions()})
+}
+
+func (h *Handler) adminGetBadge(w http.ResponseWriter, r *http.Request) {
+	badge, err := h.svc.BadgeDefinition(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, r, "adminGetBadge", err, "badge_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, badge)
+}
+
+func (h *Handler) adminCreateBadge(w http.ResponseWriter, r *http.Request) {
+	var req service.BadgeRule
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "adminCreateBadge", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	badge, err := h.svc.CreateBadge(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "adminCreateBadge", err, "badge_id", req.ID)
+		return
+	}
+	writeJSON(w, http.StatusCreated, badge)
+}
+
+func (h *Handler) adminUpdateBadge(w http.ResponseWriter, r *http.Request) {
+	var req service.BadgeRule
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "adminUpdateBadge", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	badge, err := h.svc.UpdateBadge(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		writeServiceError(w, r, "adminUpdateBadge", err, "badge_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, badge)
+}
+
+func (h *Handler) adminDeleteBadge(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteBadge(r.Context(), r.PathValue("id")); err != nil {
+		writeServiceError(w, r, "adminDeleteBadge", err, "badge_id", r.PathValue("id"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) adminReloadBadges(w http.ResponseWriter, r *http.Request) {
+	status, err := h.svc.ReloadBadgeCatalog(r.Context())
+	if err != nil {
+		writeServiceError(w, r, "adminReloadBadges", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
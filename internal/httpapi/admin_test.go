@@ -0,0 +1,73 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ling/internal/knowledge"
+	"ling/internal/service"
+	"ling/internal/store"
+)
+
+func TestAdminBadgeRoutesRequireToken(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	handler := NewHandler(service.New(st, knowledge.BaseKnowledge))
+	router := NewRouter(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/badges", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without configured token, got %d", rec.Code)
+	}
+
+	handler.SetAdminToken("secret")
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/badges", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong token, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/badges/badge_01_plantae", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with valid token, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAdminDeliverySubscriptionRejectsDisabledChannel(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	handler := NewHandler(service.New(st, knowledge.BaseKnowledge))
+	handler.SetAdminToken("secret")
+	router := NewRouter(handler)
+
+	body := `{"child_id":"kid_1","channel":"email","target":"mum@example.com","send_at":"20:00"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/deliveries/subscriptions", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unconfigured channel, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/deliveries/attempts", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"attempts":[]`) {
+		t.Fatalf("expected empty attempt list, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,19 @@
+package httpapi
+
+import "ling/pkg/api"
+
+// 响应外层结构定义在 pkg/api，供服务端与 SDK 共用。
+type (
+	BadgeRuleListResponse            = api.BadgeRuleListResponse
+	BadgeUnlockListResponse          = api.BadgeUnlockListResponse
+	DeliverySubscriptionListResponse = api.DeliverySubscriptionListResponse
+	DeliveryAttemptListResponse      = api.DeliveryAttemptListResponse
+	ErrorResponse                    = api.ErrorResponse
+	InviteCodeRequest                = api.InviteCodeRequest
+	GroupListResponse                = api.GroupListResponse
+	HealthResponse                   = api.HealthResponse
+	PokedexResponse                  = api.PokedexResponse
+	PokedexBadgeResponse             = api.PokedexBadgeResponse
+	ReviewDueResponse                = api.ReviewDueResponse
+	TradeListResponse                = api.TradeListResponse
+)
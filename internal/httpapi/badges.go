@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) badgeUnlocksUnseen(w http.ResponseWriter, r *http.Request) {
+	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
+	unlocks, err := h.svc.UnseenBadgeUnlocks(r.Context(), childID)
+	if err != nil {
+		writeServiceError(w, r, "badgeUnlocksUnseen", err, "child_id", childID)
+		return
+	}
+	writeJSON(w, http.StatusOK, BadgeUnlockListResponse{ChildID: childID, Unlocks: unlocks})
+}
+
+func (h *Handler) badgeUnlocksSeen(w http.ResponseWriter, r *http.Request) {
+	var req service.BadgeSeenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "badgeUnlocksSeen", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+
+	resp, err := h.svc.MarkBadgeUnlocksSeen(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "badgeUnlocksSeen", err, "child_id", req.ChildID)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+func (h *Handler) captureMap(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	childID := strings.TrimSpace(query.Get("child_id"))
+	mapQuery, err := parseMapQuery(query.Get)
+	if err != nil {
+		writeServiceError(w, r, "captureMap", err, "child_id", childID, "query", r.URL.RawQuery)
+		return
+	}
+
+	collection, err := h.svc.CaptureMap(r.Context(), childID, mapQuery)
+	if err != nil {
+		writeServiceError(w, r, "captureMap", err, "child_id", childID)
+		return
+	}
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(collection)
+}
+
+// parseMapQuery 解析 bbox=minLng,minLat,maxLng,maxLat 或 lat/lng/radius_m，以及 cluster/zoom。
+func parseMapQuery(get func(string) string) (service.MapQuery, error) {
+	var q service.MapQuery
+	if raw := strings.TrimSpace(get("bbox")); raw != "" {
+		parts := strings.Split(raw, ",")
+		for _, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return service.MapQuery{}, fmt.Errorf("%w: bbox 格式为 minLng,minLat,maxLng,maxLat", service.ErrMapQueryInvalid)
+			}
+			q.BBox = append(q.BBox, v)
+		}
+	}
+	lat, lng := strings.TrimSpace(get("lat")), strings.TrimSpace(get("lng"))
+	if lat != "" || lng != "" {
+		latV, latErr := strconv.ParseFloat(lat, 64)
+		lngV, lngErr := strconv.ParseFloat(lng, 64)
+		radius, radiusErr := strconv.ParseFloat(strings.TrimSpace(get("radius_m")), 64)
+		if latErr != nil || lngErr != nil || radiusErr != nil {
+			return service.MapQuery{}, fmt.Errorf("%w: 半径查询需要数值 lat、lng 与 radius_m", service.ErrMapQueryInvalid)
+		}
+		q.Center = &model.GeoPoint{Latitude: latV, Longitude: lngV}
+		q.RadiusM = radius
+	}
+	switch strings.ToLower(strings.TrimSpace(get("cluster"))) {
+	case "", "0", "false":
+	case "1", "true":
+		q.Cluster = true
+	default:
+		return service.MapQuery{}, fmt.Errorf("%w: cluster 必须是 true 或 false", service.ErrMapQueryInvalid)
+	}
+	q.Zoom = service.DefaultClusterZoom
+	if raw := strings.TrimSpace(get("zoom")); raw != "" {
+		zoom, err := strconv.Atoi(raw)
+		if err != nil {
+			return service.MapQuery{}, fmt.Errorf("%w: zoom 必须是整数", service.ErrMapQueryInvalid)
+		}
+		q.Zoom = zoom
+	}
+	return q, nil
+}
+
+func (h *Handler) nearbyHints(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	childID := strings.TrimSpace(query.Get("child_id"))
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(query.Get("lat")), 64)
+	lng, lngErr := strconv.ParseFloat(strings.TrimSpace(query.Get("lng")), 64)
+	if latErr != nil || lngErr != nil {
+		logBadRequest(r, "nearbyHints", "invalid parameter", "child_id", childID, "query", r.URL.RawQuery)
+		writeError(w, r, fmt.Errorf("%w: 需要数值 lat 与 lng", service.ErrQueryInvalid))
+		return
+	}
+	limit := 0
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			writeError(w, r, fmt.Errorf("%w: limit 必须是整数", service.ErrQueryInvalid))
+			return
+		}
+		limit = parsed
+	}
+
+	hints, err := h.svc.NearbyHints(r.Context(), childID, lat, lng, limit, localeHint(r, query.Get("locale")))
+	if err != nil {
+		writeServiceError(w, r, "nearbyHints", err, "child_id", childID)
+		return
+	}
+	writeJSON(w, http.StatusOK, hints)
+}
@@ -0,0 +1,91 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) adminListDeliverySubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.svc.ListDeliverySubscriptions(r.Context())
+	if err != nil {
+		writeServiceError(w, r, "adminListDeliverySubscriptions", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, DeliverySubscriptionListResponse{
+		Channels:      h.svc.DeliveryChannels(),
+		Subscriptions: subs,
+	})
+}
+
+func (h *Handler) adminCreateDeliverySubscription(w http.ResponseWriter, r *http.Request) {
+	var req service.DeliverySubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "adminCreateDeliverySubscription", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	sub, err := h.svc.CreateDeliverySubscription(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "adminCreateDeliverySubscription", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+func (h *Handler) adminUpdateDeliverySubscription(w http.ResponseWriter, r *http.Request) {
+	var req service.DeliverySubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "adminUpdateDeliverySubscription", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	sub, err := h.svc.UpdateDeliverySubscription(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		writeServiceError(w, r, "adminUpdateDeliverySubscription", err, "subscription_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, sub)
+}
+
+func (h *Handler) adminDeleteDeliverySubscription(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteDeliverySubscription(r.Context(), r.PathValue("id")); err != nil {
+		writeServiceError(w, r, "adminDeleteDeliverySubscription", err, "subscription_id", r.PathValue("id"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) adminSendDelivery(w http.ResponseWriter, r *http.Request) {
+	attempt, err := h.svc.SendDeliveryNow(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeServiceError(w, r, "adminSendDelivery", err, "subscription_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, attempt)
+}
+
+func (h *Handler) adminListDeliveryAttempts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	subscriptionID := strings.TrimSpace(query.Get("subscription_id"))
+	limit := 50
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			logBadRequest(r, "adminListDeliveryAttempts", "invalid parameter", "limit", raw)
+			writeError(w, r, fmt.Errorf("%w: limit 必须是正整数", service.ErrQueryInvalid))
+			return
+		}
+		limit = parsed
+	}
+	attempts, err := h.svc.DeliveryAttempts(r.Context(), subscriptionID, query.Get("status"), limit)
+	if err != nil {
+		writeServiceError(w, r, "adminListDeliveryAttempts", err, "subscription_id", subscriptionID)
+		return
+	}
+	writeJSON(w, http.StatusOK, DeliveryAttemptListResponse{Attempts: attempts})
+}
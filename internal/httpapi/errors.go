@@ -0,0 +1,107 @@
+package httpapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"ling/internal/i18n"
+	"ling/internal/logging"
+	"ling/internal/service"
+)
+
+// writeServiceError 是错误响应的统一出口：状态码、错误码与补充信息都取自 service.Error，
+// 其他错误按 500 INTERNAL 处理。attrs 为写进日志的 key/value 对，如 "child_id", childID，
+// 同时会补进本次请求的访问日志。
+func writeServiceError(w http.ResponseWriter, r *http.Request, op string, err error, attrs ...any) {
+	typed := service.AsError(err)
+	logging.AddFields(r.Context(), attrs...)
+	args := append([]any{"op", op, "kind", errorKind(typed.Status), "code", typed.Code, "err", err}, attrs...)
+	slog.Log(r.Context(), statusLevel(typed.Status), "request failed", args...)
+	writeError(w, r, err)
+}
+
+// logBadRequest 记录在调用 service 之前就被拒绝的请求（如请求体或查询参数无法解析），
+// 调用方随后自行写 400 响应。
+func logBadRequest(r *http.Request, op string, reason string, attrs ...any) {
+	logging.AddFields(r.Context(), attrs...)
+	args := append([]any{"op", op, "reason", reason}, attrs...)
+	slog.WarnContext(r.Context(), "bad request", args...)
+}
+
+// writeError 只写响应不记日志，供已自行记录原因的调用方使用（如请求体解析失败）。
+// 文案语言与响应体一致（见 errorLocale）；非默认语言使用目录中按错误码登记的文案，补充的上下文只保留在日志里。
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	typed := service.AsError(err)
+	message := err.Error()
+	if typed.Status >= http.StatusInternalServerError && typed.Code != service.CodeInternal {
+		// 依赖服务的原始错误只进日志，响应里给稳定的默认文案。
+		message = typed.Message
+	}
+	locale := errorLocale(r)
+	if locale != i18n.Default {
+		if localized, ok := i18n.Lookup(locale, typed.Code); ok {
+			message = localized
+		}
+	}
+	w.Header().Set("Content-Language", string(locale))
+	writeJSON(w, typed.Status, ErrorResponse{
+		Error:   message,
+		Code:    typed.Code,
+		Details: typed.Details,
+	})
+}
+
+type localeResolverKey struct{}
+
+// withLocale 为请求挂上语言记录，service 确定语言后写入，错误响应随之使用同一语言。
+func (h *Handler) withLocale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := i18n.WithRequestLocale(r.Context())
+		ctx = context.WithValue(ctx, localeResolverKey{}, h.svc)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// errorLocale 返回错误文案的语言：优先沿用 service 为本次请求确定的语言；service 尚未确定时
+// （如参数校验失败），按 child_id 查询参数对应的孩子资料、locale 查询参数与 Accept-Language 的顺序解析。
+func errorLocale(r *http.Request) i18n.Locale {
+	if locale, ok := i18n.RequestLocale(r.Context()); ok {
+		return locale
+	}
+	query := r.URL.Query()
+	hint := localeHint(r, query.Get("locale"))
+	if svc, ok := r.Context().Value(localeResolverKey{}).(*service.Service); ok && svc != nil {
+		return svc.Locale(r.Context(), query.Get("child_id"), hint)
+	}
+	return i18n.Negotiate(hint)
+}
+
+func errorKind(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusGone:
+		return "expired"
+	case http.StatusTooManyRequests:
+		return "rate limited"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	case http.StatusGatewayTimeout:
+		return "timeout"
+	case service.StatusClientClosedRequest:
+		return "canceled"
+	}
+	if status >= http.StatusInternalServerError {
+		return "internal error"
+	}
+	return "error"
+}
@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var req service.GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "createGroup", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	group, err := h.svc.CreateGroup(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "createGroup", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, group)
+}
+
+func (h *Handler) updateGroup(w http.ResponseWriter, r *http.Request) {
+	var req service.GroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "updateGroup", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	group, err := h.svc.UpdateGroup(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		writeServiceError(w, r, "updateGroup", err, "group_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, group)
+}
+
+func (h *Handler) regenerateInviteCode(w http.ResponseWriter, r *http.Request) {
+	var req InviteCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "regenerateInviteCode", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	group, err := h.svc.RegenerateInviteCode(r.Context(), r.PathValue("id"), req.OwnerID)
+	if err != nil {
+		writeServiceError(w, r, "regenerateInviteCode", err, "group_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, group)
+}
+
+func (h *Handler) joinGroup(w http.ResponseWriter, r *http.Request) {
+	var req service.GroupJoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "joinGroup", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	detail, err := h.svc.JoinGroup(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "joinGroup", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, detail)
+}
+
+func (h *Handler) removeGroupMember(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.RemoveGroupMember(r.Context(), r.PathValue("id"), r.PathValue("child_id"), groupViewer(r)); err != nil {
+		writeServiceError(w, r, "removeGroupMember", err, "group_id", r.PathValue("id"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.svc.Groups(r.Context(), groupViewer(r))
+	if err != nil {
+		writeServiceError(w, r, "listGroups", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, GroupListResponse{Groups: groups})
+}
+
+func (h *Handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	detail, err := h.svc.Group(r.Context(), r.PathValue("id"), groupViewer(r))
+	if err != nil {
+		writeServiceError(w, r, "getGroup", err, "group_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, detail)
+}
+
+func (h *Handler) groupLeaderboard(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	board, err := h.svc.GroupLeaderboard(r.Context(), r.PathValue("id"), query.Get("metric"), query.Get("window"), groupViewer(r))
+	if err != nil {
+		writeServiceError(w, r, "groupLeaderboard", err, "group_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, board)
+}
+
+func (h *Handler) groupFeed(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			logBadRequest(r, "groupFeed", "invalid parameter", "group_id", r.PathValue("id"), "limit", raw)
+			writeError(w, r, fmt.Errorf("%w: limit 必须是正整数", service.ErrQueryInvalid))
+			return
+		}
+		limit = parsed
+	}
+	feed, err := h.svc.GroupFeed(r.Context(), r.PathValue("id"), limit, groupViewer(r))
+	if err != nil {
+		writeServiceError(w, r, "groupFeed", err, "group_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, feed)
+}
+
+// groupViewer 从查询参数读取查看者：孩子用 child_id，群主用 owner_id。
+func groupViewer(r *http.Request) service.GroupViewer {
+	query := r.URL.Query()
+	return service.GroupViewer{
+		ChildID: strings.TrimSpace(query.Get("child_id")),
+		OwnerID: strings.TrimSpace(query.Get("owner_id")),
+	}
+}
@@ -1,57 +1,76 @@
 package httpapi
 
 import (
+	"bytes"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"io"
-	"log"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"ling/internal/report"
 	"ling/internal/service"
 )
 
 type Handler struct {
-	svc *service.Service
+	svc        *service.Service
+	adminToken string
+	renderer   *report.Renderer
+	rateLimit  RateLimitConfig
+	// spec 在 NewRouter 中按路由表生成一次，servers 按请求补上。
+	spec map[string]any
 }
 
 func NewHandler(svc *service.Service) *Handler {
-	return &Handler{svc: svc}
+	return &Handler{svc: svc, renderer: report.NewRenderer(report.DefaultPalette())}
+}
+
+// SetReportRenderer 替换日报 HTML/PDF 渲染器，通常用于加载品牌色板文件。
+func (h *Handler) SetReportRenderer(renderer *report.Renderer) {
+	if renderer != nil {
+		h.renderer = renderer
+	}
 }
 
 func (h *Handler) healthz(w http.ResponseWriter, _ *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// livez 只说明进程仍在处理请求，不检查依赖：依赖故障时重启进程无济于事，应由 /readyz 摘除流量。
+func (h *Handler) livez(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// readyz 返回各依赖的检查结果；存储不可用或服务正在退出时返回 503，响应体格式不变。
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.Readiness(r.Context())
+	if err != nil {
+		writeServiceError(w, r, "readyz", err)
+		return
+	}
+	status := http.StatusOK
+	if !resp.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
 }
 
 func (h *Handler) scan(w http.ResponseWriter, r *http.Request) {
 	var req service.ScanRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("scan decode error: %v", err)
-		writeError(w, http.StatusBadRequest, "请求体格式不正确")
+		logBadRequest(r, "scan", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
 		return
 	}
+	req.Locale = localeHint(r, req.Locale)
 
-	resp, err := h.svc.Scan(req)
+	resp, err := h.svc.Scan(r.Context(), req)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrUnsupportedObject), errors.Is(err, service.ErrScanInputRequired):
-			log.Printf("scan bad request: child_id=%s label=%s err=%v", req.ChildID, req.DetectedLabel, err)
-			writeError(w, http.StatusBadRequest, err.Error())
-			return
-		case errors.Is(err, service.ErrLLMUnavailable), errors.Is(err, service.ErrContentGenerate):
-			log.Printf("scan unavailable: child_id=%s err=%v", req.ChildID, err)
-			if errors.Is(err, service.ErrContentGenerate) {
-				writeError(w, http.StatusServiceUnavailable, service.ErrContentGenerate.Error())
-				return
-			}
-			writeError(w, http.StatusServiceUnavailable, err.Error())
-			return
-		default:
-			log.Printf("scan internal error: child_id=%s label=%s err=%v", req.ChildID, req.DetectedLabel, err)
-			writeError(w, http.StatusInternalServerError, err.Error())
-			return
-		}
+		writeServiceError(w, r, "scan", err, "child_id", req.ChildID, "label", req.DetectedLabel)
+		return
 	}
 
 	writeJSON(w, http.StatusOK, resp)
@@ -60,24 +79,14 @@ func (h *Handler) scan(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) scanImage(w http.ResponseWriter, r *http.Request) {
 	var req service.ScanImageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("scanImage decode error: %v", err)
-		writeError(w, http.StatusBadRequest, "请求体格式不正确")
+		logBadRequest(r, "scanImage", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
 		return
 	}
 
-	resp, err := h.svc.ScanImage(req)
+	resp, err := h.svc.ScanImage(r.Context(), req)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrImageRequired):
-			log.Printf("scanImage bad request: child_id=%s err=%v", req.ChildID, err)
-			writeError(w, http.StatusBadRequest, err.Error())
-		case errors.Is(err, service.ErrLLMUnavailable):
-			log.Printf("scanImage unavailable: child_id=%s err=%v", req.ChildID, err)
-			writeError(w, http.StatusServiceUnavailable, err.Error())
-		default:
-			log.Printf("scanImage internal error: child_id=%s image_url=%t image_base64=%t err=%v", req.ChildID, strings.TrimSpace(req.ImageURL) != "", strings.TrimSpace(req.ImageBase64) != "", err)
-			writeError(w, http.StatusInternalServerError, err.Error())
-		}
+		writeServiceError(w, r, "scanImage", err, "child_id", req.ChildID, "image_url", strings.TrimSpace(req.ImageURL) != "", "image_base64", strings.TrimSpace(req.ImageBase64) != "")
 		return
 	}
 
@@ -87,24 +96,15 @@ func (h *Handler) scanImage(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) answer(w http.ResponseWriter, r *http.Request) {
 	var req service.AnswerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("answer decode error: %v", err)
-		writeError(w, http.StatusBadRequest, "请求体格式不正确")
+		logBadRequest(r, "answer", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
 		return
 	}
+	req.Locale = localeHint(r, req.Locale)
 
-	resp, err := h.svc.SubmitAnswer(req)
+	resp, err := h.svc.SubmitAnswer(r.Context(), req)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrSessionNotFound):
-			log.Printf("answer not found: session_id=%s err=%v", req.SessionID, err)
-			writeError(w, http.StatusNotFound, err.Error())
-		case errors.Is(err, service.ErrAlreadyCaptured):
-			log.Printf("answer conflict: session_id=%s err=%v", req.SessionID, err)
-			writeError(w, http.StatusConflict, err.Error())
-		default:
-			log.Printf("answer internal error: session_id=%s err=%v", req.SessionID, err)
-			writeError(w, http.StatusInternalServerError, err.Error())
-		}
+		writeServiceError(w, r, "answer", err, "session_id", req.SessionID)
 		return
 	}
 
@@ -114,68 +114,54 @@ func (h *Handler) answer(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) companionScene(w http.ResponseWriter, r *http.Request) {
 	var req service.CompanionSceneRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("companionScene decode error: %v", err)
-		writeError(w, http.StatusBadRequest, "请求体格式不正确")
+		logBadRequest(r, "companionScene", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
 		return
 	}
+	req.Locale = localeHint(r, req.Locale)
 
-	resp, err := h.svc.GenerateCompanionScene(req)
+	resp, err := h.svc.GenerateCompanionScene(r.Context(), req)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrObjectTypeMissing), errors.Is(err, service.ErrInvalidChildAge):
-			log.Printf("companionScene bad request: child_id=%s object_type=%s err=%v", req.ChildID, req.ObjectType, err)
-			writeError(w, http.StatusBadRequest, err.Error())
-		case errors.Is(err, service.ErrLLMUnavailable), errors.Is(err, service.ErrMediaUnavailable):
-			log.Printf("companionScene unavailable: child_id=%s err=%v", req.ChildID, err)
-			writeError(w, http.StatusServiceUnavailable, err.Error())
-		default:
-			log.Printf("companionScene internal error: child_id=%s object_type=%s err=%v", req.ChildID, req.ObjectType, err)
-			writeError(w, http.StatusInternalServerError, err.Error())
-		}
+		writeServiceError(w, r, "companionScene", err, "child_id", req.ChildID, "object_type", req.ObjectType)
 		return
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
 
+// uploadImageForm 只用于文档，描述 uploadImage 的 multipart 表单字段。
+type uploadImageForm struct {
+	File *multipart.FileHeader `json:"file"`
+}
+
 func (h *Handler) uploadImage(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseMultipartForm(16 << 20); err != nil {
-		log.Printf("uploadImage parse form error: %v", err)
-		writeError(w, http.StatusBadRequest, "上传表单格式不正确")
+		logBadRequest(r, "uploadImage", "parse form error", "err", err)
+		writeError(w, r, fmt.Errorf("%w: 上传表单格式不正确", service.ErrRequestBodyInvalid))
 		return
 	}
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		log.Printf("uploadImage form file error: %v", err)
-		writeError(w, http.StatusBadRequest, "请提供 file 文件字段")
+		logBadRequest(r, "uploadImage", "form file error", "err", err)
+		writeError(w, r, fmt.Errorf("%w: 请提供 file 文件字段", service.ErrRequestBodyInvalid))
 		return
 	}
 	defer file.Close()
 
 	data, err := io.ReadAll(io.LimitReader(file, 16<<20))
 	if err != nil {
-		log.Printf("uploadImage read error: %v", err)
-		writeError(w, http.StatusBadRequest, "读取上传文件失败")
+		logBadRequest(r, "uploadImage", "read error", "err", err)
+		writeError(w, r, fmt.Errorf("%w: 读取上传文件失败", service.ErrRequestBodyInvalid))
 		return
 	}
 
-	resp, err := h.svc.UploadImage(service.UploadImageRequest{
+	resp, err := h.svc.UploadImage(r.Context(), service.UploadImageRequest{
 		FileName: header.Filename,
 		Bytes:    data,
 	})
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrImageRequired):
-			log.Printf("uploadImage bad request: err=%v", err)
-			writeError(w, http.StatusBadRequest, err.Error())
-		case errors.Is(err, service.ErrLLMUnavailable), errors.Is(err, service.ErrImageUpload):
-			log.Printf("uploadImage unavailable: err=%v", err)
-			writeError(w, http.StatusServiceUnavailable, err.Error())
-		default:
-			log.Printf("uploadImage internal error: err=%v", err)
-			writeError(w, http.StatusInternalServerError, err.Error())
-		}
+		writeServiceError(w, r, "uploadImage", err)
 		return
 	}
 
@@ -185,30 +171,15 @@ func (h *Handler) uploadImage(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) companionChat(w http.ResponseWriter, r *http.Request) {
 	var req service.CompanionChatRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("companionChat decode error: %v", err)
-		writeError(w, http.StatusBadRequest, "请求体格式不正确")
+		logBadRequest(r, "companionChat", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
 		return
 	}
+	req.Locale = localeHint(r, req.Locale)
 
-	resp, err := h.svc.ChatCompanion(req)
+	resp, err := h.svc.ChatCompanion(r.Context(), req)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrObjectTypeMissing),
-			errors.Is(err, service.ErrInvalidChildAge),
-			errors.Is(err, service.ErrChildMessageEmpty):
-			log.Printf("companionChat bad request: child_id=%s object_type=%s err=%v", req.ChildID, req.ObjectType, err)
-			writeError(w, http.StatusBadRequest, err.Error())
-		case errors.Is(err, service.ErrLLMUnavailable),
-			errors.Is(err, service.ErrMediaUnavailable):
-			log.Printf("companionChat unavailable: child_id=%s err=%v", req.ChildID, err)
-			writeError(w, http.StatusServiceUnavailable, err.Error())
-		case errors.Is(err, service.ErrCompanionTimeout):
-			log.Printf("companionChat timeout: child_id=%s object_type=%s err=%v", req.ChildID, req.ObjectType, err)
-			writeError(w, http.StatusGatewayTimeout, err.Error())
-		default:
-			log.Printf("companionChat internal error: child_id=%s object_type=%s err=%v", req.ChildID, req.ObjectType, err)
-			writeError(w, http.StatusInternalServerError, err.Error())
-		}
+		writeServiceError(w, r, "companionChat", err, "child_id", req.ChildID, "object_type", req.ObjectType)
 		return
 	}
 
@@ -218,27 +189,15 @@ func (h *Handler) companionChat(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) companionVoice(w http.ResponseWriter, r *http.Request) {
 	var req service.CompanionVoiceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("companionVoice decode error: %v", err)
-		writeError(w, http.StatusBadRequest, "请求体格式不正确")
+		logBadRequest(r, "companionVoice", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
 		return
 	}
+	req.Locale = localeHint(r, req.Locale)
 
-	resp, err := h.svc.SynthesizeCompanionVoice(req)
+	resp, err := h.svc.SynthesizeCompanionVoice(r.Context(), req)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrObjectTypeMissing),
-			errors.Is(err, service.ErrInvalidChildAge),
-			errors.Is(err, service.ErrStoryTextMissing):
-			log.Printf("companionVoice bad request: child_id=%s object_type=%s err=%v", req.ChildID, req.ObjectType, err)
-			writeError(w, http.StatusBadRequest, err.Error())
-		case errors.Is(err, service.ErrLLMUnavailable),
-			errors.Is(err, service.ErrMediaUnavailable):
-			log.Printf("companionVoice unavailable: child_id=%s err=%v", req.ChildID, err)
-			writeError(w, http.StatusServiceUnavailable, err.Error())
-		default:
-			log.Printf("companionVoice internal error: child_id=%s object_type=%s err=%v", req.ChildID, req.ObjectType, err)
-			writeError(w, http.StatusInternalServerError, err.Error())
-		}
+		writeServiceError(w, r, "companionVoice", err, "child_id", req.ChildID, "object_type", req.ObjectType)
 		return
 	}
 
@@ -247,59 +206,82 @@ func (h *Handler) companionVoice(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) pokedex(w http.ResponseWriter, r *http.Request) {
 	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
-	entries, err := h.svc.Pokedex(childID)
+	entries, err := h.svc.Pokedex(r.Context(), childID)
 	if err != nil {
-		log.Printf("pokedex internal error: child_id=%s err=%v", childID, err)
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, r, "pokedex", err, "child_id", childID)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"child_id": childID,
-		"entries":  entries,
-	})
+	writeJSON(w, http.StatusOK, PokedexResponse{ChildID: childID, Entries: entries})
 }
 
 func (h *Handler) pokedexBadges(w http.ResponseWriter, r *http.Request) {
 	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
-	badges, err := h.svc.PokedexBadges(childID)
+	badges, err := h.svc.PokedexBadges(r.Context(), childID, localeHint(r, r.URL.Query().Get("locale")))
 	if err != nil {
-		log.Printf("pokedex badges internal error: child_id=%s err=%v", childID, err)
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, r, "pokedex", err, "child_id", childID)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"child_id": childID,
-		"badges":   badges,
-	})
+	writeJSON(w, http.StatusOK, PokedexBadgeResponse{ChildID: childID, Badges: badges})
 }
 
 func (h *Handler) dailyReport(w http.ResponseWriter, r *http.Request) {
 	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
 	dateParam := strings.TrimSpace(r.URL.Query().Get("date"))
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	switch format {
+	case "", "json", "html", "pdf":
+	default:
+		logBadRequest(r, "dailyReport", "invalid parameter", "child_id", childID, "format", format)
+		writeError(w, r, fmt.Errorf("%w: format 仅支持 json、html、pdf", service.ErrQueryInvalid))
+		return
+	}
 	day := time.Now()
 	if dateParam != "" {
 		parsed, err := time.Parse("2006-01-02", dateParam)
 		if err != nil {
-			log.Printf("dailyReport bad request: child_id=%s date=%s err=%v", childID, dateParam, err)
-			writeError(w, http.StatusBadRequest, "date 必须是 YYYY-MM-DD 格式")
+			logBadRequest(r, "dailyReport", "invalid parameter", "child_id", childID, "date", dateParam, "err", err)
+			writeError(w, r, fmt.Errorf("%w: date 必须是 YYYY-MM-DD 格式", service.ErrQueryInvalid))
 			return
 		}
 		day = parsed
 	}
 
-	report, err := h.svc.DailyReport(childID, day)
+	dailyReport, err := h.svc.DailyReport(r.Context(), childID, day, localeHint(r, r.URL.Query().Get("locale")))
 	if err != nil {
-		log.Printf("dailyReport internal error: child_id=%s date=%s err=%v", childID, day.Format("2006-01-02"), err)
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, r, "dailyReport", err, "child_id", childID, "date", day.Format("2006-01-02"))
 		return
 	}
-	writeJSON(w, http.StatusOK, report)
+
+	var buf bytes.Buffer
+	switch format {
+	case "html":
+		err = h.renderer.DailyHTML(&buf, dailyReport)
+	case "pdf":
+		err = h.renderer.DailyPDF(r.Context(), &buf, dailyReport)
+	default:
+		writeJSON(w, http.StatusOK, dailyReport)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, r, "dailyReport", err, "child_id", dailyReport.ChildID, "date", dailyReport.Date, "format", format)
+		return
+	}
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"cityling-daily-%s-%s.pdf\"", url.PathEscape(dailyReport.ChildID), dailyReport.Date))
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]string{
-		"error": message,
-	})
+// localeHint 返回请求显式指定的 locale，未指定时取 Accept-Language；孩子资料中的设置由服务层优先采用。
+func localeHint(r *http.Request, locale string) string {
+	if locale = strings.TrimSpace(locale); locale != "" {
+		return locale
+	}
+	return r.Header.Get("Accept-Language")
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload any) {
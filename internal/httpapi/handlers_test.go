@@ -10,8 +10,10 @@ import (
 	"testing"
 	"time"
 
+	"ling/internal/i18n"
 	"ling/internal/knowledge"
 	"ling/internal/llm"
+	"ling/internal/model"
 	"ling/internal/service"
 	"ling/internal/store"
 )
@@ -49,6 +51,48 @@ func TestScanUnknownObjectFallsBackTo200(t *testing.T) {
 	}
 }
 
+func TestProbeEndpointsReportDependencies(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	svc := service.New(st, knowledge.BaseKnowledge)
+	router := NewRouter(NewHandler(svc))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected /livez 200, got %d", rec.Code)
+	}
+
+	readyz := func() (int, service.ReadinessResponse) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var resp service.ReadinessResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode /readyz response error = %v, body=%s", err, rec.Body.String())
+		}
+		return rec.Code, resp
+	}
+
+	code, resp := readyz()
+	if code != http.StatusOK || resp.Status != service.ReadinessReady {
+		t.Fatalf("expected ready, got %d %+v", code, resp)
+	}
+	if len(resp.Dependencies) == 0 || resp.Dependencies[0].Name != "store" || resp.Dependencies[0].Status != service.DependencyOK {
+		t.Fatalf("expected store dependency ok, got %+v", resp.Dependencies)
+	}
+
+	svc.BeginShutdown()
+	code, resp = readyz()
+	if code != http.StatusServiceUnavailable || resp.Status != service.ReadinessShuttingDown {
+		t.Fatalf("expected 503 shutting_down, got %d %+v", code, resp)
+	}
+	if len(resp.Dependencies) == 0 {
+		t.Fatalf("expected dependencies in 503 body, got %+v", resp)
+	}
+}
+
 func TestCompanionSceneUnavailableReturns503(t *testing.T) {
 	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
 	if err != nil {
@@ -80,6 +124,132 @@ func TestCompanionSceneUnavailableReturns503(t *testing.T) {
 	if got := resp["error"]; got != service.ErrLLMUnavailable.Error() {
 		t.Fatalf("expected error %q, got %q", service.ErrLLMUnavailable.Error(), got)
 	}
+	if got := resp["code"]; got != service.CodeLLMUnavailable {
+		t.Fatalf("expected code %q, got %q", service.CodeLLMUnavailable, got)
+	}
+}
+
+func TestScanInvalidChildAgeReturnsCodeAndDetails(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	h := NewHandler(service.New(st, knowledge.BaseKnowledge))
+
+	payload, _ := json.Marshal(map[string]any{"child_id": "kid_httpapi_age", "child_age": 2, "detected_label": "tree"})
+	rec := httptest.NewRecorder()
+	h.scan(rec, httptest.NewRequest(http.MethodPost, "/api/v1/scan", bytes.NewReader(payload)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d, body=%s", http.StatusBadRequest, rec.Code, rec.Body.String())
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response error = %v", err)
+	}
+	if resp.Code != service.CodeChildAgeInvalid || resp.Error != service.ErrInvalidChildAge.Error() || resp.Details["max"] != float64(15) {
+		t.Fatalf("unexpected error response %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	h.scan(rec, httptest.NewRequest(http.MethodPost, "/api/v1/scan", strings.NewReader("{")))
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusBadRequest || resp.Code != service.CodeRequestBodyInvalid {
+		t.Fatalf("expected REQUEST_BODY_INVALID, got status %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestErrorsAndScanFollowAcceptLanguage(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(st, knowledge.BaseKnowledge)))
+
+	payload, _ := json.Marshal(map[string]any{"child_id": "kid_httpapi_en", "child_age": 2, "detected_label": "tree"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/scan", bytes.NewReader(payload))
+	req.Header.Set("Accept-Language", "en-GB,en;q=0.9")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var errResp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("decode response error = %v", err)
+	}
+	if rec.Code != http.StatusBadRequest || errResp.Code != service.CodeChildAgeInvalid || errResp.Error != "child_age must be between 3 and 15" {
+		t.Fatalf("unexpected localized error: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Language"); got != "en" {
+		t.Fatalf("expected Content-Language en, got %q", got)
+	}
+
+	payload, _ = json.Marshal(map[string]any{"child_id": "kid_httpapi_en", "child_age": 8, "detected_label": "tree"})
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/scan", bytes.NewReader(payload))
+	req.Header.Set("Accept-Language", "en")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var scanResp service.ScanResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &scanResp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("scan failed: status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if scanResp.ObjectName != "tree" {
+		t.Fatalf("expected english object name, got %q", scanResp.ObjectName)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/profile", strings.NewReader(`{"child_id":"kid_httpapi_en","locale":"fr"}`))
+	req.Header.Set("Accept-Language", "zh-TW")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || rec.Code != http.StatusBadRequest || errResp.Code != service.CodeLocaleUnsupported {
+		t.Fatalf("expected LOCALE_UNSUPPORTED, got status=%d body=%s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(errResp.Error, "不支援") {
+		t.Fatalf("expected traditional chinese message, got %q", errResp.Error)
+	}
+}
+
+func TestErrorsFollowProfileLocale(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(st, knowledge.BaseKnowledge)))
+	if err := st.SaveChildProfile(model.ChildProfile{ChildID: "kid_profile_en", Locale: "en"}); err != nil {
+		t.Fatalf("SaveChildProfile() error = %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		req    *http.Request
+		locale string
+		code   string
+	}{
+		{"body child", httptest.NewRequest(http.MethodPost, "/api/v1/scan", strings.NewReader(`{"child_id":"kid_profile_en","child_age":2,"detected_label":"tree"}`)), "en", service.CodeChildAgeInvalid},
+		{"query child", httptest.NewRequest(http.MethodGet, "/api/v1/report/weekly?child_id=kid_profile_en&date=bad", nil), "en", service.CodeQueryInvalid},
+		{"query locale", httptest.NewRequest(http.MethodGet, "/api/v1/report/weekly?child_id=kid_other&date=bad&locale=zh-TW", nil), "zh-TW", service.CodeQueryInvalid},
+		{"no profile", httptest.NewRequest(http.MethodGet, "/api/v1/report/weekly?child_id=kid_other&date=bad", nil), "zh-CN", service.CodeQueryInvalid},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, tc.req)
+		var errResp ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || errResp.Code != tc.code {
+			t.Fatalf("%s: expected %s, got status=%d body=%s", tc.name, tc.code, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Language"); got != tc.locale {
+			t.Fatalf("%s: expected Content-Language %s, got %q", tc.name, tc.locale, got)
+		}
+		if want, ok := i18n.Lookup(i18n.Locale(tc.locale), tc.code); tc.locale != "zh-CN" && (!ok || errResp.Error != want) {
+			t.Fatalf("%s: expected %q, got %q", tc.name, want, errResp.Error)
+		}
+	}
+}
+
+func TestErrorCodesHaveTranslations(t *testing.T) {
+	for _, e := range service.ErrorCatalog() {
+		for _, locale := range []i18n.Locale{i18n.En, i18n.ZhTW} {
+			if _, ok := i18n.Lookup(locale, e.Code); !ok {
+				t.Errorf("catalog %s has no message for %s", locale, e.Code)
+			}
+		}
+	}
 }
 
 func TestCompanionSceneMissingObjectTypeReturns400(t *testing.T) {
@@ -247,3 +417,221 @@ func TestCompanionVoiceMissingTextReturns400(t *testing.T) {
 		t.Fatalf("expected status %d, got %d, body=%s", http.StatusBadRequest, rec.Code, rec.Body.String())
 	}
 }
+
+func TestDailyReportFormats(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(st, knowledge.BaseKnowledge)))
+
+	cases := []struct {
+		format      string
+		status      int
+		contentType string
+		prefix      string
+	}{
+		{format: "html", status: http.StatusOK, contentType: "text/html; charset=utf-8", prefix: "<!DOCTYPE html>"},
+		{format: "pdf", status: http.StatusOK, contentType: "application/pdf", prefix: "%PDF-"},
+		{format: "docx", status: http.StatusBadRequest, contentType: "application/json", prefix: "{"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/report/daily?child_id=kid_1&date=2026-02-13&format="+tc.format, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("format=%s: expected %d, got %d body=%s", tc.format, tc.status, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Type"); got != tc.contentType {
+			t.Fatalf("format=%s: expected content type %q, got %q", tc.format, tc.contentType, got)
+		}
+		if !strings.HasPrefix(rec.Body.String(), tc.prefix) {
+			t.Fatalf("format=%s: unexpected body prefix %q", tc.format, rec.Body.String()[:min(20, rec.Body.Len())])
+		}
+	}
+}
+
+func TestCaptureMapReturnsGeoJSON(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(st, knowledge.BaseKnowledge)))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/map/captures?child_id=kid_1&bbox=121.4,31.2,121.5,31.3&cluster=true", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d body=%s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/geo+json" {
+		t.Fatalf("expected geo+json content type, got %q", got)
+	}
+	var collection map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &collection); err != nil {
+		t.Fatalf("decode response error = %v", err)
+	}
+	if collection["type"] != "FeatureCollection" {
+		t.Fatalf("expected FeatureCollection, got %v", collection)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/map/captures?child_id=kid_1&bbox=abc", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for bad bbox, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestGroupRoutesEnforceMembership(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(st, knowledge.BaseKnowledge)))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/groups", strings.NewReader(`{"name":"我们家","owner_id":"parent_1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d body=%s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var group map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &group); err != nil {
+		t.Fatalf("decode response error = %v", err)
+	}
+	groupID, _ := group["id"].(string)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/groups/"+groupID+"/leaderboard?child_id=stranger", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d for non-member, got %d", http.StatusForbidden, rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/groups/"+groupID+"/leaderboard?owner_id=parent_1&window=abc", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for bad window, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/groups/join", strings.NewReader(`{"invite_code":"NOPE0000","child_id":"kid_1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for unknown invite code, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestSpiritShareRendersHTMLCard(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	if err := st.SaveSpirit(model.Spirit{ID: "spirit_tree", Name: "木木", ObjectType: "tree", Intro: "我是木木", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveSpirit() error = %v", err)
+	}
+	if err := st.AddCapture(model.Capture{ID: "cap_1", ChildID: "kid_1", SpiritID: "spirit_tree", SpiritName: "木木", ObjectType: "tree", Fact: "树会呼吸", CapturedAt: time.Now()}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(st, knowledge.BaseKnowledge)))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/shares", strings.NewReader(`{"child_id":"kid_1","capture_id":"cap_1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d body=%s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var share model.SpiritShare
+	if err := json.Unmarshal(rec.Body.Bytes(), &share); err != nil {
+		t.Fatalf("decode response error = %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/shares/"+share.Token+"?format=html", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d body=%s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Fatalf("expected html content type, got %q", got)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "我是木木") || strings.Contains(body, "kid_1") {
+		t.Fatalf("expected card with intro and without child id, got:\n%s", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/shares/unknown", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d for unknown token, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestOpenAPIDocumentsErrorCodes(t *testing.T) {
+	spec := openAPISpec((&Handler{}).routes())
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	codes := schemas["ErrorResponse"].(map[string]any)["properties"].(map[string]any)["code"].(map[string]any)["enum"].([]string)
+	for _, want := range []string{service.CodeInternal, service.CodeChildAgeInvalid, service.CodeTradeConflict} {
+		found := false
+		for _, code := range codes {
+			found = found || code == want
+		}
+		if !found {
+			t.Fatalf("expected %s in ErrorResponse codes %v", want, codes)
+		}
+	}
+
+	scan := spec["paths"].(map[string]any)["/api/v1/scan"].(map[string]any)["post"].(map[string]any)
+	badRequest := scan["responses"].(map[string]any)["400"].(map[string]any)
+	if badRequest["content"] == nil {
+		t.Fatalf("expected 400 response to reference ErrorResponse, got %+v", badRequest)
+	}
+}
+
+func TestOpenAPIDocsReferenceExistingFields(t *testing.T) {
+	spec := openAPISpec((&Handler{}).routes())
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	properties := func(name string) map[string]any {
+		schema, ok := schemas[name].(map[string]any)
+		if !ok {
+			t.Fatalf("documented schema %s is not generated from any route", name)
+		}
+		props, _ := schema["properties"].(map[string]any)
+		return props
+	}
+	for key := range fieldDocs {
+		name, field, _ := strings.Cut(key, ".")
+		if _, ok := properties(name)[field]; !ok {
+			t.Fatalf("fieldDocs key %s does not match a field of %s", key, name)
+		}
+	}
+	for name, required := range schemaRequired {
+		props := properties(name)
+		for _, field := range required {
+			if _, ok := props[field]; !ok {
+				t.Fatalf("required field %s is not a field of %s", field, name)
+			}
+		}
+	}
+	for name := range schemaDescriptions {
+		properties(name)
+	}
+	for name := range schemaExamples {
+		properties(name)
+	}
+
+	scan := spec["paths"].(map[string]any)["/api/v1/scan"].(map[string]any)["post"].(map[string]any)
+	content := scan["requestBody"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)
+	example, _ := content["example"].(map[string]any)
+	if example["child_id"] == nil || example["detected_label"] == nil {
+		t.Fatalf("expected scan request example, got %+v", content["example"])
+	}
+	if _, ok := example["image_url"]; ok {
+		t.Fatalf("expected empty fields to be dropped from example, got %+v", example)
+	}
+}
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ling/internal/metrics"
+)
+
+// serveMetrics 以 Prometheus 文本格式输出进程内指标，供抓取使用，不写进 OpenAPI 文档。
+func (h *Handler) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics.Default.Handler().ServeHTTP(w, r)
+}
+
+// withMetrics 按路由模式与状态码记录请求数与耗时。未匹配到路由的请求统一记为 unmatched，
+// 避免把任意路径写成标签。
+func withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec, ok := w.(*statusRecorder)
+		if !ok {
+			rec = &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		}
+		next.ServeHTTP(rec, r)
+
+		route := routePattern(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(rec.status)
+		metrics.HTTPRequests.Inc(route, status)
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, status)
+	})
+}
@@ -0,0 +1,145 @@
+package httpapi
+
+import (
+	"fmt"
+	"strings"
+
+	"ling/internal/i18n"
+	"ling/internal/service"
+)
+
+// 以下表格为反射生成的 schema 补充 Go 类型表达不了的信息，键为组件名或 "组件名.字段名"。
+// 新增字段不登记也会出现在文档里；登记了不存在的字段会让 TestOpenAPIDocsReferenceExistingFields 失败。
+
+// fieldDocs 为字段补充描述、枚举、取值范围与示例。
+var fieldDocs = map[string]map[string]any{
+	"AnswerRequest.locale":                          localeProperty(),
+	"AnswerResponse.completed_quests":               {"description": "本次作答刚完成的每日任务"},
+	"BadgeCatalogStatus.source":                     {"description": "规则文件路径或 embedded"},
+	"BadgeCriterion.type":                           {"enum": []string{"collect_examples", "distinct_objects", "captures_in_window", "streak", "badges", "accuracy", "all", "any"}},
+	"BadgeRule.rule":                                {"description": "由 criteria 生成", "readOnly": true},
+	"BadgeUnlock.capture_id":                        {"description": "触发点亮的收集记录"},
+	"BadgeUnlock.session_id":                        {"description": "触发点亮的扫描会话"},
+	"ChildProfile.locale":                           {"description": "未设置时省略，跟随请求的 Accept-Language", "enum": localeEnum()},
+	"CompanionChatRequest.locale":                   localeProperty(),
+	"CompanionSceneRequest.locale":                  localeProperty(),
+	"CompanionSceneRequest.source_image_base64":     {"description": "可选。旧字段，建议迁移到 source_image_url"},
+	"CompanionSceneRequest.source_image_url":        {"description": "可选。传入识别原图 URL，启用图生图角色生成（推荐）"},
+	"CompanionSceneRequest.spirit_id":               {"description": "可选。生成的角色形象会保存到该精灵上，用于分享卡片"},
+	"CompanionSceneResponse.character_image_base64": {"description": "可选。角色图的base64数据，前端可优先使用以避免外链加载失败"},
+	"CompanionVoiceRequest.locale":                  localeProperty(),
+	"DailyReport.narrative_source":                  {"enum": []string{"llm", "template"}},
+	"DeliveryAttempt.status":                        {"enum": []string{"sent", "retrying", "failed"}},
+	"DeliveryAttempt.trigger":                       {"enum": []string{"scheduled", "manual"}},
+	"DeliverySubscriptionRequest.channel":           {"enum": []string{"email", "webhook"}},
+	"DeliverySubscriptionRequest.send_at":           {"description": "每天推送的本地时间 HH:MM", "example": "20:00"},
+	"DeliverySubscriptionRequest.target":            {"description": "邮箱地址或 Webhook URL"},
+	"DeliverySubscriptionRequest.timezone":          {"example": "Asia/Shanghai"},
+	"ErrorResponse.code":                            {"description": "稳定的错误码", "enum": errorCodes()},
+	"ErrorResponse.details":                         {"description": "可选的结构化补充信息，如 CHILD_AGE_INVALID 的 min/max、SHARE_EXPIRED 的 expired_at"},
+	"ErrorResponse.error":                           {"description": "可读文案，按 Accept-Language 本地化，可能附带补充说明；客户端不应据此分支"},
+	"GeoJSONFeature.type":                           {"example": "Feature"},
+	"GeoJSONFeatureCollection.type":                 {"example": "FeatureCollection"},
+	"GeoJSONGeometry.coordinates":                   {"description": "[longitude, latitude]"},
+	"GeoJSONGeometry.type":                          {"example": "Point"},
+	"GeoPoint.accuracy_m":                           {"description": "不小于降低精度带来的误差"},
+	"GroupJoinRequest.child_age":                    {"minimum": 3, "maximum": 15},
+	"GroupRequest.hide_counts_under_age":            {"description": "大于 0 时，小于该年龄的孩子在排行榜上看不到具体数量", "minimum": 0, "maximum": 16},
+	"GroupRequest.kind":                             {"enum": []string{"family", "classroom"}, "default": "family"},
+	"GroupRequest.owner_id":                         {"description": "群主（家长或老师）ID"},
+	"HealthResponse.status":                         {"example": "ok"},
+	"PeriodReport.narrative_source":                 {"enum": []string{"llm", "template"}},
+	"PeriodReport.period":                           {"enum": []string{service.ReportPeriodWeekly, service.ReportPeriodMonthly}},
+	"PokedexBadge.available":                        {"description": "限时勋章是否处于开放窗口内"},
+	"ProfileRequest.locale":                         {"description": "zh-CN、en 或 zh-TW（也接受 en-US、zh-HK 等标签）；空字符串表示清除设置"},
+	"ProgressEvent.reason":                          {"description": "xp_gained 的来源：capture/first_object_type/first_try_correct/companion_chat/quest_reward"},
+	"ProgressEvent.type":                            {"enum": []string{"xp_gained", "level_up", "streak_started", "streak_extended", "streak_grace_used", "streak_reset"}},
+	"QuestClaimRequest.locale":                      localeProperty(),
+	"Quest.type":                                    {"enum": []string{"capture", "first_try_correct", "companion_chat"}},
+	"ReviewAnswerRequest.locale":                    localeProperty(),
+	"ScanImageResponse.detected_label":              {"description": "中文识别结果"},
+	"ScanImageResponse.detected_label_en":           {"description": "英文标准标签(mailbox/tree/manhole/road_sign/traffic_light)"},
+	"ScanRequest.accuracy":                          {"description": "可选，定位精度（米）"},
+	"ScanRequest.latitude":                          {"description": "可选，与 longitude 成对提供；保存时会降低精度", "minimum": -90, "maximum": 90},
+	"ScanRequest.locale":                            localeProperty(),
+	"ScanRequest.longitude":                         {"minimum": -180, "maximum": 180},
+	"ScanResponse.object_name":                      {"description": "对象在本次请求语言下的展示名"},
+	"Spirit.child_id":                               {"description": "专属精灵的主人"},
+	"Spirit.image_stage":                            {"description": "image_url 对应的阶段"},
+	"Spirit.image_url":                              {"description": "角色形象，进入新阶段后会更新"},
+	"SpiritEvolution.image_pending":                 {"description": "新阶段形象正在后台生成"},
+	"SpiritShareRequest.expires_in_days":            {"default": 30, "minimum": 1, "maximum": 365},
+	"TradeDetail.status":                            {"enum": []string{"pending", "accepted", "declined", "cancelled"}},
+	"TradeProposal.capture_id":                      {"description": "发起人拿出的收集"},
+	"TradeProposal.child_id":                        {"description": "发起人"},
+	"TradeProposal.recipient_capture_id":            {"description": "想换取的对方收集"},
+}
+
+// schemaRequired 为请求体登记必填字段。
+var schemaRequired = map[string][]string{
+	"AnswerRequest":               {"session_id", "child_id", "answer"},
+	"BadgeRule":                   {"id"},
+	"CompanionChatRequest":        {"child_age", "object_type", "child_message"},
+	"CompanionSceneRequest":       {"child_age", "object_type"},
+	"CompanionVoiceRequest":       {"child_age", "object_type", "text"},
+	"DeliverySubscriptionRequest": {"child_id", "channel", "target", "send_at"},
+	"ErrorResponse":               {"error", "code"},
+	"GroupJoinRequest":            {"invite_code", "child_id"},
+	"GroupRequest":                {"name", "owner_id"},
+	"ProfileRequest":              {"child_id", "locale"},
+	"ReviewAnswerRequest":         {"review_id", "child_id", "answer"},
+	"ScanImageRequest":            {"child_id", "child_age"},
+	"ScanRequest":                 {"child_id", "child_age"},
+	"SpiritShareRequest":          {"child_id", "capture_id"},
+	"TradeActionRequest":          {"child_id"},
+	"TradeProposal":               {"group_id", "child_id", "capture_id", "recipient_id", "recipient_capture_id"},
+	"UploadImageForm":             {"file"},
+}
+
+// schemaDescriptions 为组件补充整体说明。
+var schemaDescriptions = map[string]string{
+	"ErrorResponse":            errorCodeTable(),
+	"GeoJSONFeatureCollection": "单条收集的 properties 含 capture_id、spirit_id、spirit_name、object_type、captured_at、accuracy_m；聚合要素的 properties 含 cluster=true、point_count、object_types、latest_at。",
+	"PeriodTrend":              "本周期减去上一周期的差值",
+	"ScanRequest":              "支持两种模式：1) 传 detected_label；2) 传 image_url 或 image_base64（自动识别后再出题）。",
+}
+
+// schemaExamples 为组件登记示例，写法与路由上的 Example 相同。
+var schemaExamples = map[string]any{
+	"ErrorResponse": ErrorResponse{Error: service.ErrRequestBodyInvalid.Message, Code: service.ErrRequestBodyInvalid.Code},
+}
+
+// errorCodes 与 errorCodeTable 取自 service 的错误码目录，新增错误码时文档自动更新。
+func errorCodes() []string {
+	catalog := service.ErrorCatalog()
+	codes := make([]string, 0, len(catalog))
+	for _, e := range catalog {
+		codes = append(codes, e.Code)
+	}
+	return codes
+}
+
+func errorCodeTable() string {
+	var table strings.Builder
+	table.WriteString("错误码与 HTTP 状态：\n\n| code | status | 默认文案 |\n| --- | --- | --- |\n")
+	for _, e := range service.ErrorCatalog() {
+		fmt.Fprintf(&table, "| %s | %d | %s |\n", e.Code, e.Status, e.Message)
+	}
+	return table.String()
+}
+
+func localeEnum() []string {
+	locales := make([]string, 0, len(i18n.Supported))
+	for _, locale := range i18n.Supported {
+		locales = append(locales, string(locale))
+	}
+	return locales
+}
+
+// localeProperty 描述请求体里的可选 locale 字段。
+func localeProperty() map[string]any {
+	return map[string]any{
+		"enum":        localeEnum(),
+		"description": "可选，生成内容与提示文案的语言；未传时取 Accept-Language，孩子资料中设置的语言优先",
+	}
+}
@@ -0,0 +1,185 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"maps"
+	"mime/multipart"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+)
+
+// schemaRegistry 把请求/响应类型反射成 components/schemas。
+// 组件名取 Go 类型名（首字母大写），不同包的同名类型加包名前缀；字段名与 encoding/json 的规则一致。
+type schemaRegistry struct {
+	schemas map[string]any
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]any),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func (r *schemaRegistry) schemaOf(v any) map[string]any {
+	return r.schema(reflect.TypeOf(v))
+}
+
+func (r *schemaRegistry) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case fileHeaderType:
+		return map[string]any{"type": "string", "format": "binary"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return r.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": r.schema(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object", "additionalProperties": true}
+		}
+		return map[string]any{"type": "object", "additionalProperties": r.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t, "")
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + r.component(t)}
+	}
+	return map[string]any{}
+}
+
+// component 注册具名结构体并返回组件名；先占位再展开，自引用的类型也不会死循环。
+func (r *schemaRegistry) component(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	name := upperFirst(t.Name())
+	if _, taken := r.schemas[name]; taken {
+		name = upperFirst(path.Base(t.PkgPath())) + name
+	}
+	r.names[t] = name
+	r.schemas[name] = map[string]any{}
+	r.schemas[name] = r.structSchema(t, name)
+	return name
+}
+
+// structSchema 展开字段，具名组件再叠加 openapi_docs.go 里登记的描述、必填字段与示例。
+func (r *schemaRegistry) structSchema(t reflect.Type, name string) map[string]any {
+	properties := make(map[string]any)
+	r.addFields(properties, t)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if name == "" {
+		return schema
+	}
+	for field, prop := range properties {
+		if doc, ok := fieldDocs[name+"."+field]; ok {
+			maps.Copy(prop.(map[string]any), doc)
+		}
+	}
+	if required, ok := schemaRequired[name]; ok {
+		schema["required"] = required
+	}
+	if description, ok := schemaDescriptions[name]; ok {
+		schema["description"] = description
+	}
+	if example, ok := schemaExamples[name]; ok {
+		schema["example"] = exampleValue(example)
+	}
+	return schema
+}
+
+func (r *schemaRegistry) addFields(properties map[string]any, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(properties, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = r.schema(field.Type)
+	}
+}
+
+// exampleValue 把示例值按 JSON 编码规则转成文档里的对象，并去掉零值字段，只保留示例真正填写的部分。
+func exampleValue(v any) any {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil
+	}
+	if object, ok := decoded.(map[string]any); ok {
+		for key, value := range object {
+			if isZeroJSON(value) {
+				delete(object, key)
+			}
+		}
+	}
+	return decoded
+}
+
+func isZeroJSON(v any) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case float64:
+		return value == 0
+	case bool:
+		return !value
+	case []any:
+		return len(value) == 0
+	case map[string]any:
+		return len(value) == 0
+	}
+	return false
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) profile(w http.ResponseWriter, r *http.Request) {
+	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
+	profile, err := h.svc.Profile(r.Context(), childID)
+	if err != nil {
+		writeServiceError(w, r, "profile", err, "child_id", childID)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (h *Handler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	var req service.ProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "updateProfile", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+
+	profile, err := h.svc.UpdateProfile(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "updateProfile", err, "child_id", req.ChildID, "locale", req.Locale)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
@@ -0,0 +1,16 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+func (h *Handler) progress(w http.ResponseWriter, r *http.Request) {
+	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
+	profile, err := h.svc.Progress(r.Context(), childID)
+	if err != nil {
+		writeServiceError(w, r, "progress", err, "child_id", childID)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
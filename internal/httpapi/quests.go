@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) quests(w http.ResponseWriter, r *http.Request) {
+	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
+	board, err := h.svc.Quests(r.Context(), childID, localeHint(r, r.URL.Query().Get("locale")))
+	if err != nil {
+		writeServiceError(w, r, "quests", err, "child_id", childID)
+		return
+	}
+	writeJSON(w, http.StatusOK, board)
+}
+
+func (h *Handler) claimQuest(w http.ResponseWriter, r *http.Request) {
+	questID := r.PathValue("id")
+	var req service.QuestClaimRequest
+	// 请求体可以省略；提供 child_id 时会校验任务归属。
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		logBadRequest(r, "claimQuest", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+
+	req.Locale = localeHint(r, req.Locale)
+	resp, err := h.svc.ClaimQuest(r.Context(), questID, req)
+	if err != nil {
+		writeServiceError(w, r, "claimQuest", err, "quest_id", questID)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,247 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ling/internal/metrics"
+	"ling/internal/ratelimit"
+	"ling/internal/service"
+)
+
+// 限流类别，见 route.RateLimit。
+const (
+	rateLimitDefault = "default"
+	// rateLimitLLM 用于会调用大模型、生图或 TTS 的接口，限额更紧，避免单个客户端耗尽上游配额。
+	rateLimitLLM = "llm"
+	// rateLimitExempt 用于探针、指标与文档，这些接口不限流。
+	rateLimitExempt = "exempt"
+)
+
+// maxRateKeyPeek 为从 JSON 请求体中查找 child_id 时最多读取的字节数；child_id 排在超长字段
+// （如 image_base64）之后时找不到，这类请求只计入 IP 令牌桶。
+const maxRateKeyPeek = 64 << 10
+
+// RateLimitConfig 配置按调用方计数的令牌桶限流。
+type RateLimitConfig struct {
+	// Backend 为 nil 时不限流。
+	Backend ratelimit.Backend
+	// Limits 的键为限流类别（default、llm）或路由模式（如 "POST /api/v1/scan/image"），路由模式优先；
+	// 未配置的类别使用 DefaultRateLimits，配成零值表示不限流。
+	Limits map[string]ratelimit.Limit
+	// ChildLimits 与 Limits 的键相同，为同一 IP 下每个 child_id 额外叠加的更严格限额；默认为空，不按孩子计数。
+	ChildLimits map[string]ratelimit.Limit
+	// TrustProxy 为 true 时取 X-Forwarded-For 的最后一跳作为客户端 IP，只应在服务位于可信反向代理之后时开启。
+	TrustProxy bool
+}
+
+// DefaultRateLimits 返回各限流类别的默认限额。
+func DefaultRateLimits() map[string]ratelimit.Limit {
+	return map[string]ratelimit.Limit{
+		rateLimitDefault: {Burst: 120, Period: time.Minute},
+		rateLimitLLM:     {Burst: 20, Period: time.Minute},
+	}
+}
+
+// SetRateLimit 开启限流，cfg.Limits 覆盖在 DefaultRateLimits 之上。
+func (h *Handler) SetRateLimit(cfg RateLimitConfig) {
+	limits := DefaultRateLimits()
+	for name, limit := range cfg.Limits {
+		limits[name] = limit
+	}
+	cfg.Limits = limits
+	h.rateLimit = cfg
+}
+
+// rateBucket 是一次请求要扣减的一个令牌桶。
+type rateBucket struct {
+	kind  string
+	key   string
+	limit ratelimit.Limit
+}
+
+// withRateLimit 为每条路由、每个调用方维护令牌桶。每个请求都计入管理账号或客户端 IP 的令牌桶；
+// 配置了 ChildLimits 时，带 child_id 的请求再计入同一 IP 下该孩子的令牌桶，child_id 只能收紧限额，
+// 换一个 child_id 绕不过 IP 限额。任一令牌桶拒绝时退回本次已从其他桶扣掉的令牌，被限流的孩子
+// 不会连带消耗同一 IP 下其他孩子的额度。放行的响应带上最紧的那个令牌桶的 RateLimit-* 头，
+// 超限时返回 429 RATE_LIMITED 与 Retry-After。后端出错时放行（fail open），共享后端故障不应拖垮整个服务。
+func (h *Handler) withRateLimit(rt route, next http.HandlerFunc) http.HandlerFunc {
+	class := rt.RateLimit
+	if class == "" {
+		class = rateLimitDefault
+	}
+	if class == rateLimitExempt {
+		return next
+	}
+	pattern := rt.Method + " " + rt.Path
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := h.rateLimit
+		if cfg.Backend == nil {
+			next(w, r)
+			return
+		}
+		buckets := h.rateBuckets(r, cfg, pattern, class)
+		var tightest *ratelimit.Decision
+		var taken []rateBucket
+		for _, bucket := range buckets {
+			decision, err := cfg.Backend.Take(r.Context(), pattern+"|"+bucket.key, bucket.limit)
+			if err != nil {
+				metrics.RateLimitBackendErrors.Inc()
+				slog.WarnContext(r.Context(), "rate limit backend failed, allowing request", "err", err)
+				continue
+			}
+			if !decision.Allowed {
+				refundRateLimit(r, cfg.Backend, pattern, taken)
+				rejectRateLimited(w, r, pattern, bucket, decision)
+				return
+			}
+			taken = append(taken, bucket)
+			if tightest == nil || decision.Remaining < tightest.Remaining {
+				tightest = &decision
+			}
+		}
+		if tightest != nil {
+			setRateLimitHeaders(w.Header(), *tightest)
+		}
+		next(w, r)
+	}
+}
+
+// rateBuckets 返回请求要扣减的令牌桶，第一个总是管理账号或客户端 IP。
+func (h *Handler) rateBuckets(r *http.Request, cfg RateLimitConfig, pattern string, class string) []rateBucket {
+	var buckets []rateBucket
+	if limit := routeLimit(cfg.Limits, pattern, class); limit.Enabled() {
+		kind, id := h.rateLimitKey(r, cfg.TrustProxy)
+		buckets = append(buckets, rateBucket{kind: kind, key: kind + ":" + id, limit: limit})
+	}
+	childLimit := routeLimit(cfg.ChildLimits, pattern, class)
+	if !childLimit.Enabled() || h.adminAuthorized(r) {
+		return buckets
+	}
+	if childID := requestChildID(r); childID != "" {
+		key := "ip:" + clientIP(r, cfg.TrustProxy) + "|child:" + childID
+		buckets = append(buckets, rateBucket{kind: "child", key: key, limit: childLimit})
+	}
+	return buckets
+}
+
+// refundRateLimit 退回被拒请求已扣掉的令牌；退回失败只记日志，最多让该桶少一个令牌。
+func refundRateLimit(r *http.Request, backend ratelimit.Backend, pattern string, taken []rateBucket) {
+	for _, bucket := range taken {
+		if err := backend.Refund(r.Context(), pattern+"|"+bucket.key, bucket.limit); err != nil {
+			metrics.RateLimitBackendErrors.Inc()
+			slog.WarnContext(r.Context(), "rate limit refund failed", "rate_limit_key", bucket.kind, "err", err)
+		}
+	}
+}
+
+func routeLimit(limits map[string]ratelimit.Limit, pattern string, class string) ratelimit.Limit {
+	if limit, ok := limits[pattern]; ok {
+		return limit
+	}
+	return limits[class]
+}
+
+func setRateLimitHeaders(header http.Header, decision ratelimit.Decision) {
+	header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+}
+
+func rejectRateLimited(w http.ResponseWriter, r *http.Request, pattern string, bucket rateBucket, decision ratelimit.Decision) {
+	setRateLimitHeaders(w.Header(), decision)
+	retryAfter := max(ceilSeconds(decision.RetryAfter), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	metrics.RateLimited.Inc(pattern, bucket.kind)
+	writeServiceError(w, r, "rate_limit", service.ErrRateLimited.WithDetails(map[string]any{
+		"limit":               decision.Limit,
+		"period_seconds":      int(bucket.limit.Period.Seconds()),
+		"retry_after_seconds": retryAfter,
+	}), "rate_limit_key", bucket.kind)
+}
+
+// rateLimitKey 识别必须计数的调用方：带有效管理令牌时为 account，否则为客户端 ip。
+func (h *Handler) rateLimitKey(r *http.Request, trustProxy bool) (kind string, id string) {
+	if h.adminAuthorized(r) {
+		return "account", "admin"
+	}
+	return "ip", clientIP(r, trustProxy)
+}
+
+// requestChildID 先查 query，再查 JSON 请求体的顶层 child_id。读过的请求体会原样放回，处理器仍能完整解析。
+func requestChildID(r *http.Request) string {
+	if childID := strings.TrimSpace(r.URL.Query().Get("child_id")); childID != "" {
+		return childID
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return ""
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return ""
+	}
+	var peeked bytes.Buffer
+	dec := json.NewDecoder(io.TeeReader(io.LimitReader(r.Body, maxRateKeyPeek), &peeked))
+	childID := topLevelString(dec, "child_id")
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(&peeked, r.Body), r.Body}
+	return strings.TrimSpace(childID)
+}
+
+// topLevelString 逐个跳过顶层字段，直到找到字符串字段 name；格式不对或超出读取上限时返回空串。
+func topLevelString(dec *json.Decoder, name string) string {
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return ""
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		key, ok := tok.(string)
+		if err != nil || !ok {
+			return ""
+		}
+		if key == name {
+			var value string
+			if err := dec.Decode(&value); err != nil {
+				return ""
+			}
+			return value
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return ""
+		}
+	}
+	return ""
+}
+
+// clientIP 返回调用方 IP。trustProxy 时取 X-Forwarded-For 的最后一跳，即可信代理看到的对端地址；
+// 更靠前的条目由客户端自行填写，不可信。
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			hops := strings.Split(forwarded, ",")
+			if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) weeklyReport(w http.ResponseWriter, r *http.Request) {
+	h.periodReport(w, r, service.ReportPeriodWeekly)
+}
+
+func (h *Handler) monthlyReport(w http.ResponseWriter, r *http.Request) {
+	h.periodReport(w, r, service.ReportPeriodMonthly)
+}
+
+func (h *Handler) periodReport(w http.ResponseWriter, r *http.Request, period string) {
+	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
+	dateParam := strings.TrimSpace(r.URL.Query().Get("date"))
+	anchor := time.Now()
+	if dateParam != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateParam, time.Local)
+		if err != nil {
+			logBadRequest(r, period+"Report", "invalid parameter", "child_id", childID, "date", dateParam, "err", err)
+			writeError(w, r, fmt.Errorf("%w: date 必须是 YYYY-MM-DD 格式", service.ErrQueryInvalid))
+			return
+		}
+		anchor = parsed
+	}
+
+	report, err := h.svc.PeriodReport(r.Context(), childID, period, anchor, localeHint(r, r.URL.Query().Get("locale")))
+	if err != nil {
+		writeServiceError(w, r, period+"Report", err, "child_id", childID, "date", anchor.Format("2006-01-02"))
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
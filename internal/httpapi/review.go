@@ -0,0 +1,51 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) reviewDue(w http.ResponseWriter, r *http.Request) {
+	childID := strings.TrimSpace(r.URL.Query().Get("child_id"))
+	limit := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			logBadRequest(r, "reviewDue", "invalid parameter", "child_id", childID, "limit", raw)
+			writeError(w, r, fmt.Errorf("%w: limit 必须是正整数", service.ErrQueryInvalid))
+			return
+		}
+		limit = parsed
+	}
+
+	items, err := h.svc.DueReviews(r.Context(), childID, time.Now(), limit)
+	if err != nil {
+		writeServiceError(w, r, "reviewDue", err, "child_id", childID)
+		return
+	}
+	writeJSON(w, http.StatusOK, ReviewDueResponse{ChildID: childID, Items: items})
+}
+
+func (h *Handler) reviewAnswer(w http.ResponseWriter, r *http.Request) {
+	var req service.ReviewAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "reviewAnswer", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+
+	req.Locale = localeHint(r, req.Locale)
+	resp, err := h.svc.SubmitReview(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "reviewAnswer", err, "review_id", req.ReviewID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
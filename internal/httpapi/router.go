@@ -1,33 +1,81 @@
 package httpapi
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"ling/internal/logging"
+)
+
+// 接口处理时限，见 route.Timeout。
+const (
+	defaultRouteTimeout = 15 * time.Second
+	// llmRouteTimeout 覆盖一次识别或生成调用及其重试、换模型。
+	llmRouteTimeout = 60 * time.Second
+	// companionSceneTimeout 覆盖剧情文案、并发的生图与语音以及随后的形象下载。
+	companionSceneTimeout = 120 * time.Second
 )
 
+// requestIDHeader 用于透传请求 ID：调用方传入的合法值原样沿用，否则由服务端生成，并在响应头返回。
+const requestIDHeader = "X-Request-ID"
+
 func NewRouter(handler *Handler) http.Handler {
+	routes := handler.routes()
+	handler.spec = openAPISpec(routes)
+	mux := newMux(handler, routes)
+	return withRequestID(withRoute(mux, withTracing(withRequestLogging(withMetrics(handler.withLocale(withCORS(withJSONContentType(mux))))))))
+}
+
+func newMux(handler *Handler, routes []route) *http.ServeMux {
 	mux := http.NewServeMux()
+	for _, rt := range routes {
+		next := rt.Handler
+		if rt.Admin {
+			next = handler.requireAdmin(next)
+		}
+		next = handler.withRateLimit(rt, next)
+		timeout := rt.Timeout
+		if timeout <= 0 {
+			timeout = defaultRouteTimeout
+		}
+		next = withDeadline(timeout, next)
+		mux.HandleFunc(rt.Method+" "+rt.Path, next)
+	}
+	return mux
+}
+
+// withDeadline 给请求 context 加上处理时限。客户端断开时 net/http 会取消同一个 context，
+// 两种情况下 service 与上游调用都会提前结束，超时响应 504 REQUEST_TIMEOUT。
+func withDeadline(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
+
+type routeKey struct{}
+
+// withRoute 预先用 mux 解析出请求命中的路由模式放进 context。
+// ServeMux 只在它收到的那个请求对象上设置 Pattern，外层中间件经 WithContext 派生请求后就看不到，
+// 因此访问日志、指标与链路追踪统一从 context 读取。
+func withRoute(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := mux.Handler(r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, pattern)))
+	})
+}
 
-	mux.HandleFunc("GET /healthz", handler.healthz)
-	mux.HandleFunc("GET /docs", handler.swaggerUI)
-	mux.HandleFunc("GET /docs/", handler.swaggerUI)
-	mux.HandleFunc("GET /docs/openapi.json", handler.swaggerSpec)
-	mux.HandleFunc("GET /swagger", handler.swaggerUI)
-	mux.HandleFunc("GET /swagger/", handler.swaggerUI)
-	mux.HandleFunc("GET /swagger/openapi.json", handler.swaggerSpec)
-	mux.HandleFunc("POST /api/v1/scan", handler.scan)
-	mux.HandleFunc("POST /api/v1/scan/image", handler.scanImage)
-	mux.HandleFunc("POST /api/v1/media/upload", handler.uploadImage)
-	mux.HandleFunc("POST /api/v1/companion/scene", handler.companionScene)
-	mux.HandleFunc("POST /api/v1/companion/chat", handler.companionChat)
-	mux.HandleFunc("POST /api/v1/companion/voice", handler.companionVoice)
-	mux.HandleFunc("POST /api/v1/answer", handler.answer)
-	mux.HandleFunc("GET /api/v1/pokedex", handler.pokedex)
-	mux.HandleFunc("GET /api/v1/pokedex/badges", handler.pokedexBadges)
-	mux.HandleFunc("GET /api/v1/report/daily", handler.dailyReport)
-
-	return withRequestLogging(withCORS(withJSONContentType(mux)))
+// routePattern 返回请求命中的路由模式，未匹配到路由时为空串。
+func routePattern(r *http.Request) string {
+	if pattern, ok := r.Context().Value(routeKey{}).(string); ok {
+		return pattern
+	}
+	return r.Pattern
 }
 
 func withJSONContentType(next http.Handler) http.Handler {
@@ -39,20 +87,75 @@ func withJSONContentType(next http.Handler) http.Handler {
 	})
 }
 
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID 只接受不超过 128 个字符的可见 ASCII，避免把任意内容写进日志。
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// withRequestLogging 在请求结束时输出一条访问日志；处理过程中通过 logging.AddFields 补充的字段
+// （如 child_id、object_type）会一并输出。route 取自路由表中的模式，便于按接口聚合。
 func withRequestLogging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		ctx, fields := logging.WithFields(r.Context())
+		r = r.WithContext(ctx)
 		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(rec, r)
-		log.Printf("%s %s -> %d (%s) from %s", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Truncate(time.Millisecond), r.RemoteAddr)
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", routePattern(r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+			slog.String("remote_addr", r.RemoteAddr),
+		}
+		attrs = append(attrs, fields.Attrs()...)
+		slog.LogAttrs(ctx, statusLevel(rec.status), "http request", attrs...)
 	})
 }
 
+func statusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
 func withCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Admin-Token, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset")
 		w.Header().Set("Access-Control-Max-Age", "600")
 
 		if r.Method == http.MethodOptions {
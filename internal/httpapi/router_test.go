@@ -2,14 +2,32 @@ package httpapi
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 
 	"ling/internal/knowledge"
+	"ling/internal/logging"
+	"ling/internal/metrics"
+	"ling/internal/ratelimit"
 	"ling/internal/service"
 	"ling/internal/store"
+	"ling/internal/tracing"
 )
 
 func TestWithCORSPreflight(t *testing.T) {
@@ -57,6 +75,396 @@ func TestWithCORSAddsHeadersForNormalRequest(t *testing.T) {
 	}
 }
 
+func TestRequestIDAndAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, slog.LevelInfo, logging.FormatJSON))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(st, knowledge.BaseKnowledge)))
+
+	body := []byte(`{"child_id":"kid_1","child_age":8,"detected_label":"路灯"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/scan", bytes.NewReader(body))
+	req.Header.Set(requestIDHeader, "client-req-1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get(requestIDHeader); got != "client-req-1" {
+		t.Fatalf("expected incoming request id to be echoed, got %q", got)
+	}
+
+	var access map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("expected json log line, got %q: %v", line, err)
+		}
+		if entry["msg"] == "http request" {
+			access = entry
+		}
+	}
+	if access == nil {
+		t.Fatalf("expected an access log entry, got %q", buf.String())
+	}
+	want := map[string]any{
+		"request_id":  "client-req-1",
+		"route":       "POST /api/v1/scan",
+		"status":      float64(http.StatusOK),
+		"child_id":    "kid_1",
+		"object_type": "路灯",
+	}
+	for key, value := range want {
+		if access[key] != value {
+			t.Errorf("access log %s = %v, want %v", key, access[key], value)
+		}
+	}
+	if _, ok := access["latency_ms"]; !ok {
+		t.Errorf("expected latency_ms in access log %v", access)
+	}
+
+	for _, incoming := range []string{"", "has space", strings.Repeat("x", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		if incoming != "" {
+			req.Header.Set(requestIDHeader, incoming)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		got := rec.Header().Get(requestIDHeader)
+		if len(got) != 32 || got == incoming {
+			t.Errorf("expected a generated request id for %q, got %q", incoming, got)
+		}
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(store.Observe(st, func(_ context.Context, operation string, start time.Time, err error) {
+		metrics.ObserveStore(operation, start, err)
+	}), knowledge.BaseKnowledge)))
+
+	for i := 0; i < 2; i++ {
+		body := []byte(`{"child_id":"kid_metrics","child_age":8,"detected_label":"路灯"}`)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/scan", bytes.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("scan status = %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/route", nil))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("metrics status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", got)
+	}
+	text := rec.Body.String()
+	for _, want := range []string{
+		`cityling_http_requests_total{route="POST /api/v1/scan",status="200"}`,
+		`cityling_http_request_duration_seconds_bucket{route="POST /api/v1/scan",status="200",le="+Inf"}`,
+		`cityling_http_requests_total{route="unmatched",status="404"}`,
+		`cityling_scan_cache_lookups_total{result="hit"}`,
+		`cityling_scan_cache_lookups_total{result="miss"}`,
+		"cityling_scan_cache_hit_ratio ",
+		`cityling_content_source_total{kind="learning",source="`,
+		`cityling_store_operation_duration_seconds_count{operation="SaveSession"}`,
+		"# TYPE cityling_upstream_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestTracingSpansFollowIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	router := NewRouter(NewHandler(service.New(store.Observe(st, tracing.ObserveStore), knowledge.BaseKnowledge)))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	body := []byte(`{"child_id":"kid_trace","child_age":8,"detected_label":"路灯"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/scan", bytes.NewReader(body))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	req.Header.Set(requestIDHeader, "trace-req-1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scan status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		if got := span.SpanContext().TraceID().String(); got != traceID {
+			t.Errorf("span %s trace id = %s, want %s", span.Name(), got, traceID)
+		}
+		spans[span.Name()] = span
+	}
+	server, ok := spans["POST /api/v1/scan"]
+	if !ok {
+		t.Fatalf("expected a server span named after the route, got %v", spanNames(recorder.Ended()))
+	}
+	if server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("server span kind = %v", server.SpanKind())
+	}
+	wantServerAttrs := map[attribute.Key]attribute.Value{
+		"http.route":                attribute.StringValue("POST /api/v1/scan"),
+		"http.response.status_code": attribute.IntValue(http.StatusOK),
+		"request_id":                attribute.StringValue("trace-req-1"),
+	}
+	assertSpanAttrs(t, server, wantServerAttrs)
+
+	scan, ok := spans["Service.Scan"]
+	if !ok {
+		t.Fatalf("expected a Service.Scan span, got %v", spanNames(recorder.Ended()))
+	}
+	if scan.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("Service.Scan parent = %s, want server span %s", scan.Parent().SpanID(), server.SpanContext().SpanID())
+	}
+	assertSpanAttrs(t, scan, map[attribute.Key]attribute.Value{
+		"child_id":    attribute.StringValue("kid_trace"),
+		"object_type": attribute.StringValue("路灯"),
+	})
+
+	save, ok := spans["store.SaveSession"]
+	if !ok {
+		t.Fatalf("expected a store.SaveSession span, got %v", spanNames(recorder.Ended()))
+	}
+	if save.Parent().SpanID() != scan.SpanContext().SpanID() {
+		t.Errorf("store.SaveSession parent = %s, want Service.Scan %s", save.Parent().SpanID(), scan.SpanContext().SpanID())
+	}
+}
+
+func TestTracingCoversSubmitAnswerStoreWrites(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previousProvider) })
+
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	svc := service.New(store.Observe(st, tracing.ObserveStore), knowledge.BaseKnowledge)
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{ChildID: "kid_trace", ChildAge: 8, DetectedLabel: "mailbox"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	session, _, err := st.GetSession(scanResp.SessionID)
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	recorder.Reset()
+
+	router := NewRouter(NewHandler(svc))
+	body, _ := json.Marshal(map[string]string{"session_id": session.ID, "child_id": "kid_trace", "answer": session.QuizA})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/answer", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("answer status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var answer sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "Service.SubmitAnswer" {
+			answer = span
+		}
+	}
+	if answer == nil {
+		t.Fatalf("expected a Service.SubmitAnswer span, got %v", spanNames(recorder.Ended()))
+	}
+	want := map[string]bool{"store.SaveProgress": false, "store.SaveReviewItem": false, "store.SaveQuest": false, "store.SaveSpirit": false}
+	for _, span := range recorder.Ended() {
+		if _, ok := want[span.Name()]; ok && span.Parent().SpanID() == answer.SpanContext().SpanID() {
+			want[span.Name()] = true
+		}
+	}
+	for name, seen := range want {
+		if !seen {
+			t.Errorf("expected %s under Service.SubmitAnswer, got %v", name, spanNames(recorder.Ended()))
+		}
+	}
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	return names
+}
+
+func assertSpanAttrs(t *testing.T, span sdktrace.ReadOnlySpan, want map[attribute.Key]attribute.Value) {
+	t.Helper()
+	got := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		got[kv.Key] = kv.Value
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("span %s attribute %s = %v, want %v", span.Name(), key, got[key].Emit(), value.Emit())
+		}
+	}
+}
+
+func TestRouteDeadlineReturns504(t *testing.T) {
+	handler := withDeadline(20*time.Millisecond, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		writeServiceError(w, r, "slow", fmt.Errorf("upstream: %w", r.Context().Err()))
+	})
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected status 504, got %d", rec.Code)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	if body.Code != service.CodeRequestTimeout {
+		t.Fatalf("expected code %s, got %s", service.CodeRequestTimeout, body.Code)
+	}
+}
+
+func TestRateLimitChargesIPAndNarrowsPerChild(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	handler := NewHandler(service.New(st, knowledge.BaseKnowledge))
+	handler.SetRateLimit(RateLimitConfig{
+		Backend:     ratelimit.NewMemoryBackend(),
+		Limits:      map[string]ratelimit.Limit{rateLimitLLM: {Burst: 3, Period: time.Minute}},
+		ChildLimits: map[string]ratelimit.Limit{rateLimitLLM: {Burst: 2, Period: time.Minute}},
+	})
+	router := NewRouter(handler)
+	scan := func(childID string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"child_age":8,"detected_label":"路灯","child_id":%q}`, childID)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/scan", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) ErrorResponse {
+		var body ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode error response: %v", err)
+		}
+		return body
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		rec := scan("kid_a")
+		// 限流读过的请求体仍能被处理器完整解析。
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "session_id") {
+			t.Fatalf("request %d: expected 200, got %d body=%s", i, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != wantRemaining {
+			t.Fatalf("request %d: expected the tighter child bucket's remaining %s, got %q", i, wantRemaining, got)
+		}
+	}
+
+	childBefore := metrics.RateLimited.Value("POST /api/v1/scan", "child")
+	rec := scan("kid_a")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected per-child 429, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Fatalf("expected Retry-After 30, got %q", got)
+	}
+	if body := decode(rec); body.Code != service.CodeRateLimited || body.Details["retry_after_seconds"] != float64(30) {
+		t.Fatalf("unexpected 429 body %+v", body)
+	}
+	if got := metrics.RateLimited.Value("POST /api/v1/scan", "child"); got != childBefore+1 {
+		t.Fatalf("expected child rate limited counter to grow by 1, got %v -> %v", childBefore, got)
+	}
+
+	// 被孩子令牌桶拒绝的请求退回了 IP 令牌，同一 IP 下的其他孩子不受影响。
+	rec = scan("kid_sibling")
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected the refunded IP token to serve a sibling, got %d remaining=%q body=%s", rec.Code, rec.Header().Get("RateLimit-Remaining"), rec.Body.String())
+	}
+
+	// 换一个 child_id 仍计入同一个 IP 令牌桶，绕不过 IP 限额。
+	ipBefore := metrics.RateLimited.Value("POST /api/v1/scan", "ip")
+	rec = scan("kid_fresh")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected IP 429 for a new child_id, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("RateLimit-Limit"); got != "3" {
+		t.Fatalf("expected IP bucket limit 3, got %q", got)
+	}
+	if got := metrics.RateLimited.Value("POST /api/v1/scan", "ip"); got != ipBefore+1 {
+		t.Fatalf("expected ip rate limited counter to grow by 1, got %v -> %v", ipBefore, got)
+	}
+
+	// 探针不限流。
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("expected /healthz to be exempt, got %d %v", rec.Code, rec.Header())
+		}
+	}
+}
+
+type failingRateLimitBackend struct{}
+
+func (failingRateLimitBackend) Take(context.Context, string, ratelimit.Limit) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("redis: connection refused")
+}
+
+func (failingRateLimitBackend) Refund(context.Context, string, ratelimit.Limit) error {
+	return errors.New("redis: connection refused")
+}
+
+func TestRateLimitFailsOpenAndKeysByProxiedIP(t *testing.T) {
+	h := &Handler{}
+	h.SetRateLimit(RateLimitConfig{Backend: failingRateLimitBackend{}, TrustProxy: true})
+	served := false
+	next := h.withRateLimit(route{Method: http.MethodGet, Path: "/api/v1/pokedex"}, func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	})
+	next(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/pokedex", nil))
+	if !served {
+		t.Fatalf("expected request to be served when the backend fails")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pokedex", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+	if kind, id := h.rateLimitKey(req, true); kind != "ip" || id != "198.51.100.7" {
+		t.Fatalf("expected last proxy hop, got %s %s", kind, id)
+	}
+	if _, id := h.rateLimitKey(req, false); id != "192.0.2.1" {
+		t.Fatalf("expected remote address without trusted proxy, got %s", id)
+	}
+}
+
 func TestCompanionSceneRouteRegistered(t *testing.T) {
 	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
 	if err != nil {
@@ -116,3 +524,73 @@ func TestCompanionVoiceRouteRegistered(t *testing.T) {
 		t.Fatalf("expected companion voice route to be registered, got 404")
 	}
 }
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	handler := NewHandler(service.New(st, knowledge.BaseKnowledge))
+	router := NewRouter(handler)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var spec struct {
+		Servers []map[string]string `json:"servers"`
+		Paths   map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode spec error = %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0]["url"] != "http://example.com" {
+		t.Fatalf("expected servers to follow the request host, got %+v", spec.Servers)
+	}
+
+	routes := handler.routes()
+	mux := newMux(handler, routes)
+	documented := 0
+	for _, rt := range routes {
+		pattern := rt.Method + " " + rt.Path
+		target := strings.NewReplacer("{", "", "}", "").Replace(rt.Path)
+		if _, got := mux.Handler(httptest.NewRequest(rt.Method, target, nil)); got != pattern {
+			t.Fatalf("expected %s to be registered, request matched %q", pattern, got)
+		}
+		if rt.Hidden {
+			continue
+		}
+		op, ok := spec.Paths[rt.Path][strings.ToLower(rt.Method)]
+		if !ok {
+			t.Fatalf("registered route %s is missing from the OpenAPI spec", pattern)
+		}
+		if op.OperationID == "" {
+			t.Fatalf("expected operationId for %s", pattern)
+		}
+		for _, name := range pathParamPattern.FindAllStringSubmatch(rt.Path, -1) {
+			found := false
+			for _, p := range op.Parameters {
+				found = found || (p.In == "path" && p.Name == name[1])
+			}
+			if !found {
+				t.Fatalf("expected path parameter %s documented for %s", name[1], pattern)
+			}
+		}
+		documented++
+	}
+
+	operations := 0
+	for _, item := range spec.Paths {
+		operations += len(item)
+	}
+	if operations != documented {
+		t.Fatalf("expected %d documented operations, got %d", documented, operations)
+	}
+}
@@ -0,0 +1,500 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+// route 同时描述路由注册与 OpenAPI 文档：NewRouter 按这张表注册，/docs/openapi.json 也由它生成，
+// 新增接口只需在 routes() 里加一项。请求体与响应体写成对应 Go 类型的零值，schema 由反射生成。
+type route struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+	// Admin 路由经过 requireAdmin，文档中标注 adminToken 并补上 401/403。
+	Admin bool
+	// Hidden 路由不写进文档，如文档页自身。
+	Hidden bool
+	// Timeout 为处理时限，到期后请求 context 取消，service 与上游调用随之结束；0 表示 defaultRouteTimeout。
+	// 调用大模型、生图或 TTS 的接口需要按上游耗时单独放宽。
+	Timeout time.Duration
+	// RateLimit 为限流类别：空串按 default，大模型相关接口用 llm，探针与文档用 exempt。
+	RateLimit string
+
+	Summary     string
+	Description string
+	Params      []param
+	// Body 为请求体类型的零值，nil 表示没有请求体。
+	Body          any
+	BodyOptional  bool
+	BodyMediaType string
+	// Example 为请求体示例，零值字段不会出现在文档里。
+	Example   any
+	Responses []response
+}
+
+// param 描述 query 或 path 参数；路径里的 {name} 未声明时按必填字符串补齐。
+type param struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Type        string
+	Enum        []string
+	Default     any
+	Maximum     int
+}
+
+// response 的 Body 为 nil 且状态码 >= 400 时使用 ErrorResponse。
+type response struct {
+	Status      int
+	Description string
+	Body        any
+	// MediaType 默认 application/json。
+	MediaType string
+	// Formats 为 format 参数可选的其他输出，如 text/html、application/pdf。
+	Formats []string
+}
+
+func childIDQuery() param {
+	return param{Name: "child_id", Description: "孩子 ID，默认 guest"}
+}
+
+func localeQuery() param {
+	return param{Name: "locale", Description: "文案语言，未传时取 Accept-Language；孩子资料中设置的语言优先", Enum: localeEnum()}
+}
+
+func groupViewerParams() []param {
+	return []param{
+		{Name: "child_id", Description: "以成员身份查看"},
+		{Name: "owner_id", Description: "以群主身份查看"},
+	}
+}
+
+func (h *Handler) routes() []route {
+	return []route{
+		{Method: http.MethodGet, Path: "/healthz", Handler: h.healthz, RateLimit: rateLimitExempt, Summary: "健康检查",
+			Responses: []response{{Status: http.StatusOK, Description: "OK", Body: HealthResponse{}}}},
+		// 存活与就绪探针供编排系统使用，不进 SDK 与文档；/readyz 的 503 响应体仍是依赖状态而不是 ErrorResponse。
+		{Method: http.MethodGet, Path: "/livez", Handler: h.livez, RateLimit: rateLimitExempt, Hidden: true},
+		{Method: http.MethodGet, Path: "/readyz", Handler: h.readyz, RateLimit: rateLimitExempt, Hidden: true},
+		{Method: http.MethodGet, Path: "/metrics", Handler: h.serveMetrics, RateLimit: rateLimitExempt, Hidden: true},
+		{Method: http.MethodGet, Path: "/docs", Handler: h.swaggerUI, RateLimit: rateLimitExempt, Hidden: true},
+		{Method: http.MethodGet, Path: "/docs/", Handler: h.swaggerUI, RateLimit: rateLimitExempt, Hidden: true},
+		{Method: http.MethodGet, Path: "/docs/openapi.json", Handler: h.swaggerSpec, RateLimit: rateLimitExempt, Hidden: true},
+		{Method: http.MethodGet, Path: "/swagger", Handler: h.swaggerUI, RateLimit: rateLimitExempt, Hidden: true},
+		{Method: http.MethodGet, Path: "/swagger/", Handler: h.swaggerUI, RateLimit: rateLimitExempt, Hidden: true},
+		{Method: http.MethodGet, Path: "/swagger/openapi.json", Handler: h.swaggerSpec, RateLimit: rateLimitExempt, Hidden: true},
+
+		{Method: http.MethodPost, Path: "/api/v1/scan", Handler: h.scan, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "根据图片或标签生成题目和科普",
+			Body:    service.ScanRequest{},
+			Example: service.ScanRequest{ChildID: "kid_1", ChildAge: 8, DetectedLabel: "路灯"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.ScanResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+				{Status: http.StatusServiceUnavailable, Description: "未配置大模型能力（图片识别场景）"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/scan/image", Handler: h.scanImage, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "传图片 URL（推荐）或 base64 并由大模型识别主体",
+			Body:    service.ScanImageRequest{},
+			Example: service.ScanImageRequest{ChildID: "kid_1", ChildAge: 8, ImageURL: "https://example.com/photo.jpg"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.ScanImageResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+				{Status: http.StatusServiceUnavailable, Description: "未配置大模型能力"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/media/upload", Handler: h.uploadImage, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "上传图片并返回公网 URL",
+			Body: uploadImageForm{}, BodyMediaType: "multipart/form-data",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.UploadImageResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+				{Status: http.StatusServiceUnavailable, Description: "未配置上传能力"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/companion/scene", Handler: h.companionScene, Timeout: companionSceneTimeout, RateLimit: rateLimitLLM, Summary: "生成角色剧情首句、卡通图与语音",
+			Body:    service.CompanionSceneRequest{},
+			Example: service.CompanionSceneRequest{ChildID: "kid_1", ChildAge: 8, ObjectType: "路灯", Weather: "晴天", Environment: "小区门口"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.CompanionSceneResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+				{Status: http.StatusServiceUnavailable, Description: "未配置大模型/生图/TTS能力"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/companion/chat", Handler: h.companionChat, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "角色剧情多轮对话（文本+语音）",
+			Body: service.CompanionChatRequest{},
+			Example: service.CompanionChatRequest{
+				ChildID: "kid_1", ChildAge: 8, ObjectType: "路灯", CharacterName: "亮亮",
+				History: []string{"亮亮：你好呀，我是路灯亮亮！"}, ChildMessage: "你晚上会害怕吗？",
+			},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.CompanionChatResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+				{Status: http.StatusServiceUnavailable, Description: "未配置大模型/TTS能力"},
+				{Status: http.StatusGatewayTimeout, Description: "剧情回复生成超时"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/companion/voice", Handler: h.companionVoice, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "为单句剧情文本生成语音",
+			Body:    service.CompanionVoiceRequest{},
+			Example: service.CompanionVoiceRequest{ChildID: "kid_1", ChildAge: 8, ObjectType: "路灯", Text: "天黑了，我来帮你照亮回家的路！"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.CompanionVoiceResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+				{Status: http.StatusServiceUnavailable, Description: "未配置TTS能力"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/answer", Handler: h.answer, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "提交答案",
+			Body:    service.AnswerRequest{},
+			Example: service.AnswerRequest{SessionID: "ses_1", ChildID: "kid_1", Answer: "晚上"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.AnswerResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求错误"},
+				{Status: http.StatusNotFound, Description: "会话不存在"},
+				{Status: http.StatusConflict, Description: "会话已完成"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/pokedex", Handler: h.pokedex, Summary: "查询图鉴",
+			Params: []param{childIDQuery()},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: PokedexResponse{}},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/pokedex/badges", Handler: h.pokedexBadges, Summary: "查询图鉴勋章进度",
+			Params: []param{childIDQuery(), localeQuery()},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: PokedexBadgeResponse{}},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/pokedex/badges/unseen", Handler: h.badgeUnlocksUnseen, Summary: "查询尚未展示过的勋章点亮记录",
+			Params: []param{childIDQuery()},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: BadgeUnlockListResponse{}},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/pokedex/badges/seen", Handler: h.badgeUnlocksSeen, Summary: "将勋章点亮记录标记为已读（unlock_ids 为空时标记全部）",
+			Body:    service.BadgeSeenRequest{},
+			Example: service.BadgeSeenRequest{ChildID: "kid_1", UnlockIDs: []string{"unlock_1"}},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.BadgeSeenResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求体格式错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/report/daily", Handler: h.dailyReport, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "查询每日报告",
+			Params: []param{
+				childIDQuery(),
+				{Name: "date", Description: "日期，格式 YYYY-MM-DD"},
+				{Name: "format", Description: "输出格式：json（默认）、html 或 pdf，html/pdf 便于家长直接分享", Enum: []string{"json", "html", "pdf"}},
+				{Name: "locale", Description: "报告语言，未传时取 Accept-Language；孩子资料中设置的语言优先", Enum: localeEnum()},
+			},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.DailyReport{}, Formats: []string{"text/html", "application/pdf"}},
+				{Status: http.StatusBadRequest, Description: "日期或输出格式错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/report/weekly", Handler: h.weeklyReport, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "查询周报（与上周对比，含家长版解读）",
+			Params: []param{childIDQuery(), {Name: "date", Description: "周期内任意日期，格式 YYYY-MM-DD，默认今天"}, localeQuery()},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.PeriodReport{}},
+				{Status: http.StatusBadRequest, Description: "日期格式错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/report/monthly", Handler: h.monthlyReport, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "查询月报（与上月对比，含家长版解读）",
+			Params: []param{childIDQuery(), {Name: "date", Description: "周期内任意日期，格式 YYYY-MM-DD，默认今天"}, localeQuery()},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.PeriodReport{}},
+				{Status: http.StatusBadRequest, Description: "日期格式错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/review/due", Handler: h.reviewDue, Summary: "查询到期的复习题（来自已收集的知识点）",
+			Params: []param{childIDQuery(), {Name: "limit", Description: "返回条数，默认 10，最大 50", Type: "integer"}},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: ReviewDueResponse{}},
+				{Status: http.StatusBadRequest, Description: "参数错误"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/review/answer", Handler: h.reviewAnswer, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Summary: "提交复习答案并更新复习排期（不产生新的收集）",
+			Body:    service.ReviewAnswerRequest{},
+			Example: service.ReviewAnswerRequest{ReviewID: "rev_1", ChildID: "kid_1", Answer: "晚上"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.ReviewAnswerResponse{}},
+				{Status: http.StatusBadRequest, Description: "请求错误"},
+				{Status: http.StatusNotFound, Description: "复习题不存在"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/progress", Handler: h.progress, Summary: "查询孩子的经验值、等级与连续探索天数",
+			Params: []param{childIDQuery()},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.ProgressProfile{}},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/profile", Handler: h.profile, Summary: "查询孩子的偏好设置（如界面与生成内容的语言）",
+			Params: []param{childIDQuery()},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.ChildProfile{}},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodPut, Path: "/api/v1/profile", Handler: h.updateProfile, Summary: "更新孩子的偏好设置；locale 传空字符串表示跟随 Accept-Language",
+			Body:    service.ProfileRequest{},
+			Example: service.ProfileRequest{ChildID: "kid_1", Locale: "en"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.ChildProfile{}},
+				{Status: http.StatusBadRequest, Description: "请求体格式错误或语言不受支持"},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/map/captures", Handler: h.captureMap, Summary: "以 GeoJSON 查询孩子的探索地图",
+			Params: []param{
+				childIDQuery(),
+				{Name: "bbox", Description: "minLng,minLat,maxLng,maxLat；minLng > maxLng 表示跨越 180° 经线"},
+				{Name: "lat", Description: "半径查询中心纬度，与 bbox 二选一", Type: "number"},
+				{Name: "lng", Description: "半径查询中心经度", Type: "number"},
+				{Name: "radius_m", Description: "半径（米），最大 100000", Type: "number"},
+				{Name: "cluster", Description: "按网格聚合，适合缩小后的视图", Type: "boolean"},
+				{Name: "zoom", Description: "地图缩放级别 0-22，决定聚合网格大小", Type: "integer", Default: 12},
+			},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.GeoJSONFeatureCollection{}, MediaType: "application/geo+json"},
+				{Status: http.StatusBadRequest, Description: "查询参数无效"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/hints/nearby", Handler: h.nearbyHints, Summary: "附近可以找的对象提示",
+			Description: "基于其他孩子的匿名聚合收集记录，返回粗略位置附近常见、但该孩子尚未收集的对象类型，能补齐勋章的排在前面。区域或对象的贡献人数少于 3 人、以及 24 小时内的收集不参与统计。",
+			Params: []param{
+				childIDQuery(),
+				{Name: "lat", Required: true, Type: "number"},
+				{Name: "lng", Required: true, Type: "number"},
+				{Name: "limit", Type: "integer", Default: 5, Maximum: 20},
+				localeQuery(),
+			},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.NearbyHints{}},
+				{Status: http.StatusBadRequest, Description: "坐标无效"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/quests", Handler: h.quests, Summary: "查询孩子今天的任务",
+			Description: "当天第一次查询时按模板生成 3 个任务；同一孩子同一天的任务保持不变。",
+			Params:      []param{childIDQuery(), localeQuery()},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.QuestBoard{}},
+				{Status: http.StatusInternalServerError, Description: "服务错误"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/quests/{id}/claim", Handler: h.claimQuest, Summary: "领取已完成任务的奖励",
+			Description: "发放任务的 reward_xp，并返回计入该任务进度的收集。",
+			Body:        service.QuestClaimRequest{}, BodyOptional: true,
+			Example: service.QuestClaimRequest{ChildID: "kid_1"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.QuestClaimResponse{}},
+				{Status: http.StatusNotFound, Description: "任务不存在"},
+				{Status: http.StatusConflict, Description: "任务未完成或奖励已领取"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/groups", Handler: h.listGroups, Summary: "列出孩子加入的群组或群主创建的群组",
+			Params: groupViewerParams(),
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: GroupListResponse{}},
+				{Status: http.StatusBadRequest, Description: "缺少 child_id 或 owner_id"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/groups", Handler: h.createGroup, Summary: "创建家庭或班级群组",
+			Body:    service.GroupRequest{},
+			Example: service.GroupRequest{Name: "三年二班", Kind: "classroom", OwnerID: "teacher_1"},
+			Responses: []response{
+				{Status: http.StatusCreated, Description: "成功", Body: model.Group{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/groups/join", Handler: h.joinGroup, Summary: "凭邀请码加入群组",
+			Body:    service.GroupJoinRequest{},
+			Example: service.GroupJoinRequest{InviteCode: "K7Q2M9XA", ChildID: "kid_1", DisplayName: "小明", ChildAge: 8},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.GroupDetail{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusNotFound, Description: "邀请码无效或已失效"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/groups/{id}", Handler: h.getGroup, Summary: "查看群组与成员",
+			Description: "邀请码与成员年龄只返回给群主。",
+			Params:      groupViewerParams(),
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.GroupDetail{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusForbidden, Description: "不是群主或成员"},
+				{Status: http.StatusNotFound, Description: "群组不存在"},
+			}},
+		{Method: http.MethodPut, Path: "/api/v1/groups/{id}", Handler: h.updateGroup, Summary: "修改群组设置（仅群主）",
+			Body: service.GroupRequest{},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.Group{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusForbidden, Description: "不是群主或成员"},
+				{Status: http.StatusNotFound, Description: "群组不存在"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/groups/{id}/invite-code", Handler: h.regenerateInviteCode, Summary: "重新生成邀请码（仅群主），旧邀请码立即失效",
+			Body:    InviteCodeRequest{},
+			Example: InviteCodeRequest{OwnerID: "teacher_1"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.Group{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusForbidden, Description: "不是群主或成员"},
+				{Status: http.StatusNotFound, Description: "群组不存在"},
+			}},
+		{Method: http.MethodDelete, Path: "/api/v1/groups/{id}/members/{child_id}", Handler: h.removeGroupMember, Summary: "退出群组或由群主移除成员",
+			Params: []param{
+				{Name: "child_id", In: "path", Required: true},
+				{Name: "child_id", Description: "孩子本人退出时传自己的 ID"},
+				{Name: "owner_id", Description: "群主移除成员"},
+			},
+			Responses: []response{
+				{Status: http.StatusNoContent, Description: "已移除"},
+				{Status: http.StatusForbidden, Description: "没有权限"},
+				{Status: http.StatusNotFound, Description: "群组不存在"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/groups/{id}/leaderboard", Handler: h.groupLeaderboard, Summary: "群组排行榜",
+			Description: "当群组设置了 hide_counts_under_age 且查看的孩子小于该年龄（或未填年龄）时，只返回名次和 1-3 颗星，不返回具体数量。",
+			Params: append([]param{
+				{Name: "metric", Enum: []string{"captures", "distinct_objects", "badges", "streak"}, Default: "captures"},
+				{Name: "window", Description: "all、today 或 Nd（含今天在内的最近 N 天，最多 365）；streak 指标始终为当前连续天数", Default: "7d"},
+			}, groupViewerParams()...),
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.GroupLeaderboard{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusForbidden, Description: "不是群主或成员"},
+				{Status: http.StatusNotFound, Description: "群组不存在"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/groups/{id}/feed", Handler: h.groupFeed, Summary: "群组最近收集动态",
+			Description: "只包含成员加入群组之后的收集，不包含位置。",
+			Params:      append([]param{{Name: "limit", Type: "integer", Default: 20, Maximum: 100}}, groupViewerParams()...),
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.GroupFeed{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusForbidden, Description: "不是群主或成员"},
+				{Status: http.StatusNotFound, Description: "群组不存在"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/shares", Handler: h.createSpiritShare, Summary: "为自己的一条收集生成只读分享链接",
+			Body:    service.SpiritShareRequest{},
+			Example: service.SpiritShareRequest{ChildID: "kid_1", CaptureID: "cap_1", ExpiresInDays: 7},
+			Responses: []response{
+				{Status: http.StatusCreated, Description: "已创建", Body: model.SpiritShare{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusNotFound, Description: "收集不存在或不属于该孩子"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/shares/{token}", Handler: h.spiritCard, Summary: "查看分享的精灵卡片（公开、只读）",
+			Description: "链接被撤销、过期，或该收集已交换给别人后不再可见。卡片不包含孩子身份和位置。",
+			Params:      []param{{Name: "format", Enum: []string{"json", "html"}}},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.SpiritCard{}, Formats: []string{"text/html"}},
+				{Status: http.StatusBadRequest, Description: "format 无效"},
+				{Status: http.StatusNotFound, Description: "链接不存在或已失效"},
+				{Status: http.StatusGone, Description: "链接已过期"},
+			}},
+		{Method: http.MethodDelete, Path: "/api/v1/shares/{token}", Handler: h.revokeSpiritShare, Summary: "撤销分享链接",
+			Params: []param{{Name: "child_id", Required: true}},
+			Responses: []response{
+				{Status: http.StatusNoContent, Description: "已撤销"},
+				{Status: http.StatusNotFound, Description: "链接不存在"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/trades", Handler: h.listTrades, Summary: "查看孩子发起或收到的交换",
+			Params: []param{
+				{Name: "child_id", Required: true},
+				{Name: "status", Enum: []string{"pending", "accepted", "declined", "cancelled"}},
+			},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: TradeListResponse{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/trades", Handler: h.proposeTrade, Summary: "向同群组的孩子发起收集交换",
+			Body: service.TradeProposal{},
+			Example: service.TradeProposal{
+				GroupID: "grp_1", ChildID: "kid_1", CaptureID: "cap_1",
+				RecipientID: "kid_2", RecipientCaptureID: "cap_2",
+			},
+			Responses: []response{
+				{Status: http.StatusCreated, Description: "已发起", Body: service.TradeDetail{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusForbidden, Description: "双方不在同一群组"},
+				{Status: http.StatusNotFound, Description: "群组或收集不存在"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/trades/{id}/{action}", Handler: h.respondTrade, Summary: "接受、拒绝或取消交换",
+			Description: "accept/decline 由对方操作，cancel 由发起人操作。接受时两条收集的归属在同一事务内互换，并清除拍摄位置。",
+			Params: []param{{Name: "action", In: "path", Required: true, Enum: []string{
+				service.TradeActionAccept, service.TradeActionDecline, service.TradeActionCancel,
+			}}},
+			Body:    service.TradeActionRequest{},
+			Example: service.TradeActionRequest{ChildID: "kid_2"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.TradeDetail{}},
+				{Status: http.StatusBadRequest, Description: "参数无效"},
+				{Status: http.StatusForbidden, Description: "无权执行该操作"},
+				{Status: http.StatusNotFound, Description: "交换不存在"},
+				{Status: http.StatusConflict, Description: "交换已处理，或收集已不在原主人手中"},
+			}},
+
+		{Method: http.MethodGet, Path: "/api/v1/admin/badges", Handler: h.adminListBadges, Admin: true, Summary: "管理：列出全部勋章定义",
+			Responses: []response{{Status: http.StatusOK, Description: "成功", Body: BadgeRuleListResponse{}}}},
+		{Method: http.MethodPost, Path: "/api/v1/admin/badges", Handler: h.adminCreateBadge, Admin: true, Summary: "管理：新增勋章并写入规则文件",
+			Body: service.BadgeRule{},
+			Responses: []response{
+				{Status: http.StatusCreated, Description: "已创建", Body: service.BadgeRule{}},
+				{Status: http.StatusBadRequest, Description: "勋章配置无效"},
+				{Status: http.StatusConflict, Description: "勋章 ID 已存在或未配置规则文件"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/admin/badges/reload", Handler: h.adminReloadBadges, Admin: true, Summary: "管理：重新加载勋章规则文件与图片清单",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.BadgeCatalogStatus{}},
+				{Status: http.StatusBadRequest, Description: "规则文件校验失败，保留原规则"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/admin/badges/{id}", Handler: h.adminGetBadge, Admin: true, Summary: "管理：查询单个勋章定义",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.BadgeRule{}},
+				{Status: http.StatusNotFound, Description: "勋章不存在"},
+			}},
+		{Method: http.MethodPut, Path: "/api/v1/admin/badges/{id}", Handler: h.adminUpdateBadge, Admin: true, Summary: "管理：替换勋章定义",
+			Body: service.BadgeRule{},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: service.BadgeRule{}},
+				{Status: http.StatusBadRequest, Description: "勋章配置无效"},
+				{Status: http.StatusNotFound, Description: "勋章不存在"},
+			}},
+		{Method: http.MethodDelete, Path: "/api/v1/admin/badges/{id}", Handler: h.adminDeleteBadge, Admin: true, Summary: "管理：删除勋章",
+			Responses: []response{
+				{Status: http.StatusNoContent, Description: "已删除"},
+				{Status: http.StatusBadRequest, Description: "仍被其他勋章条件引用"},
+				{Status: http.StatusNotFound, Description: "勋章不存在"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/admin/deliveries/subscriptions", Handler: h.adminListDeliverySubscriptions, Admin: true, Summary: "管理：列出日报推送订阅与已启用的推送方式",
+			Responses: []response{{Status: http.StatusOK, Description: "成功", Body: DeliverySubscriptionListResponse{}}}},
+		{Method: http.MethodPost, Path: "/api/v1/admin/deliveries/subscriptions", Handler: h.adminCreateDeliverySubscription, Admin: true, Summary: "管理：新增日报推送订阅",
+			Body: service.DeliverySubscriptionRequest{},
+			Example: service.DeliverySubscriptionRequest{
+				ParentID: "parent_1", ChildID: "kid_1", Channel: "email",
+				Target: "parent@example.com", SendAt: "20:00", Timezone: "Asia/Shanghai",
+			},
+			Responses: []response{
+				{Status: http.StatusCreated, Description: "已创建", Body: model.DeliverySubscription{}},
+				{Status: http.StatusBadRequest, Description: "订阅配置无效或推送方式未启用"},
+			}},
+		{Method: http.MethodPut, Path: "/api/v1/admin/deliveries/subscriptions/{id}", Handler: h.adminUpdateDeliverySubscription, Admin: true, Summary: "管理：修改日报推送订阅",
+			Body: service.DeliverySubscriptionRequest{},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: model.DeliverySubscription{}},
+				{Status: http.StatusBadRequest, Description: "订阅配置无效或推送方式未启用"},
+				{Status: http.StatusNotFound, Description: "订阅不存在"},
+			}},
+		{Method: http.MethodDelete, Path: "/api/v1/admin/deliveries/subscriptions/{id}", Handler: h.adminDeleteDeliverySubscription, Admin: true, Summary: "管理：删除日报推送订阅",
+			Responses: []response{
+				{Status: http.StatusNoContent, Description: "已删除"},
+				{Status: http.StatusNotFound, Description: "订阅不存在"},
+			}},
+		{Method: http.MethodPost, Path: "/api/v1/admin/deliveries/subscriptions/{id}/send", Handler: h.adminSendDelivery, Timeout: llmRouteTimeout, RateLimit: rateLimitLLM, Admin: true, Summary: "管理：立即推送订阅当天的日报",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "已尝试推送，结果见 status", Body: model.DeliveryAttempt{}},
+				{Status: http.StatusNotFound, Description: "订阅不存在"},
+			}},
+		{Method: http.MethodGet, Path: "/api/v1/admin/deliveries/attempts", Handler: h.adminListDeliveryAttempts, Admin: true, Summary: "管理：查询投递记录（最新在前）",
+			Params: []param{
+				{Name: "subscription_id"},
+				{Name: "status", Enum: []string{"sent", "retrying", "failed"}},
+				{Name: "limit", Type: "integer", Default: 50},
+			},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "成功", Body: DeliveryAttemptListResponse{}},
+				{Status: http.StatusBadRequest, Description: "limit 无效"},
+			}},
+	}
+}
@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) createSpiritShare(w http.ResponseWriter, r *http.Request) {
+	var req service.SpiritShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "createSpiritShare", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	share, err := h.svc.CreateSpiritShare(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "createSpiritShare", err, "capture_id", req.CaptureID)
+		return
+	}
+	writeJSON(w, http.StatusCreated, share)
+}
+
+// spiritCard 是公开的只读页面，format=html 时直接返回可分享的卡片。
+func (h *Handler) spiritCard(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	switch format {
+	case "", "json", "html":
+	default:
+		writeError(w, r, fmt.Errorf("%w: format 仅支持 json、html", service.ErrQueryInvalid))
+		return
+	}
+	card, err := h.svc.SpiritCard(r.Context(), token)
+	if err != nil {
+		writeServiceError(w, r, "spiritCard", err, "token", token)
+		return
+	}
+	if format != "html" {
+		writeJSON(w, http.StatusOK, card)
+		return
+	}
+	var buf bytes.Buffer
+	if err := h.renderer.SpiritCardHTML(&buf, card); err != nil {
+		writeServiceError(w, r, "spiritCard", err, "token", token)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (h *Handler) revokeSpiritShare(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	if err := h.svc.RevokeSpiritShare(r.Context(), token, r.URL.Query().Get("child_id")); err != nil {
+		writeServiceError(w, r, "revokeSpiritShare", err, "token", token)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
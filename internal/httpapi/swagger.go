@@ -1,7 +1,12 @@
 package httpapi
 
 import (
+	"maps"
 	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -33,7 +38,14 @@ func (h *Handler) swaggerUI(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) swaggerSpec(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, openAPISpec(requestBaseURL(r)))
+	spec := h.spec
+	if spec == nil {
+		spec = openAPISpec(h.routes())
+	}
+	doc := make(map[string]any, len(spec)+1)
+	maps.Copy(doc, spec)
+	doc["servers"] = []map[string]string{{"url": requestBaseURL(r)}}
+	writeJSON(w, http.StatusOK, doc)
 }
 
 func requestBaseURL(r *http.Request) string {
@@ -53,7 +65,22 @@ func requestBaseURL(r *http.Request) string {
 	return scheme + "://" + host
 }
 
-func openAPISpec(serverURL string) map[string]any {
+// openAPISpec 由路由表与请求/响应类型生成文档，不含 servers。
+func openAPISpec(routes []route) map[string]any {
+	schemas := newSchemaRegistry()
+	schemas.schemaOf(ErrorResponse{})
+	paths := make(map[string]any)
+	for _, rt := range routes {
+		if rt.Hidden {
+			continue
+		}
+		item, ok := paths[rt.Path].(map[string]any)
+		if !ok {
+			item = make(map[string]any)
+			paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = rt.operation(schemas)
+	}
 	return map[string]any{
 		"openapi": "3.0.3",
 		"info": map[string]any{
@@ -61,568 +88,172 @@ func openAPISpec(serverURL string) map[string]any {
 			"description": "城市灵后端 API 文档",
 			"version":     "1.0.0",
 		},
-		"servers": []map[string]string{
-			{"url": serverURL},
-		},
-		"paths": map[string]any{
-			"/healthz": map[string]any{
-				"get": map[string]any{
-					"summary":     "健康检查",
-					"operationId": "healthz",
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "OK",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/HealthResponse"},
-								},
-							},
-						},
-					},
-				},
-			},
-			"/api/v1/scan": map[string]any{
-				"post": map[string]any{
-					"summary":     "根据图片或标签生成题目和科普",
-					"operationId": "scan",
-					"requestBody": map[string]any{
-						"required": true,
-						"content": map[string]any{
-							"application/json": map[string]any{
-								"schema": map[string]any{"$ref": "#/components/schemas/ScanRequest"},
-							},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/ScanResponse"},
-								},
-							},
-						},
-						"400": map[string]any{"description": "请求错误"},
-						"503": map[string]any{"description": "未配置大模型能力（图片识别场景）"},
-						"500": map[string]any{"description": "服务错误"},
-					},
-				},
-			},
-			"/api/v1/scan/image": map[string]any{
-				"post": map[string]any{
-					"summary":     "传图片 URL（推荐）或 base64 并由大模型识别主体",
-					"operationId": "scanImage",
-					"requestBody": map[string]any{
-						"required": true,
-						"content": map[string]any{
-							"application/json": map[string]any{
-								"schema": map[string]any{"$ref": "#/components/schemas/ScanImageRequest"},
-							},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/ScanImageResponse"},
-								},
-							},
-						},
-						"400": map[string]any{"description": "请求错误"},
-						"503": map[string]any{"description": "未配置大模型能力"},
-						"500": map[string]any{"description": "服务错误"},
-					},
-				},
-			},
-			"/api/v1/media/upload": map[string]any{
-				"post": map[string]any{
-					"summary":     "上传图片并返回公网 URL",
-					"operationId": "uploadImage",
-					"requestBody": map[string]any{
-						"required": true,
-						"content": map[string]any{
-							"multipart/form-data": map[string]any{
-								"schema": map[string]any{
-									"type":     "object",
-									"required": []string{"file"},
-									"properties": map[string]any{
-										"file": map[string]any{
-											"type":   "string",
-											"format": "binary",
-										},
-									},
-								},
-							},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/UploadImageResponse"},
-								},
-							},
-						},
-						"400": map[string]any{"description": "请求错误"},
-						"503": map[string]any{"description": "未配置上传能力"},
-						"500": map[string]any{"description": "服务错误"},
-					},
-				},
-			},
-			"/api/v1/companion/scene": map[string]any{
-				"post": map[string]any{
-					"summary":     "生成角色剧情首句、卡通图与语音",
-					"operationId": "companionScene",
-					"requestBody": map[string]any{
-						"required": true,
-						"content": map[string]any{
-							"application/json": map[string]any{
-								"schema": map[string]any{"$ref": "#/components/schemas/CompanionSceneRequest"},
-							},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/CompanionSceneResponse"},
-								},
-							},
-						},
-						"400": map[string]any{"description": "请求错误"},
-						"503": map[string]any{"description": "未配置大模型/生图/TTS能力"},
-						"500": map[string]any{"description": "服务错误"},
-					},
-				},
-			},
-			"/api/v1/companion/chat": map[string]any{
-				"post": map[string]any{
-					"summary":     "角色剧情多轮对话（文本+语音）",
-					"operationId": "companionChat",
-					"requestBody": map[string]any{
-						"required": true,
-						"content": map[string]any{
-							"application/json": map[string]any{
-								"schema": map[string]any{"$ref": "#/components/schemas/CompanionChatRequest"},
-							},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/CompanionChatResponse"},
-								},
-							},
-						},
-						"400": map[string]any{"description": "请求错误"},
-						"503": map[string]any{"description": "未配置大模型/TTS能力"},
-						"500": map[string]any{"description": "服务错误"},
-					},
-				},
-			},
-			"/api/v1/companion/voice": map[string]any{
-				"post": map[string]any{
-					"summary":     "为单句剧情文本生成语音",
-					"operationId": "companionVoice",
-					"requestBody": map[string]any{
-						"required": true,
-						"content": map[string]any{
-							"application/json": map[string]any{
-								"schema": map[string]any{"$ref": "#/components/schemas/CompanionVoiceRequest"},
-							},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/CompanionVoiceResponse"},
-								},
-							},
-						},
-						"400": map[string]any{"description": "请求错误"},
-						"503": map[string]any{"description": "未配置TTS能力"},
-						"500": map[string]any{"description": "服务错误"},
-					},
-				},
-			},
-			"/api/v1/answer": map[string]any{
-				"post": map[string]any{
-					"summary":     "提交答案",
-					"operationId": "answer",
-					"requestBody": map[string]any{
-						"required": true,
-						"content": map[string]any{
-							"application/json": map[string]any{
-								"schema": map[string]any{"$ref": "#/components/schemas/AnswerRequest"},
-							},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/AnswerResponse"},
-								},
-							},
-						},
-						"404": map[string]any{"description": "会话不存在"},
-						"409": map[string]any{"description": "会话已完成"},
-						"500": map[string]any{"description": "服务错误"},
-					},
-				},
-			},
-			"/api/v1/pokedex": map[string]any{
-				"get": map[string]any{
-					"summary":     "查询图鉴",
-					"operationId": "pokedex",
-					"parameters": []map[string]any{
-						{
-							"name":        "child_id",
-							"in":          "query",
-							"required":    false,
-							"description": "孩子 ID，默认 guest",
-							"schema":      map[string]any{"type": "string"},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/PokedexResponse"},
-								},
-							},
-						},
-					},
-				},
-			},
-			"/api/v1/pokedex/badges": map[string]any{
-				"get": map[string]any{
-					"summary":     "查询图鉴勋章进度",
-					"operationId": "pokedexBadges",
-					"parameters": []map[string]any{
-						{
-							"name":        "child_id",
-							"in":          "query",
-							"required":    false,
-							"description": "孩子 ID，默认 guest",
-							"schema":      map[string]any{"type": "string"},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/PokedexBadgeResponse"},
-								},
-							},
-						},
-					},
-				},
-			},
-			"/api/v1/report/daily": map[string]any{
-				"get": map[string]any{
-					"summary":     "查询每日报告",
-					"operationId": "dailyReport",
-					"parameters": []map[string]any{
-						{
-							"name":        "child_id",
-							"in":          "query",
-							"required":    false,
-							"description": "孩子 ID，默认 guest",
-							"schema":      map[string]any{"type": "string"},
-						},
-						{
-							"name":        "date",
-							"in":          "query",
-							"required":    false,
-							"description": "日期，格式 YYYY-MM-DD",
-							"schema":      map[string]any{"type": "string"},
-						},
-					},
-					"responses": map[string]any{
-						"200": map[string]any{
-							"description": "成功",
-							"content": map[string]any{
-								"application/json": map[string]any{
-									"schema": map[string]any{"$ref": "#/components/schemas/DailyReport"},
-								},
-							},
-						},
-						"400": map[string]any{"description": "日期格式错误"},
-						"500": map[string]any{"description": "服务错误"},
-					},
-				},
-			},
-		},
+		"paths": paths,
 		"components": map[string]any{
-			"schemas": map[string]any{
-				"HealthResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"status": map[string]any{"type": "string", "example": "ok"},
-					},
-				},
-				"ErrorResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"error": map[string]any{"type": "string"},
-					},
-				},
-				"ScanRequest": map[string]any{
-					"type":        "object",
-					"required":    []string{"child_id", "child_age"},
-					"description": "支持两种模式：1) 传 detected_label；2) 传 image_url 或 image_base64（自动识别后再出题）。",
-					"properties": map[string]any{
-						"child_id":       map[string]any{"type": "string"},
-						"child_age":      map[string]any{"type": "integer"},
-						"detected_label": map[string]any{"type": "string"},
-						"image_base64":   map[string]any{"type": "string"},
-						"image_url":      map[string]any{"type": "string"},
-					},
-				},
-				"ScanImageRequest": map[string]any{
-					"type":     "object",
-					"required": []string{"child_id", "child_age"},
-					"properties": map[string]any{
-						"child_id":     map[string]any{"type": "string"},
-						"child_age":    map[string]any{"type": "integer"},
-						"image_base64": map[string]any{"type": "string"},
-						"image_url":    map[string]any{"type": "string"},
-					},
-				},
-				"AnswerRequest": map[string]any{
-					"type":     "object",
-					"required": []string{"session_id", "child_id", "answer"},
-					"properties": map[string]any{
-						"session_id": map[string]any{"type": "string"},
-						"child_id":   map[string]any{"type": "string"},
-						"answer":     map[string]any{"type": "string"},
-					},
-				},
-				"CompanionSceneRequest": map[string]any{
-					"type":     "object",
-					"required": []string{"child_age", "object_type"},
-					"properties": map[string]any{
-						"child_id":            map[string]any{"type": "string"},
-						"child_age":           map[string]any{"type": "integer"},
-						"object_type":         map[string]any{"type": "string"},
-						"weather":             map[string]any{"type": "string"},
-						"environment":         map[string]any{"type": "string"},
-						"object_traits":       map[string]any{"type": "string"},
-						"source_image_url":    map[string]any{"type": "string", "description": "可选。传入识别原图 URL，启用图生图角色生成（推荐）"},
-						"source_image_base64": map[string]any{"type": "string", "description": "可选。旧字段，建议迁移到 source_image_url"},
-					},
-				},
-				"UploadImageResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"image_url": map[string]any{"type": "string"},
-					},
-				},
-				"CompanionChatRequest": map[string]any{
-					"type":     "object",
-					"required": []string{"child_age", "object_type", "child_message"},
-					"properties": map[string]any{
-						"child_id":              map[string]any{"type": "string"},
-						"child_age":             map[string]any{"type": "integer"},
-						"object_type":           map[string]any{"type": "string"},
-						"character_name":        map[string]any{"type": "string"},
-						"character_personality": map[string]any{"type": "string"},
-						"weather":               map[string]any{"type": "string"},
-						"environment":           map[string]any{"type": "string"},
-						"object_traits":         map[string]any{"type": "string"},
-						"history": map[string]any{
-							"type":  "array",
-							"items": map[string]any{"type": "string"},
-						},
-						"child_message": map[string]any{"type": "string"},
-					},
-				},
-				"CompanionVoiceRequest": map[string]any{
-					"type":     "object",
-					"required": []string{"child_age", "object_type", "text"},
-					"properties": map[string]any{
-						"child_id":    map[string]any{"type": "string"},
-						"child_age":   map[string]any{"type": "integer"},
-						"object_type": map[string]any{"type": "string"},
-						"text":        map[string]any{"type": "string"},
-					},
-				},
-				"Spirit": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"id":          map[string]any{"type": "string"},
-						"name":        map[string]any{"type": "string"},
-						"object_type": map[string]any{"type": "string"},
-						"personality": map[string]any{"type": "string"},
-						"intro":       map[string]any{"type": "string"},
-						"created_at":  map[string]any{"type": "string", "format": "date-time"},
-					},
-				},
-				"ScanResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"session_id":  map[string]any{"type": "string"},
-						"object_type": map[string]any{"type": "string"},
-						"spirit":      map[string]any{"$ref": "#/components/schemas/Spirit"},
-						"fact":        map[string]any{"type": "string"},
-						"quiz":        map[string]any{"type": "string"},
-						"dialogues": map[string]any{
-							"type":  "array",
-							"items": map[string]any{"type": "string"},
-						},
-						"cache_hit": map[string]any{"type": "boolean"},
-					},
-				},
-				"ScanImageResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"detected_label":    map[string]any{"type": "string", "description": "中文识别结果"},
-						"detected_label_en": map[string]any{"type": "string", "description": "英文标准标签(mailbox/tree/manhole/road_sign/traffic_light)"},
-						"raw_label":         map[string]any{"type": "string"},
-						"reason":            map[string]any{"type": "string"},
-					},
-				},
-				"CompanionSceneResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"character_name":        map[string]any{"type": "string"},
-						"character_personality": map[string]any{"type": "string"},
-						"dialog_text":           map[string]any{"type": "string"},
-						"image_prompt":          map[string]any{"type": "string"},
-						"character_image_url":   map[string]any{"type": "string"},
-						"character_image_base64": map[string]any{
-							"type":        "string",
-							"description": "可选。角色图的base64数据，前端可优先使用以避免外链加载失败",
-						},
-						"character_image_mime_type": map[string]any{"type": "string"},
-						"voice_audio_base64":        map[string]any{"type": "string"},
-						"voice_mime_type":           map[string]any{"type": "string"},
-					},
-				},
-				"CompanionChatResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"reply_text":         map[string]any{"type": "string"},
-						"voice_audio_base64": map[string]any{"type": "string"},
-						"voice_mime_type":    map[string]any{"type": "string"},
-					},
-				},
-				"CompanionVoiceResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"voice_audio_base64": map[string]any{"type": "string"},
-						"voice_mime_type":    map[string]any{"type": "string"},
-					},
-				},
-				"Capture": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"id":          map[string]any{"type": "string"},
-						"child_id":    map[string]any{"type": "string"},
-						"spirit_id":   map[string]any{"type": "string"},
-						"spirit_name": map[string]any{"type": "string"},
-						"object_type": map[string]any{"type": "string"},
-						"fact":        map[string]any{"type": "string"},
-						"captured_at": map[string]any{"type": "string", "format": "date-time"},
-					},
-				},
-				"AnswerResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"correct":  map[string]any{"type": "boolean"},
-						"captured": map[string]any{"type": "boolean"},
-						"message":  map[string]any{"type": "string"},
-						"capture":  map[string]any{"$ref": "#/components/schemas/Capture"},
-					},
-				},
-				"PokedexEntry": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"spirit_id":   map[string]any{"type": "string"},
-						"spirit_name": map[string]any{"type": "string"},
-						"object_type": map[string]any{"type": "string"},
-						"captures":    map[string]any{"type": "integer"},
-						"last_seen_at": map[string]any{
-							"type":   "string",
-							"format": "date-time",
-						},
-					},
-				},
-				"PokedexResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"child_id": map[string]any{"type": "string"},
-						"entries": map[string]any{
-							"type":  "array",
-							"items": map[string]any{"$ref": "#/components/schemas/PokedexEntry"},
-						},
-					},
-				},
-				"PokedexBadge": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"id":           map[string]any{"type": "string"},
-						"category_id":  map[string]any{"type": "string"},
-						"name":         map[string]any{"type": "string"},
-						"code":         map[string]any{"type": "string"},
-						"description":  map[string]any{"type": "string"},
-						"record_scope": map[string]any{"type": "string"},
-						"rule":         map[string]any{"type": "string"},
-						"image_url":    map[string]any{"type": "string"},
-						"image_file":   map[string]any{"type": "string"},
-						"unlocked":     map[string]any{"type": "boolean"},
-						"progress":     map[string]any{"type": "integer"},
-						"target":       map[string]any{"type": "integer"},
-						"examples": map[string]any{
-							"type":  "array",
-							"items": map[string]any{"type": "string"},
-						},
-						"collected_examples": map[string]any{
-							"type":  "array",
-							"items": map[string]any{"type": "string"},
-						},
-					},
-				},
-				"PokedexBadgeResponse": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"child_id": map[string]any{"type": "string"},
-						"badges": map[string]any{
-							"type":  "array",
-							"items": map[string]any{"$ref": "#/components/schemas/PokedexBadge"},
-						},
-					},
-				},
-				"DailyReport": map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"date":           map[string]any{"type": "string"},
-						"child_id":       map[string]any{"type": "string"},
-						"total_captured": map[string]any{"type": "integer"},
-						"captures": map[string]any{
-							"type":  "array",
-							"items": map[string]any{"$ref": "#/components/schemas/Capture"},
-						},
-						"knowledge_points": map[string]any{
-							"type":  "array",
-							"items": map[string]any{"type": "string"},
-						},
-						"generated_text": map[string]any{"type": "string"},
-						"generated_at":   map[string]any{"type": "string", "format": "date-time"},
-					},
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]any{
+				"adminToken": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "CITYLING_ADMIN_TOKEN，也可通过 X-Admin-Token 头传递",
 				},
 			},
 		},
 	}
 }
+
+func (rt route) operation(schemas *schemaRegistry) map[string]any {
+	op := map[string]any{
+		"operationId": handlerName(rt.Handler),
+		"summary":     rt.Summary,
+	}
+	if rt.Description != "" {
+		op["description"] = rt.Description
+	}
+	if params := rt.parameters(); len(params) > 0 {
+		op["parameters"] = params
+	}
+	if rt.Body != nil {
+		mediaType := rt.BodyMediaType
+		if mediaType == "" {
+			mediaType = "application/json"
+		}
+		content := map[string]any{"schema": schemas.schemaOf(rt.Body)}
+		if rt.Example != nil {
+			content["example"] = exampleValue(rt.Example)
+		}
+		op["requestBody"] = map[string]any{
+			"required": !rt.BodyOptional,
+			"content":  map[string]any{mediaType: content},
+		}
+	}
+	responses := make(map[string]any)
+	for _, resp := range rt.responses() {
+		responses[strconv.Itoa(resp.Status)] = resp.document(schemas)
+	}
+	op["responses"] = responses
+	if rt.Admin {
+		op["security"] = []map[string][]string{{"adminToken": {}}}
+	}
+	return op
+}
+
+// responses 为管理接口补上 requireAdmin 可能返回的 401/403，为参与限流的接口补上 429。
+func (rt route) responses() []response {
+	var extras []response
+	if rt.Admin {
+		extras = append(extras,
+			response{Status: http.StatusUnauthorized, Description: "管理令牌无效"},
+			response{Status: http.StatusForbidden, Description: "管理接口未启用"},
+		)
+	}
+	if rt.RateLimit != rateLimitExempt {
+		extras = append(extras, response{Status: http.StatusTooManyRequests, Description: "请求过于频繁，按 Retry-After 秒数后重试"})
+	}
+	if len(extras) == 0 {
+		return rt.Responses
+	}
+	result := append([]response(nil), rt.Responses...)
+	for _, extra := range extras {
+		if !hasStatus(result, extra.Status) {
+			result = append(result, extra)
+		}
+	}
+	return result
+}
+
+func hasStatus(responses []response, status int) bool {
+	for _, resp := range responses {
+		if resp.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// parameters 先列路径参数（未声明的按必填字符串补齐），再列声明的参数。
+func (rt route) parameters() []map[string]any {
+	declared := make(map[string]bool)
+	for _, p := range rt.Params {
+		if p.In == "path" {
+			declared[p.Name] = true
+		}
+	}
+	var params []map[string]any
+	for _, match := range pathParamPattern.FindAllStringSubmatch(rt.Path, -1) {
+		if !declared[match[1]] {
+			params = append(params, param{Name: match[1], In: "path", Required: true}.document())
+		}
+	}
+	for _, p := range rt.Params {
+		params = append(params, p.document())
+	}
+	return params
+}
+
+func (p param) document() map[string]any {
+	in := p.In
+	if in == "" {
+		in = "query"
+	}
+	schemaType := p.Type
+	if schemaType == "" {
+		schemaType = "string"
+	}
+	schema := map[string]any{"type": schemaType}
+	if len(p.Enum) > 0 {
+		schema["enum"] = p.Enum
+	}
+	if p.Default != nil {
+		schema["default"] = p.Default
+	}
+	if p.Maximum > 0 {
+		schema["maximum"] = p.Maximum
+	}
+	doc := map[string]any{
+		"name":     p.Name,
+		"in":       in,
+		"required": p.Required || in == "path",
+		"schema":   schema,
+	}
+	if p.Description != "" {
+		doc["description"] = p.Description
+	}
+	return doc
+}
+
+func (resp response) document(schemas *schemaRegistry) map[string]any {
+	doc := map[string]any{"description": resp.Description}
+	body := resp.Body
+	if body == nil && resp.Status >= http.StatusBadRequest {
+		body = ErrorResponse{}
+	}
+	if body == nil {
+		return doc
+	}
+	mediaType := resp.MediaType
+	if mediaType == "" {
+		mediaType = "application/json"
+	}
+	content := map[string]any{
+		mediaType: map[string]any{"schema": schemas.schemaOf(body)},
+	}
+	for _, format := range resp.Formats {
+		schema := map[string]any{"type": "string"}
+		if format == "application/pdf" {
+			schema["format"] = "binary"
+		}
+		content[format] = map[string]any{"schema": schema}
+	}
+	doc["content"] = content
+	return doc
+}
+
+// handlerName 取处理函数的方法名作为 operationId，如 (*Handler).scan 得到 scan。
+func handlerName(handler http.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,26 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"ling/internal/logging"
+	"ling/internal/tracing"
+)
+
+// withTracing 为每个请求开始一个以路由模式命名的服务端 span，service、llm 与存储的 span 都挂在它下面。
+func withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.StartHTTPServer(r, routePattern(r))
+		if id := logging.RequestID(ctx); id != "" {
+			span.SetAttributes(attribute.String("request_id", id))
+		}
+		rec, ok := w.(*statusRecorder)
+		if !ok {
+			rec = &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		tracing.EndHTTPServer(span, rec.status)
+	})
+}
@@ -0,0 +1,49 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ling/internal/service"
+)
+
+func (h *Handler) proposeTrade(w http.ResponseWriter, r *http.Request) {
+	var req service.TradeProposal
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "proposeTrade", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	trade, err := h.svc.ProposeTrade(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, r, "proposeTrade", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, trade)
+}
+
+func (h *Handler) respondTrade(w http.ResponseWriter, r *http.Request) {
+	var req service.TradeActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logBadRequest(r, "respondTrade", "decode error", "err", err)
+		writeError(w, r, service.ErrRequestBodyInvalid)
+		return
+	}
+	trade, err := h.svc.RespondTrade(r.Context(), r.PathValue("id"), r.PathValue("action"), req)
+	if err != nil {
+		writeServiceError(w, r, "respondTrade", err, "trade_id", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, trade)
+}
+
+func (h *Handler) listTrades(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	childID := query.Get("child_id")
+	trades, err := h.svc.Trades(r.Context(), childID, query.Get("status"))
+	if err != nil {
+		writeServiceError(w, r, "listTrades", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, TradeListResponse{ChildID: childID, Trades: trades})
+}
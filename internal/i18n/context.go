@@ -0,0 +1,43 @@
+package i18n
+
+import (
+	"context"
+	"sync"
+)
+
+type requestLocaleKey struct{}
+
+// requestLocale 记录一次请求最终使用的语言。
+type requestLocale struct {
+	mu     sync.Mutex
+	locale Locale
+	set    bool
+}
+
+// WithRequestLocale 在 context 中挂上一个空的语言记录。业务层确定语言后用 SetRequestLocale 写入，
+// 错误响应等后续环节再用 RequestLocale 读取，保证同一请求的文案语言一致。
+func WithRequestLocale(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestLocaleKey{}, &requestLocale{})
+}
+
+// SetRequestLocale 记录本次请求使用的语言；context 中没有语言记录时忽略。
+func SetRequestLocale(ctx context.Context, l Locale) {
+	record, ok := ctx.Value(requestLocaleKey{}).(*requestLocale)
+	if !ok {
+		return
+	}
+	record.mu.Lock()
+	record.locale, record.set = l, true
+	record.mu.Unlock()
+}
+
+// RequestLocale 返回本次请求已确定的语言，尚未确定时第二个返回值为 false。
+func RequestLocale(ctx context.Context) (Locale, bool) {
+	record, ok := ctx.Value(requestLocaleKey{}).(*requestLocale)
+	if !ok {
+		return "", false
+	}
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	return record.locale, record.set
+}
@@ -0,0 +1,127 @@
+// Package i18n 负责语言协商与面向用户的文案目录。
+//
+// 目录按语言放在 messages/<locale>.json，键为错误码或点分的文案键（如 answer.captured）。
+// 查不到时依次回退到简体中文与键本身，因此新增文案只需先补 zh-CN。
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Locale string
+
+const (
+	ZhCN Locale = "zh-CN"
+	En   Locale = "en"
+	ZhTW Locale = "zh-TW"
+
+	Default = ZhCN
+)
+
+// Supported 为支持的语言，顺序即文档中的展示顺序。
+var Supported = []Locale{ZhCN, En, ZhTW}
+
+//go:embed messages/*.json
+var messageFS embed.FS
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[Locale]map[string]string {
+	result := make(map[Locale]map[string]string, len(Supported))
+	for _, locale := range Supported {
+		raw, err := messageFS.ReadFile("messages/" + string(locale) + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog for %s: %v", locale, err))
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog for %s: %v", locale, err))
+		}
+		result[locale] = messages
+	}
+	return result
+}
+
+// Parse 把语言标签归一到支持的语言：zh、zh-CN、zh-Hans、zh-SG 为简体，zh-TW、zh-HK、zh-MO、zh-Hant 为繁体，en-* 为英文。
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	switch {
+	case tag == "":
+		return "", false
+	case tag == "zh-tw", tag == "zh-hk", tag == "zh-mo", strings.HasPrefix(tag, "zh-hant"):
+		return ZhTW, true
+	case tag == "zh", strings.HasPrefix(tag, "zh-"):
+		return ZhCN, true
+	case tag == "en", strings.HasPrefix(tag, "en-"):
+		return En, true
+	}
+	return "", false
+}
+
+// Negotiate 按 Accept-Language 的权重选出支持的语言，也接受单个语言标签；都不支持时返回 Default。
+func Negotiate(header string) Locale {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	for _, c := range candidates {
+		if locale, ok := Parse(c.tag); ok {
+			return locale
+		}
+	}
+	return Default
+}
+
+// Chinese 表示该语言的读者能读中文内容（如内置知识库）。
+func (l Locale) Chinese() bool {
+	return l == ZhCN || l == ZhTW
+}
+
+// TTSLanguage 返回语音合成的 language_type。
+func (l Locale) TTSLanguage() string {
+	if l == En {
+		return "English"
+	}
+	return "Chinese"
+}
+
+// Lookup 只查该语言自己的目录，不回退。
+func Lookup(l Locale, key string) (string, bool) {
+	message, ok := catalogs[l][key]
+	return message, ok
+}
+
+// Text 返回本地化文案，args 非空时按 fmt 格式化。
+func Text(l Locale, key string, args ...any) string {
+	message, ok := Lookup(l, key)
+	if !ok {
+		if message, ok = Lookup(Default, key); !ok {
+			message = key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
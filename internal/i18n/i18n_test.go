@@ -0,0 +1,50 @@
+package i18n
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]Locale{
+		"":                             ZhCN,
+		"en":                           En,
+		"en-US,en;q=0.9":               En,
+		"fr-FR, zh-HK;q=0.8, en;q=0.5": ZhTW,
+		"zh-Hant-TW":                   ZhTW,
+		"zh-Hans-CN":                   ZhCN,
+		"en;q=0.3, zh;q=0.9":           ZhCN,
+		"fr, de":                       ZhCN,
+		"en;q=0, zh-TW":                ZhTW,
+	}
+	for header, want := range cases {
+		if got := Negotiate(header); got != want {
+			t.Errorf("Negotiate(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestTextFallsBackToDefaultThenKey(t *testing.T) {
+	if got := Text(En, "answer.captured"); got != "Correct! You caught the spirit." {
+		t.Fatalf("unexpected english text %q", got)
+	}
+	if got := Text(En, "INTERNAL"); got != "Internal server error" {
+		t.Fatalf("unexpected english error text %q", got)
+	}
+	if got := Text(Locale("fr"), "answer.captured"); got != Text(ZhCN, "answer.captured") {
+		t.Fatalf("expected fallback to zh-CN, got %q", got)
+	}
+	if got := Text(En, "missing.key"); got != "missing.key" {
+		t.Fatalf("expected key fallback, got %q", got)
+	}
+	if got := Text(En, "report.daily_summary", "kid_1", 2, 3); got != "Today kid_1 caught 2 spirits and learned 3 facts." {
+		t.Fatalf("unexpected formatted text %q", got)
+	}
+}
+
+func TestCatalogsCoverDefaultKeys(t *testing.T) {
+	for key := range catalogs[Default] {
+		for _, locale := range Supported {
+			if _, ok := Lookup(locale, key); !ok {
+				t.Errorf("catalog %s is missing key %q", locale, key)
+			}
+		}
+	}
+}
@@ -4,8 +4,9 @@ import "ling/internal/model"
 
 var BaseKnowledge = []model.KnowledgeItem{
 	{
-		ObjectType: "manhole",
-		Aliases:    []string{"well_cover", "drain_cover"},
+		ObjectType:   "manhole",
+		Aliases:      []string{"well_cover", "drain_cover"},
+		DisplayNames: map[string]string{"zh-CN": "井盖", "en": "manhole cover", "zh-TW": "人孔蓋"},
 		Facts: []string{
 			"井盖是地下设施检修和维护的重要入口。",
 			"很多城市会在井盖图案中加入本地文化元素。",
@@ -16,8 +17,9 @@ var BaseKnowledge = []model.KnowledgeItem{
 		},
 	},
 	{
-		ObjectType: "mailbox",
-		Aliases:    []string{"post_box"},
+		ObjectType:   "mailbox",
+		Aliases:      []string{"post_box"},
+		DisplayNames: map[string]string{"zh-CN": "邮箱", "en": "mailbox", "zh-TW": "郵筒"},
 		Facts: []string{
 			"邮箱是信件和明信片的集中投递点。",
 			"邮政系统会通过邮编快速分拣信件。",
@@ -28,8 +30,9 @@ var BaseKnowledge = []model.KnowledgeItem{
 		},
 	},
 	{
-		ObjectType: "tree",
-		Aliases:    []string{"street_tree"},
+		ObjectType:   "tree",
+		Aliases:      []string{"street_tree"},
+		DisplayNames: map[string]string{"zh-CN": "树", "en": "tree", "zh-TW": "樹"},
 		Facts: []string{
 			"树木会吸收二氧化碳并释放氧气。",
 			"行道树通过遮阴可以降低城市体感温度。",
@@ -40,8 +43,9 @@ var BaseKnowledge = []model.KnowledgeItem{
 		},
 	},
 	{
-		ObjectType: "road_sign",
-		Aliases:    []string{"traffic_sign", "sign"},
+		ObjectType:   "road_sign",
+		Aliases:      []string{"traffic_sign", "sign"},
+		DisplayNames: map[string]string{"zh-CN": "路牌", "en": "road sign", "zh-TW": "路牌"},
 		Facts: []string{
 			"路牌会传达警示、规则和方向信息。",
 			"路牌的形状和颜色能帮助人们快速识别含义。",
@@ -52,8 +56,9 @@ var BaseKnowledge = []model.KnowledgeItem{
 		},
 	},
 	{
-		ObjectType: "traffic_light",
-		Aliases:    []string{"signal_light"},
+		ObjectType:   "traffic_light",
+		Aliases:      []string{"signal_light"},
+		DisplayNames: map[string]string{"zh-CN": "红绿灯", "en": "traffic light", "zh-TW": "紅綠燈"},
 		Facts: []string{
 			"红绿灯用于协调车辆和行人的通行秩序。",
 			"在常见交通规则中，红灯停、绿灯行。",
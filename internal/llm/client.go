@@ -230,7 +230,7 @@ func NewClient(cfg Config) (*Client, error) {
 }
 
 func (c *Client) RecognizeObject(ctx context.Context, imageBase64 string, imageURL string) (RecognizeResult, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := context.WithTimeout(withCapability(ctx, capabilityVision), c.timeout)
 	defer cancel()
 
 	imageRef := strings.TrimSpace(imageURL)
@@ -282,8 +282,9 @@ func (c *Client) RecognizeObject(ctx context.Context, imageBase64 string, imageU
 	return RecognizeResult{}, lastErr
 }
 
-func (c *Client) GenerateLearningContent(ctx context.Context, objectType string, childAge int, spiritName string, personality string) (LearningContent, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+// GenerateLearningContent 生成科普知识、小问答与精灵台词；language 为输出语言（如 en、zh-TW），为空时使用简体中文。
+func (c *Client) GenerateLearningContent(ctx context.Context, objectType string, childAge int, spiritName string, personality string, language string) (LearningContent, error) {
+	ctx, cancel := context.WithTimeout(withCapability(ctx, capabilityLearning), c.timeout)
 	defer cancel()
 
 	body := map[string]any{
@@ -295,7 +296,7 @@ func (c *Client) GenerateLearningContent(ctx context.Context, objectType string,
 			},
 			{
 				"role":    "user",
-				"content": fmt.Sprintf("孩子年龄:%d; 物体类型:%s; 精灵名字:%s; 精灵性格:%s。请生成JSON字段: fact(1句), quiz_question(1句), quiz_answer(短语), dialogues(3-4句数组)。", childAge, objectType, spiritName, personality),
+				"content": fmt.Sprintf("孩子年龄:%d; 物体类型:%s; 精灵名字:%s; 精灵性格:%s。请生成JSON字段: fact(1句), quiz_question(1句), quiz_answer(短语), dialogues(3-4句数组)。", childAge, objectType, spiritName, personality) + languageInstruction(language),
 			},
 		},
 		"temperature": 0.7,
@@ -338,7 +339,7 @@ func (c *Client) GenerateLearningContent(ctx context.Context, objectType string,
 }
 
 func (c *Client) JudgeAnswer(ctx context.Context, question string, givenAnswer string) (AnswerJudgeResult, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := context.WithTimeout(withCapability(ctx, capabilityJudge), c.timeout)
 	defer cancel()
 
 	body := map[string]any{
@@ -400,18 +401,17 @@ func (c *Client) doJSON(ctx context.Context, path string, payload any) ([]byte,
 		req.Header.Set("x-platform-id", c.platformID)
 	}
 
+	call := beginUpstream(ctx, requestURL, payloadModel(payload))
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		call.end(0, err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf(
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		err = fmt.Errorf(
 			"llm request failed, status=%d url=%s model=%s key_meta={%s} body=%s",
 			resp.StatusCode,
 			requestURL,
@@ -420,6 +420,10 @@ func (c *Client) doJSON(ctx context.Context, path string, payload any) ([]byte,
 			truncateText(string(respBody), 320),
 		)
 	}
+	call.end(resp.StatusCode, err)
+	if err != nil {
+		return nil, err
+	}
 	return respBody, nil
 }
 
@@ -1,12 +1,23 @@
 package llm
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"ling/internal/logging"
+	"ling/internal/metrics"
 )
 
 func TestParseVisionRecognizeResultFromTruncatedJSON(t *testing.T) {
@@ -170,3 +181,120 @@ func TestJudgeAnswerOnlyUsesQuestionAndAnswer(t *testing.T) {
 		t.Fatalf("expected user prompt not to contain extra fields, got %q", userPrompt)
 	}
 }
+
+func TestUpstreamCallIsLoggedCountedAndTraced(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&buf, slog.LevelInfo, logging.FormatJSON))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previousProvider) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:    "test-key",
+		BaseURL:   server.URL,
+		ChatModel: "qwen3.5-flash-test",
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	client.httpClient = server.Client()
+
+	ctx, parent := otel.Tracer("test").Start(logging.WithRequestID(context.Background(), "req-judge"), "parent")
+	failedBefore := metrics.UpstreamRequests.Value(capabilityJudge, "error")
+	if _, err := client.JudgeAnswer(ctx, "这个动物会汪汪叫吗？", "会"); err == nil {
+		t.Fatalf("expected upstream error")
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single json log line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "llm upstream call" || entry["level"] != "WARN" {
+		t.Fatalf("unexpected log entry %v", entry)
+	}
+	if entry["request_id"] != "req-judge" || entry["model"] != "qwen3.5-flash-test" || entry["status"] != float64(http.StatusBadGateway) {
+		t.Fatalf("expected request id, model and status in log entry, got %v", entry)
+	}
+	if _, ok := entry["latency_ms"]; !ok {
+		t.Fatalf("expected latency_ms in log entry, got %v", entry)
+	}
+	if entry["capability"] != capabilityJudge {
+		t.Fatalf("expected capability judge, got %v", entry["capability"])
+	}
+	if got := metrics.UpstreamRequests.Value(capabilityJudge, "error"); got != failedBefore+1 {
+		t.Fatalf("expected one failed judge call to be counted, got %v -> %v", failedBefore, got)
+	}
+	if entry["trace_id"] != parent.SpanContext().TraceID().String() {
+		t.Fatalf("expected trace_id of the parent span in log entry, got %v", entry["trace_id"])
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "llm.judge" {
+		t.Fatalf("expected one llm.judge span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("expected llm.judge to be a child of the caller span")
+	}
+	if span.Status().Code != codes.Error {
+		t.Fatalf("expected error status, got %v", span.Status())
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["llm.model"].AsString() != "qwen3.5-flash-test" || attrs["llm.attempt"].AsInt64() != 1 || attrs["http.response.status_code"].AsInt64() != http.StatusBadGateway {
+		t.Fatalf("expected model, attempt and status attributes, got %v", span.Attributes())
+	}
+}
+
+func TestProbeChecksModelsEndpointWithConfiguredKey(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Method != http.MethodGet || r.URL.Path != "/compatible-mode/v1/models" {
+			t.Errorf("unexpected probe request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer bad-key" {
+			t.Errorf("expected probe to use the chat key, got %q", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:       "bad-key",
+		BaseURL:      server.URL,
+		ImageBaseURL: server.URL,
+		VoiceBaseURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	client.httpClient = server.Client()
+
+	results := client.Probe(context.Background())
+	if len(results) != 3 {
+		t.Fatalf("expected 3 probe results, got %+v", results)
+	}
+	chat := results[0]
+	if chat.Capability != ProbeChat || chat.Status != http.StatusUnauthorized || chat.Err == nil {
+		t.Fatalf("expected chat probe to report 401, got %+v", chat)
+	}
+	for _, result := range results[1:] {
+		if !result.Skipped {
+			t.Fatalf("expected non-DashScope %s upstream to be skipped, got %+v", result.Capability, result)
+		}
+	}
+	if hits != 1 {
+		t.Fatalf("expected a single upstream probe, got %d", hits)
+	}
+}
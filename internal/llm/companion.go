@@ -26,6 +26,8 @@ type CompanionSceneRequest struct {
 	Weather      string
 	Environment  string
 	ObjectTraits string
+	// Language 为台词的输出语言（如 en、zh-TW），为空时使用简体中文。
+	Language string
 }
 
 type CompanionScene struct {
@@ -46,6 +48,7 @@ type CompanionReplyRequest struct {
 	ObjectTraits         string
 	History              []string
 	ChildMessage         string
+	Language             string
 }
 
 type CompanionReply struct {
@@ -62,7 +65,7 @@ const (
 )
 
 func (c *Client) GenerateCompanionScene(ctx context.Context, req CompanionSceneRequest) (CompanionScene, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := context.WithTimeout(withCapability(ctx, capabilityCompanion), c.timeout)
 	defer cancel()
 
 	body := map[string]any{
@@ -101,11 +104,12 @@ func (c *Client) GenerateCompanionScene(ctx context.Context, req CompanionSceneR
 }
 
 func (c *Client) GenerateCharacterImage(ctx context.Context, imagePrompt string, sourceImage string) (string, error) {
+	ctx = withCapability(ctx, capabilityImage)
 	if strings.TrimSpace(c.imageAPIKey) == "" {
 		return "", ErrImageCapabilityUnavailable
 	}
 	// 这里不再额外套超时，避免上游慢请求在客户端提前被 context cancel。
-	// 调用方可按需在更高层控制超时策略。
+	// 超时由调用方在更高层控制，HTTP 接口使用路由的处理时限。
 	requestURL := resolveImageGenerationRequestURL(c.imageBaseURL)
 	trimmedPrompt := normalizeImagePrompt(strings.TrimSpace(imagePrompt))
 	trimmedSourceImage := strings.TrimSpace(sourceImage)
@@ -369,7 +373,7 @@ func extractDataURLBase64Payload(dataURL string) string {
 }
 
 func (c *Client) GenerateCompanionReply(ctx context.Context, req CompanionReplyRequest) (CompanionReply, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.companionChatTimeout)
+	ctx, cancel := context.WithTimeout(withCapability(ctx, capabilityCompanion), c.companionChatTimeout)
 	defer cancel()
 
 	historyBlock := buildCompanionHistoryBlock(req.History)
@@ -409,7 +413,9 @@ func (c *Client) GenerateCompanionReply(ctx context.Context, req CompanionReplyR
 	return reply, nil
 }
 
-func (c *Client) SynthesizeSpeech(ctx context.Context, text string, objectType string) ([]byte, string, error) {
+// SynthesizeSpeech 合成角色语音。language 为朗读语言（如 en、zh-TW），为空时使用配置的 VoiceLangCode。
+func (c *Client) SynthesizeSpeech(ctx context.Context, text string, objectType string, language string) ([]byte, string, error) {
+	ctx = withCapability(ctx, capabilityTTS)
 	if strings.TrimSpace(c.voiceAPIKey) == "" || strings.TrimSpace(c.voiceModelID) == "" {
 		return nil, "", ErrVoiceCapabilityUnavailable
 	}
@@ -421,6 +427,10 @@ func (c *Client) SynthesizeSpeech(ctx context.Context, text string, objectType s
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
+	languageType := normalizeTTSLanguageType(c.voiceLangCode)
+	if strings.TrimSpace(language) != "" {
+		languageType = normalizeTTSLanguageType(language)
+	}
 	requestURL := resolveTTSGenerationRequestURL(c.voiceBaseURL)
 	for _, voice := range c.ttsVoiceCandidates(objectType, c.voiceID) {
 		body := map[string]any{
@@ -428,7 +438,7 @@ func (c *Client) SynthesizeSpeech(ctx context.Context, text string, objectType s
 			"input": map[string]any{
 				"text":          trimmedText,
 				"voice":         voice,
-				"language_type": normalizeTTSLanguageType(c.voiceLangCode),
+				"language_type": languageType,
 			},
 			"parameters": map[string]any{
 				"stream": false,
@@ -469,9 +479,9 @@ func normalizeTTSLanguageType(lang string) string {
 	switch strings.ToLower(strings.TrimSpace(lang)) {
 	case "", "auto":
 		return "Auto"
-	case "zh", "cn", "zh-cn", "chinese":
+	case "zh", "cn", "zh-cn", "zh-tw", "zh-hk", "chinese":
 		return "Chinese"
-	case "en", "en-us", "english":
+	case "en", "en-us", "en-gb", "english":
 		return "English"
 	case "ja", "japanese":
 		return "Japanese"
@@ -605,19 +615,22 @@ func (c *Client) downloadBinary(ctx context.Context, resourceURL string, fallbac
 	if err != nil {
 		return nil, "", err
 	}
+	call := beginUpstream(ctx, resourceURL, "")
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		call.end(0, err)
 		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		err = fmt.Errorf("download resource failed, status=%d", resp.StatusCode)
+	}
+	call.end(resp.StatusCode, err)
 	if err != nil {
 		return nil, "", err
 	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, "", fmt.Errorf("download resource failed, status=%d", resp.StatusCode)
-	}
 	contentType := strings.TrimSpace(resp.Header.Get("Content-Type"))
 	if contentType == "" {
 		contentType = fallbackContentType
@@ -629,6 +642,7 @@ func (c *Client) downloadBinary(ctx context.Context, resourceURL string, fallbac
 }
 
 func (c *Client) DownloadImage(ctx context.Context, imageURL string) ([]byte, string, error) {
+	ctx = withCapability(ctx, capabilityDownload)
 	trimmedURL := strings.TrimSpace(imageURL)
 	if trimmedURL == "" {
 		return nil, "", ErrInvalidResponse
@@ -645,19 +659,22 @@ func (c *Client) DownloadImage(ctx context.Context, imageURL string) ([]byte, st
 		return nil, "", err
 	}
 
+	call := beginUpstream(ctx, trimmedURL, "")
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		call.end(0, err)
 		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		err = fmt.Errorf("download image failed, status=%d", resp.StatusCode)
+	}
+	call.end(resp.StatusCode, err)
 	if err != nil {
 		return nil, "", err
 	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, "", fmt.Errorf("download image failed, status=%d", resp.StatusCode)
-	}
 	contentType := strings.TrimSpace(resp.Header.Get("Content-Type"))
 	if contentType == "" {
 		contentType = "image/png"
@@ -727,13 +744,16 @@ func (c *Client) doMediaRequest(ctx context.Context, requestURL string, apiKey s
 		req.Header.Set("x-platform-id", c.platformID)
 	}
 
+	call := beginUpstream(ctx, requestURL, payloadModel(payload))
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		call.end(0, err)
 		return nil, nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	call.end(resp.StatusCode, err)
 	if err != nil {
 		return nil, nil, resp.StatusCode, err
 	}
@@ -837,7 +857,7 @@ image_prompt 规则（必须满足）：
 		defaultText(req.Environment, "户外"),
 		defaultText(req.ObjectTraits, "圆润可爱"),
 		companionAgeLayerInstruction(age),
-	)
+	) + languageInstruction(req.Language)
 }
 
 func buildCompanionReplySystemPrompt() string {
@@ -901,7 +921,7 @@ func buildCompanionReplyUserPrompt(req CompanionReplyRequest, historyBlock strin
 		companionAgeLayerInstruction(age),
 		historyBlock,
 		strings.TrimSpace(req.ChildMessage),
-	)
+	) + languageInstruction(req.Language)
 }
 
 func normalizeCompanionAge(age int) int {
@@ -536,7 +536,7 @@ func TestSynthesizeSpeech(t *testing.T) {
 	}
 	client.httpClient = server.Client()
 
-	audio, mime, err := client.SynthesizeSpeech(context.Background(), "你好，小朋友", "猫")
+	audio, mime, err := client.SynthesizeSpeech(context.Background(), "你好，小朋友", "猫", "")
 	if err != nil {
 		t.Fatalf("SynthesizeSpeech() error = %v", err)
 	}
@@ -0,0 +1,16 @@
+package llm
+
+import "strings"
+
+// languageInstruction 返回追加在提示词末尾的输出语言要求。提示词本身按简体中文编写，
+// 因此 language 为空或为简体中文时不追加任何内容。
+func languageInstruction(language string) string {
+	switch strings.ToLower(strings.TrimSpace(language)) {
+	case "en", "en-us", "en-gb", "english":
+		return "\n\n语言要求：所有面向孩子或家长的文字内容改用英文（English）输出，覆盖上文对简体中文的要求；JSON 字段名保持不变。"
+	case "zh-tw", "zh-hk", "zh-mo", "zh-hant":
+		return "\n\n语言要求：所有面向孩子或家长的文字内容改用繁体中文输出，覆盖上文对简体中文的要求；JSON 字段名保持不变。"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"ling/internal/metrics"
+	"ling/internal/tracing"
+)
+
+// 上游能力，用于日志、指标与链路追踪中区分调用用途。
+const (
+	capabilityVision    = "vision"
+	capabilityLearning  = "learning"
+	capabilityJudge     = "judge"
+	capabilityCompanion = "companion"
+	capabilityReport    = "report"
+	capabilityImage     = "image"
+	capabilityTTS       = "tts"
+	capabilityDownload  = "download"
+	capabilityCOSUpload = "cos_upload"
+	capabilityProbe     = "probe"
+)
+
+type capabilityKey struct{}
+
+// capabilityState 为一次能力调用的上下文，attempts 统计其中已发出的上游请求数（含重试与换模型）。
+type capabilityState struct {
+	name     string
+	attempts atomic.Int32
+}
+
+// withCapability 标记后续上游调用的用途；同一能力内部的重试、下载等调用都记在该能力下。
+func withCapability(ctx context.Context, capability string) context.Context {
+	return context.WithValue(ctx, capabilityKey{}, &capabilityState{name: capability})
+}
+
+func capabilityFrom(ctx context.Context) string {
+	if state, ok := ctx.Value(capabilityKey{}).(*capabilityState); ok {
+		return state.name
+	}
+	return "unknown"
+}
+
+func nextAttempt(ctx context.Context) int {
+	if state, ok := ctx.Value(capabilityKey{}).(*capabilityState); ok {
+		return int(state.attempts.Add(1))
+	}
+	return 1
+}
+
+// upstreamCall 是一次进行中的上游请求，由 beginUpstream 开始、end 结束。
+type upstreamCall struct {
+	ctx        context.Context
+	span       trace.Span
+	capability string
+	requestURL string
+	model      string
+	attempt    int
+	start      time.Time
+}
+
+// beginUpstream 开始一次上游请求，并在 ctx 的 span 下开一个 llm.<capability> 子 span。
+func beginUpstream(ctx context.Context, requestURL string, model string) *upstreamCall {
+	capability := capabilityFrom(ctx)
+	attempt := nextAttempt(ctx)
+	attrs := []attribute.KeyValue{
+		attribute.String("llm.capability", capability),
+		attribute.String("llm.upstream", upstreamName(requestURL)),
+		attribute.Int("llm.attempt", attempt),
+	}
+	if model != "" {
+		attrs = append(attrs, attribute.String("llm.model", model))
+	}
+	_, span := tracing.Start(ctx, "llm."+capability, attrs...)
+	return &upstreamCall{
+		ctx:        ctx,
+		span:       span,
+		capability: capability,
+		requestURL: requestURL,
+		model:      model,
+		attempt:    attempt,
+		start:      time.Now(),
+	}
+}
+
+// end 记录一次上游调用的日志、指标与 span。请求 ID 由调用方 context 带入，便于把同一次扫描的识别、生成与判题调用串起来。
+// status 为 0 表示没有拿到 HTTP 响应（如超时或连接失败）。
+func (u *upstreamCall) end(status int, err error) {
+	metrics.ObserveUpstream(u.capability, u.start, err)
+	attrs := []slog.Attr{
+		slog.String("capability", u.capability),
+		slog.String("upstream", upstreamName(u.requestURL)),
+		slog.Int64("latency_ms", time.Since(u.start).Milliseconds()),
+	}
+	if u.attempt > 1 {
+		attrs = append(attrs, slog.Int("attempt", u.attempt))
+	}
+	if u.model != "" {
+		attrs = append(attrs, slog.String("model", u.model))
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+		u.span.SetAttributes(attribute.Int("http.response.status_code", status))
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("err", err.Error()))
+	}
+	slog.LogAttrs(u.ctx, level, "llm upstream call", attrs...)
+	tracing.End(u.span, err)
+}
+
+// upstreamName 只保留主机与路径，避免把查询参数里的签名写进日志。
+func upstreamName(requestURL string) string {
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host + parsed.Path
+}
+
+// payloadModel 取请求体中的 model 字段，多模态接口与对话接口的请求体都在顶层带有该字段。
+func payloadModel(payload any) string {
+	body, ok := payload.(map[string]any)
+	if !ok {
+		return ""
+	}
+	model, _ := body["model"].(string)
+	return model
+}
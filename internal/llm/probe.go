@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// 探测结果中的能力名，对话类能力（识别、学习内容、判题、陪伴、报告）共用同一上游。
+const (
+	ProbeChat  = "chat"
+	ProbeImage = "image"
+	ProbeVoice = "voice"
+)
+
+const dashScopeCompatibleModelsPath = "/compatible-mode/v1/models"
+
+// ProbeResult 是一项上游能力的探测结果。
+type ProbeResult struct {
+	Capability string
+	// Skipped 表示该上游没有可免费调用的模型列表接口，未做探测。
+	Skipped bool
+	// Status 为上游返回的 HTTP 状态码，没有拿到响应时为 0。
+	Status int
+	Err    error
+}
+
+type probeTarget struct {
+	capability string
+	requestURL string
+	apiKey     string
+}
+
+// Probe 用各能力配置的 API Key 请求 OpenAI 兼容的模型列表接口，确认上游可达且 Key 有效，不会产生计费调用。
+// 多个能力共用同一地址与 Key 时只请求一次。
+func (c *Client) Probe(ctx context.Context) []ProbeResult {
+	ctx = withCapability(ctx, capabilityProbe)
+	targets := []probeTarget{
+		{capability: ProbeChat, requestURL: chatModelsURL(c.baseURL, c.chatCompletionsPath), apiKey: c.apiKey},
+		{capability: ProbeImage, requestURL: dashScopeModelsURL(c.imageBaseURL), apiKey: c.imageAPIKey},
+		{capability: ProbeVoice, requestURL: dashScopeModelsURL(c.voiceBaseURL), apiKey: c.voiceAPIKey},
+	}
+
+	type outcome struct {
+		status int
+		err    error
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		outcomes = make(map[probeTarget]*outcome)
+	)
+	for _, target := range targets {
+		if target.requestURL == "" {
+			continue
+		}
+		key := probeTarget{requestURL: target.requestURL, apiKey: target.apiKey}
+		mu.Lock()
+		_, seen := outcomes[key]
+		if !seen {
+			outcomes[key] = &outcome{}
+		}
+		mu.Unlock()
+		if seen {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := c.probeOnce(ctx, key.requestURL, key.apiKey)
+			mu.Lock()
+			outcomes[key].status, outcomes[key].err = status, err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	results := make([]ProbeResult, 0, len(targets))
+	for _, target := range targets {
+		if target.requestURL == "" {
+			results = append(results, ProbeResult{Capability: target.capability, Skipped: true})
+			continue
+		}
+		got := outcomes[probeTarget{requestURL: target.requestURL, apiKey: target.apiKey}]
+		results = append(results, ProbeResult{Capability: target.capability, Status: got.status, Err: got.err})
+	}
+	return results
+}
+
+func (c *Client) probeOnce(ctx context.Context, requestURL string, apiKey string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(apiKey))
+
+	call := beginUpstream(ctx, requestURL, "")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		call.end(0, err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = fmt.Errorf("probe failed, status=%d url=%s key_meta={%s}", resp.StatusCode, requestURL, safeKeyMeta(apiKey))
+	}
+	call.end(resp.StatusCode, err)
+	return resp.StatusCode, err
+}
+
+// chatModelsURL 由对话接口地址推出同一前缀下的模型列表地址，地址不是 OpenAI 兼容格式时返回空串。
+func chatModelsURL(baseURL string, chatCompletionsPath string) string {
+	requestURL := baseURL + chatCompletionsPath
+	idx := strings.LastIndex(strings.ToLower(requestURL), "/chat/completions")
+	if idx < 0 {
+		return ""
+	}
+	return requestURL[:idx] + "/models"
+}
+
+// dashScopeModelsURL 返回 DashScope 主机上的兼容模式模型列表地址；生图与语音的多模态接口没有模型列表，
+// 只能借同一主机的兼容接口校验 Key，其他主机返回空串。
+func dashScopeModelsURL(baseURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	if !strings.HasSuffix(strings.ToLower(parsed.Host), "dashscope.aliyuncs.com") {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host + dashScopeCompatibleModelsPath
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const parentReportMaxSuggestions = 2
+
+type ParentReportRequest struct {
+	// PeriodLabel 描述报告覆盖的时间段，例如“今天”“本周（2026-02-09 至 2026-02-15）”。
+	PeriodLabel string
+	ChildName   string
+	ChildAge    int
+	Stats       []string
+	Highlights  []string
+	// Language 为报告的输出语言（如 en、zh-TW），为空时使用简体中文。
+	Language string
+}
+
+type ParentReport struct {
+	Summary     string
+	Suggestions []string
+	RawContent  string
+}
+
+// GenerateParentReport 使用剧情文案模型为家长撰写学习报告摘要，并给出线下延伸建议。
+func (c *Client) GenerateParentReport(ctx context.Context, req ParentReportRequest) (ParentReport, error) {
+	ctx, cancel := context.WithTimeout(withCapability(ctx, capabilityReport), c.companionChatTimeout)
+	defer cancel()
+
+	body := map[string]any{
+		"model": c.companionModel,
+		"messages": []map[string]any{
+			{
+				"role":    "system",
+				"content": buildParentReportSystemPrompt(),
+			},
+			{
+				"role":    "user",
+				"content": buildParentReportUserPrompt(req),
+			},
+		},
+		"temperature": 0.6,
+		"max_tokens":  400,
+		"response_format": map[string]any{
+			"type": "json_object",
+		},
+	}
+
+	raw, err := c.doJSON(ctx, c.chatCompletionsPath, body)
+	if err != nil {
+		return ParentReport{}, err
+	}
+	content, err := extractAssistantContent(raw)
+	if err != nil {
+		return ParentReport{}, err
+	}
+	report, err := parseParentReport(content)
+	if err != nil {
+		return ParentReport{}, err
+	}
+	report.RawContent = content
+	return report, nil
+}
+
+func parseParentReport(content string) (ParentReport, error) {
+	payload := extractJSONPayload(strings.TrimSpace(content))
+	var parsed struct {
+		Summary     string   `json:"summary"`
+		Suggestions []string `json:"suggestions"`
+	}
+	if err := unmarshalFirstJSONObject(payload, &parsed); err != nil {
+		return ParentReport{}, fmt.Errorf("parse parent report failed: %w", err)
+	}
+	report := ParentReport{Summary: strings.TrimSpace(parsed.Summary)}
+	if report.Summary == "" {
+		return ParentReport{}, ErrInvalidResponse
+	}
+	for _, suggestion := range parsed.Suggestions {
+		if suggestion = strings.TrimSpace(suggestion); suggestion != "" {
+			report.Suggestions = append(report.Suggestions, suggestion)
+		}
+		if len(report.Suggestions) == parentReportMaxSuggestions {
+			break
+		}
+	}
+	return report, nil
+}
+
+func buildParentReportSystemPrompt() string {
+	return "你是儿童城市探索应用的学习顾问，负责向家长汇报孩子的探索与学习情况。只输出 JSON，不要 markdown。"
+}
+
+func buildParentReportUserPrompt(req ParentReportRequest) string {
+	age := normalizeCompanionAge(req.ChildAge)
+	return fmt.Sprintf(
+		`请根据以下数据为家长撰写学习报告，严格按 JSON 输出。
+输入信息：
+- 报告时间段: %s
+- 孩子称呼: %s
+- 孩子年龄: %d
+- 年龄认知层: %s
+- 统计数据:
+%s
+- 亮点记录:
+%s
+
+输出 JSON 字段：
+{"summary":"","suggestions":["",""]}
+
+写作规则（必须满足）：
+1) summary 面向家长，语气温暖、具体，120-200 字，简体中文；引用真实的收集对象或知识点，不要编造数据。
+2) 如有与上一周期的对比，客观描述变化，进步时具体表扬，退步时给出温和鼓励，不要制造焦虑。
+3) suggestions 给出 2 条适合该年龄的线下延伸活动或亲子对话开场白，每条不超过 40 字。
+4) 不要罗列原始统计数字清单，不要使用编号。`,
+		defaultText(req.PeriodLabel, "今天"),
+		defaultText(req.ChildName, "孩子"),
+		age,
+		companionAgeLayerInstruction(age),
+		buildParentReportList(req.Stats, "(无统计数据)"),
+		buildParentReportList(req.Highlights, "(无亮点记录)"),
+	) + languageInstruction(req.Language)
+}
+
+func buildParentReportList(items []string, empty string) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			lines = append(lines, "  · "+item)
+		}
+	}
+	if len(lines) == 0 {
+		return "  " + empty
+	}
+	return strings.Join(lines, "\n")
+}
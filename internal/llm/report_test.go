@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseParentReportKeepsTwoSuggestions(t *testing.T) {
+	report, err := parseParentReport("```json\n{\"summary\":\" 本周表现很棒 \",\"suggestions\":[\"一\",\" \",\"二\",\"三\"]}\n```")
+	if err != nil {
+		t.Fatalf("parseParentReport() error = %v", err)
+	}
+	if report.Summary != "本周表现很棒" {
+		t.Fatalf("unexpected summary %q", report.Summary)
+	}
+	if len(report.Suggestions) != 2 || report.Suggestions[1] != "二" {
+		t.Fatalf("unexpected suggestions %v", report.Suggestions)
+	}
+	if _, err := parseParentReport(`{"summary":""}`); err == nil {
+		t.Fatalf("expected empty summary to be rejected")
+	}
+}
+
+func TestBuildParentReportUserPromptIncludesStats(t *testing.T) {
+	prompt := buildParentReportUserPrompt(ParentReportRequest{
+		PeriodLabel: "本周（2026-02-09 至 2026-02-15）",
+		ChildAge:    5,
+		Stats:       []string{"收集精灵 3 次（上周 1 次）"},
+	})
+	for _, want := range []string{"本周（2026-02-09 至 2026-02-15）", "收集精灵 3 次", "3-6岁", "(无亮点记录)"} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("expected prompt to contain %q, got:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestBuildParentReportUserPromptAppendsLanguage(t *testing.T) {
+	base := buildParentReportUserPrompt(ParentReportRequest{ChildAge: 8})
+	if strings.Contains(base, "语言要求") {
+		t.Fatalf("expected no language instruction for default locale, got:\n%s", base)
+	}
+	english := buildParentReportUserPrompt(ParentReportRequest{ChildAge: 8, Language: "en"})
+	if !strings.Contains(english, "English") {
+		t.Fatalf("expected english instruction, got:\n%s", english)
+	}
+	traditional := buildParentReportUserPrompt(ParentReportRequest{ChildAge: 8, Language: "zh-TW"})
+	if !strings.Contains(traditional, "繁体中文") {
+		t.Fatalf("expected traditional chinese instruction, got:\n%s", traditional)
+	}
+	if got := normalizeTTSLanguageType("zh-TW"); got != "Chinese" {
+		t.Fatalf("normalizeTTSLanguageType(zh-TW) = %q, want Chinese", got)
+	}
+}
@@ -24,6 +24,7 @@ var fileNamePattern = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
 
 // UploadImageBytesToPublicURL uploads image bytes to a public URL via native Go COS SDK only.
 func (c *Client) UploadImageBytesToPublicURL(ctx context.Context, imageBytes []byte, fileName string) (string, error) {
+	ctx = withCapability(ctx, capabilityCOSUpload)
 	if len(imageBytes) == 0 {
 		return "", fmt.Errorf("image bytes is empty")
 	}
@@ -60,7 +61,10 @@ func (c *Client) uploadViaCOS(ctx context.Context, imageBytes []byte, fileName s
 	})
 
 	key := buildUploadObjectKey(fileName)
-	if _, err := client.Object.Put(ctx, key, bytes.NewReader(imageBytes), nil); err != nil {
+	call := beginUpstream(ctx, bucketURL.String(), "")
+	_, err = client.Object.Put(ctx, key, bytes.NewReader(imageBytes), nil)
+	call.end(0, err)
+	if err != nil {
 		return "", err
 	}
 
@@ -0,0 +1,141 @@
+// Package logging 统一服务端日志：基于 log/slog 输出 JSON，按请求 ID 串起一次请求内的所有日志。
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// ParseLevel 解析 debug、info、warn、error（大小写不敏感），空串按 info 处理。
+func ParseLevel(raw string) (slog.Level, error) {
+	var level slog.Level
+	if strings.TrimSpace(raw) == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(raw))); err != nil {
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q", raw)
+	}
+	return level, nil
+}
+
+// New 创建日志记录器；format 为 text 时输出便于本地阅读的 key=value，其余情况输出 JSON。
+// 记录时若 context 里带有请求 ID 或链路追踪 span，会自动附加 request_id、trace_id 字段。
+func New(w io.Writer, level slog.Leveler, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if strings.EqualFold(strings.TrimSpace(format), FormatText) {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{Handler: handler})
+}
+
+// Setup 按配置创建日志记录器并设为默认值，标准库 log 包的输出也会转到这里。
+// 级别无法解析时仍按 info 安装，并返回错误供调用方提示。
+func Setup(w io.Writer, level string, format string) error {
+	parsed, err := ParseLevel(level)
+	slog.SetDefault(New(w, parsed, format))
+	return err
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		record.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+type requestIDKey struct{}
+
+type fieldsKey struct{}
+
+// WithRequestID 把请求 ID 放进 context，之后经该 context 记录的日志都会带上它。
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 context 中的请求 ID，没有时返回空串。
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Fields 收集一次请求处理过程中补充的日志字段（如 child_id、object_type），
+// 由访问日志在请求结束时统一输出。
+type Fields struct {
+	mu    sync.Mutex
+	attrs []any
+}
+
+// WithFields 在 context 中挂上一个空的字段收集器。
+func WithFields(ctx context.Context) (context.Context, *Fields) {
+	fields := &Fields{}
+	return context.WithValue(ctx, fieldsKey{}, fields), fields
+}
+
+// AddFields 向请求的字段收集器追加 key/value 对；context 中没有收集器时忽略。同名字段以最后一次为准。
+func AddFields(ctx context.Context, args ...any) {
+	if ctx == nil {
+		return
+	}
+	fields, ok := ctx.Value(fieldsKey{}).(*Fields)
+	if !ok {
+		return
+	}
+	fields.mu.Lock()
+	fields.attrs = append(fields.attrs, args...)
+	fields.mu.Unlock()
+}
+
+// Attrs 返回去重后的字段，空值会被跳过。
+func (f *Fields) Attrs() []slog.Attr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record := slog.Record{}
+	record.Add(f.attrs...)
+	index := make(map[string]int)
+	var attrs []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Value.Kind() == slog.KindString && attr.Value.String() == "" {
+			return true
+		}
+		if i, ok := index[attr.Key]; ok {
+			attrs[i] = attr
+			return true
+		}
+		index[attr.Key] = len(attrs)
+		attrs = append(attrs, attr)
+		return true
+	})
+	return attrs
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"WARN":    slog.LevelWarn,
+		" error ": slog.LevelError,
+	}
+	for raw, want := range cases {
+		got, err := ParseLevel(raw)
+		if err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", raw, got, err, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatalf("expected error for unknown level")
+	}
+}
+
+func TestLoggerAddsRequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo, FormatJSON)
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	logger.DebugContext(ctx, "hidden")
+	logger.With("op", "scan").InfoContext(ctx, "visible", "child_id", "kid_1")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single json line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "visible" || entry["request_id"] != "req-1" || entry["op"] != "scan" || entry["child_id"] != "kid_1" {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+}
+
+func TestFieldsKeepLastValuePerKey(t *testing.T) {
+	ctx, fields := WithFields(context.Background())
+	AddFields(ctx, "child_id", "kid_1", "object_type", "")
+	AddFields(ctx, "object_type", "路灯", "child_id", "kid_2")
+	AddFields(context.Background(), "ignored", true)
+
+	attrs := fields.Attrs()
+	if len(attrs) != 2 {
+		t.Fatalf("expected 2 attrs, got %v", attrs)
+	}
+	if attrs[0].Key != "child_id" || attrs[0].Value.String() != "kid_2" {
+		t.Fatalf("unexpected child_id attr %v", attrs[0])
+	}
+	if attrs[1].Key != "object_type" || attrs[1].Value.String() != "路灯" {
+		t.Fatalf("unexpected object_type attr %v", attrs[1])
+	}
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import "time"
+
+// Default 是服务端使用的指标集合，/metrics 输出的就是它。
+var Default = NewRegistry()
+
+var (
+	httpBuckets     = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	upstreamBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 45, 90}
+	storeBuckets    = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 1}
+)
+
+// HTTP 接口。route 取路由表中的模式（如 "POST /api/v1/scan"），未匹配到路由的请求记为 unmatched。
+var (
+	HTTPRequests = Default.NewCounter(
+		"cityling_http_requests_total",
+		"HTTP requests by route and status code.",
+		"route", "status",
+	)
+	HTTPRequestDuration = Default.NewHistogram(
+		"cityling_http_request_duration_seconds",
+		"HTTP request latency by route and status code.",
+		httpBuckets,
+		"route", "status",
+	)
+)
+
+// 大模型与多媒体上游。capability 取 vision、learning、judge、companion、report、image、tts、download、cos_upload、probe。
+var (
+	UpstreamRequests = Default.NewCounter(
+		"cityling_upstream_requests_total",
+		"Upstream calls by capability and outcome (ok or error).",
+		"capability", "outcome",
+	)
+	UpstreamDuration = Default.NewHistogram(
+		"cityling_upstream_request_duration_seconds",
+		"Upstream call latency by capability.",
+		upstreamBuckets,
+		"capability",
+	)
+)
+
+// 扫描内容缓存与内容来源。
+var (
+	ScanCacheLookups = Default.NewCounter(
+		"cityling_scan_cache_lookups_total",
+		"Scan content cache lookups by result (hit or miss).",
+		"result",
+	)
+	// ContentSource 记录内容最终来自哪里：llm 为大模型，knowledge_base 为内置知识库，template 为本地模板兜底，
+	// rule 为判题时的本地答案比对。
+	ContentSource = Default.NewCounter(
+		"cityling_content_source_total",
+		"Generated content by kind (learning, companion_scene, answer_judge) and source (llm, knowledge_base, template, rule).",
+		"kind", "source",
+	)
+)
+
+// 存储操作。
+var (
+	StoreOperationDuration = Default.NewHistogram(
+		"cityling_store_operation_duration_seconds",
+		"Store operation latency by operation.",
+		storeBuckets,
+		"operation",
+	)
+	StoreErrors = Default.NewCounter(
+		"cityling_store_errors_total",
+		"Store operations that returned an error, by operation.",
+		"operation",
+	)
+)
+
+// 限流。key_kind 为限流 key 的来源：account、child 或 ip。
+var (
+	RateLimited = Default.NewCounter(
+		"cityling_rate_limited_total",
+		"Requests rejected with 429 by route and key kind.",
+		"route", "key_kind",
+	)
+	// RateLimitBackendErrors 统计限流后端出错而放行的请求，持续增长说明共享后端不可用。
+	RateLimitBackendErrors = Default.NewCounter(
+		"cityling_rate_limit_backend_errors_total",
+		"Rate limit checks that failed open because the backend returned an error.",
+	)
+)
+
+func init() {
+	Default.NewGaugeFunc(
+		"cityling_scan_cache_hit_ratio",
+		"Share of scan cache lookups that were hits since start, 0 before the first lookup.",
+		func() float64 {
+			hits := ScanCacheLookups.Value("hit")
+			total := hits + ScanCacheLookups.Value("miss")
+			if total == 0 {
+				return 0
+			}
+			return hits / total
+		},
+	)
+}
+
+// ObserveUpstream 记录一次上游调用，err 非空时计入失败。
+func ObserveUpstream(capability string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	UpstreamRequests.Inc(capability, outcome)
+	UpstreamDuration.Observe(time.Since(start).Seconds(), capability)
+}
+
+// ObserveStore 记录一次存储操作的耗时，err 非空时计入失败。
+func ObserveStore(operation string, start time.Time, err error) {
+	StoreOperationDuration.Observe(time.Since(start).Seconds(), operation)
+	if err != nil {
+		StoreErrors.Inc(operation)
+	}
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTextFormatsCountersHistogramsAndGauges(t *testing.T) {
+	reg := NewRegistry()
+	requests := reg.NewCounter("test_requests_total", "Requests.", "route", "status")
+	latency := reg.NewHistogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	reg.NewGaugeFunc("test_ratio", "Ratio.", func() float64 { return 0.25 })
+
+	requests.Inc("POST /api/v1/scan", "200")
+	requests.Add(2, "POST /api/v1/scan", "200")
+	requests.Inc(`say "hi"`, "500")
+	requests.Add(-1, "POST /api/v1/scan", "200")
+	latency.Observe(0.05, "POST /api/v1/scan")
+	latency.Observe(0.5, "POST /api/v1/scan")
+	latency.Observe(3, "POST /api/v1/scan")
+
+	var out strings.Builder
+	if err := reg.WriteText(&out); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{route="POST /api/v1/scan",status="200"} 3
+test_requests_total{route="say \"hi\"",status="500"} 1
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{route="POST /api/v1/scan",le="0.1"} 1
+test_latency_seconds_bucket{route="POST /api/v1/scan",le="1"} 2
+test_latency_seconds_bucket{route="POST /api/v1/scan",le="+Inf"} 3
+test_latency_seconds_sum{route="POST /api/v1/scan"} 3.55
+test_latency_seconds_count{route="POST /api/v1/scan"} 3
+# HELP test_ratio Ratio.
+# TYPE test_ratio gauge
+test_ratio 0.25
+`
+	if out.String() != want {
+		t.Fatalf("unexpected exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if got := requests.Value("POST /api/v1/scan", "200"); got != 3 {
+		t.Fatalf("Value() = %v, want 3", got)
+	}
+}
+
+func TestRegisterRejectsDuplicateNames(t *testing.T) {
+	reg := NewRegistry()
+	reg.NewCounter("dup_total", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic for duplicate metric name")
+		}
+	}()
+	reg.NewCounter("dup_total", "Second.")
+}
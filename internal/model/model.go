@@ -1,12 +1,18 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"ling/pkg/api"
+)
 
 type KnowledgeItem struct {
 	ObjectType string
 	Aliases    []string
-	Facts      []string
-	Quiz       []QuizItem
+	// DisplayNames 为对象在各语言下的展示名，键为语言标签（zh-CN、en、zh-TW）。
+	DisplayNames map[string]string
+	Facts        []string
+	Quiz         []QuizItem
 }
 
 type QuizItem struct {
@@ -14,15 +20,6 @@ type QuizItem struct {
 	Answer   string
 }
 
-type Spirit struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	ObjectType  string    `json:"object_type"`
-	Personality string    `json:"personality"`
-	Intro       string    `json:"intro"`
-	CreatedAt   time.Time `json:"created_at"`
-}
-
 type ScanSession struct {
 	ID          string    `json:"id"`
 	ChildID     string    `json:"child_id"`
@@ -37,49 +34,73 @@ type ScanSession struct {
 	Captured    bool      `json:"captured"`
 	CapturedAt  time.Time `json:"captured_at,omitempty"`
 	AnswerGiven string    `json:"answer_given,omitempty"`
+	Attempts    int       `json:"attempts,omitempty"`
+	Location    *GeoPoint `json:"location,omitempty"`
 }
 
-type Capture struct {
-	ID         string    `json:"id"`
-	ChildID    string    `json:"child_id"`
-	SpiritID   string    `json:"spirit_id"`
-	SpiritName string    `json:"spirit_name"`
-	ObjectType string    `json:"object_type"`
-	Fact       string    `json:"fact"`
-	CapturedAt time.Time `json:"captured_at"`
+type CompanionMessage struct {
+	ID            string    `json:"id"`
+	ChildID       string    `json:"child_id"`
+	ObjectType    string    `json:"object_type"`
+	CharacterName string    `json:"character_name"`
+	ChildMessage  string    `json:"child_message"`
+	ReplyText     string    `json:"reply_text"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
-type PokedexEntry struct {
-	SpiritID   string    `json:"spirit_id"`
-	SpiritName string    `json:"spirit_name"`
-	ObjectType string    `json:"object_type"`
-	Captures   int       `json:"captures"`
-	LastSeenAt time.Time `json:"last_seen_at"`
+type ReviewItem struct {
+	ID             string    `json:"id"`
+	ChildID        string    `json:"child_id"`
+	CaptureID      string    `json:"capture_id"`
+	SpiritName     string    `json:"spirit_name"`
+	ObjectType     string    `json:"object_type"`
+	Fact           string    `json:"fact"`
+	Question       string    `json:"question"`
+	Answer         string    `json:"answer"`
+	EaseFactor     float64   `json:"ease_factor"`
+	IntervalDays   int       `json:"interval_days"`
+	Repetitions    int       `json:"repetitions"`
+	Lapses         int       `json:"lapses"`
+	DueAt          time.Time `json:"due_at"`
+	LastReviewedAt time.Time `json:"last_reviewed_at,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
-type PokedexBadge struct {
-	ID          string   `json:"id"`
-	CategoryID  string   `json:"category_id"`
-	Name        string   `json:"name"`
-	Code        string   `json:"code"`
-	Description string   `json:"description"`
-	RecordScope string   `json:"record_scope"`
-	Rule        string   `json:"rule"`
-	ImageURL    string   `json:"image_url"`
-	ImageFile   string   `json:"image_file"`
-	Unlocked    bool     `json:"unlocked"`
-	Progress    int      `json:"progress"`
-	Target      int      `json:"target"`
-	Examples    []string `json:"examples,omitempty"`
-	Collected   []string `json:"collected_examples,omitempty"`
+type ChildProgress struct {
+	ChildID             string    `json:"child_id"`
+	XP                  int       `json:"xp"`
+	Level               int       `json:"level"`
+	CurrentStreak       int       `json:"current_streak"`
+	LongestStreak       int       `json:"longest_streak"`
+	LastActiveDate      string    `json:"last_active_date,omitempty"`
+	CompanionChatDate   string    `json:"companion_chat_date,omitempty"`
+	CompanionChatAwards int       `json:"companion_chat_awards"`
+	UpdatedAt           time.Time `json:"updated_at"`
 }
 
-type DailyReport struct {
-	Date            string    `json:"date"`
-	ChildID         string    `json:"child_id"`
-	TotalCaptured   int       `json:"total_captured"`
-	Captures        []Capture `json:"captures"`
-	KnowledgePoints []string  `json:"knowledge_points"`
-	GeneratedText   string    `json:"generated_text"`
-	GeneratedAt     time.Time `json:"generated_at"`
-}
+// 以下类型会出现在 HTTP 响应中，定义在 pkg/api，供服务端与 SDK 共用。
+type (
+	Spirit                   = api.Spirit
+	Capture                  = api.Capture
+	GeoPoint                 = api.GeoPoint
+	GeoJSONGeometry          = api.GeoJSONGeometry
+	GeoJSONFeature           = api.GeoJSONFeature
+	GeoJSONFeatureCollection = api.GeoJSONFeatureCollection
+	PokedexEntry             = api.PokedexEntry
+	PokedexBadge             = api.PokedexBadge
+	DailyReport              = api.DailyReport
+	PeriodStats              = api.PeriodStats
+	PeriodTrend              = api.PeriodTrend
+	PeriodReport             = api.PeriodReport
+	ProgressEvent            = api.ProgressEvent
+	BadgeUnlock              = api.BadgeUnlock
+	DeliverySubscription     = api.DeliverySubscription
+	DeliveryAttempt          = api.DeliveryAttempt
+	Quest                    = api.Quest
+	Group                    = api.Group
+	GroupMember              = api.GroupMember
+	SpiritShare              = api.SpiritShare
+	SpiritCard               = api.SpiritCard
+	Trade                    = api.Trade
+	ChildProfile             = api.ChildProfile
+)
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval 为清理已补满令牌桶的最短间隔；补满的桶与新建的桶等价，删掉不影响结果。
+const sweepInterval = time.Minute
+
+// MemoryBackend 在进程内维护令牌桶，多实例部署时每个实例各自计数。
+type MemoryBackend struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (m *MemoryBackend) Take(_ context.Context, key string, limit Limit) (Decision, error) {
+	if !limit.Enabled() {
+		return Decision{}, ErrInvalidLimit
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweepLocked(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	b.tokens = refill(b.tokens, now.Sub(b.updated), limit)
+	b.updated = now
+	b.limit = limit
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return decide(b.tokens, allowed, limit), nil
+}
+
+func (m *MemoryBackend) Refund(_ context.Context, key string, limit Limit) error {
+	if !limit.Enabled() {
+		return ErrInvalidLimit
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.buckets[key]
+	if !ok {
+		// 桶已被当作补满清理掉，无需退回。
+		return nil
+	}
+	now := m.now()
+	b.tokens = math.Min(float64(limit.Burst), refill(b.tokens, now.Sub(b.updated), limit)+1)
+	b.updated = now
+	return nil
+}
+
+func (m *MemoryBackend) sweepLocked(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if refill(b.tokens, now.Sub(b.updated), b.limit) >= float64(b.limit.Burst) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package ratelimit 实现按 key 计数的令牌桶限流，后端可以是进程内内存，也可以是多实例共享的 Redis。
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit 描述一个令牌桶：容量为 Burst，每个 Period 匀速补回 Burst 个令牌。
+// 例如 20/m 允许一次连发 20 个请求，之后每 3 秒恢复一个。
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Enabled 报告该限额是否生效，零值表示不限流。
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Period > 0
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	switch l.Period {
+	case time.Second:
+		return fmt.Sprintf("%d/s", l.Burst)
+	case time.Minute:
+		return fmt.Sprintf("%d/m", l.Burst)
+	case time.Hour:
+		return fmt.Sprintf("%d/h", l.Burst)
+	}
+	return fmt.Sprintf("%d/%s", l.Burst, l.Period)
+}
+
+// ParseLimit 解析 "<次数>/<周期>"，周期可以是 s、m、h 或 Go 时长（如 10s）；"off" 与 "0" 表示不限流。
+func ParseLimit(raw string) (Limit, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "off") || raw == "0" {
+		return Limit{}, nil
+	}
+	count, period, ok := strings.Cut(raw, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q: want <count>/<period>", raw)
+	}
+	burst, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || burst < 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: invalid count", raw)
+	}
+	var d time.Duration
+	switch period = strings.TrimSpace(period); period {
+	case "s":
+		d = time.Second
+	case "m":
+		d = time.Minute
+	case "h":
+		d = time.Hour
+	default:
+		if d, err = time.ParseDuration(period); err != nil || d <= 0 {
+			return Limit{}, fmt.Errorf("rate limit %q: invalid period", raw)
+		}
+	}
+	return Limit{Burst: burst, Period: d}, nil
+}
+
+// ParseLimits 解析逗号分隔的 "<名称>=<限额>" 列表，如 "default=120/m,llm=20/m,POST /api/v1/scan/image=10/m"。
+func ParseLimits(raw string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, item := range strings.Split(raw, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("rate limit entry %q: want <name>=<count>/<period>", strings.TrimSpace(item))
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, err
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+// Decision 是一次取令牌的结果。
+type Decision struct {
+	Allowed bool
+	Limit   int
+	// Remaining 为本次之后桶里剩余的整令牌数。
+	Remaining int
+	// RetryAfter 只在被拒绝时有值，为下一个令牌补回前的等待时间。
+	RetryAfter time.Duration
+	// Reset 为桶补满所需的时间。
+	Reset time.Duration
+}
+
+// Backend 按 key 维护令牌桶。同一 key 应始终使用相同的 limit。
+type Backend interface {
+	Take(ctx context.Context, key string, limit Limit) (Decision, error)
+	// Refund 退回一个此前 Take 取走的令牌，用于同一请求的其他令牌桶拒绝时撤销扣减；桶内令牌不超过容量。
+	Refund(ctx context.Context, key string, limit Limit) error
+}
+
+// ErrInvalidLimit 表示传入了未生效的限额。
+var ErrInvalidLimit = errors.New("ratelimit: limit must have positive burst and period")
+
+// refill 返回经过 elapsed 后桶内的令牌数，不超过容量。
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	if elapsed <= 0 {
+		return tokens
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*ratePerSecond(limit))
+}
+
+// decide 根据取令牌之后的余量算出 Decision，内存与 Redis 后端共用，保证响应头一致。
+func decide(tokens float64, allowed bool, limit Limit) Decision {
+	rate := ratePerSecond(limit)
+	d := Decision{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / rate),
+	}
+	if !allowed {
+		d.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return d
+}
+
+func ratePerSecond(limit Limit) float64 {
+	return float64(limit.Burst) / limit.Period.Seconds()
+}
+
+func seconds(v float64) time.Duration {
+	if v <= 0 {
+		return 0
+	}
+	return time.Duration(v * float64(time.Second))
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("default=120/m, llm=20/m,POST /api/v1/scan/image=5/10s,GET /healthz=off")
+	if err != nil {
+		t.Fatalf("ParseLimits() error = %v", err)
+	}
+	want := map[string]Limit{
+		"default":                 {Burst: 120, Period: time.Minute},
+		"llm":                     {Burst: 20, Period: time.Minute},
+		"POST /api/v1/scan/image": {Burst: 5, Period: 10 * time.Second},
+		"GET /healthz":            {},
+	}
+	for name, limit := range want {
+		if got := limits[name]; got != limit {
+			t.Fatalf("%s: expected %v, got %v", name, limit, got)
+		}
+	}
+	for _, bad := range []string{"default", "llm=20", "llm=x/m", "llm=5/fortnight"} {
+		if _, err := ParseLimits(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestMemoryBackendRefillsAtConfiguredRate(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	backend := NewMemoryBackend()
+	backend.now = func() time.Time { return now }
+	limit := Limit{Burst: 2, Period: 10 * time.Second}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if d, _ := backend.Take(ctx, "ip:1", limit); !d.Allowed {
+			t.Fatalf("request %d should fit the burst, got %+v", i, d)
+		}
+	}
+	denied, _ := backend.Take(ctx, "ip:1", limit)
+	if denied.Allowed || denied.Remaining != 0 || denied.RetryAfter != 5*time.Second || denied.Reset != 10*time.Second {
+		t.Fatalf("expected denial with 5s retry and 10s reset, got %+v", denied)
+	}
+	if d, _ := backend.Take(ctx, "ip:2", limit); !d.Allowed {
+		t.Fatalf("other keys must have their own bucket, got %+v", d)
+	}
+	if err := backend.Refund(ctx, "ip:2", limit); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if err := backend.Refund(ctx, "ip:2", limit); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if d, _ := backend.Take(ctx, "ip:2", limit); !d.Allowed || d.Remaining != 1 {
+		t.Fatalf("expected refunds to restore the bucket without exceeding the burst, got %+v", d)
+	}
+
+	now = now.Add(5 * time.Second)
+	if d, _ := backend.Take(ctx, "ip:1", limit); !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("expected one token after 5s, got %+v", d)
+	}
+
+	now = now.Add(time.Hour)
+	backend.Take(ctx, "ip:3", limit)
+	if len(backend.buckets) != 1 {
+		t.Fatalf("expected refilled buckets to be swept, got %d", len(backend.buckets))
+	}
+}
+
+func TestRedisBackendSharesBuckets(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	server.SetTime(time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC))
+
+	// 两个后端实例模拟两台服务器共用同一个 Redis。
+	first := NewRedisBackend(client, "cityling:rl:")
+	second := NewRedisBackend(client, "cityling:rl:")
+	limit := Limit{Burst: 2, Period: 10 * time.Second}
+	ctx := context.Background()
+
+	if d, err := first.Take(ctx, "child:kid_1", limit); err != nil || !d.Allowed || d.Remaining != 1 {
+		t.Fatalf("first take: %+v err=%v", d, err)
+	}
+	if d, err := second.Take(ctx, "child:kid_1", limit); err != nil || !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("second take: %+v err=%v", d, err)
+	}
+	denied, err := first.Take(ctx, "child:kid_1", limit)
+	if err != nil || denied.Allowed || denied.RetryAfter != 5*time.Second {
+		t.Fatalf("expected shared denial with 5s retry, got %+v err=%v", denied, err)
+	}
+	if ttl := server.TTL("cityling:rl:child:kid_1"); ttl <= 0 {
+		t.Fatalf("expected bucket key to expire, got ttl %v", ttl)
+	}
+	if err := second.Refund(ctx, "child:kid_1", limit); err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if d, err := first.Take(ctx, "child:kid_1", limit); err != nil || !d.Allowed || d.Remaining != 0 {
+		t.Fatalf("expected the refunded token to be shared, got %+v err=%v", d, err)
+	}
+
+	server.SetTime(time.Date(2026, 3, 2, 9, 0, 5, 0, time.UTC))
+	if d, err := second.Take(ctx, "child:kid_1", limit); err != nil || !d.Allowed {
+		t.Fatalf("expected a token after 5s, got %+v err=%v", d, err)
+	}
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript 在 Redis 内原子地补充并取走一个令牌。时间取 Redis 服务器的 TIME，避免各实例时钟不一致；
+// 键在桶补满后过期。返回 {是否放行, 剩余令牌}，令牌数以字符串返回以保留小数。
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local period_ms = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+local rate = burst / period_ms
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// refundScript 原子地补充并退回一个令牌，不超过容量；键不存在说明桶已补满过期，不做处理。
+var refundScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local period_ms = tonumber(ARGV[2])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	return 0
+end
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local rate = burst / period_ms
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate)
+end
+tokens = math.min(burst, tokens + 1)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return 1
+`)
+
+// RedisBackend 把令牌桶存在 Redis 里，多个实例共享同一份计数。
+type RedisBackend struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisBackend 使用 client 存储令牌桶，键名为 prefix 加上限流 key。
+func NewRedisBackend(client redis.Scripter, prefix string) *RedisBackend {
+	return &RedisBackend{client: client, prefix: prefix}
+}
+
+func (r *RedisBackend) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	if !limit.Enabled() {
+		return Decision{}, ErrInvalidLimit
+	}
+	raw, err := takeScript.Run(ctx, r.client, []string{r.prefix + key}, limit.Burst, limit.Period.Milliseconds()).Slice()
+	if err != nil {
+		return Decision{}, err
+	}
+	if len(raw) != 2 {
+		return Decision{}, fmt.Errorf("ratelimit: unexpected script reply %v", raw)
+	}
+	allowed, _ := raw[0].(int64)
+	text, _ := raw[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Decision{}, fmt.Errorf("ratelimit: unexpected token count %q: %w", text, err)
+	}
+	return decide(tokens, allowed == 1, limit), nil
+}
+
+func (r *RedisBackend) Refund(ctx context.Context, key string, limit Limit) error {
+	if !limit.Enabled() {
+		return ErrInvalidLimit
+	}
+	return refundScript.Run(ctx, r.client, []string{r.prefix + key}, limit.Burst, limit.Period.Milliseconds()).Err()
+}
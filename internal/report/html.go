@@ -0,0 +1,37 @@
+package report
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+
+	"ling/internal/model"
+)
+
+var (
+	//go:embed templates/daily_report.html
+	dailyReportHTML string
+
+	dailyReportTemplate = template.Must(template.New("daily_report").Parse(dailyReportHTML))
+
+	//go:embed templates/spirit_card.html
+	spiritCardHTML string
+
+	spiritCardTemplate = template.Must(template.New("spirit_card").Parse(spiritCardHTML))
+)
+
+// DailyHTML 输出单文件 HTML 日报，样式内联，方便在微信等环境直接打开。
+func (r *Renderer) DailyHTML(w io.Writer, report model.DailyReport) error {
+	return dailyReportTemplate.Execute(w, struct {
+		Report  model.DailyReport
+		Palette Palette
+	}{Report: report, Palette: r.palette})
+}
+
+// SpiritCardHTML 输出只读的精灵分享卡片，样式与日报一致。
+func (r *Renderer) SpiritCardHTML(w io.Writer, card model.SpiritCard) error {
+	return spiritCardTemplate.Execute(w, struct {
+		Card    model.SpiritCard
+		Palette Palette
+	}{Card: card, Palette: r.palette})
+}
@@ -0,0 +1,117 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const DefaultPalettePath = "design/brand/brand_palette.json"
+
+type BrandColors struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+	Accent    string `json:"accent"`
+	Highlight string `json:"highlight"`
+	Ink       string `json:"ink"`
+}
+
+type SemanticColors struct {
+	Success string `json:"success"`
+	Warning string `json:"warning"`
+	Error   string `json:"error"`
+	Info    string `json:"info"`
+}
+
+type SurfaceColors struct {
+	Background string `json:"background"`
+	Card       string `json:"card"`
+	Muted      string `json:"muted"`
+}
+
+// Palette 对应 design/brand/brand_palette.json 中的品牌色板。
+type Palette struct {
+	Brand    BrandColors    `json:"brand"`
+	Semantic SemanticColors `json:"semantic"`
+	Surface  SurfaceColors  `json:"surface"`
+}
+
+func DefaultPalette() Palette {
+	return Palette{
+		Brand: BrandColors{
+			Primary:   "#F08CB7",
+			Secondary: "#A7D8FF",
+			Accent:    "#9EE6D2",
+			Highlight: "#FFE7A7",
+			Ink:       "#5F4B74",
+		},
+		Semantic: SemanticColors{
+			Success: "#7CCFAE",
+			Warning: "#F6C87A",
+			Error:   "#E27B96",
+			Info:    "#9DBAF6",
+		},
+		Surface: SurfaceColors{
+			Background: "#FFF8FE",
+			Card:       "#FFFFFD",
+			Muted:      "#F7F0FA",
+		},
+	}
+}
+
+// LoadPalette 读取品牌色板文件，文件中缺失的颜色沿用默认值。
+func LoadPalette(path string) (Palette, error) {
+	palette := DefaultPalette()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return palette, err
+	}
+	if err := json.Unmarshal(raw, &palette); err != nil {
+		return DefaultPalette(), fmt.Errorf("parse brand palette failed: %w", err)
+	}
+	for _, color := range palette.colors() {
+		if _, err := parseHexColor(color); err != nil {
+			return DefaultPalette(), err
+		}
+	}
+	return palette, nil
+}
+
+func (p Palette) colors() []string {
+	return []string{
+		p.Brand.Primary, p.Brand.Secondary, p.Brand.Accent, p.Brand.Highlight, p.Brand.Ink,
+		p.Semantic.Success, p.Semantic.Warning, p.Semantic.Error, p.Semantic.Info,
+		p.Surface.Background, p.Surface.Card, p.Surface.Muted,
+	}
+}
+
+type rgb struct {
+	R, G, B float64
+}
+
+func parseHexColor(value string) (rgb, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) != 6 {
+		return rgb{}, fmt.Errorf("invalid brand colour %q", value)
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgb{}, fmt.Errorf("invalid brand colour %q", value)
+	}
+	return rgb{
+		R: float64(n>>16&0xFF) / 255,
+		G: float64(n>>8&0xFF) / 255,
+		B: float64(n&0xFF) / 255,
+	}, nil
+}
+
+// mustColor 用于已经校验过的色板；解析失败时退回墨色。
+func mustColor(value string) rgb {
+	c, err := parseHexColor(value)
+	if err != nil {
+		c, _ = parseHexColor(DefaultPalette().Brand.Ink)
+	}
+	return c
+}
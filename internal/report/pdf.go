@@ -0,0 +1,308 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"ling/internal/model"
+)
+
+// A4 纸张尺寸（单位：pt）与版心边距。
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 40.0
+
+	pdfBadgeSize = 64.0
+)
+
+// pdfDocument 是一个极简的 PDF 1.4 写入器：对象按编号顺序存放，最后统一输出交叉引用表。
+// 中文使用阅读器内置的 STSong-Light（Adobe-GB1）字体，无需嵌入字体文件。
+type pdfDocument struct {
+	objects [][]byte
+}
+
+func (d *pdfDocument) reserve() int {
+	d.objects = append(d.objects, nil)
+	return len(d.objects)
+}
+
+func (d *pdfDocument) set(id int, body []byte) {
+	d.objects[id-1] = body
+}
+
+func (d *pdfDocument) add(body []byte) int {
+	id := d.reserve()
+	d.set(id, body)
+	return id
+}
+
+func (d *pdfDocument) addStream(dict string, data []byte) int {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<< %s /Length %d >>\nstream\n", dict, len(data))
+	buf.Write(data)
+	buf.WriteString("\nendstream")
+	return d.add(buf.Bytes())
+}
+
+func (d *pdfDocument) writeTo(w io.Writer, rootID int) error {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(d.objects))
+	for i, body := range d.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		buf.Write(body)
+		buf.WriteString("\nendobj\n")
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, rootID, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfLayout 自上而下排版，空间不足时自动换页。
+type pdfLayout struct {
+	palette Palette
+	pages   []*bytes.Buffer
+	page    *bytes.Buffer
+	y       float64
+	images  []jpegImage
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = &bytes.Buffer{}
+	l.pages = append(l.pages, l.page)
+	l.fillRect(0, 0, pdfPageWidth, pdfPageHeight, l.palette.Surface.Background)
+	l.y = pdfPageHeight - pdfMargin
+}
+
+func (l *pdfLayout) ensure(height float64) {
+	if l.y-height < pdfMargin {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) fillRect(x, y, w, h float64, color string) {
+	c := mustColor(color)
+	fmt.Fprintf(l.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", c.R, c.G, c.B, x, y, w, h)
+}
+
+func (l *pdfLayout) text(x, y, size float64, color string, s string) {
+	c := mustColor(color)
+	fmt.Fprintf(l.page, "BT %.3f %.3f %.3f rg /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", c.R, c.G, c.B, size, x, y, encodeUCS2(s))
+}
+
+func (l *pdfLayout) image(img jpegImage, x, y, size float64) {
+	l.images = append(l.images, img)
+	fmt.Fprintf(l.page, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", size, size, x, y, len(l.images))
+}
+
+// paragraph 按版心宽度折行输出文本。
+func (l *pdfLayout) paragraph(x, size float64, color string, s string) {
+	lineHeight := size * 1.5
+	for _, line := range wrapText(s, size, pdfPageWidth-pdfMargin-x) {
+		l.ensure(lineHeight)
+		l.y -= lineHeight
+		l.text(x, l.y+size*0.3, size, color, line)
+	}
+}
+
+func (l *pdfLayout) heading(title string) {
+	l.ensure(40)
+	l.y -= 28
+	l.fillRect(pdfMargin, l.y-2, 5, 18, l.palette.Brand.Accent)
+	l.text(pdfMargin+12, l.y, 15, l.palette.Brand.Ink, title)
+	l.y -= 6
+}
+
+func (l *pdfLayout) bullets(items []string) {
+	for _, item := range items {
+		l.ensure(20)
+		l.fillRect(pdfMargin+4, l.y-11, 4, 4, l.palette.Brand.Primary)
+		l.paragraph(pdfMargin+16, 11, l.palette.Brand.Ink, item)
+	}
+}
+
+// DailyPDF 输出 A4 版式的 PDF 日报。勋章图片下载失败时以品牌色块占位，不影响整体生成。
+func (r *Renderer) DailyPDF(ctx context.Context, w io.Writer, report model.DailyReport) error {
+	p := r.palette
+	l := &pdfLayout{palette: p}
+	l.newPage()
+
+	// 页眉色带
+	l.fillRect(0, pdfPageHeight-96, pdfPageWidth, 96, p.Brand.Primary)
+	l.text(pdfMargin, pdfPageHeight-52, 24, p.Surface.Card, "城市灵 · 探索日报")
+	l.text(pdfMargin, pdfPageHeight-76, 12, p.Surface.Card, report.Date+" · "+report.ChildID)
+	l.y = pdfPageHeight - 96 - 16
+
+	// 统计卡片
+	stats := []struct {
+		label string
+		value int
+	}{
+		{"收集精灵", report.TotalCaptured},
+		{"知识点", len(report.KnowledgePoints)},
+		{"新勋章", len(report.NewBadges)},
+	}
+	gap := 12.0
+	cardWidth := (pdfPageWidth - 2*pdfMargin - gap*float64(len(stats)-1)) / float64(len(stats))
+	l.y -= 64
+	for i, stat := range stats {
+		x := pdfMargin + float64(i)*(cardWidth+gap)
+		l.fillRect(x, l.y, cardWidth, 64, p.Brand.Secondary)
+		l.fillRect(x+2, l.y+2, cardWidth-4, 60, p.Surface.Card)
+		value := fmt.Sprintf("%d", stat.value)
+		l.text(x+(cardWidth-textWidth(value, 24))/2, l.y+30, 24, p.Brand.Primary, value)
+		l.text(x+(cardWidth-textWidth(stat.label, 11))/2, l.y+12, 11, p.Brand.Ink, stat.label)
+	}
+
+	l.heading("今日小结")
+	l.paragraph(pdfMargin, 12, p.Brand.Ink, report.GeneratedText)
+
+	if len(report.Captures) > 0 {
+		l.heading("今日精灵")
+		for _, capture := range report.Captures {
+			l.ensure(44)
+			l.y -= 4
+			l.paragraph(pdfMargin+8, 12, p.Brand.Primary, fmt.Sprintf("%s（%s）", capture.SpiritName, capture.ObjectType))
+			l.paragraph(pdfMargin+8, 11, p.Brand.Ink, capture.Fact)
+		}
+	}
+
+	if len(report.KnowledgePoints) > 0 {
+		l.heading("知识点")
+		l.bullets(report.KnowledgePoints)
+	}
+
+	if len(report.NewBadges) > 0 {
+		l.heading("新点亮勋章")
+		cell := pdfBadgeSize + 32
+		perRow := int((pdfPageWidth - 2*pdfMargin) / cell)
+		for i, badge := range report.NewBadges {
+			col := i % perRow
+			if col == 0 {
+				l.ensure(pdfBadgeSize + 28)
+				l.y -= pdfBadgeSize + 28
+			}
+			x := pdfMargin + float64(col)*cell
+			if img, err := r.loadImage(ctx, badge.ImageURL); err == nil {
+				l.image(img, x+16, l.y+20, pdfBadgeSize)
+			} else {
+				l.fillRect(x+16, l.y+20, pdfBadgeSize, pdfBadgeSize, p.Brand.Highlight)
+			}
+			name := truncateText(badge.BadgeName, 10, cell)
+			l.text(x+(cell-textWidth(name, 10))/2, l.y+4, 10, p.Brand.Ink, name)
+		}
+	}
+
+	if len(report.Suggestions) > 0 {
+		l.heading("亲子延伸建议")
+		l.bullets(report.Suggestions)
+	}
+
+	l.text(pdfMargin, pdfMargin-16, 9, p.Brand.Ink, "城市灵生成于 "+report.GeneratedAt.Format("2006-01-02 15:04"))
+
+	return l.write(w)
+}
+
+func (l *pdfLayout) write(w io.Writer) error {
+	doc := &pdfDocument{}
+	catalogID := doc.reserve()
+	pagesID := doc.reserve()
+	descriptorID := doc.add([]byte("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>"))
+	cidFontID := doc.add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light /CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor %d 0 R /DW 1000 /W [1 95 500] >>", descriptorID)))
+	fontID := doc.add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [%d 0 R] >>", cidFontID)))
+
+	var xobjects strings.Builder
+	for i, img := range l.images {
+		id := doc.addStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode", img.width, img.height, img.colorSpace), img.data)
+		fmt.Fprintf(&xobjects, " /Im%d %d 0 R", i+1, id)
+	}
+	resources := fmt.Sprintf("<< /Font << /F1 %d 0 R >> /XObject <<%s >> >>", fontID, xobjects.String())
+
+	kids := make([]string, 0, len(l.pages))
+	for _, page := range l.pages {
+		contentID := doc.addStream("", page.Bytes())
+		pageID := doc.add([]byte(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>", pagesID, pdfPageWidth, pdfPageHeight, resources, contentID)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+	doc.set(pagesID, []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))))
+	doc.set(catalogID, []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID)))
+	return doc.writeTo(w, catalogID)
+}
+
+// encodeUCS2 把文本编码为 UniGB-UCS2-H 需要的大端 UCS-2 十六进制串；超出基本平面的字符（如表情）无法显示，直接略过。
+func encodeUCS2(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r > 0xFFFF || r == utf8.RuneError {
+			continue
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+// runeWidth 估算字符宽度（单位：字号）：ASCII 半角，其余按全角计。
+func runeWidth(r rune) float64 {
+	if r < 0x80 {
+		return 0.5
+	}
+	return 1
+}
+
+func textWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		width += runeWidth(r) * size
+	}
+	return width
+}
+
+func wrapText(s string, size float64, maxWidth float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(s), "\n") {
+		var line strings.Builder
+		width := 0.0
+		for _, r := range strings.TrimSpace(paragraph) {
+			w := runeWidth(r) * size
+			if width+w > maxWidth && line.Len() > 0 {
+				lines = append(lines, line.String())
+				line.Reset()
+				width = 0
+			}
+			line.WriteRune(r)
+			width += w
+		}
+		if line.Len() > 0 {
+			lines = append(lines, line.String())
+		}
+	}
+	return lines
+}
+
+func truncateText(s string, size float64, maxWidth float64) string {
+	if textWidth(s, size) <= maxWidth {
+		return s
+	}
+	var b strings.Builder
+	width := textWidth("…", size)
+	for _, r := range s {
+		w := runeWidth(r) * size
+		if width+w > maxWidth {
+			break
+		}
+		b.WriteRune(r)
+		width += w
+	}
+	return b.String() + "…"
+}
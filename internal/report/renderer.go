@@ -0,0 +1,124 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	imageFetchTimeout = 5 * time.Second
+	maxImageBytes     = 4 << 20
+)
+
+// Renderer 把日报渲染为可分享的 HTML 或 PDF，全部使用纯 Go 实现，不依赖浏览器。
+type Renderer struct {
+	palette    Palette
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	images map[string]jpegImage
+}
+
+type jpegImage struct {
+	data       []byte
+	width      int
+	height     int
+	colorSpace string
+}
+
+func NewRenderer(palette Palette) *Renderer {
+	return &Renderer{
+		palette:    palette,
+		httpClient: &http.Client{Timeout: imageFetchTimeout},
+		images:     make(map[string]jpegImage),
+	}
+}
+
+func (r *Renderer) Palette() Palette {
+	return r.palette
+}
+
+// loadImage 下载勋章图片并转换成 PDF 可直接嵌入的 JPEG；成功结果按 URL 缓存。
+func (r *Renderer) loadImage(ctx context.Context, url string) (jpegImage, error) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return jpegImage{}, fmt.Errorf("empty image url")
+	}
+	r.mu.Lock()
+	cached, ok := r.images[url]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return jpegImage{}, err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return jpegImage{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return jpegImage{}, fmt.Errorf("fetch image %s: status %d", url, resp.StatusCode)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return jpegImage{}, err
+	}
+	if len(raw) > maxImageBytes {
+		return jpegImage{}, fmt.Errorf("image %s exceeds %d bytes", url, maxImageBytes)
+	}
+	img, err := toJPEG(raw)
+	if err != nil {
+		return jpegImage{}, err
+	}
+
+	r.mu.Lock()
+	r.images[url] = img
+	r.mu.Unlock()
+	return img, nil
+}
+
+// toJPEG 直接透传 RGB/灰度 JPEG，其余格式（PNG、GIF、CMYK JPEG）解码后重新编码。
+func toJPEG(raw []byte) (jpegImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return jpegImage{}, err
+	}
+	if format == "jpeg" {
+		switch cfg.ColorModel {
+		case color.YCbCrModel:
+			return jpegImage{data: raw, width: cfg.Width, height: cfg.Height, colorSpace: "/DeviceRGB"}, nil
+		case color.GrayModel:
+			return jpegImage{data: raw, width: cfg.Width, height: cfg.Height, colorSpace: "/DeviceGray"}, nil
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return jpegImage{}, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return jpegImage{}, err
+	}
+	bounds := img.Bounds()
+	colorSpace := "/DeviceRGB"
+	if _, gray := img.(*image.Gray); gray {
+		colorSpace = "/DeviceGray"
+	}
+	return jpegImage{data: buf.Bytes(), width: bounds.Dx(), height: bounds.Dy(), colorSpace: colorSpace}, nil
+}
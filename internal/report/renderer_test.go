@@ -0,0 +1,170 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+)
+
+func sampleDailyReport(imageURLs ...string) model.DailyReport {
+	report := model.DailyReport{
+		Date:            "2026-02-13",
+		ChildID:         "kid_1",
+		TotalCaptured:   1,
+		Captures:        []model.Capture{{ID: "c1", SpiritName: "树爷爷", ObjectType: "tree", Fact: "树会通过叶子呼吸"}},
+		KnowledgePoints: []string{"树会通过叶子呼吸"},
+		GeneratedText:   "今天孩子认识了<树爷爷>。",
+		Suggestions:     []string{"一起摸摸树皮"},
+		GeneratedAt:     time.Date(2026, 2, 13, 20, 0, 0, 0, time.UTC),
+	}
+	for i, url := range imageURLs {
+		report.NewBadges = append(report.NewBadges, model.BadgeUnlock{ID: "u" + string(rune('1'+i)), BadgeName: "植物界", ImageURL: url})
+	}
+	return report
+}
+
+func TestDailyHTMLUsesPaletteAndEscapesText(t *testing.T) {
+	palette := DefaultPalette()
+	palette.Brand.Primary = "#123456"
+	var buf bytes.Buffer
+	if err := NewRenderer(palette).DailyHTML(&buf, sampleDailyReport("https://example.com/badge.jpg")); err != nil {
+		t.Fatalf("DailyHTML() error = %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{"#123456", "树爷爷", "&lt;树爷爷&gt;", `src="https://example.com/badge.jpg"`, "一起摸摸树皮"} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("expected HTML to contain %q, got:\n%s", want, html)
+		}
+	}
+}
+
+func TestDailyPDFEmbedsBadgeImages(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 0xCC
+	}
+	var jpegBytes, pngBytes bytes.Buffer
+	if err := jpeg.Encode(&jpegBytes, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	if err := png.Encode(&pngBytes, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.jpg":
+			_, _ = w.Write(jpegBytes.Bytes())
+		case "/b.png":
+			_, _ = w.Write(pngBytes.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	report := sampleDailyReport(server.URL+"/a.jpg", server.URL+"/b.png", server.URL+"/missing.jpg")
+	if err := NewRenderer(DefaultPalette()).DailyPDF(context.Background(), &buf, report); err != nil {
+		t.Fatalf("DailyPDF() error = %v", err)
+	}
+	pdf := buf.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("unexpected PDF envelope")
+	}
+	if got := strings.Count(pdf, "/Filter /DCTDecode"); got != 2 {
+		t.Fatalf("expected 2 embedded images, got %d", got)
+	}
+	if !strings.Contains(pdf, "/Encoding /UniGB-UCS2-H") || !strings.Contains(pdf, "<"+encodeUCS2("树爷爷（tree）")+">") {
+		t.Fatalf("expected CJK text encoded for STSong-Light")
+	}
+	xref := strings.LastIndex(pdf, "\nxref\n") + 1
+	if !strings.Contains(pdf, "startxref\n"+strconv.Itoa(xref)+"\n") {
+		t.Fatalf("startxref does not point at the xref table")
+	}
+}
+
+func TestDailyPDFBreaksLongReportsIntoPages(t *testing.T) {
+	report := sampleDailyReport()
+	for i := 0; i < 80; i++ {
+		report.KnowledgePoints = append(report.KnowledgePoints, strings.Repeat("知识", 30))
+	}
+	var buf bytes.Buffer
+	if err := NewRenderer(DefaultPalette()).DailyPDF(context.Background(), &buf, report); err != nil {
+		t.Fatalf("DailyPDF() error = %v", err)
+	}
+	if got := strings.Count(buf.String(), "/Type /Page "); got < 2 {
+		t.Fatalf("expected multiple pages, got %d", got)
+	}
+}
+
+func TestToJPEGKeepsGrayscale(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 4, 4))
+	gray.Set(1, 1, color.Gray{Y: 200})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gray); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	img, err := toJPEG(buf.Bytes())
+	if err != nil {
+		t.Fatalf("toJPEG() error = %v", err)
+	}
+	if img.colorSpace != "/DeviceGray" || img.width != 4 {
+		t.Fatalf("unexpected image %+v", img)
+	}
+}
+
+func TestLoadPalette(t *testing.T) {
+	palette, err := LoadPalette(filepath.Join("..", "..", DefaultPalettePath))
+	if err != nil {
+		t.Fatalf("LoadPalette() error = %v", err)
+	}
+	if palette.Brand.Primary != "#F08CB7" || palette.Surface.Background != "#FFF8FE" {
+		t.Fatalf("unexpected palette %+v", palette)
+	}
+
+	path := filepath.Join(t.TempDir(), "palette.json")
+	if err := os.WriteFile(path, []byte(`{"brand":{"primary":"pink"}}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := LoadPalette(path); err == nil {
+		t.Fatalf("expected invalid colour error")
+	}
+}
+
+func TestSpiritCardHTMLEscapesText(t *testing.T) {
+	var buf bytes.Buffer
+	card := model.SpiritCard{
+		Token:             "tok",
+		SpiritName:        "<木木>",
+		ObjectType:        "tree",
+		Personality:       "安静",
+		CharacterImageURL: "https://example.com/mumu.png",
+		Fact:              "树会呼吸",
+		CapturedAt:        time.Date(2026, 2, 13, 9, 0, 0, 0, time.UTC),
+	}
+	if err := NewRenderer(DefaultPalette()).SpiritCardHTML(&buf, card); err != nil {
+		t.Fatalf("SpiritCardHTML() error = %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{"&lt;木木&gt;", `src="https://example.com/mumu.png"`, "树会呼吸", "安静", "2026-02-13"} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("expected HTML to contain %q, got:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "自我介绍") {
+		t.Fatal("expected empty intro section to be omitted")
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import "ling/pkg/api"
+
+// 请求与响应类型定义在 pkg/api，供服务端与 SDK 共用。
+type (
+	BadgeCatalogStatus          = api.BadgeCatalogStatus
+	BadgeCriterion              = api.BadgeCriterion
+	BadgeSeenRequest            = api.BadgeSeenRequest
+	BadgeSeenResponse           = api.BadgeSeenResponse
+	BadgeRule                   = api.BadgeRule
+	MapQuery                    = api.MapQuery
+	DeliverySubscriptionRequest = api.DeliverySubscriptionRequest
+	GroupRequest                = api.GroupRequest
+	GroupJoinRequest            = api.GroupJoinRequest
+	GroupViewer                 = api.GroupViewer
+	GroupDetail                 = api.GroupDetail
+	LeaderboardEntry            = api.LeaderboardEntry
+	GroupLeaderboard            = api.GroupLeaderboard
+	GroupFeedItem               = api.GroupFeedItem
+	GroupFeed                   = api.GroupFeed
+	NearbyHintBadge             = api.NearbyHintBadge
+	NearbyHint                  = api.NearbyHint
+	NearbyHints                 = api.NearbyHints
+	ProfileRequest              = api.ProfileRequest
+	ProgressUpdate              = api.ProgressUpdate
+	ProgressProfile             = api.ProgressProfile
+	QuestBoard                  = api.QuestBoard
+	QuestClaimRequest           = api.QuestClaimRequest
+	QuestClaimResponse          = api.QuestClaimResponse
+	ReviewDueItem               = api.ReviewDueItem
+	ReviewAnswerRequest         = api.ReviewAnswerRequest
+	ReviewAnswerResponse        = api.ReviewAnswerResponse
+	ScanRequest                 = api.ScanRequest
+	ScanResponse                = api.ScanResponse
+	ScanImageRequest            = api.ScanImageRequest
+	ScanImageResponse           = api.ScanImageResponse
+	AnswerRequest               = api.AnswerRequest
+	AnswerResponse              = api.AnswerResponse
+	CompanionSceneRequest       = api.CompanionSceneRequest
+	CompanionSceneResponse      = api.CompanionSceneResponse
+	CompanionChatRequest        = api.CompanionChatRequest
+	CompanionChatResponse       = api.CompanionChatResponse
+	CompanionVoiceRequest       = api.CompanionVoiceRequest
+	CompanionVoiceResponse      = api.CompanionVoiceResponse
+	UploadImageResponse         = api.UploadImageResponse
+	SpiritEvolution             = api.SpiritEvolution
+	SpiritShareRequest          = api.SpiritShareRequest
+	TradeProposal               = api.TradeProposal
+	TradeActionRequest          = api.TradeActionRequest
+	TradeDetail                 = api.TradeDetail
+)
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ling/internal/tracing"
+)
+
+const badgeCatalogEmbeddedSource = "embedded"
+
+func (s *Service) badgeCatalog() ([]BadgeRule, map[string]string) {
+	s.catalogMu.RLock()
+	defer s.catalogMu.RUnlock()
+	return s.badgeRules, s.badgeImageURL
+}
+
+// SetBadgeRulesFile 指定可编辑的勋章规则文件。文件存在时立即加载；不存在时继续使用内置规则，
+// 并在第一次通过管理接口修改时以内置规则为基础创建该文件。
+func (s *Service) SetBadgeRulesFile(ctx context.Context, path string) (BadgeCatalogStatus, error) {
+	s.catalogMu.Lock()
+	s.badgeRulesFile = strings.TrimSpace(path)
+	s.catalogMu.Unlock()
+	return s.ReloadBadgeCatalog(ctx)
+}
+
+// ReloadBadgeCatalog 重新读取勋章规则与图片清单；校验失败时保留当前规则不变。
+func (s *Service) ReloadBadgeCatalog(ctx context.Context) (_ BadgeCatalogStatus, err error) {
+	ctx, span := startSpan(ctx, "ReloadBadgeCatalog")
+	defer func() { tracing.End(span, err) }()
+	s.catalogMu.Lock()
+	defer s.catalogMu.Unlock()
+
+	raw, source, err := s.readBadgeRulesLocked()
+	if err != nil {
+		return BadgeCatalogStatus{}, err
+	}
+	rules, err := parseBadgeRules(raw)
+	if err != nil {
+		return BadgeCatalogStatus{}, err
+	}
+	images := loadBadgeImageURLMap()
+	s.badgeRules = rules
+	s.badgeImageURL = images
+	return BadgeCatalogStatus{
+		Source:     source,
+		Badges:     len(rules),
+		Images:     len(images),
+		ReloadedAt: time.Now(),
+	}, nil
+}
+
+func (s *Service) BadgeDefinitions() []BadgeRule {
+	rules, _ := s.badgeCatalog()
+	return append([]BadgeRule(nil), rules...)
+}
+
+func (s *Service) BadgeDefinition(ctx context.Context, id string) (_ BadgeRule, err error) {
+	ctx, span := startSpan(ctx, "BadgeDefinition")
+	defer func() { tracing.End(span, err) }()
+	id = strings.TrimSpace(id)
+	rules, _ := s.badgeCatalog()
+	for _, rule := range rules {
+		if rule.ID == id {
+			return rule, nil
+		}
+	}
+	return BadgeRule{}, ErrBadgeNotFound
+}
+
+func (s *Service) CreateBadge(ctx context.Context, rule BadgeRule) (_ BadgeRule, err error) {
+	ctx, span := startSpan(ctx, "CreateBadge")
+	defer func() { tracing.End(span, err) }()
+	rule.ID = strings.TrimSpace(rule.ID)
+	if rule.ID == "" {
+		return BadgeRule{}, fmt.Errorf("%w: 请提供 id", ErrBadgeInvalid)
+	}
+	return s.mutateBadgeCatalog(rule.ID, func(defs []BadgeRule) ([]BadgeRule, error) {
+		if badgeIndex(defs, rule.ID) >= 0 {
+			return nil, ErrBadgeExists
+		}
+		return append(defs, rule), nil
+	})
+}
+
+func (s *Service) UpdateBadge(ctx context.Context, id string, rule BadgeRule) (_ BadgeRule, err error) {
+	ctx, span := startSpan(ctx, "UpdateBadge")
+	defer func() { tracing.End(span, err) }()
+	id = strings.TrimSpace(id)
+	rule.ID = id
+	return s.mutateBadgeCatalog(id, func(defs []BadgeRule) ([]BadgeRule, error) {
+		idx := badgeIndex(defs, id)
+		if idx < 0 {
+			return nil, ErrBadgeNotFound
+		}
+		defs[idx] = rule
+		return defs, nil
+	})
+}
+
+func (s *Service) DeleteBadge(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteBadge")
+	defer func() { tracing.End(span, err) }()
+	id = strings.TrimSpace(id)
+	_, err = s.mutateBadgeCatalog("", func(defs []BadgeRule) ([]BadgeRule, error) {
+		idx := badgeIndex(defs, id)
+		if idx < 0 {
+			return nil, ErrBadgeNotFound
+		}
+		return append(defs[:idx], defs[idx+1:]...), nil
+	})
+	return err
+}
+
+// mutateBadgeCatalog 在规则文件的原始定义上应用修改，整体校验通过后写回文件并替换内存中的规则。
+// changedID 非空时额外要求该勋章的图片可以解析。
+func (s *Service) mutateBadgeCatalog(changedID string, mutate func([]BadgeRule) ([]BadgeRule, error)) (BadgeRule, error) {
+	s.catalogMu.Lock()
+	defer s.catalogMu.Unlock()
+
+	if s.badgeRulesFile == "" {
+		return BadgeRule{}, ErrBadgeReadOnly
+	}
+	raw, _, err := s.readBadgeRulesLocked()
+	if err != nil {
+		return BadgeRule{}, err
+	}
+	var catalog badgeRuleCatalog
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		return BadgeRule{}, fmt.Errorf("%w: %v", ErrBadgeInvalid, err)
+	}
+	defs, err := mutate(catalog.Badges)
+	if err != nil {
+		return BadgeRule{}, err
+	}
+	for i := range defs {
+		// 规则文案与目标值由条件生成，不写入文件。
+		defs[i].Rule = ""
+		defs[i].Target = 0
+	}
+	rules, err := normalizeBadgeRules(defs)
+	if err != nil {
+		return BadgeRule{}, err
+	}
+
+	images := loadBadgeImageURLMap()
+	var changed BadgeRule
+	if changedID != "" {
+		idx := badgeIndex(rules, changedID)
+		if idx < 0 {
+			return BadgeRule{}, ErrBadgeNotFound
+		}
+		changed = rules[idx]
+		if resolveBadgeImage(changed, images) == "" {
+			return BadgeRule{}, fmt.Errorf("%w: 勋章 %s 缺少可用的图片，请提供 image_url 或在图片清单中登记", ErrBadgeInvalid, changedID)
+		}
+	}
+
+	catalog.Badges = defs
+	if err := writeBadgeRulesFile(s.badgeRulesFile, catalog); err != nil {
+		return BadgeRule{}, err
+	}
+	s.badgeRules = rules
+	s.badgeImageURL = images
+	return changed, nil
+}
+
+func (s *Service) readBadgeRulesLocked() ([]byte, string, error) {
+	if s.badgeRulesFile != "" {
+		raw, err := os.ReadFile(s.badgeRulesFile)
+		if err == nil {
+			return raw, s.badgeRulesFile, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, "", err
+		}
+	}
+	return badgeRulesRawJSON, badgeCatalogEmbeddedSource, nil
+}
+
+func writeBadgeRulesFile(path string, catalog badgeRuleCatalog) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func badgeIndex(rules []BadgeRule, id string) int {
+	for i, rule := range rules {
+		if strings.TrimSpace(rule.ID) == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ling/internal/service"
+)
+
+func TestBadgeCatalogAdminCRUDPersistsAndReloads(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+
+	if _, err := svc.CreateBadge(context.Background(), service.BadgeRule{ID: "badge_x", Keywords: []string{"x"}, ImageURL: "https://example.com/x.png"}); !errors.Is(err, service.ErrBadgeReadOnly) {
+		t.Fatalf("expected read-only error without rules file, got %v", err)
+	}
+
+	rulesFile := filepath.Join(t.TempDir(), "badge_rules.json")
+	status, err := svc.SetBadgeRulesFile(context.Background(), rulesFile)
+	if err != nil {
+		t.Fatalf("SetBadgeRulesFile() error = %v", err)
+	}
+	if status.Source != "embedded" || status.Badges == 0 {
+		t.Fatalf("expected embedded catalog before first write, got %+v", status)
+	}
+	builtIn := status.Badges
+
+	created, err := svc.CreateBadge(context.Background(), service.BadgeRule{
+		ID:         "badge_spring",
+		CategoryID: "90",
+		Name:       "春日限定",
+		ImageURL:   "https://example.com/spring.png",
+		Keywords:   []string{"樱花"},
+		Criteria:   &service.BadgeCriterion{Type: "distinct_objects", Count: 1},
+	})
+	if err != nil {
+		t.Fatalf("CreateBadge() error = %v", err)
+	}
+	if !strings.Contains(created.Rule, "樱花") {
+		t.Fatalf("expected generated rule text, got %q", created.Rule)
+	}
+	if _, err := svc.CreateBadge(context.Background(), created); !errors.Is(err, service.ErrBadgeExists) {
+		t.Fatalf("expected duplicate id error, got %v", err)
+	}
+	if _, err := svc.CreateBadge(context.Background(), service.BadgeRule{ID: "badge_noimg", Keywords: []string{"桥"}}); !errors.Is(err, service.ErrBadgeInvalid) {
+		t.Fatalf("expected unresolvable image error, got %v", err)
+	}
+	if _, err := svc.CreateBadge(context.Background(), service.BadgeRule{ID: "badge_empty", ImageURL: "https://example.com/e.png"}); !errors.Is(err, service.ErrBadgeInvalid) {
+		t.Fatalf("expected empty keywords error, got %v", err)
+	}
+
+	created.Keywords = []string{"樱花", "桃花"}
+	if _, err := svc.UpdateBadge(context.Background(), "badge_spring", created); err != nil {
+		t.Fatalf("UpdateBadge() error = %v", err)
+	}
+	if _, err := svc.UpdateBadge(context.Background(), "badge_missing", created); !errors.Is(err, service.ErrBadgeNotFound) {
+		t.Fatalf("expected not found on update, got %v", err)
+	}
+	if len(svc.BadgeDefinitions()) != builtIn+1 {
+		t.Fatalf("expected %d badges after create, got %d", builtIn+1, len(svc.BadgeDefinitions()))
+	}
+
+	raw, err := os.ReadFile(rulesFile)
+	if err != nil {
+		t.Fatalf("expected rules file to be written: %v", err)
+	}
+	if !strings.Contains(string(raw), "桃花") {
+		t.Fatalf("expected updated keywords in rules file")
+	}
+
+	// 模拟直接编辑文件后热加载。
+	edited := strings.Replace(string(raw), "春日限定", "春日赏花", 1)
+	if err := os.WriteFile(rulesFile, []byte(edited), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	status, err = svc.ReloadBadgeCatalog(context.Background())
+	if err != nil {
+		t.Fatalf("ReloadBadgeCatalog() error = %v", err)
+	}
+	if status.Source != rulesFile {
+		t.Fatalf("expected reload from file, got %+v", status)
+	}
+	badge, err := svc.BadgeDefinition(context.Background(), "badge_spring")
+	if err != nil || badge.Name != "春日赏花" {
+		t.Fatalf("expected reloaded badge name, got %+v err=%v", badge, err)
+	}
+
+	if err := os.WriteFile(rulesFile, []byte(`{"badges":[{"id":"a","keywords":["x"]},{"id":"a","keywords":["y"]}]}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err := svc.ReloadBadgeCatalog(context.Background()); !errors.Is(err, service.ErrBadgeInvalid) {
+		t.Fatalf("expected duplicate ids to be rejected, got %v", err)
+	}
+	if _, err := svc.BadgeDefinition(context.Background(), "badge_spring"); err != nil {
+		t.Fatalf("failed reload should keep previous catalog, got %v", err)
+	}
+}
+
+func TestLimitedTimeBadgeOnlyCountsCapturesInWindow(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	if _, err := svc.SetBadgeRulesFile(context.Background(), filepath.Join(t.TempDir(), "badge_rules.json")); err != nil {
+		t.Fatalf("SetBadgeRulesFile() error = %v", err)
+	}
+	now := time.Now()
+	past := now.Add(-48 * time.Hour)
+	ended := now.Add(-24 * time.Hour)
+	upcoming := now.Add(24 * time.Hour)
+	for _, rule := range []service.BadgeRule{
+		{ID: "badge_past_event", Name: "已结束活动", ImageURL: "https://example.com/a.png", Keywords: []string{"树"},
+			AvailableFrom: &past, AvailableUntil: &ended},
+		{ID: "badge_live_event", Name: "进行中活动", ImageURL: "https://example.com/b.png", Keywords: []string{"树"},
+			AvailableFrom: &past, AvailableUntil: &upcoming},
+	} {
+		rule.Criteria = &service.BadgeCriterion{Type: "distinct_objects", Count: 1}
+		if _, err := svc.CreateBadge(context.Background(), rule); err != nil {
+			t.Fatalf("CreateBadge(%s) error = %v", rule.ID, err)
+		}
+	}
+
+	resp := captureObject(t, svc, st, "kid_event", "tree")
+	got := map[string]bool{}
+	for _, unlock := range resp.NewBadges {
+		got[unlock.BadgeID] = true
+	}
+	if !got["badge_live_event"] || got["badge_past_event"] {
+		t.Fatalf("expected only the live event badge to unlock, got %+v", resp.NewBadges)
+	}
+
+	badges, err := svc.PokedexBadges(context.Background(), "kid_event", "")
+	if err != nil {
+		t.Fatalf("PokedexBadges() error = %v", err)
+	}
+	for _, badge := range badges {
+		if badge.ID == "badge_past_event" && (badge.Available || badge.Unlocked || badge.AvailableUntil == nil) {
+			t.Fatalf("expected ended event badge to be unavailable and locked, got %+v", badge)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ling/internal/i18n"
+	"ling/internal/model"
+)
+
+const (
+	criterionCollectExamples  = "collect_examples"
+	criterionDistinctObjects  = "distinct_objects"
+	criterionCapturesInWindow = "captures_in_window"
+	criterionStreak           = "streak"
+	criterionBadges           = "badges"
+	criterionAccuracy         = "accuracy"
+	criterionAll              = "all"
+	criterionAny              = "any"
+)
+
+type criterionResult struct {
+	Progress  int
+	Target    int
+	Satisfied bool
+	Collected []string
+}
+
+type badgeEvalContext struct {
+	captures []model.Capture
+	sessions []model.ScanSession
+	names    map[string]string
+	results  map[string]criterionResult
+}
+
+func defaultBadgeCriterion(rule BadgeRule) BadgeCriterion {
+	if len(rule.Examples) > 0 {
+		return BadgeCriterion{Type: criterionCollectExamples}
+	}
+	count := rule.Target
+	if count <= 0 {
+		count = len(rule.Keywords)
+	}
+	return BadgeCriterion{Type: criterionDistinctObjects, Count: count}
+}
+
+// normalizeCriterion 用勋章自身的示例/关键词补全条件里省略的字段，并修正非法取值。
+func normalizeCriterion(c BadgeCriterion, rule BadgeRule) BadgeCriterion {
+	c.Type = strings.ToLower(strings.TrimSpace(c.Type))
+	switch c.Type {
+	case criterionCollectExamples:
+		if len(c.Examples) == 0 {
+			c.Examples = append([]string(nil), rule.Examples...)
+		}
+		if c.Count <= 0 || c.Count > len(c.Examples) {
+			c.Count = len(c.Examples)
+		}
+	case criterionDistinctObjects:
+		if len(c.Keywords) == 0 {
+			c.Keywords = append(append([]string(nil), rule.Keywords...), rule.Examples...)
+		}
+	case criterionStreak:
+		if c.Days <= 0 {
+			c.Days = 1
+		}
+	case criterionCapturesInWindow:
+		if c.WindowDays <= 0 {
+			c.WindowDays = 1
+		}
+	case criterionBadges:
+		if c.Count <= 0 || c.Count > len(c.BadgeIDs) {
+			c.Count = len(c.BadgeIDs)
+		}
+	case criterionAccuracy:
+		if c.MinAnswers <= 0 {
+			c.MinAnswers = 1
+		}
+		if c.MinAccuracy <= 0 || c.MinAccuracy > 1 {
+			c.MinAccuracy = 1
+		}
+	case criterionAll, criterionAny:
+		for i := range c.All {
+			c.All[i] = normalizeCriterion(c.All[i], rule)
+		}
+		for i := range c.Any {
+			c.Any[i] = normalizeCriterion(c.Any[i], rule)
+		}
+	}
+	if c.Count <= 0 {
+		c.Count = 1
+	}
+	return c
+}
+
+func referencedBadges(c BadgeCriterion) []string {
+	switch c.Type {
+	case criterionBadges:
+		return c.BadgeIDs
+	case criterionAll, criterionAny:
+		var ids []string
+		for _, child := range c.All {
+			ids = append(ids, referencedBadges(child)...)
+		}
+		for _, child := range c.Any {
+			ids = append(ids, referencedBadges(child)...)
+		}
+		return ids
+	default:
+		return nil
+	}
+}
+
+func needsSessions(c BadgeCriterion) bool {
+	switch c.Type {
+	case criterionAccuracy:
+		return true
+	case criterionAll, criterionAny:
+		for _, child := range c.All {
+			if needsSessions(child) {
+				return true
+			}
+		}
+		for _, child := range c.Any {
+			if needsSessions(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluateBadgeRules 按依赖顺序计算所有勋章：先算不依赖其他勋章的规则，组合勋章在其依赖全部算完后再算；
+// 存在循环依赖的勋章视为未点亮。
+func evaluateBadgeRules(rules []BadgeRule, ctx *badgeEvalContext) map[string]criterionResult {
+	if ctx.results == nil {
+		ctx.results = make(map[string]criterionResult, len(rules))
+	}
+	if ctx.names == nil {
+		ctx.names = make(map[string]string, len(rules))
+		for _, rule := range rules {
+			ctx.names[rule.ID] = rule.Name
+		}
+	}
+	pending := append([]BadgeRule(nil), rules...)
+	for len(pending) > 0 {
+		next := pending[:0]
+		for _, rule := range pending {
+			ready := true
+			for _, id := range referencedBadges(*rule.Criteria) {
+				if _, known := ctx.names[id]; !known {
+					continue
+				}
+				if _, done := ctx.results[id]; !done {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				next = append(next, rule)
+				continue
+			}
+			ruleCtx := ctx
+			if badgeLimitedTime(rule) {
+				ruleCtx = ctx.within(rule)
+			}
+			ctx.results[rule.ID] = evaluateCriterion(*rule.Criteria, ruleCtx)
+		}
+		if len(next) == len(pending) {
+			for _, rule := range next {
+				ctx.results[rule.ID] = criterionResult{Target: 1}
+			}
+			break
+		}
+		pending = next
+	}
+	return ctx.results
+}
+
+// within 返回只包含限时勋章开放窗口内收集与作答记录的上下文，勋章结果与原上下文共享。
+func (ctx *badgeEvalContext) within(rule BadgeRule) *badgeEvalContext {
+	scoped := &badgeEvalContext{names: ctx.names, results: ctx.results}
+	for _, capture := range ctx.captures {
+		if badgeAvailableAt(rule, capture.CapturedAt) {
+			scoped.captures = append(scoped.captures, capture)
+		}
+	}
+	for _, session := range ctx.sessions {
+		if badgeAvailableAt(rule, session.CreatedAt) {
+			scoped.sessions = append(scoped.sessions, session)
+		}
+	}
+	return scoped
+}
+
+func evaluateCriterion(c BadgeCriterion, ctx *badgeEvalContext) criterionResult {
+	switch c.Type {
+	case criterionCollectExamples:
+		collected := collectExamples(c.Examples, ctx.captures)
+		return countResult(len(collected), c.Count, collected)
+	case criterionDistinctObjects:
+		seen := make(map[string]struct{})
+		for _, capture := range ctx.captures {
+			if captureMatchesKeywords(capture, c.Keywords) {
+				seen[normalizeBadgeToken(capture.ObjectType)] = struct{}{}
+			}
+		}
+		return countResult(len(seen), c.Count, nil)
+	case criterionCapturesInWindow:
+		return countResult(bestWindowCount(ctx.captures, c.Keywords, c.WindowDays), c.Count, nil)
+	case criterionStreak:
+		return countResult(longestCaptureStreak(ctx.captures, c.Keywords), c.Days, nil)
+	case criterionBadges:
+		unlocked := 0
+		for _, id := range c.BadgeIDs {
+			if ctx.results[id].Satisfied {
+				unlocked++
+			}
+		}
+		return countResult(unlocked, c.Count, nil)
+	case criterionAccuracy:
+		return accuracyResult(ctx.sessions, c.MinAnswers, c.MinAccuracy)
+	case criterionAll:
+		result := criterionResult{Satisfied: len(c.All) > 0}
+		for _, child := range c.All {
+			sub := evaluateCriterion(child, ctx)
+			result.Progress += sub.Progress
+			result.Target += sub.Target
+			result.Satisfied = result.Satisfied && sub.Satisfied
+			result.Collected = append(result.Collected, sub.Collected...)
+		}
+		return result
+	case criterionAny:
+		best := criterionResult{Target: 1}
+		bestRatio := -1.0
+		for _, child := range c.Any {
+			sub := evaluateCriterion(child, ctx)
+			ratio := float64(sub.Progress) / float64(max(sub.Target, 1))
+			if sub.Satisfied {
+				ratio = math.Inf(1)
+			}
+			if ratio > bestRatio {
+				best, bestRatio = sub, ratio
+			}
+		}
+		return best
+	default:
+		return criterionResult{Target: 1}
+	}
+}
+
+func countResult(progress int, target int, collected []string) criterionResult {
+	if target <= 0 {
+		target = 1
+	}
+	if progress > target {
+		progress = target
+	}
+	return criterionResult{
+		Progress:  progress,
+		Target:    target,
+		Satisfied: progress >= target,
+		Collected: collected,
+	}
+}
+
+func collectExamples(examples []string, captures []model.Capture) []string {
+	if len(examples) == 0 || len(captures) == 0 {
+		return nil
+	}
+	collected := make([]string, 0, len(examples))
+	seen := make(map[string]struct{}, len(examples))
+	for _, example := range examples {
+		if normalizeBadgeToken(example) == "" {
+			continue
+		}
+		for _, capture := range captures {
+			if objectMatchesKeyword(capture.ObjectType, example) {
+				if _, exists := seen[example]; !exists {
+					seen[example] = struct{}{}
+					collected = append(collected, example)
+				}
+				break
+			}
+		}
+	}
+	return collected
+}
+
+func captureMatchesKeywords(capture model.Capture, keywords []string) bool {
+	if len(keywords) == 0 {
+		return strings.TrimSpace(capture.ObjectType) != ""
+	}
+	for _, keyword := range keywords {
+		if objectMatchesKeyword(capture.ObjectType, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func bestWindowCount(captures []model.Capture, keywords []string, windowDays int) int {
+	times := make([]time.Time, 0, len(captures))
+	for _, capture := range captures {
+		if captureMatchesKeywords(capture, keywords) {
+			times = append(times, capture.CapturedAt)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	window := time.Duration(windowDays) * 24 * time.Hour
+	best := 0
+	start := 0
+	for end := range times {
+		for times[end].Sub(times[start]) >= window {
+			start++
+		}
+		if count := end - start + 1; count > best {
+			best = count
+		}
+	}
+	return best
+}
+
+func longestCaptureStreak(captures []model.Capture, keywords []string) int {
+	days := make(map[string]struct{})
+	for _, capture := range captures {
+		if captureMatchesKeywords(capture, keywords) {
+			days[capture.CapturedAt.In(time.Local).Format(progressDateLayout)] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(days))
+	for day := range days {
+		sorted = append(sorted, day)
+	}
+	sort.Strings(sorted)
+
+	best, current := 0, 0
+	var prev time.Time
+	for _, raw := range sorted {
+		day, err := time.ParseInLocation(progressDateLayout, raw, time.Local)
+		if err != nil {
+			continue
+		}
+		if current > 0 && day.AddDate(0, 0, -1).Equal(prev) {
+			current++
+		} else {
+			current = 1
+		}
+		prev = day
+		if current > best {
+			best = current
+		}
+	}
+	return best
+}
+
+// accuracyResult 以已作答题数为进度；正确率未达标时进度停在目标前一步，避免误报为已点亮。
+func accuracyResult(sessions []model.ScanSession, minAnswers int, minAccuracy float64) criterionResult {
+	answered, correct := 0, 0
+	for _, session := range sessions {
+		if strings.TrimSpace(session.AnswerGiven) == "" {
+			continue
+		}
+		answered++
+		if session.Captured {
+			correct++
+		}
+	}
+	progress := min(answered, minAnswers)
+	accurate := answered > 0 && float64(correct)/float64(answered) >= minAccuracy
+	if progress >= minAnswers && !accurate {
+		progress = minAnswers - 1
+	}
+	return criterionResult{
+		Progress:  progress,
+		Target:    minAnswers,
+		Satisfied: answered >= minAnswers && accurate,
+	}
+}
+
+// describeCriterion 生成条件在 locale 下的可读说明，names 为勋章 ID 到名称的映射。
+func describeCriterion(c BadgeCriterion, names map[string]string, locale i18n.Locale) string {
+	switch c.Type {
+	case criterionCollectExamples:
+		if c.Count >= len(c.Examples) {
+			return i18n.Text(locale, "badge.criteria.collect_all", len(c.Examples))
+		}
+		return i18n.Text(locale, "badge.criteria.collect_some", c.Count)
+	case criterionDistinctObjects:
+		return i18n.Text(locale, "badge.criteria.distinct_objects", c.Count, describeKeywords(c.Keywords, locale))
+	case criterionCapturesInWindow:
+		return i18n.Text(locale, "badge.criteria.captures_in_window", c.WindowDays, c.Count, describeKeywords(c.Keywords, locale))
+	case criterionStreak:
+		if len(c.Keywords) == 0 {
+			return i18n.Text(locale, "badge.criteria.streak_any", c.Days)
+		}
+		return i18n.Text(locale, "badge.criteria.streak", c.Days, describeKeywords(c.Keywords, locale))
+	case criterionBadges:
+		labels := make([]string, 0, len(c.BadgeIDs))
+		for _, id := range c.BadgeIDs {
+			name := strings.TrimSpace(names[id])
+			if name == "" {
+				name = id
+			}
+			labels = append(labels, i18n.Text(locale, "badge.criteria.badge_name", name))
+		}
+		joined := strings.Join(labels, i18n.Text(locale, "badge.criteria.badge_separator"))
+		if c.Count >= len(c.BadgeIDs) {
+			return i18n.Text(locale, "badge.criteria.badges_all", joined)
+		}
+		return i18n.Text(locale, "badge.criteria.badges_some", joined, c.Count)
+	case criterionAccuracy:
+		return i18n.Text(locale, "badge.criteria.accuracy", c.MinAnswers, int(math.Round(c.MinAccuracy*100)))
+	case criterionAll:
+		parts := make([]string, 0, len(c.All))
+		for _, child := range c.All {
+			parts = append(parts, describeCriterion(child, names, locale))
+		}
+		return strings.Join(parts, i18n.Text(locale, "badge.criteria.and"))
+	case criterionAny:
+		parts := make([]string, 0, len(c.Any))
+		for _, child := range c.Any {
+			parts = append(parts, describeCriterion(child, names, locale))
+		}
+		return strings.Join(parts, i18n.Text(locale, "badge.criteria.or"))
+	default:
+		return i18n.Text(locale, "badge.criteria.default")
+	}
+}
+
+func describeKeywords(keywords []string, locale i18n.Locale) string {
+	if len(keywords) == 0 {
+		return i18n.Text(locale, "badge.keywords.any")
+	}
+	shown := keywords
+	if len(shown) > 3 {
+		shown = shown[:3]
+	}
+	if len(keywords) > len(shown) {
+		return i18n.Text(locale, "badge.keywords.more", strings.Join(shown, "/"))
+	}
+	return i18n.Text(locale, "badge.keywords.list", strings.Join(shown, "/"))
+}
+
+func validateBadgeCriterion(c BadgeCriterion) error {
+	switch c.Type {
+	case criterionCollectExamples:
+		if len(c.Examples) == 0 {
+			return fmt.Errorf("%s 条件缺少 examples", c.Type)
+		}
+	case criterionDistinctObjects:
+		if len(c.Keywords) == 0 {
+			return fmt.Errorf("%s 条件缺少 keywords", c.Type)
+		}
+	case criterionCapturesInWindow, criterionStreak, criterionAccuracy:
+	case criterionBadges:
+		if len(c.BadgeIDs) == 0 {
+			return fmt.Errorf("%s 条件缺少 badge_ids", c.Type)
+		}
+	case criterionAll, criterionAny:
+		children := c.All
+		if c.Type == criterionAny {
+			children = c.Any
+		}
+		if len(children) == 0 {
+			return fmt.Errorf("%s 条件缺少子条件", c.Type)
+		}
+		for _, child := range children {
+			if err := validateBadgeCriterion(child); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("未知的勋章条件类型: %q", c.Type)
+	}
+	return nil
+}
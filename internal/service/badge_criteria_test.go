@@ -0,0 +1,142 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+)
+
+const criteriaCatalogJSON = `{
+  "badges": [
+    {
+      "id": "birds", "category_id": "01", "name": "观鸟者",
+      "examples": ["麻雀", "鸽子", "喜鹊"],
+      "criteria": { "type": "distinct_objects", "keywords": ["麻雀", "鸽子", "喜鹊", "乌鸦"], "count": 3 }
+    },
+    {
+      "id": "weekend", "category_id": "02", "name": "周末探险",
+      "criteria": { "type": "captures_in_window", "window_days": 2, "count": 3 }
+    },
+    {
+      "id": "streak", "category_id": "03", "name": "坚持不懈",
+      "criteria": { "type": "streak", "days": 3 }
+    },
+    {
+      "id": "scholar", "category_id": "04", "name": "小学霸",
+      "criteria": { "type": "accuracy", "min_answers": 4, "min_accuracy": 0.75 }
+    },
+    {
+      "id": "master", "category_id": "05", "name": "全能大师",
+      "criteria": { "type": "all", "all": [
+        { "type": "badges", "badge_ids": ["birds", "streak"] },
+        { "type": "any", "any": [
+          { "type": "badges", "badge_ids": ["scholar"] },
+          { "type": "distinct_objects", "keywords": ["树"], "count": 1 }
+        ] }
+      ] }
+    },
+    {
+      "id": "plants", "category_id": "06", "name": "植物",
+      "examples": ["松树", "荷花"]
+    }
+  ]
+}`
+
+func TestBadgeCriteriaEvaluation(t *testing.T) {
+	t.Parallel()
+
+	rules, err := parseBadgeRules([]byte(criteriaCatalogJSON))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	day := time.Date(2026, 5, 1, 10, 0, 0, 0, time.Local)
+	capture := func(objectType string, offsetDays int) model.Capture {
+		return model.Capture{ObjectType: objectType, CapturedAt: day.AddDate(0, 0, offsetDays)}
+	}
+	ctx := &badgeEvalContext{
+		captures: []model.Capture{
+			capture("麻雀", 0),
+			capture("麻雀", 0),
+			capture("鸽子", 1),
+			capture("喜鹊", 2),
+			capture("松树", 5),
+		},
+		sessions: []model.ScanSession{
+			{AnswerGiven: "a", Captured: true},
+			{AnswerGiven: "b", Captured: true},
+			{AnswerGiven: "c", Captured: false},
+			{AnswerGiven: "d", Captured: false},
+			{AnswerGiven: ""},
+		},
+	}
+	results := evaluateBadgeRules(rules, ctx)
+
+	cases := []struct {
+		id        string
+		progress  int
+		target    int
+		satisfied bool
+	}{
+		{"birds", 3, 3, true},
+		{"weekend", 3, 3, true},
+		{"streak", 3, 3, true},
+		// 已答 4 题但正确率只有 50%，进度停在目标前一步。
+		{"scholar", 3, 4, false},
+		{"master", 3, 3, true},
+		{"plants", 1, 2, false},
+	}
+	for _, tc := range cases {
+		got := results[tc.id]
+		if got.Progress != tc.progress || got.Target != tc.target || got.Satisfied != tc.satisfied {
+			t.Errorf("%s: got progress=%d target=%d satisfied=%v, want %d/%d/%v",
+				tc.id, got.Progress, got.Target, got.Satisfied, tc.progress, tc.target, tc.satisfied)
+		}
+	}
+	if collected := results["plants"].Collected; len(collected) != 1 || collected[0] != "松树" {
+		t.Fatalf("expected plants to collect 松树, got %v", collected)
+	}
+}
+
+func TestBadgeCriteriaCyclesStayLocked(t *testing.T) {
+	t.Parallel()
+
+	rules, err := parseBadgeRules([]byte(`{"badges": [
+		{"id": "a", "name": "A", "criteria": {"type": "badges", "badge_ids": ["b"]}},
+		{"id": "b", "name": "B", "criteria": {"type": "badges", "badge_ids": ["a"]}}
+	]}`))
+	if err != nil {
+		t.Fatalf("parse rules: %v", err)
+	}
+	results := evaluateBadgeRules(rules, &badgeEvalContext{})
+	if results["a"].Satisfied || results["b"].Satisfied {
+		t.Fatalf("expected cyclic badges to stay locked, got %+v", results)
+	}
+}
+
+func TestBadgeCriteriaRejectsUnknownType(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseBadgeRules([]byte(`{"badges": [{"id": "x", "criteria": {"type": "magic"}}]}`))
+	if err == nil || !strings.Contains(err.Error(), "magic") {
+		t.Fatalf("expected unknown criterion type error, got %v", err)
+	}
+}
+
+func TestEmbeddedBadgeRulesKeepFullCollectionTarget(t *testing.T) {
+	t.Parallel()
+
+	rules := loadBadgeRules()
+	if len(rules) == 0 {
+		t.Fatal("expected embedded badge rules")
+	}
+	for _, rule := range rules {
+		if rule.Criteria.Type != criterionCollectExamples || rule.Criteria.Count != len(rule.Examples) {
+			t.Fatalf("%s: expected collect-all-examples criterion, got %+v", rule.ID, rule.Criteria)
+		}
+		if !strings.Contains(rule.Rule, "全部") {
+			t.Fatalf("%s: unexpected rule text %q", rule.ID, rule.Rule)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+func (s *Service) UnseenBadgeUnlocks(ctx context.Context, childID string) (_ []model.BadgeUnlock, err error) {
+	ctx, span := startSpan(ctx, "UnseenBadgeUnlocks")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	unlocks, err := st.ListBadgeUnlocksByChild(childID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.BadgeUnlock, 0, len(unlocks))
+	for _, unlock := range unlocks {
+		if !unlock.Seen {
+			result = append(result, unlock)
+		}
+	}
+	return result, nil
+}
+
+// MarkBadgeUnlocksSeen 将指定的点亮记录标记为已读；unlock_ids 为空时标记该孩子全部未读记录。
+func (s *Service) MarkBadgeUnlocksSeen(ctx context.Context, req BadgeSeenRequest) (_ BadgeSeenResponse, err error) {
+	ctx, span := startSpan(ctx, "MarkBadgeUnlocksSeen")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID := strings.TrimSpace(req.ChildID)
+	if childID == "" {
+		childID = "guest"
+	}
+	wanted := make(map[string]struct{}, len(req.UnlockIDs))
+	for _, id := range req.UnlockIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			wanted[id] = struct{}{}
+		}
+	}
+
+	s.badgeMu.Lock()
+	defer s.badgeMu.Unlock()
+
+	unlocks, err := st.ListBadgeUnlocksByChild(childID)
+	if err != nil {
+		return BadgeSeenResponse{}, err
+	}
+	now := time.Now()
+	marked := 0
+	for _, unlock := range unlocks {
+		if unlock.Seen {
+			continue
+		}
+		if len(wanted) > 0 {
+			if _, ok := wanted[unlock.ID]; !ok {
+				continue
+			}
+		}
+		unlock.Seen = true
+		unlock.SeenAt = now
+		if err := st.SaveBadgeUnlock(unlock); err != nil {
+			return BadgeSeenResponse{}, err
+		}
+		marked++
+	}
+	return BadgeSeenResponse{ChildID: childID, Marked: marked}, nil
+}
+
+// recordBadgeUnlocks 在一次作答结算后比对勋章状态，持久化新点亮的勋章并返回它们。
+func (s *Service) recordBadgeUnlocks(ctx context.Context, session model.ScanSession, capture *model.Capture, now time.Time) ([]model.BadgeUnlock, error) {
+	st := s.storeFor(ctx)
+	rules, images := s.badgeCatalog()
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	childID := session.ChildID
+
+	s.badgeMu.Lock()
+	defer s.badgeMu.Unlock()
+
+	existing, err := st.ListBadgeUnlocksByChild(childID)
+	if err != nil {
+		return nil, err
+	}
+	recorded := make(map[string]struct{}, len(existing))
+	for _, unlock := range existing {
+		recorded[unlock.BadgeID] = struct{}{}
+	}
+	captures, err := st.ListCapturesByChild(childID)
+	if err != nil {
+		return nil, err
+	}
+	results, err := evaluateBadges(st, rules, childID, captures, "")
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]BadgeRule, 0)
+	for _, rule := range rules {
+		if _, ok := recorded[rule.ID]; ok {
+			continue
+		}
+		if !badgeAvailableAt(rule, now) {
+			continue
+		}
+		if results[rule.ID].Satisfied {
+			candidates = append(candidates, rule)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// 去掉本次作答后重新计算：此前就已满足条件的勋章（如历史数据）补记为已读，不当作新点亮。
+	previous := make([]model.Capture, 0, len(captures))
+	for _, c := range captures {
+		if capture == nil || c.ID != capture.ID {
+			previous = append(previous, c)
+		}
+	}
+	before, err := evaluateBadges(st, rules, childID, previous, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := make([]model.BadgeUnlock, 0, len(candidates))
+	for _, rule := range candidates {
+		unlock := model.BadgeUnlock{
+			ID:         s.newID("unlock") + "_" + rule.ID,
+			ChildID:    childID,
+			BadgeID:    rule.ID,
+			BadgeName:  rule.Name,
+			ImageURL:   resolveBadgeImage(rule, images),
+			UnlockedAt: now,
+		}
+		if before[rule.ID].Satisfied {
+			unlock.Seen = true
+			unlock.SeenAt = now
+		} else {
+			unlock.SessionID = session.ID
+			if capture != nil {
+				unlock.CaptureID = capture.ID
+			}
+		}
+		if err := st.SaveBadgeUnlock(unlock); err != nil {
+			return nil, err
+		}
+		if !unlock.Seen {
+			fresh = append(fresh, unlock)
+		}
+	}
+	return fresh, nil
+}
@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/service"
+	"ling/internal/store"
+)
+
+const plantBadgeID = "badge_01_plantae"
+
+func seedPlantCaptures(t *testing.T, st *store.JSONStore, childID string, objectTypes ...string) {
+	t.Helper()
+	for i, objectType := range objectTypes {
+		err := st.AddCapture(model.Capture{
+			ID:         "seed_" + objectType,
+			ChildID:    childID,
+			SpiritName: "种子精灵",
+			ObjectType: objectType,
+			CapturedAt: time.Now().Add(-time.Duration(i+1) * time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("AddCapture() error = %v", err)
+		}
+	}
+}
+
+func TestSubmitAnswerReturnsNewlyUnlockedBadge(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	childID := "kid_badge_unlock"
+	// 松树由本次收集的 tree 补齐，其余 9 个示例预先写入。
+	seedPlantCaptures(t, st, childID, "向日葵", "多肉植物", "银杏叶", "捕蝇草", "灵芝", "荷花", "枫叶", "玫瑰", "草坪")
+
+	resp := captureObject(t, svc, st, childID, "tree")
+	var unlock *model.BadgeUnlock
+	for i := range resp.NewBadges {
+		if resp.NewBadges[i].BadgeID == plantBadgeID {
+			unlock = &resp.NewBadges[i]
+		}
+	}
+	if unlock == nil {
+		t.Fatalf("expected %s in new_badges, got %+v", plantBadgeID, resp.NewBadges)
+	}
+	if unlock.CaptureID != resp.Capture.ID || unlock.Seen {
+		t.Fatalf("unexpected unlock record: %+v", unlock)
+	}
+
+	again := captureObject(t, svc, st, childID, "tree")
+	for _, badge := range again.NewBadges {
+		if badge.BadgeID == plantBadgeID {
+			t.Fatalf("badge should only be reported once, got %+v", again.NewBadges)
+		}
+	}
+
+	badges, err := svc.PokedexBadges(context.Background(), childID, "")
+	if err != nil {
+		t.Fatalf("PokedexBadges() error = %v", err)
+	}
+	for _, badge := range badges {
+		if badge.ID == plantBadgeID && (!badge.Unlocked || badge.UnlockedAt == nil) {
+			t.Fatalf("expected persisted unlock time on badge, got %+v", badge)
+		}
+	}
+
+	unseen, err := svc.UnseenBadgeUnlocks(context.Background(), childID)
+	if err != nil {
+		t.Fatalf("UnseenBadgeUnlocks() error = %v", err)
+	}
+	if len(unseen) != 1 || unseen[0].ID != unlock.ID {
+		t.Fatalf("expected one unseen unlock, got %+v", unseen)
+	}
+	marked, err := svc.MarkBadgeUnlocksSeen(context.Background(), service.BadgeSeenRequest{ChildID: childID})
+	if err != nil {
+		t.Fatalf("MarkBadgeUnlocksSeen() error = %v", err)
+	}
+	if marked.Marked != 1 {
+		t.Fatalf("expected 1 marked unlock, got %+v", marked)
+	}
+	unseen, err = svc.UnseenBadgeUnlocks(context.Background(), childID)
+	if err != nil {
+		t.Fatalf("UnseenBadgeUnlocks() error = %v", err)
+	}
+	if len(unseen) != 0 {
+		t.Fatalf("expected no unseen unlocks after marking, got %+v", unseen)
+	}
+}
+
+func TestBadgeUnlocksBackfillPreviouslySatisfiedBadgesAsSeen(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	childID := "kid_badge_backfill"
+	seedPlantCaptures(t, st, childID, "向日葵", "多肉植物", "松树", "银杏叶", "捕蝇草", "灵芝", "荷花", "枫叶", "玫瑰", "草坪")
+
+	resp := captureObject(t, svc, st, childID, "tree")
+	for _, badge := range resp.NewBadges {
+		if badge.BadgeID == plantBadgeID {
+			t.Fatalf("previously satisfied badge should not be reported as new, got %+v", resp.NewBadges)
+		}
+	}
+	unlocks, err := st.ListBadgeUnlocksByChild(childID)
+	if err != nil {
+		t.Fatalf("ListBadgeUnlocksByChild() error = %v", err)
+	}
+	if len(unlocks) != 1 || unlocks[0].BadgeID != plantBadgeID || !unlocks[0].Seen || unlocks[0].CaptureID != "" {
+		t.Fatalf("expected backfilled seen unlock, got %+v", unlocks)
+	}
+}
+
+func TestPokedexBadgeRulesFollowLocale(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+
+	badges, err := svc.PokedexBadges(context.Background(), "kid_badge_en", "en")
+	if err != nil {
+		t.Fatalf("PokedexBadges() error = %v", err)
+	}
+	for _, badge := range badges {
+		if !strings.HasPrefix(badge.Rule, "Unlocks after you ") {
+			t.Fatalf("expected english rule for %s, got %q", badge.ID, badge.Rule)
+		}
+	}
+	zh, err := svc.PokedexBadges(context.Background(), "kid_badge_en", "")
+	if err != nil {
+		t.Fatalf("PokedexBadges() error = %v", err)
+	}
+	if len(zh) == 0 || !strings.HasPrefix(zh[0].Rule, "需") {
+		t.Fatalf("expected default chinese rule, got %+v", zh)
+	}
+}
@@ -1,14 +1,21 @@
 package service
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"os"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
+	"ling/internal/i18n"
 	"ling/internal/model"
+	"ling/internal/store"
+	"ling/internal/tracing"
 )
 
 const defaultBadgeAssetManifestPath = "design/badges/cloud_badge_assets.json"
@@ -20,23 +27,8 @@ var (
 	badgeTokenCleaner = regexp.MustCompile(`[\\s_\\-·（）()【】\\[\\],，。:：;；、/\\\\]+`)
 )
 
-type badgeRule struct {
-	ID          string   `json:"id"`
-	CategoryID  string   `json:"category_id"`
-	Name        string   `json:"name"`
-	Code        string   `json:"code"`
-	Description string   `json:"description"`
-	RecordScope string   `json:"record_scope"`
-	Rule        string   `json:"rule"`
-	Target      int      `json:"target"`
-	ImageFile   string   `json:"image_file"`
-	ImageURL    string   `json:"image_url,omitempty"`
-	Keywords    []string `json:"keywords"`
-	Examples    []string `json:"examples"`
-}
-
 type badgeRuleCatalog struct {
-	Badges []badgeRule `json:"badges"`
+	Badges []BadgeRule `json:"badges"`
 }
 
 type badgeAssetManifest struct {
@@ -49,31 +41,100 @@ type badgeAssetItem struct {
 	ImageURL   string `json:"image_url"`
 }
 
-func loadBadgeRules() []badgeRule {
-	var catalog badgeRuleCatalog
-	if err := json.Unmarshal(badgeRulesRawJSON, &catalog); err != nil {
+func loadBadgeRules() []BadgeRule {
+	rules, err := parseBadgeRules(badgeRulesRawJSON)
+	if err != nil {
+		slog.Error("load badge rules failed", "err", err)
 		return nil
 	}
-	rules := make([]badgeRule, 0, len(catalog.Badges))
-	for _, rule := range catalog.Badges {
+	return rules
+}
+
+func parseBadgeRules(raw []byte) ([]BadgeRule, error) {
+	var catalog badgeRuleCatalog
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadgeInvalid, err)
+	}
+	return normalizeBadgeRules(catalog.Badges)
+}
+
+// normalizeBadgeRules 校验勋章定义（ID 唯一、关键词/示例非空、条件与引用合法）并补全默认条件与规则文案。
+func normalizeBadgeRules(defs []BadgeRule) ([]BadgeRule, error) {
+	rules := make([]BadgeRule, 0, len(defs))
+	names := make(map[string]string, len(defs))
+	for _, rule := range defs {
 		rule.ID = strings.TrimSpace(rule.ID)
 		if rule.ID == "" {
 			continue
 		}
-		if len(rule.Examples) > 0 {
-			rule.Target = len(rule.Examples)
-		} else if len(rule.Keywords) > 0 {
-			rule.Target = len(rule.Keywords)
-		} else if rule.Target <= 0 {
-			rule.Target = 1
+		if _, exists := names[rule.ID]; exists {
+			return nil, fmt.Errorf("%w: 勋章 ID %s 重复", ErrBadgeInvalid, rule.ID)
+		}
+		rule.Keywords = trimBadgeTerms(rule.Keywords)
+		rule.Examples = trimBadgeTerms(rule.Examples)
+		// 未声明条件的勋章按关键词/示例收集，两者不能同时为空；组合类条件可以不带关键词。
+		if rule.Criteria == nil && len(rule.Keywords) == 0 && len(rule.Examples) == 0 {
+			return nil, fmt.Errorf("%w: 勋章 %s 的 keywords 与 examples 不能同时为空", ErrBadgeInvalid, rule.ID)
 		}
-		rule.Rule = "需完成该类全部示例收集后点亮。"
+		if rule.AvailableFrom != nil && rule.AvailableUntil != nil && !rule.AvailableUntil.After(*rule.AvailableFrom) {
+			return nil, fmt.Errorf("%w: 勋章 %s 的 available_until 必须晚于 available_from", ErrBadgeInvalid, rule.ID)
+		}
+		criterion := defaultBadgeCriterion(rule)
+		if rule.Criteria != nil {
+			criterion = *rule.Criteria
+		}
+		criterion = normalizeCriterion(criterion, rule)
+		if err := validateBadgeCriterion(criterion); err != nil {
+			return nil, fmt.Errorf("%w: 勋章 %s 的点亮条件无效: %v", ErrBadgeInvalid, rule.ID, err)
+		}
+		rule.Criteria = &criterion
+		names[rule.ID] = rule.Name
 		rules = append(rules, rule)
 	}
+	for i := range rules {
+		for _, id := range referencedBadges(*rules[i].Criteria) {
+			if _, ok := names[id]; !ok {
+				return nil, fmt.Errorf("%w: 勋章 %s 引用了不存在的勋章 %s", ErrBadgeInvalid, rules[i].ID, id)
+			}
+		}
+		rules[i].Rule = badgeRuleText(rules[i], names, i18n.Default)
+	}
 	sort.Slice(rules, func(i, j int) bool {
 		return rules[i].CategoryID < rules[j].CategoryID
 	})
-	return rules
+	return rules, nil
+}
+
+// badgeRuleText 生成勋章点亮条件在 locale 下的说明。
+func badgeRuleText(rule BadgeRule, names map[string]string, locale i18n.Locale) string {
+	return i18n.Text(locale, "badge.rule", describeCriterion(*rule.Criteria, names, locale))
+}
+
+func trimBadgeTerms(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func badgeAvailableAt(r BadgeRule, t time.Time) bool {
+	if r.AvailableFrom != nil && t.Before(*r.AvailableFrom) {
+		return false
+	}
+	if r.AvailableUntil != nil && !t.Before(*r.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+func badgeLimitedTime(r BadgeRule) bool {
+	return r.AvailableFrom != nil || r.AvailableUntil != nil
 }
 
 func loadBadgeImageURLMap() map[string]string {
@@ -105,65 +166,74 @@ func loadBadgeImageURLMap() map[string]string {
 	return lookup
 }
 
-func (s *Service) PokedexBadges(childID string) ([]model.PokedexBadge, error) {
+// PokedexBadges 返回孩子的勋章进度；locale 为语言提示，决定点亮条件说明的语言，孩子资料中的设置优先。
+func (s *Service) PokedexBadges(ctx context.Context, childID string, locale string) (_ []model.PokedexBadge, err error) {
+	ctx, span := startSpan(ctx, "PokedexBadges")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
 	childID = strings.TrimSpace(childID)
 	if childID == "" {
 		childID = "guest"
 	}
-	captures, err := s.store.ListCapturesByChild(childID)
+	captures, err := st.ListCapturesByChild(childID)
 	if err != nil {
 		return nil, err
 	}
-	if len(s.badgeRules) == 0 {
+	rules, images := s.badgeCatalog()
+	if len(rules) == 0 {
 		return []model.PokedexBadge{}, nil
 	}
 
-	badges := make([]model.PokedexBadge, 0, len(s.badgeRules))
-	for _, rule := range s.badgeRules {
-		matchedObjects := make(map[string]struct{})
-		for _, capture := range captures {
-			objectType := strings.TrimSpace(capture.ObjectType)
-			if objectType == "" {
-				continue
-			}
-			if matchBadgeRule(rule, objectType) {
-				matchedObjects[normalizeBadgeToken(objectType)] = struct{}{}
-			}
-		}
-
-		collectedExamples := collectMatchedExamples(rule, captures)
-		progress := len(collectedExamples)
-		if progress == 0 && len(rule.Examples) == 0 {
-			progress = len(matchedObjects)
-		}
-		target := rule.Target
-		if target <= 0 {
-			target = 1
-		}
-		imageURL := strings.TrimSpace(rule.ImageURL)
-		if imageURL == "" {
-			imageURL = strings.TrimSpace(s.badgeImageURL[rule.ID])
-		}
-		if imageURL == "" {
-			imageURL = strings.TrimSpace(s.badgeImageURL[rule.ImageFile])
-		}
+	results, err := evaluateBadges(st, rules, childID, captures, "")
+	if err != nil {
+		return nil, err
+	}
+	unlocks, err := st.ListBadgeUnlocksByChild(childID)
+	if err != nil {
+		return nil, err
+	}
+	unlockedAt := make(map[string]time.Time, len(unlocks))
+	for _, unlock := range unlocks {
+		unlockedAt[unlock.BadgeID] = unlock.UnlockedAt
+	}
 
-		badges = append(badges, model.PokedexBadge{
+	lang := s.locale(ctx, childID, locale)
+	names := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		names[rule.ID] = rule.Name
+	}
+	now := time.Now()
+	badges := make([]model.PokedexBadge, 0, len(rules))
+	for _, rule := range rules {
+		result := results[rule.ID]
+		badge := model.PokedexBadge{
 			ID:          rule.ID,
 			CategoryID:  rule.CategoryID,
 			Name:        rule.Name,
 			Code:        rule.Code,
 			Description: rule.Description,
 			RecordScope: rule.RecordScope,
-			Rule:        rule.Rule,
-			ImageURL:    imageURL,
+			Rule:        badgeRuleText(rule, names, lang),
+			ImageURL:    resolveBadgeImage(rule, images),
 			ImageFile:   rule.ImageFile,
-			Unlocked:    progress >= target,
-			Progress:    progress,
-			Target:      target,
+			Unlocked:    result.Satisfied,
+			Progress:    result.Progress,
+			Target:      result.Target,
 			Examples:    append([]string(nil), rule.Examples...),
-			Collected:   collectedExamples,
-		})
+			Collected:   result.Collected,
+			Available:   badgeAvailableAt(rule, now),
+		}
+		if badgeLimitedTime(rule) {
+			badge.AvailableFrom = rule.AvailableFrom
+			badge.AvailableUntil = rule.AvailableUntil
+		}
+		// 已记录点亮的勋章保持点亮，即使条件（如正确率）之后又不再满足。
+		if at, ok := unlockedAt[rule.ID]; ok {
+			badge.Unlocked = true
+			badge.Progress = badge.Target
+			badge.UnlockedAt = &at
+		}
+		badges = append(badges, badge)
 	}
 
 	sort.Slice(badges, func(i, j int) bool {
@@ -172,28 +242,36 @@ func (s *Service) PokedexBadges(childID string) ([]model.PokedexBadge, error) {
 	return badges, nil
 }
 
-func collectMatchedExamples(rule badgeRule, captures []model.Capture) []string {
-	if len(rule.Examples) == 0 || len(captures) == 0 {
-		return nil
-	}
-	collected := make([]string, 0, len(rule.Examples))
-	seen := make(map[string]struct{}, len(rule.Examples))
-	for _, example := range rule.Examples {
-		exampleToken := normalizeBadgeToken(example)
-		if exampleToken == "" {
+// evaluateBadges 计算孩子当前各勋章的进度；skipSessionID 非空时忽略该会话的作答记录。
+func evaluateBadges(st store.Store, rules []BadgeRule, childID string, captures []model.Capture, skipSessionID string) (map[string]criterionResult, error) {
+	evalCtx := &badgeEvalContext{captures: captures}
+	for _, rule := range rules {
+		if !needsSessions(*rule.Criteria) {
 			continue
 		}
-		for _, capture := range captures {
-			if objectMatchesKeyword(capture.ObjectType, example) {
-				if _, exists := seen[example]; !exists {
-					seen[example] = struct{}{}
-					collected = append(collected, example)
-				}
-				break
+		sessions, err := st.ListSessionsByChild(childID)
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range sessions {
+			if session.ID != skipSessionID {
+				evalCtx.sessions = append(evalCtx.sessions, session)
 			}
 		}
+		break
+	}
+	return evaluateBadgeRules(rules, evalCtx), nil
+}
+
+func resolveBadgeImage(rule BadgeRule, images map[string]string) string {
+	imageURL := strings.TrimSpace(rule.ImageURL)
+	if imageURL == "" {
+		imageURL = strings.TrimSpace(images[rule.ID])
+	}
+	if imageURL == "" && strings.TrimSpace(rule.ImageFile) != "" {
+		imageURL = strings.TrimSpace(images[rule.ImageFile])
 	}
-	return collected
+	return imageURL
 }
 
 func (s *Service) isObjectTrackedByBadge(objectType string) bool {
@@ -201,7 +279,8 @@ func (s *Service) isObjectTrackedByBadge(objectType string) bool {
 	if trimmed == "" {
 		return false
 	}
-	for _, rule := range s.badgeRules {
+	rules, _ := s.badgeCatalog()
+	for _, rule := range rules {
 		if matchBadgeRule(rule, trimmed) {
 			return true
 		}
@@ -209,7 +288,7 @@ func (s *Service) isObjectTrackedByBadge(objectType string) bool {
 	return false
 }
 
-func matchBadgeRule(rule badgeRule, objectType string) bool {
+func matchBadgeRule(rule BadgeRule, objectType string) bool {
 	objectTokens := uniqueNormalizedBadgeTokens(
 		objectType,
 		objectTypeToChinese(objectType),
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+// DefaultClusterZoom 为未指定缩放级别时的聚合粒度，大致对应城市街区视图。
+const DefaultClusterZoom = 12
+
+const (
+	// defaultLocationPrecision 为保存坐标时保留的小数位数，3 位约等于 110 米。
+	defaultLocationPrecision = 3
+	maxLocationPrecision     = 6
+
+	earthRadiusM        = 6371000.0
+	metersPerDegreeLat  = 111320.0
+	maxMapRadiusM       = 100000.0
+	maxMapZoom          = 22
+	clusterCellsPerTile = 4
+	clusterTopTypes     = 3
+)
+
+// SetLocationPrecision 设置保存坐标时保留的小数位数（0-6），用于在隐私与地图精度之间取舍。
+func (s *Service) SetLocationPrecision(decimals int) {
+	if decimals < 0 {
+		decimals = 0
+	}
+	if decimals > maxLocationPrecision {
+		decimals = maxLocationPrecision
+	}
+	s.locationPrecision = decimals
+}
+
+// scanLocation 校验扫描请求中的位置并降低精度；未提供位置时返回 nil。
+func (s *Service) scanLocation(req ScanRequest) (*model.GeoPoint, error) {
+	if req.Latitude == nil && req.Longitude == nil {
+		return nil, nil
+	}
+	if req.Latitude == nil || req.Longitude == nil {
+		return nil, ErrLocationInvalid
+	}
+	lat, lng := *req.Latitude, *req.Longitude
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, ErrLocationInvalid
+	}
+	accuracy := 0.0
+	if req.Accuracy != nil {
+		if *req.Accuracy < 0 || math.IsNaN(*req.Accuracy) {
+			return nil, ErrLocationInvalid
+		}
+		accuracy = *req.Accuracy
+	}
+	return reduceLocationPrecision(lat, lng, accuracy, s.locationPrecision), nil
+}
+
+func reduceLocationPrecision(lat, lng, accuracy float64, decimals int) *model.GeoPoint {
+	scale := math.Pow(10, float64(decimals))
+	point := &model.GeoPoint{
+		Latitude:  math.Round(lat*scale) / scale,
+		Longitude: math.Round(lng*scale) / scale,
+	}
+	// 四舍五入最多偏移半个格子的对角线。
+	step := 1 / scale
+	roundingError := math.Hypot(step*metersPerDegreeLat, step*metersPerDegreeLat*math.Cos(lat*math.Pi/180)) / 2
+	point.AccuracyM = math.Round(math.Max(accuracy, roundingError))
+	return point
+}
+
+// CaptureMap 以 GeoJSON FeatureCollection 返回孩子带位置的收集记录，可按范围过滤并按网格聚合。
+func (s *Service) CaptureMap(ctx context.Context, childID string, query MapQuery) (_ model.GeoJSONFeatureCollection, err error) {
+	ctx, span := startSpan(ctx, "CaptureMap")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	contains, err := mapFilter(query)
+	if err != nil {
+		return model.GeoJSONFeatureCollection{}, err
+	}
+	captures, err := st.ListCapturesByChild(childID)
+	if err != nil {
+		return model.GeoJSONFeatureCollection{}, err
+	}
+
+	located := make([]model.Capture, 0, len(captures))
+	for _, capture := range captures {
+		if capture.Location != nil && contains(*capture.Location) {
+			located = append(located, capture)
+		}
+	}
+	sort.SliceStable(located, func(i, j int) bool {
+		return located[i].CapturedAt.After(located[j].CapturedAt)
+	})
+
+	collection := model.GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []model.GeoJSONFeature{}}
+	if query.Cluster {
+		collection.Features = clusterCaptures(located, query.Zoom)
+		return collection, nil
+	}
+	for _, capture := range located {
+		collection.Features = append(collection.Features, captureFeature(capture))
+	}
+	return collection, nil
+}
+
+func mapFilter(query MapQuery) (func(model.GeoPoint) bool, error) {
+	if query.Zoom < 0 || query.Zoom > maxMapZoom {
+		return nil, fmt.Errorf("%w: zoom 必须在 0 到 %d 之间", ErrMapQueryInvalid, maxMapZoom)
+	}
+	switch {
+	case len(query.BBox) > 0 && query.Center != nil:
+		return nil, fmt.Errorf("%w: bbox 与半径查询只能二选一", ErrMapQueryInvalid)
+	case len(query.BBox) > 0:
+		if len(query.BBox) != 4 {
+			return nil, fmt.Errorf("%w: bbox 格式为 minLng,minLat,maxLng,maxLat", ErrMapQueryInvalid)
+		}
+		minLng, minLat, maxLng, maxLat := query.BBox[0], query.BBox[1], query.BBox[2], query.BBox[3]
+		if minLat > maxLat || minLat < -90 || maxLat > 90 || minLng < -180 || maxLng > 180 {
+			return nil, fmt.Errorf("%w: bbox 超出经纬度范围", ErrMapQueryInvalid)
+		}
+		return func(p model.GeoPoint) bool {
+			if p.Latitude < minLat || p.Latitude > maxLat {
+				return false
+			}
+			// minLng > maxLng 表示范围跨越 180° 经线。
+			if minLng <= maxLng {
+				return p.Longitude >= minLng && p.Longitude <= maxLng
+			}
+			return p.Longitude >= minLng || p.Longitude <= maxLng
+		}, nil
+	case query.Center != nil:
+		center := *query.Center
+		if center.Latitude < -90 || center.Latitude > 90 || center.Longitude < -180 || center.Longitude > 180 {
+			return nil, fmt.Errorf("%w: 中心点超出经纬度范围", ErrMapQueryInvalid)
+		}
+		if query.RadiusM <= 0 || query.RadiusM > maxMapRadiusM {
+			return nil, fmt.Errorf("%w: radius_m 必须在 0 到 %.0f 之间", ErrMapQueryInvalid, maxMapRadiusM)
+		}
+		return func(p model.GeoPoint) bool {
+			return haversineMeters(center, p) <= query.RadiusM
+		}, nil
+	default:
+		return func(model.GeoPoint) bool { return true }, nil
+	}
+}
+
+func haversineMeters(a, b model.GeoPoint) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func captureFeature(capture model.Capture) model.GeoJSONFeature {
+	return model.GeoJSONFeature{
+		Type: "Feature",
+		Geometry: model.GeoJSONGeometry{
+			Type:        "Point",
+			Coordinates: []float64{capture.Location.Longitude, capture.Location.Latitude},
+		},
+		Properties: map[string]any{
+			"capture_id":  capture.ID,
+			"spirit_id":   capture.SpiritID,
+			"spirit_name": capture.SpiritName,
+			"object_type": capture.ObjectType,
+			"captured_at": capture.CapturedAt,
+			"accuracy_m":  capture.Location.AccuracyM,
+		},
+	}
+}
+
+// clusterCaptures 按经纬度网格聚合收集记录：网格边长约为该缩放级别下 1/4 个 256px 瓦片，
+// 单点网格仍按普通要素返回，多点网格返回位于质心的聚合要素。
+func clusterCaptures(captures []model.Capture, zoom int) []model.GeoJSONFeature {
+	cell := 360 / math.Pow(2, float64(zoom)) / clusterCellsPerTile
+
+	type bucket struct {
+		captures []model.Capture
+		latSum   float64
+		lngSum   float64
+	}
+	buckets := make(map[[2]int]*bucket)
+	order := make([][2]int, 0)
+	for _, capture := range captures {
+		key := [2]int{
+			int(math.Floor((capture.Location.Longitude + 180) / cell)),
+			int(math.Floor((capture.Location.Latitude + 90) / cell)),
+		}
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{}
+			buckets[key] = b
+			order = append(order, key)
+		}
+		b.captures = append(b.captures, capture)
+		b.latSum += capture.Location.Latitude
+		b.lngSum += capture.Location.Longitude
+	}
+
+	features := make([]model.GeoJSONFeature, 0, len(order))
+	for _, key := range order {
+		b := buckets[key]
+		if len(b.captures) == 1 {
+			features = append(features, captureFeature(b.captures[0]))
+			continue
+		}
+		count := float64(len(b.captures))
+		features = append(features, model.GeoJSONFeature{
+			Type: "Feature",
+			Geometry: model.GeoJSONGeometry{
+				Type:        "Point",
+				Coordinates: []float64{roundCoord(b.lngSum / count), roundCoord(b.latSum / count)},
+			},
+			Properties: map[string]any{
+				"cluster":      true,
+				"point_count":  len(b.captures),
+				"object_types": topObjectTypes(b.captures, clusterTopTypes),
+				"latest_at":    b.captures[0].CapturedAt,
+			},
+		})
+	}
+	return features
+}
+
+func topObjectTypes(captures []model.Capture, limit int) []string {
+	counts := make(map[string]int)
+	for _, capture := range captures {
+		counts[capture.ObjectType]++
+	}
+	types := make([]string, 0, len(counts))
+	for objectType := range counts {
+		types = append(types, objectType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if counts[types[i]] != counts[types[j]] {
+			return counts[types[i]] > counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	if len(types) > limit {
+		types = types[:limit]
+	}
+	return types
+}
+
+func roundCoord(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
@@ -0,0 +1,180 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+func TestScanLocationIsRoundedAndCarriedIntoCapture(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	lat, lng, accuracy := 31.230416, 121.473701, 8.0
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
+		ChildID:       "kid_geo",
+		ChildAge:      8,
+		DetectedLabel: "tree",
+		Latitude:      &lat,
+		Longitude:     &lng,
+		Accuracy:      &accuracy,
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	session, ok, err := st.GetSession(scanResp.SessionID)
+	if err != nil || !ok {
+		t.Fatalf("GetSession() error = %v, ok=%v", err, ok)
+	}
+	if session.Location == nil || session.Location.Latitude != 31.23 || session.Location.Longitude != 121.474 {
+		t.Fatalf("expected session location rounded to 3 decimals, got %+v", session.Location)
+	}
+	if session.Location.AccuracyM < 50 {
+		t.Fatalf("expected accuracy to cover the rounding error, got %v", session.Location.AccuracyM)
+	}
+
+	if _, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
+		SessionID: scanResp.SessionID,
+		ChildID:   "kid_geo",
+		Answer:    session.QuizA,
+	}); err != nil {
+		t.Fatalf("SubmitAnswer() error = %v", err)
+	}
+	captures, err := st.ListCapturesByChild("kid_geo")
+	if err != nil || len(captures) != 1 {
+		t.Fatalf("ListCapturesByChild() = %d captures, err=%v", len(captures), err)
+	}
+	if captures[0].Location == nil || *captures[0].Location != *session.Location {
+		t.Fatalf("expected capture location %+v, got %+v", session.Location, captures[0].Location)
+	}
+
+	collection, err := svc.CaptureMap(context.Background(), "kid_geo", service.MapQuery{})
+	if err != nil {
+		t.Fatalf("CaptureMap() error = %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 1 {
+		t.Fatalf("expected one feature, got %+v", collection)
+	}
+	coords := collection.Features[0].Geometry.Coordinates
+	if len(coords) != 2 || coords[0] != 121.474 || coords[1] != 31.23 {
+		t.Fatalf("expected [lng, lat] coordinates, got %v", coords)
+	}
+}
+
+func TestScanRejectsInvalidLocation(t *testing.T) {
+	t.Parallel()
+
+	svc, _ := newTestService(t)
+	lat, badLng := 31.23, 200.0
+	cases := []service.ScanRequest{
+		{ChildID: "kid_geo", ChildAge: 8, DetectedLabel: "tree", Latitude: &lat},
+		{ChildID: "kid_geo", ChildAge: 8, DetectedLabel: "tree", Latitude: &lat, Longitude: &badLng},
+	}
+	for _, req := range cases {
+		if _, err := svc.Scan(context.Background(), req); !errors.Is(err, service.ErrLocationInvalid) {
+			t.Fatalf("expected ErrLocationInvalid, got %v", err)
+		}
+	}
+}
+
+func TestCaptureMapFiltersAndClusters(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	now := time.Now().UTC()
+	points := []struct {
+		id         string
+		objectType string
+		location   *model.GeoPoint
+	}{
+		{id: "cap_a", objectType: "tree", location: &model.GeoPoint{Latitude: 31.230, Longitude: 121.473}},
+		{id: "cap_b", objectType: "tree", location: &model.GeoPoint{Latitude: 31.231, Longitude: 121.474}},
+		{id: "cap_c", objectType: "mailbox", location: &model.GeoPoint{Latitude: 31.232, Longitude: 121.473}},
+		{id: "cap_d", objectType: "bench", location: &model.GeoPoint{Latitude: 39.904, Longitude: 116.407}},
+		{id: "cap_e", objectType: "tree"},
+	}
+	for i, p := range points {
+		err := st.AddCapture(model.Capture{
+			ID:         p.id,
+			ChildID:    "kid_map",
+			SpiritID:   "spirit_" + p.objectType,
+			ObjectType: p.objectType,
+			CapturedAt: now.Add(time.Duration(i) * time.Minute),
+			Location:   p.location,
+		})
+		if err != nil {
+			t.Fatalf("AddCapture() error = %v", err)
+		}
+	}
+
+	all, err := svc.CaptureMap(context.Background(), "kid_map", service.MapQuery{})
+	if err != nil {
+		t.Fatalf("CaptureMap() error = %v", err)
+	}
+	if len(all.Features) != 4 || all.Features[0].Properties["capture_id"] != "cap_d" {
+		t.Fatalf("expected 4 located captures newest first, got %+v", all.Features)
+	}
+
+	inBox, err := svc.CaptureMap(context.Background(), "kid_map", service.MapQuery{BBox: []float64{121.4, 31.2, 121.5, 31.3}})
+	if err != nil {
+		t.Fatalf("CaptureMap(bbox) error = %v", err)
+	}
+	if len(inBox.Features) != 3 {
+		t.Fatalf("expected 3 captures in bbox, got %d", len(inBox.Features))
+	}
+
+	nearby, err := svc.CaptureMap(context.Background(), "kid_map", service.MapQuery{
+		Center:  &model.GeoPoint{Latitude: 31.230, Longitude: 121.473},
+		RadiusM: 150,
+	})
+	if err != nil {
+		t.Fatalf("CaptureMap(radius) error = %v", err)
+	}
+	if len(nearby.Features) != 2 {
+		t.Fatalf("expected 2 captures within 150m, got %d", len(nearby.Features))
+	}
+
+	clustered, err := svc.CaptureMap(context.Background(), "kid_map", service.MapQuery{Cluster: true, Zoom: 8})
+	if err != nil {
+		t.Fatalf("CaptureMap(cluster) error = %v", err)
+	}
+	if len(clustered.Features) != 2 {
+		t.Fatalf("expected a Shanghai cluster and a Beijing point, got %+v", clustered.Features)
+	}
+	var cluster model.GeoJSONFeature
+	for _, feature := range clustered.Features {
+		if feature.Properties["cluster"] == true {
+			cluster = feature
+		}
+	}
+	if cluster.Properties["point_count"] != 3 {
+		t.Fatalf("expected cluster of 3, got %+v", cluster.Properties)
+	}
+	types, _ := cluster.Properties["object_types"].([]string)
+	if len(types) != 2 || types[0] != "tree" {
+		t.Fatalf("expected tree to lead cluster object types, got %v", types)
+	}
+}
+
+func TestCaptureMapRejectsInvalidQuery(t *testing.T) {
+	t.Parallel()
+
+	svc, _ := newTestService(t)
+	queries := []service.MapQuery{
+		{BBox: []float64{121.4, 31.2, 121.5}},
+		{BBox: []float64{121.4, 31.3, 121.5, 31.2}},
+		{Center: &model.GeoPoint{Latitude: 31.2, Longitude: 121.4}},
+		{Center: &model.GeoPoint{Latitude: 31.2, Longitude: 121.4}, RadiusM: 200000},
+		{BBox: []float64{121.4, 31.2, 121.5, 31.3}, Center: &model.GeoPoint{Latitude: 31.2, Longitude: 121.4}, RadiusM: 100},
+		{Cluster: true, Zoom: 30},
+	}
+	for _, query := range queries {
+		if _, err := svc.CaptureMap(context.Background(), "kid_map", query); !errors.Is(err, service.ErrMapQueryInvalid) {
+			t.Fatalf("query %+v: expected ErrMapQueryInvalid, got %v", query, err)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"ling/internal/delivery"
+	"ling/internal/i18n"
+	"ling/internal/model"
+	"ling/internal/report"
+	"ling/internal/tracing"
+)
+
+const (
+	DeliveryChannelEmail   = "email"
+	DeliveryChannelWebhook = "webhook"
+
+	DeliveryStatusSent     = "sent"
+	DeliveryStatusRetrying = "retrying"
+	DeliveryStatusFailed   = "failed"
+
+	deliveryTriggerScheduled = "scheduled"
+	deliveryTriggerManual    = "manual"
+
+	deliverySendAtLayout    = "15:04"
+	defaultDeliveryTimezone = "Asia/Shanghai"
+	deliveryAttemptTimeout  = 60 * time.Second
+	deliveryEventDaily      = "daily_report"
+)
+
+// deliveryRetryBackoff 为第 n 次失败后的等待时间；用完后放弃当天的投递。
+var deliveryRetryBackoff = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute}
+
+// SetDeliveryChannel 注册一种推送方式；同名方式会被替换。
+func (s *Service) SetDeliveryChannel(channel delivery.Channel) {
+	s.deliveryMu.Lock()
+	defer s.deliveryMu.Unlock()
+	s.deliveryChannels[channel.Name()] = channel
+}
+
+// SetReportRenderer 指定推送邮件时使用的 HTML/PDF 渲染器。
+func (s *Service) SetReportRenderer(renderer *report.Renderer) {
+	if renderer == nil {
+		return
+	}
+	s.deliveryMu.Lock()
+	defer s.deliveryMu.Unlock()
+	s.renderer = renderer
+}
+
+func (s *Service) DeliveryChannels() []string {
+	s.deliveryMu.Lock()
+	defer s.deliveryMu.Unlock()
+	names := make([]string, 0, len(s.deliveryChannels))
+	for name := range s.deliveryChannels {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (s *Service) ListDeliverySubscriptions(ctx context.Context) (_ []model.DeliverySubscription, err error) {
+	ctx, span := startSpan(ctx, "ListDeliverySubscriptions")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	subs, err := st.ListDeliverySubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	if subs == nil {
+		subs = []model.DeliverySubscription{}
+	}
+	return subs, nil
+}
+
+func (s *Service) CreateDeliverySubscription(ctx context.Context, req DeliverySubscriptionRequest) (_ model.DeliverySubscription, err error) {
+	ctx, span := startSpan(ctx, "CreateDeliverySubscription")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	now := time.Now()
+	sub := model.DeliverySubscription{
+		ID:        s.newID("sub"),
+		Enabled:   true,
+		CreatedAt: now,
+	}
+	if err := s.applyDeliverySubscription(&sub, req); err != nil {
+		return model.DeliverySubscription{}, err
+	}
+	sub.UpdatedAt = now
+	if err := st.SaveDeliverySubscription(sub); err != nil {
+		return model.DeliverySubscription{}, err
+	}
+	return sub, nil
+}
+
+func (s *Service) UpdateDeliverySubscription(ctx context.Context, id string, req DeliverySubscriptionRequest) (_ model.DeliverySubscription, err error) {
+	ctx, span := startSpan(ctx, "UpdateDeliverySubscription")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	s.deliveryMu.Lock()
+	defer s.deliveryMu.Unlock()
+	sub, ok, err := st.GetDeliverySubscription(strings.TrimSpace(id))
+	if err != nil {
+		return model.DeliverySubscription{}, err
+	}
+	if !ok {
+		return model.DeliverySubscription{}, ErrDeliveryNotFound
+	}
+	if err := s.applyDeliverySubscriptionLocked(&sub, req); err != nil {
+		return model.DeliverySubscription{}, err
+	}
+	sub.UpdatedAt = time.Now()
+	if err := st.SaveDeliverySubscription(sub); err != nil {
+		return model.DeliverySubscription{}, err
+	}
+	return sub, nil
+}
+
+func (s *Service) DeleteDeliverySubscription(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteDeliverySubscription")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	s.deliveryMu.Lock()
+	defer s.deliveryMu.Unlock()
+	id = strings.TrimSpace(id)
+	if _, ok, err := st.GetDeliverySubscription(id); err != nil {
+		return err
+	} else if !ok {
+		return ErrDeliveryNotFound
+	}
+	return st.DeleteDeliverySubscription(id)
+}
+
+// DeliveryAttempts 返回投递记录（最新在前），可按订阅与状态过滤。
+func (s *Service) DeliveryAttempts(ctx context.Context, subscriptionID string, status string, limit int) (_ []model.DeliveryAttempt, err error) {
+	ctx, span := startSpan(ctx, "DeliveryAttempts")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	status = strings.TrimSpace(status)
+	storeLimit := limit
+	if status != "" {
+		storeLimit = 0
+	}
+	attempts, err := st.ListDeliveryAttempts(strings.TrimSpace(subscriptionID), storeLimit)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.DeliveryAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		if status != "" && attempt.Status != status {
+			continue
+		}
+		result = append(result, attempt)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (s *Service) applyDeliverySubscription(sub *model.DeliverySubscription, req DeliverySubscriptionRequest) error {
+	s.deliveryMu.Lock()
+	defer s.deliveryMu.Unlock()
+	return s.applyDeliverySubscriptionLocked(sub, req)
+}
+
+func (s *Service) applyDeliverySubscriptionLocked(sub *model.DeliverySubscription, req DeliverySubscriptionRequest) error {
+	sub.ParentID = strings.TrimSpace(req.ParentID)
+	sub.ChildID = strings.TrimSpace(req.ChildID)
+	sub.Channel = strings.ToLower(strings.TrimSpace(req.Channel))
+	sub.Target = strings.TrimSpace(req.Target)
+	sub.SendAt = strings.TrimSpace(req.SendAt)
+	sub.Timezone = strings.TrimSpace(req.Timezone)
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+	if sub.ChildID == "" {
+		return fmt.Errorf("%w: 请提供 child_id", ErrDeliveryInvalid)
+	}
+	if _, ok := s.deliveryChannels[sub.Channel]; !ok {
+		return fmt.Errorf("%w: %q", ErrDeliveryChannelUnavailable, sub.Channel)
+	}
+	switch sub.Channel {
+	case DeliveryChannelEmail:
+		if _, err := mail.ParseAddress(sub.Target); err != nil {
+			return fmt.Errorf("%w: target 不是有效的邮箱地址", ErrDeliveryInvalid)
+		}
+	case DeliveryChannelWebhook:
+		u, err := url.Parse(sub.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: target 必须是 http(s) 地址", ErrDeliveryInvalid)
+		}
+	}
+	if _, err := time.Parse(deliverySendAtLayout, sub.SendAt); err != nil {
+		return fmt.Errorf("%w: send_at 必须是 HH:MM 格式", ErrDeliveryInvalid)
+	}
+	if sub.Timezone == "" {
+		sub.Timezone = defaultDeliveryTimezone
+	}
+	if _, err := time.LoadLocation(sub.Timezone); err != nil {
+		return fmt.Errorf("%w: 无法识别时区 %s", ErrDeliveryInvalid, sub.Timezone)
+	}
+	return nil
+}
+
+// RunDeliveryScheduler 按 interval 轮询订阅并投递到期的日报，直到 ctx 结束。
+func (s *Service) RunDeliveryScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.DeliverDueReports(ctx, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "delivery scheduler error", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDueReports 投递所有在 now 时已到发送时刻、当天尚未完成的订阅，以及到了重试时间的失败投递。
+func (s *Service) DeliverDueReports(ctx context.Context, now time.Time) (_ []model.DeliveryAttempt, err error) {
+	ctx, span := startSpan(ctx, "DeliverDueReports")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	s.deliveryMu.Lock()
+	defer s.deliveryMu.Unlock()
+	subs, err := st.ListDeliverySubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	attempts := make([]model.DeliveryAttempt, 0)
+	for _, sub := range subs {
+		day, due := deliveryDue(sub, now)
+		if !due {
+			continue
+		}
+		attempt, err := s.deliverLocked(ctx, sub, day, now, deliveryTriggerScheduled)
+		if err != nil {
+			return attempts, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, nil
+}
+
+// SendDeliveryNow 立即推送订阅当天的日报，不受发送时刻限制，常用于验证配置。
+func (s *Service) SendDeliveryNow(ctx context.Context, id string) (_ model.DeliveryAttempt, err error) {
+	ctx, span := startSpan(ctx, "SendDeliveryNow")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	s.deliveryMu.Lock()
+	defer s.deliveryMu.Unlock()
+	sub, ok, err := st.GetDeliverySubscription(strings.TrimSpace(id))
+	if err != nil {
+		return model.DeliveryAttempt{}, err
+	}
+	if !ok {
+		return model.DeliveryAttempt{}, ErrDeliveryNotFound
+	}
+	loc, err := time.LoadLocation(sub.Timezone)
+	if err != nil {
+		return model.DeliveryAttempt{}, err
+	}
+	now := time.Now()
+	return s.deliverLocked(ctx, sub, now.In(loc), now, deliveryTriggerManual)
+}
+
+// deliveryDue 判断订阅在 now 时是否需要投递，并返回订阅时区下的报告日期。
+func deliveryDue(sub model.DeliverySubscription, now time.Time) (time.Time, bool) {
+	if !sub.Enabled {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(sub.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	local := now.In(loc)
+	date := local.Format(progressDateLayout)
+	if sub.PendingDate == date {
+		return local, sub.NextAttemptAt == nil || !now.Before(*sub.NextAttemptAt)
+	}
+	if sub.LastDeliveredDate == date {
+		return time.Time{}, false
+	}
+	sendAt, err := time.Parse(deliverySendAtLayout, sub.SendAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), sendAt.Hour(), sendAt.Minute(), 0, 0, loc)
+	return local, !local.Before(scheduled)
+}
+
+// deliverLocked 生成日报并推送，记录一次投递尝试并更新订阅的重试状态。调用方需持有 deliveryMu。
+func (s *Service) deliverLocked(ctx context.Context, sub model.DeliverySubscription, day time.Time, now time.Time, trigger string) (model.DeliveryAttempt, error) {
+	st := s.storeFor(ctx)
+	date := day.Format(progressDateLayout)
+	attemptNo := 1
+	if trigger == deliveryTriggerScheduled && sub.PendingDate == date {
+		attemptNo = sub.PendingAttempts + 1
+	}
+	attempt := model.DeliveryAttempt{
+		ID:             s.newID("delivery"),
+		SubscriptionID: sub.ID,
+		ChildID:        sub.ChildID,
+		Channel:        sub.Channel,
+		Target:         sub.Target,
+		ReportDate:     date,
+		Trigger:        trigger,
+		Attempt:        attemptNo,
+		Status:         DeliveryStatusSent,
+		CreatedAt:      now,
+	}
+
+	sendErr := s.sendDailyReportLocked(ctx, sub, day)
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+		attempt.Status = DeliveryStatusFailed
+		slog.WarnContext(ctx, "delivery failed", "subscription_id", sub.ID, "channel", sub.Channel, "date", date, "attempt", attemptNo, "err", sendErr)
+	}
+
+	// 手动推送只留下记录，不影响定时投递的进度。
+	if trigger == deliveryTriggerScheduled {
+		switch {
+		case sendErr == nil || attemptNo > len(deliveryRetryBackoff):
+			sub.LastDeliveredDate = date
+			sub.PendingDate = ""
+			sub.PendingAttempts = 0
+			sub.NextAttemptAt = nil
+		default:
+			attempt.Status = DeliveryStatusRetrying
+			next := now.Add(deliveryRetryBackoff[attemptNo-1])
+			sub.PendingDate = date
+			sub.PendingAttempts = attemptNo
+			sub.NextAttemptAt = &next
+		}
+		sub.UpdatedAt = now
+		if err := st.SaveDeliverySubscription(sub); err != nil {
+			return model.DeliveryAttempt{}, err
+		}
+	}
+	if err := st.AddDeliveryAttempt(attempt); err != nil {
+		return model.DeliveryAttempt{}, err
+	}
+	return attempt, nil
+}
+
+func (s *Service) sendDailyReportLocked(ctx context.Context, sub model.DeliverySubscription, day time.Time) error {
+	channel, ok := s.deliveryChannels[sub.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrDeliveryChannelUnavailable, sub.Channel)
+	}
+	dailyReport, err := s.DailyReport(ctx, sub.ChildID, day, "")
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryAttemptTimeout)
+	defer cancel()
+	msg := delivery.Message{
+		To:    sub.Target,
+		Event: deliveryEventDaily,
+		Payload: map[string]any{
+			"event":           deliveryEventDaily,
+			"subscription_id": sub.ID,
+			"parent_id":       sub.ParentID,
+			"child_id":        dailyReport.ChildID,
+			"date":            dailyReport.Date,
+			"report":          dailyReport,
+		},
+	}
+	if sub.Channel == DeliveryChannelEmail {
+		if err := s.composeDailyReportEmail(ctx, &msg, dailyReport, s.resolveLocale(ctx, sub.ChildID, "")); err != nil {
+			return err
+		}
+	}
+	return channel.Send(ctx, msg)
+}
+
+func (s *Service) composeDailyReportEmail(ctx context.Context, msg *delivery.Message, dailyReport model.DailyReport, locale i18n.Locale) error {
+	var html, pdf bytes.Buffer
+	if err := s.renderer.DailyHTML(&html, dailyReport); err != nil {
+		return err
+	}
+	if err := s.renderer.DailyPDF(ctx, &pdf, dailyReport); err != nil {
+		return err
+	}
+
+	var text strings.Builder
+	text.WriteString(dailyReport.GeneratedText)
+	if len(dailyReport.Suggestions) > 0 {
+		text.WriteString("\n\n" + i18n.Text(locale, "report.email.suggestions"))
+		for _, suggestion := range dailyReport.Suggestions {
+			text.WriteString("\n- " + suggestion)
+		}
+	}
+
+	msg.Subject = i18n.Text(locale, "report.email.subject", dailyReport.Date, dailyReport.ChildID)
+	msg.Text = text.String()
+	msg.HTML = html.String()
+	msg.Attachments = []delivery.Attachment{{
+		Filename:    fmt.Sprintf("cityling-daily-%s-%s.pdf", dailyReport.ChildID, dailyReport.Date),
+		ContentType: "application/pdf",
+		Data:        pdf.Bytes(),
+	}}
+	return nil
+}
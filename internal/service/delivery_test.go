@@ -0,0 +1,198 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ling/internal/delivery"
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+type fakeChannel struct {
+	name string
+
+	mu       sync.Mutex
+	fail     bool
+	messages []delivery.Message
+}
+
+func (c *fakeChannel) Name() string {
+	return c.name
+}
+
+func (c *fakeChannel) Send(_ context.Context, msg delivery.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, msg)
+	if c.fail {
+		return errors.New("mailbox unavailable")
+	}
+	return nil
+}
+
+func TestDeliverDueReportsSendsOncePerDay(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	email := &fakeChannel{name: service.DeliveryChannelEmail}
+	svc.SetDeliveryChannel(email)
+
+	sub, err := svc.CreateDeliverySubscription(context.Background(), service.DeliverySubscriptionRequest{
+		ParentID: "mum",
+		ChildID:  "kid_delivery",
+		Channel:  "email",
+		Target:   "mum@example.com",
+		SendAt:   "20:00",
+		Timezone: "UTC",
+	})
+	if err != nil {
+		t.Fatalf("CreateDeliverySubscription() error = %v", err)
+	}
+	day := time.Date(2026, 2, 13, 0, 0, 0, 0, time.UTC)
+	if err := st.AddCapture(model.Capture{ID: "c1", ChildID: "kid_delivery", SpiritName: "树爷爷", ObjectType: "tree", Fact: "树会呼吸", CapturedAt: day.Add(9 * time.Hour)}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+
+	if attempts, err := svc.DeliverDueReports(context.Background(), day.Add(19*time.Hour)); err != nil || len(attempts) != 0 {
+		t.Fatalf("expected nothing before send_at, got %+v err=%v", attempts, err)
+	}
+	attempts, err := svc.DeliverDueReports(context.Background(), day.Add(20*time.Hour+time.Minute))
+	if err != nil {
+		t.Fatalf("DeliverDueReports() error = %v", err)
+	}
+	if len(attempts) != 1 || attempts[0].Status != service.DeliveryStatusSent || attempts[0].ReportDate != "2026-02-13" {
+		t.Fatalf("unexpected attempts %+v", attempts)
+	}
+	msg := email.messages[0]
+	if msg.To != "mum@example.com" || !strings.Contains(msg.Subject, "2026-02-13") || !strings.Contains(msg.HTML, "树爷爷") {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if len(msg.Attachments) != 1 || !strings.HasPrefix(string(msg.Attachments[0].Data), "%PDF-") {
+		t.Fatalf("expected PDF attachment")
+	}
+
+	if attempts, _ := svc.DeliverDueReports(context.Background(), day.Add(22*time.Hour)); len(attempts) != 0 {
+		t.Fatalf("expected no second delivery on the same day, got %+v", attempts)
+	}
+	stored, err := svc.DeliveryAttempts(context.Background(), sub.ID, "", 0)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("expected one persisted attempt, got %+v err=%v", stored, err)
+	}
+}
+
+func TestDeliverDueReportsEmailFollowsProfileLocale(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	email := &fakeChannel{name: service.DeliveryChannelEmail}
+	svc.SetDeliveryChannel(email)
+	if err := st.SaveChildProfile(model.ChildProfile{ChildID: "kid_delivery_en", Locale: "en"}); err != nil {
+		t.Fatalf("SaveChildProfile() error = %v", err)
+	}
+	if _, err := svc.CreateDeliverySubscription(context.Background(), service.DeliverySubscriptionRequest{
+		ParentID: "mum",
+		ChildID:  "kid_delivery_en",
+		Channel:  "email",
+		Target:   "mum@example.com",
+		SendAt:   "20:00",
+		Timezone: "UTC",
+	}); err != nil {
+		t.Fatalf("CreateDeliverySubscription() error = %v", err)
+	}
+	day := time.Date(2026, 2, 13, 0, 0, 0, 0, time.UTC)
+	if err := st.AddCapture(model.Capture{ID: "c1", ChildID: "kid_delivery_en", SpiritName: "Grandpa Tree", ObjectType: "tree", Fact: "Trees breathe", CapturedAt: day.Add(9 * time.Hour)}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+
+	if _, err := svc.DeliverDueReports(context.Background(), day.Add(20*time.Hour+time.Minute)); err != nil {
+		t.Fatalf("DeliverDueReports() error = %v", err)
+	}
+	if len(email.messages) != 1 {
+		t.Fatalf("expected one email, got %d", len(email.messages))
+	}
+	msg := email.messages[0]
+	if msg.Subject != "City Ling daily report · 2026-02-13 · kid_delivery_en" {
+		t.Fatalf("expected an english subject, got %q", msg.Subject)
+	}
+	if !strings.HasPrefix(msg.Text, "Today kid_delivery_en caught 1 spirits") || !strings.Contains(msg.Text, "Things to try together:") {
+		t.Fatalf("expected an english body, got %q", msg.Text)
+	}
+}
+
+func TestDeliverDueReportsRetriesThenGivesUp(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	webhook := &fakeChannel{name: service.DeliveryChannelWebhook, fail: true}
+	svc.SetDeliveryChannel(webhook)
+	sub, err := svc.CreateDeliverySubscription(context.Background(), service.DeliverySubscriptionRequest{
+		ChildID:  "kid_retry",
+		Channel:  "webhook",
+		Target:   "https://parents.example.com/hook",
+		SendAt:   "08:00",
+		Timezone: "UTC",
+	})
+	if err != nil {
+		t.Fatalf("CreateDeliverySubscription() error = %v", err)
+	}
+
+	now := time.Date(2026, 2, 13, 8, 0, 0, 0, time.UTC)
+	waits := []time.Duration{0, time.Minute, 5 * time.Minute, 30 * time.Minute}
+	for i, wait := range waits {
+		now = now.Add(wait)
+		if early, _ := svc.DeliverDueReports(context.Background(), now.Add(-time.Second)); i > 0 && len(early) != 0 {
+			t.Fatalf("attempt %d: expected backoff to be respected, got %+v", i+1, early)
+		}
+		attempts, err := svc.DeliverDueReports(context.Background(), now)
+		if err != nil || len(attempts) != 1 {
+			t.Fatalf("attempt %d: expected one attempt, got %+v err=%v", i+1, attempts, err)
+		}
+		want := service.DeliveryStatusRetrying
+		if i == len(waits)-1 {
+			want = service.DeliveryStatusFailed
+		}
+		if attempts[0].Attempt != i+1 || attempts[0].Status != want || attempts[0].Error == "" {
+			t.Fatalf("attempt %d: unexpected %+v", i+1, attempts[0])
+		}
+	}
+	if attempts, _ := svc.DeliverDueReports(context.Background(), now.Add(time.Hour)); len(attempts) != 0 {
+		t.Fatalf("expected delivery to be given up for the day, got %+v", attempts)
+	}
+	if payload, ok := webhook.messages[0].Payload.(map[string]any); !ok || payload["child_id"] != "kid_retry" {
+		t.Fatalf("unexpected webhook payload %+v", webhook.messages[0].Payload)
+	}
+
+	failed, err := svc.DeliveryAttempts(context.Background(), sub.ID, service.DeliveryStatusFailed, 10)
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("expected one final failure, got %+v err=%v", failed, err)
+	}
+}
+
+func TestCreateDeliverySubscriptionValidates(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+	req := service.DeliverySubscriptionRequest{ChildID: "kid", Channel: "email", Target: "mum@example.com", SendAt: "20:00"}
+	if _, err := svc.CreateDeliverySubscription(context.Background(), req); !errors.Is(err, service.ErrDeliveryChannelUnavailable) {
+		t.Fatalf("expected ErrDeliveryChannelUnavailable, got %v", err)
+	}
+
+	svc.SetDeliveryChannel(&fakeChannel{name: service.DeliveryChannelEmail})
+	for _, bad := range []service.DeliverySubscriptionRequest{
+		{ChildID: "kid", Channel: "email", Target: "not-an-email", SendAt: "20:00"},
+		{ChildID: "kid", Channel: "email", Target: "mum@example.com", SendAt: "8pm"},
+		{ChildID: "kid", Channel: "email", Target: "mum@example.com", SendAt: "20:00", Timezone: "Mars/Base"},
+	} {
+		if _, err := svc.CreateDeliverySubscription(context.Background(), bad); !errors.Is(err, service.ErrDeliveryInvalid) {
+			t.Fatalf("expected ErrDeliveryInvalid for %+v, got %v", bad, err)
+		}
+	}
+	sub, err := svc.CreateDeliverySubscription(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateDeliverySubscription() error = %v", err)
+	}
+	if sub.Timezone != "Asia/Shanghai" || !sub.Enabled {
+		t.Fatalf("unexpected defaults %+v", sub)
+	}
+}
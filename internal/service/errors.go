@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// 错误码是对客户端的稳定契约：文案可以调整或本地化，错误码一旦发布就不再改名。
+const (
+	CodeInternal           = "INTERNAL"
+	CodeRequestBodyInvalid = "REQUEST_BODY_INVALID"
+	CodeQueryInvalid       = "QUERY_INVALID"
+	CodeAdminDisabled      = "ADMIN_DISABLED"
+	CodeAdminUnauthorized  = "ADMIN_UNAUTHORIZED"
+	CodeLocaleUnsupported  = "LOCALE_UNSUPPORTED"
+	CodeRequestTimeout     = "REQUEST_TIMEOUT"
+	CodeRequestCanceled    = "REQUEST_CANCELED"
+	CodeRateLimited        = "RATE_LIMITED"
+
+	CodeObjectUnsupported    = "OBJECT_UNSUPPORTED"
+	CodeSessionNotFound      = "SESSION_NOT_FOUND"
+	CodeAlreadyCaptured      = "ALREADY_CAPTURED"
+	CodeLLMUnavailable       = "LLM_UNAVAILABLE"
+	CodeImageRequired        = "IMAGE_REQUIRED"
+	CodeScanInputRequired    = "SCAN_INPUT_REQUIRED"
+	CodeContentUnavailable   = "CONTENT_UNAVAILABLE"
+	CodeChildAgeInvalid      = "CHILD_AGE_INVALID"
+	CodeObjectTypeRequired   = "OBJECT_TYPE_REQUIRED"
+	CodeChildMessageRequired = "CHILD_MESSAGE_REQUIRED"
+	CodeTextRequired         = "TEXT_REQUIRED"
+	CodeMediaUnavailable     = "MEDIA_UNAVAILABLE"
+	CodeImageUploadFailed    = "IMAGE_UPLOAD_FAILED"
+	CodeCompanionTimeout     = "COMPANION_TIMEOUT"
+	CodeReviewNotFound       = "REVIEW_NOT_FOUND"
+	CodeAnswerRequired       = "ANSWER_REQUIRED"
+	CodeBadgeNotFound        = "BADGE_NOT_FOUND"
+	CodeBadgeExists          = "BADGE_EXISTS"
+	CodeBadgeInvalid         = "BADGE_INVALID"
+	CodeBadgeReadOnly        = "BADGE_READ_ONLY"
+	CodeReportPeriodInvalid  = "REPORT_PERIOD_INVALID"
+
+	CodeDeliveryNotFound           = "DELIVERY_NOT_FOUND"
+	CodeDeliveryInvalid            = "DELIVERY_INVALID"
+	CodeDeliveryChannelUnavailable = "DELIVERY_CHANNEL_UNAVAILABLE"
+
+	CodeLocationInvalid = "LOCATION_INVALID"
+	CodeMapQueryInvalid = "MAP_QUERY_INVALID"
+
+	CodeQuestNotFound       = "QUEST_NOT_FOUND"
+	CodeQuestNotCompleted   = "QUEST_NOT_COMPLETED"
+	CodeQuestAlreadyClaimed = "QUEST_ALREADY_CLAIMED"
+
+	CodeGroupNotFound           = "GROUP_NOT_FOUND"
+	CodeGroupInvalid            = "GROUP_INVALID"
+	CodeGroupForbidden          = "GROUP_FORBIDDEN"
+	CodeInviteCodeInvalid       = "INVITE_CODE_INVALID"
+	CodeLeaderboardQueryInvalid = "LEADERBOARD_QUERY_INVALID"
+
+	CodeCaptureNotFound = "CAPTURE_NOT_FOUND"
+	CodeShareInvalid    = "SHARE_INVALID"
+	CodeShareNotFound   = "SHARE_NOT_FOUND"
+	CodeShareExpired    = "SHARE_EXPIRED"
+	CodeTradeNotFound   = "TRADE_NOT_FOUND"
+	CodeTradeInvalid    = "TRADE_INVALID"
+	CodeTradeForbidden  = "TRADE_FORBIDDEN"
+	CodeTradeConflict   = "TRADE_CONFLICT"
+)
+
+// Error 是带稳定错误码的业务错误。Status 为对应的 HTTP 状态码，Message 为默认文案，
+// Details 携带可选的结构化补充信息（如取值范围、过期时间）。
+//
+// 调用方仍按 errors.Is(err, ErrXxx) 判断；用 fmt.Errorf("%w: ...", ErrXxx) 补充上下文时错误码不变。
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Details map[string]any
+}
+
+var (
+	errorCatalogMu sync.Mutex
+	errorCatalog   = map[string]*Error{}
+)
+
+// NewError 创建业务错误并登记到错误码目录，供 OpenAPI 文档列出全部错误码。
+func NewError(code string, status int, message string) *Error {
+	e := &Error{Code: code, Status: status, Message: message}
+	errorCatalogMu.Lock()
+	if _, exists := errorCatalog[code]; !exists {
+		errorCatalog[code] = e
+	}
+	errorCatalogMu.Unlock()
+	return e
+}
+
+// ErrorCatalog 按错误码排序返回已登记的错误，INTERNAL 之外的错误都由 NewError 登记。
+func ErrorCatalog() []Error {
+	errorCatalogMu.Lock()
+	defer errorCatalogMu.Unlock()
+	result := make([]Error, 0, len(errorCatalog)+1)
+	result = append(result, Error{Code: CodeInternal, Status: http.StatusInternalServerError, Message: "服务内部错误"})
+	for _, e := range errorCatalog {
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is 按错误码比较，因此 WithDetails 得到的副本仍与原错误匹配。
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Code == e.Code
+}
+
+// WithDetails 返回附带补充信息的副本，不修改包级错误变量。
+func (e *Error) WithDetails(details map[string]any) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// StatusClientClosedRequest 沿用 nginx 的 499，表示客户端在处理完成前断开了连接，只出现在日志与指标里。
+const StatusClientClosedRequest = 499
+
+// AsError 取出错误链中的 *Error；请求超时与客户端断开分别归为 REQUEST_TIMEOUT 与 REQUEST_CANCELED，
+// 其他未识别的错误（存储、大模型等）归为 500 INTERNAL。
+func AsError(err error) *Error {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrRequestTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrRequestCanceled
+	}
+	return &Error{Code: CodeInternal, Status: http.StatusInternalServerError, Message: err.Error()}
+}
@@ -0,0 +1,551 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+const (
+	GroupKindFamily    = "family"
+	GroupKindClassroom = "classroom"
+
+	LeaderboardCaptures        = "captures"
+	LeaderboardDistinctObjects = "distinct_objects"
+	LeaderboardBadges          = "badges"
+	LeaderboardStreak          = "streak"
+
+	// DefaultLeaderboardWindow 为默认统计窗口：含今天在内的最近 7 天。
+	DefaultLeaderboardWindow = "7d"
+	leaderboardWindowAll     = "all"
+	maxLeaderboardWindowDays = 365
+
+	inviteCodeLength   = 8
+	inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	maxGroupNameRunes  = 40
+	maxGroupMembers    = 60
+	maxHideCountsAge   = 15
+	leaderboardMaxStar = 3
+
+	defaultGroupFeedLimit = 20
+	maxGroupFeedLimit     = 100
+)
+
+func (s *Service) CreateGroup(ctx context.Context, req GroupRequest) (_ model.Group, err error) {
+	ctx, span := startSpan(ctx, "CreateGroup")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	ownerID := strings.TrimSpace(req.OwnerID)
+	if ownerID == "" {
+		return model.Group{}, fmt.Errorf("%w: 请提供 owner_id", ErrGroupInvalid)
+	}
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+
+	code, err := s.uniqueInviteCodeLocked(ctx)
+	if err != nil {
+		return model.Group{}, err
+	}
+	now := time.Now()
+	group := model.Group{
+		ID:         s.newID("group"),
+		OwnerID:    ownerID,
+		Kind:       GroupKindFamily,
+		InviteCode: code,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := applyGroupRequest(&group, req); err != nil {
+		return model.Group{}, err
+	}
+	if err := st.SaveGroup(group); err != nil {
+		return model.Group{}, err
+	}
+	return group, nil
+}
+
+// UpdateGroup 修改群组名称、类型或隐藏数量设置，只有群主可以操作。
+func (s *Service) UpdateGroup(ctx context.Context, id string, req GroupRequest) (_ model.Group, err error) {
+	ctx, span := startSpan(ctx, "UpdateGroup")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+	group, err := s.ownedGroup(ctx, id, req.OwnerID)
+	if err != nil {
+		return model.Group{}, err
+	}
+	if err := applyGroupRequest(&group, req); err != nil {
+		return model.Group{}, err
+	}
+	group.UpdatedAt = time.Now()
+	if err := st.SaveGroup(group); err != nil {
+		return model.Group{}, err
+	}
+	return group, nil
+}
+
+// RegenerateInviteCode 让旧邀请码失效并生成新的，已加入的成员不受影响。
+func (s *Service) RegenerateInviteCode(ctx context.Context, id string, ownerID string) (_ model.Group, err error) {
+	ctx, span := startSpan(ctx, "RegenerateInviteCode")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+	group, err := s.ownedGroup(ctx, id, ownerID)
+	if err != nil {
+		return model.Group{}, err
+	}
+	code, err := s.uniqueInviteCodeLocked(ctx)
+	if err != nil {
+		return model.Group{}, err
+	}
+	group.InviteCode = code
+	group.UpdatedAt = time.Now()
+	if err := st.SaveGroup(group); err != nil {
+		return model.Group{}, err
+	}
+	return group, nil
+}
+
+// JoinGroup 凭邀请码把孩子加入群组；已是成员时更新昵称与年龄。
+func (s *Service) JoinGroup(ctx context.Context, req GroupJoinRequest) (_ GroupDetail, err error) {
+	ctx, span := startSpan(ctx, "JoinGroup")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	code := strings.ToUpper(strings.TrimSpace(req.InviteCode))
+	childID := strings.TrimSpace(req.ChildID)
+	if childID == "" {
+		return GroupDetail{}, fmt.Errorf("%w: 请提供 child_id", ErrGroupInvalid)
+	}
+	if req.ChildAge != 0 && (req.ChildAge < 3 || req.ChildAge > 15) {
+		return GroupDetail{}, ErrInvalidChildAge
+	}
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+
+	group, ok, err := st.GetGroupByInviteCode(code)
+	if err != nil {
+		return GroupDetail{}, err
+	}
+	if !ok || code == "" {
+		return GroupDetail{}, ErrInviteCodeInvalid
+	}
+	members, err := st.ListGroupMembers(group.ID)
+	if err != nil {
+		return GroupDetail{}, err
+	}
+	member := model.GroupMember{GroupID: group.ID, ChildID: childID, JoinedAt: time.Now()}
+	existing := false
+	for _, m := range members {
+		if m.ChildID == childID {
+			member, existing = m, true
+			break
+		}
+	}
+	if !existing && len(members) >= maxGroupMembers {
+		return GroupDetail{}, fmt.Errorf("%w: 群组成员已满 %d 人", ErrGroupInvalid, maxGroupMembers)
+	}
+	if name := strings.TrimSpace(req.DisplayName); name != "" {
+		member.DisplayName = truncateRunes(name, maxGroupNameRunes)
+	}
+	if member.DisplayName == "" {
+		member.DisplayName = childID
+	}
+	if req.ChildAge != 0 {
+		member.ChildAge = req.ChildAge
+	}
+	if err := st.SaveGroupMember(member); err != nil {
+		return GroupDetail{}, err
+	}
+	detail, err := s.groupDetail(ctx, group)
+	if err != nil {
+		return GroupDetail{}, err
+	}
+	return memberView(detail), nil
+}
+
+// RemoveGroupMember 让孩子退出群组；群主也可以移除任意成员。
+func (s *Service) RemoveGroupMember(ctx context.Context, id string, childID string, viewer GroupViewer) (err error) {
+	ctx, span := startSpan(ctx, "RemoveGroupMember")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	s.groupMu.Lock()
+	defer s.groupMu.Unlock()
+	group, ok, err := st.GetGroup(strings.TrimSpace(id))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrGroupNotFound
+	}
+	childID = strings.TrimSpace(childID)
+	if !isGroupOwner(group, viewer) && strings.TrimSpace(viewer.ChildID) != childID {
+		return ErrGroupForbidden
+	}
+	return st.DeleteGroupMember(group.ID, childID)
+}
+
+// Groups 返回孩子加入的群组（ChildID）或群主创建的群组（OwnerID）。
+func (s *Service) Groups(ctx context.Context, viewer GroupViewer) (_ []model.Group, err error) {
+	ctx, span := startSpan(ctx, "Groups")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	if childID := strings.TrimSpace(viewer.ChildID); childID != "" {
+		return st.ListGroupsByChild(childID)
+	}
+	if ownerID := strings.TrimSpace(viewer.OwnerID); ownerID != "" {
+		return st.ListGroups(ownerID)
+	}
+	return nil, fmt.Errorf("%w: 请提供 child_id 或 owner_id", ErrGroupInvalid)
+}
+
+func (s *Service) Group(ctx context.Context, id string, viewer GroupViewer) (_ GroupDetail, err error) {
+	ctx, span := startSpan(ctx, "Group")
+	defer func() { tracing.End(span, err) }()
+	group, _, err := s.viewableGroup(ctx, id, viewer)
+	if err != nil {
+		return GroupDetail{}, err
+	}
+	detail, err := s.groupDetail(ctx, group)
+	if err != nil {
+		return GroupDetail{}, err
+	}
+	if !isGroupOwner(group, viewer) {
+		detail = memberView(detail)
+	}
+	return detail, nil
+}
+
+// memberView 去掉只给群主看的信息：邀请码（避免孩子随意转发）与其他成员的年龄。
+func memberView(detail GroupDetail) GroupDetail {
+	detail.InviteCode = ""
+	members := make([]model.GroupMember, len(detail.Members))
+	for i, member := range detail.Members {
+		member.ChildAge = 0
+		members[i] = member
+	}
+	detail.Members = members
+	return detail
+}
+
+// GroupLeaderboard 按指标统计群组成员在窗口内的成绩；window 为 all 或 Nd（含今天在内的最近 N 天）。
+func (s *Service) GroupLeaderboard(ctx context.Context, id string, metric string, window string, viewer GroupViewer) (_ GroupLeaderboard, err error) {
+	ctx, span := startSpan(ctx, "GroupLeaderboard")
+	defer func() { tracing.End(span, err) }()
+	group, members, err := s.viewableGroup(ctx, id, viewer)
+	if err != nil {
+		return GroupLeaderboard{}, err
+	}
+	metric = strings.ToLower(strings.TrimSpace(metric))
+	if metric == "" {
+		metric = LeaderboardCaptures
+	}
+	switch metric {
+	case LeaderboardCaptures, LeaderboardDistinctObjects, LeaderboardBadges, LeaderboardStreak:
+	default:
+		return GroupLeaderboard{}, fmt.Errorf("%w: metric 仅支持 captures、distinct_objects、badges、streak", ErrLeaderboardQueryInvalid)
+	}
+	now := time.Now()
+	window, since, err := parseLeaderboardWindow(window, now)
+	if err != nil {
+		return GroupLeaderboard{}, err
+	}
+
+	board := GroupLeaderboard{GroupID: group.ID, Metric: metric, Window: window, Entries: []LeaderboardEntry{}}
+	if metric != LeaderboardStreak && since != nil {
+		board.Since = since
+	}
+	viewerID := strings.TrimSpace(viewer.ChildID)
+	if group.HideCountsUnderAge > 0 && !isGroupOwner(group, viewer) {
+		for _, member := range members {
+			// 未填写年龄的孩子按年龄较小处理。
+			if member.ChildID == viewerID && (member.ChildAge == 0 || member.ChildAge < group.HideCountsUnderAge) {
+				board.CountsHidden = true
+			}
+		}
+	}
+
+	values := make([]int, len(members))
+	for i, member := range members {
+		value, err := s.leaderboardValue(ctx, member.ChildID, metric, board.Since, now)
+		if err != nil {
+			return GroupLeaderboard{}, err
+		}
+		values[i] = value
+	}
+	order := make([]int, len(members))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if values[order[a]] != values[order[b]] {
+			return values[order[a]] > values[order[b]]
+		}
+		return members[order[a]].DisplayName < members[order[b]].DisplayName
+	})
+
+	top := 0
+	if len(order) > 0 {
+		top = values[order[0]]
+	}
+	for pos, idx := range order {
+		member := members[idx]
+		value := values[idx]
+		entry := LeaderboardEntry{
+			Rank:        pos + 1,
+			ChildID:     member.ChildID,
+			DisplayName: member.DisplayName,
+			Stars:       leaderboardStars(value, top),
+			Me:          viewerID != "" && member.ChildID == viewerID,
+		}
+		// 并列时名次相同（1, 1, 3）。
+		if pos > 0 && values[order[pos-1]] == value {
+			entry.Rank = board.Entries[pos-1].Rank
+		}
+		if !board.CountsHidden {
+			entry.Value = &value
+		}
+		board.Entries = append(board.Entries, entry)
+	}
+	return board, nil
+}
+
+// GroupFeed 返回群组成员最近的收集动态（最新在前）。
+func (s *Service) GroupFeed(ctx context.Context, id string, limit int, viewer GroupViewer) (_ GroupFeed, err error) {
+	ctx, span := startSpan(ctx, "GroupFeed")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	group, members, err := s.viewableGroup(ctx, id, viewer)
+	if err != nil {
+		return GroupFeed{}, err
+	}
+	if limit <= 0 {
+		limit = defaultGroupFeedLimit
+	}
+	if limit > maxGroupFeedLimit {
+		limit = maxGroupFeedLimit
+	}
+	feed := GroupFeed{GroupID: group.ID, Items: []GroupFeedItem{}}
+	for _, member := range members {
+		captures, err := st.ListCapturesByChild(member.ChildID)
+		if err != nil {
+			return GroupFeed{}, err
+		}
+		for _, capture := range captures {
+			// 只展示加入群组之后的收集。
+			if capture.CapturedAt.Before(member.JoinedAt) {
+				continue
+			}
+			feed.Items = append(feed.Items, GroupFeedItem{
+				ChildID:     member.ChildID,
+				DisplayName: member.DisplayName,
+				SpiritName:  capture.SpiritName,
+				ObjectType:  capture.ObjectType,
+				CapturedAt:  capture.CapturedAt,
+			})
+		}
+	}
+	sort.SliceStable(feed.Items, func(i, j int) bool {
+		return feed.Items[i].CapturedAt.After(feed.Items[j].CapturedAt)
+	})
+	if len(feed.Items) > limit {
+		feed.Items = feed.Items[:limit]
+	}
+	return feed, nil
+}
+
+func (s *Service) leaderboardValue(ctx context.Context, childID string, metric string, since *time.Time, now time.Time) (int, error) {
+	st := s.storeFor(ctx)
+	inWindow := func(t time.Time) bool {
+		return since == nil || !t.Before(*since)
+	}
+	switch metric {
+	case LeaderboardBadges:
+		unlocks, err := st.ListBadgeUnlocksByChild(childID)
+		if err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, unlock := range unlocks {
+			if inWindow(unlock.UnlockedAt) {
+				count++
+			}
+		}
+		return count, nil
+	case LeaderboardStreak:
+		progress, _, err := st.GetProgress(childID)
+		if err != nil {
+			return 0, err
+		}
+		progress.ChildID = childID
+		return s.progressProfile(progress, now).CurrentStreak, nil
+	}
+
+	captures, err := st.ListCapturesByChild(childID)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	types := make(map[string]struct{})
+	for _, capture := range captures {
+		if !inWindow(capture.CapturedAt) {
+			continue
+		}
+		count++
+		types[normalizeBadgeToken(capture.ObjectType)] = struct{}{}
+	}
+	if metric == LeaderboardDistinctObjects {
+		return len(types), nil
+	}
+	return count, nil
+}
+
+func parseLeaderboardWindow(window string, now time.Time) (string, *time.Time, error) {
+	window = strings.ToLower(strings.TrimSpace(window))
+	switch window {
+	case "":
+		window = DefaultLeaderboardWindow
+	case leaderboardWindowAll:
+		return window, nil, nil
+	case "today":
+		window = "1d"
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(window, "d"))
+	if !strings.HasSuffix(window, "d") || err != nil || days <= 0 || days > maxLeaderboardWindowDays {
+		return "", nil, fmt.Errorf("%w: window 必须是 all、today 或 1d-%dd", ErrLeaderboardQueryInvalid, maxLeaderboardWindowDays)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(days - 1))
+	return window, &start, nil
+}
+
+// leaderboardStars 按与第一名的差距给出 1-3 颗星，数量为 0 时不给星。
+func leaderboardStars(value int, top int) int {
+	if value <= 0 || top <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(value) * leaderboardMaxStar / float64(top)))
+}
+
+func applyGroupRequest(group *model.Group, req GroupRequest) error {
+	if name := strings.TrimSpace(req.Name); name != "" {
+		group.Name = truncateRunes(name, maxGroupNameRunes)
+	}
+	if group.Name == "" {
+		return fmt.Errorf("%w: 请提供 name", ErrGroupInvalid)
+	}
+	if kind := strings.ToLower(strings.TrimSpace(req.Kind)); kind != "" {
+		if kind != GroupKindFamily && kind != GroupKindClassroom {
+			return fmt.Errorf("%w: kind 仅支持 family 或 classroom", ErrGroupInvalid)
+		}
+		group.Kind = kind
+	}
+	if req.HideCountsUnderAge != nil {
+		age := *req.HideCountsUnderAge
+		if age < 0 || age > maxHideCountsAge+1 {
+			return fmt.Errorf("%w: hide_counts_under_age 必须在 0 到 %d 之间", ErrGroupInvalid, maxHideCountsAge+1)
+		}
+		group.HideCountsUnderAge = age
+	}
+	return nil
+}
+
+func (s *Service) ownedGroup(ctx context.Context, id string, ownerID string) (model.Group, error) {
+	st := s.storeFor(ctx)
+	group, ok, err := st.GetGroup(strings.TrimSpace(id))
+	if err != nil {
+		return model.Group{}, err
+	}
+	if !ok {
+		return model.Group{}, ErrGroupNotFound
+	}
+	if !isGroupOwner(group, GroupViewer{OwnerID: ownerID}) {
+		return model.Group{}, ErrGroupForbidden
+	}
+	return group, nil
+}
+
+// viewableGroup 返回群组与成员，查看者必须是群主或成员。
+func (s *Service) viewableGroup(ctx context.Context, id string, viewer GroupViewer) (model.Group, []model.GroupMember, error) {
+	st := s.storeFor(ctx)
+	group, ok, err := st.GetGroup(strings.TrimSpace(id))
+	if err != nil {
+		return model.Group{}, nil, err
+	}
+	if !ok {
+		return model.Group{}, nil, ErrGroupNotFound
+	}
+	members, err := st.ListGroupMembers(group.ID)
+	if err != nil {
+		return model.Group{}, nil, err
+	}
+	if isGroupOwner(group, viewer) {
+		return group, members, nil
+	}
+	if childID := strings.TrimSpace(viewer.ChildID); childID != "" {
+		for _, member := range members {
+			if member.ChildID == childID {
+				return group, members, nil
+			}
+		}
+	}
+	return model.Group{}, nil, ErrGroupForbidden
+}
+
+func (s *Service) groupDetail(ctx context.Context, group model.Group) (GroupDetail, error) {
+	st := s.storeFor(ctx)
+	members, err := st.ListGroupMembers(group.ID)
+	if err != nil {
+		return GroupDetail{}, err
+	}
+	return GroupDetail{Group: group, Members: members}, nil
+}
+
+func isGroupOwner(group model.Group, viewer GroupViewer) bool {
+	ownerID := strings.TrimSpace(viewer.OwnerID)
+	return ownerID != "" && ownerID == group.OwnerID
+}
+
+func (s *Service) uniqueInviteCodeLocked(ctx context.Context) (string, error) {
+	st := s.storeFor(ctx)
+	for attempt := 0; attempt < 10; attempt++ {
+		code, err := newInviteCode()
+		if err != nil {
+			return "", err
+		}
+		if _, exists, err := st.GetGroupByInviteCode(code); err != nil {
+			return "", err
+		} else if !exists {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("生成邀请码失败，请重试")
+}
+
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
+
+// newInviteCode 生成不含易混淆字符（0/O、1/I/L）的邀请码。
+func newInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,172 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+func TestGroupInviteAndMembership(t *testing.T) {
+	t.Parallel()
+
+	svc, _ := newTestService(t)
+	group, err := svc.CreateGroup(context.Background(), service.GroupRequest{Name: "三年二班", Kind: "classroom", OwnerID: "teacher_1"})
+	if err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+	if len(group.InviteCode) != 8 || group.Kind != "classroom" {
+		t.Fatalf("unexpected group %+v", group)
+	}
+	if _, err := svc.CreateGroup(context.Background(), service.GroupRequest{Name: "x", Kind: "club", OwnerID: "teacher_1"}); !errors.Is(err, service.ErrGroupInvalid) {
+		t.Fatalf("expected ErrGroupInvalid for unknown kind, got %v", err)
+	}
+
+	detail, err := svc.JoinGroup(context.Background(), service.GroupJoinRequest{InviteCode: group.InviteCode, ChildID: "kid_a", DisplayName: "小安", ChildAge: 8})
+	if err != nil {
+		t.Fatalf("JoinGroup() error = %v", err)
+	}
+	if len(detail.Members) != 1 || detail.InviteCode != "" || detail.Members[0].ChildAge != 0 {
+		t.Fatalf("expected member view without invite code or ages, got %+v", detail)
+	}
+
+	owned, err := svc.Group(context.Background(), group.ID, service.GroupViewer{OwnerID: "teacher_1"})
+	if err != nil {
+		t.Fatalf("Group() error = %v", err)
+	}
+	if owned.InviteCode != group.InviteCode || owned.Members[0].ChildAge != 8 {
+		t.Fatalf("expected owner view with invite code and ages, got %+v", owned)
+	}
+	if _, err := svc.Group(context.Background(), group.ID, service.GroupViewer{ChildID: "stranger"}); !errors.Is(err, service.ErrGroupForbidden) {
+		t.Fatalf("expected ErrGroupForbidden for non-member, got %v", err)
+	}
+
+	rotated, err := svc.RegenerateInviteCode(context.Background(), group.ID, "teacher_1")
+	if err != nil {
+		t.Fatalf("RegenerateInviteCode() error = %v", err)
+	}
+	if _, err := svc.JoinGroup(context.Background(), service.GroupJoinRequest{InviteCode: group.InviteCode, ChildID: "kid_b"}); !errors.Is(err, service.ErrInviteCodeInvalid) {
+		t.Fatalf("expected old invite code to be rejected, got %v", err)
+	}
+	if _, err := svc.RegenerateInviteCode(context.Background(), group.ID, "kid_a"); !errors.Is(err, service.ErrGroupForbidden) {
+		t.Fatalf("expected only the owner to rotate codes, got %v", err)
+	}
+	if _, err := svc.JoinGroup(context.Background(), service.GroupJoinRequest{InviteCode: rotated.InviteCode, ChildID: "kid_b"}); err != nil {
+		t.Fatalf("JoinGroup() with new code error = %v", err)
+	}
+
+	groups, err := svc.Groups(context.Background(), service.GroupViewer{ChildID: "kid_b"})
+	if err != nil || len(groups) != 1 || groups[0].ID != group.ID {
+		t.Fatalf("Groups(kid_b) = %+v, err=%v", groups, err)
+	}
+	if err := svc.RemoveGroupMember(context.Background(), group.ID, "kid_b", service.GroupViewer{ChildID: "kid_a"}); !errors.Is(err, service.ErrGroupForbidden) {
+		t.Fatalf("expected children to only remove themselves, got %v", err)
+	}
+	if err := svc.RemoveGroupMember(context.Background(), group.ID, "kid_b", service.GroupViewer{ChildID: "kid_b"}); err != nil {
+		t.Fatalf("RemoveGroupMember() error = %v", err)
+	}
+	if groups, _ := svc.Groups(context.Background(), service.GroupViewer{ChildID: "kid_b"}); len(groups) != 0 {
+		t.Fatalf("expected kid_b to have left, got %+v", groups)
+	}
+}
+
+func TestGroupLeaderboardAndFeed(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	hideUnder := 7
+	group, err := svc.CreateGroup(context.Background(), service.GroupRequest{Name: "我们家", OwnerID: "parent_1", HideCountsUnderAge: &hideUnder})
+	if err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+
+	// 加入前的收集不出现在动态里，但计入不限时间的排行榜。
+	if err := st.AddCapture(model.Capture{ID: "cap_old", ChildID: "kid_big", ObjectType: "tree", CapturedAt: time.Now().AddDate(0, 0, -30)}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+	for _, join := range []service.GroupJoinRequest{
+		{InviteCode: group.InviteCode, ChildID: "kid_big", DisplayName: "哥哥", ChildAge: 9},
+		{InviteCode: group.InviteCode, ChildID: "kid_small", DisplayName: "妹妹", ChildAge: 5},
+		{InviteCode: group.InviteCode, ChildID: "kid_mid", DisplayName: "表弟", ChildAge: 8},
+	} {
+		if _, err := svc.JoinGroup(context.Background(), join); err != nil {
+			t.Fatalf("JoinGroup() error = %v", err)
+		}
+	}
+	captureObject(t, svc, st, "kid_big", "tree")
+	captureObject(t, svc, st, "kid_big", "mailbox")
+	captureObject(t, svc, st, "kid_small", "tree")
+	captureObject(t, svc, st, "kid_small", "tree")
+
+	board, err := svc.GroupLeaderboard(context.Background(), group.ID, "captures", "", service.GroupViewer{ChildID: "kid_big"})
+	if err != nil {
+		t.Fatalf("GroupLeaderboard() error = %v", err)
+	}
+	if board.Window != "7d" || board.Since == nil || board.CountsHidden {
+		t.Fatalf("unexpected leaderboard header %+v", board)
+	}
+	if len(board.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", board.Entries)
+	}
+	first, second, third := board.Entries[0], board.Entries[1], board.Entries[2]
+	if first.Rank != 1 || second.Rank != 1 || *first.Value != 2 || *second.Value != 2 {
+		t.Fatalf("expected a tie for first with 2 captures, got %+v %+v", first, second)
+	}
+	if third.ChildID != "kid_mid" || third.Rank != 3 || *third.Value != 0 || third.Stars != 0 {
+		t.Fatalf("expected kid_mid last with no stars, got %+v", third)
+	}
+
+	distinct, err := svc.GroupLeaderboard(context.Background(), group.ID, "distinct_objects", "all", service.GroupViewer{OwnerID: "parent_1"})
+	if err != nil {
+		t.Fatalf("GroupLeaderboard(distinct_objects) error = %v", err)
+	}
+	if distinct.Since != nil || distinct.Entries[0].ChildID != "kid_big" || *distinct.Entries[0].Value != 2 {
+		t.Fatalf("expected kid_big to lead distinct objects, got %+v", distinct.Entries)
+	}
+
+	hidden, err := svc.GroupLeaderboard(context.Background(), group.ID, "captures", "today", service.GroupViewer{ChildID: "kid_small"})
+	if err != nil {
+		t.Fatalf("GroupLeaderboard(young viewer) error = %v", err)
+	}
+	if !hidden.CountsHidden {
+		t.Fatal("expected counts hidden for a viewer under the age threshold")
+	}
+	for _, entry := range hidden.Entries {
+		if entry.Value != nil {
+			t.Fatalf("expected no exact values, got %+v", entry)
+		}
+		if entry.Me != (entry.ChildID == "kid_small") {
+			t.Fatalf("expected only the viewer to be marked, got %+v", entry)
+		}
+	}
+	if hidden.Entries[0].Stars != 3 {
+		t.Fatalf("expected leaders to get 3 stars, got %+v", hidden.Entries[0])
+	}
+
+	if _, err := svc.GroupLeaderboard(context.Background(), group.ID, "captures", "2w", service.GroupViewer{OwnerID: "parent_1"}); !errors.Is(err, service.ErrLeaderboardQueryInvalid) {
+		t.Fatalf("expected ErrLeaderboardQueryInvalid for bad window, got %v", err)
+	}
+	if _, err := svc.GroupLeaderboard(context.Background(), group.ID, "likes", "", service.GroupViewer{OwnerID: "parent_1"}); !errors.Is(err, service.ErrLeaderboardQueryInvalid) {
+		t.Fatalf("expected ErrLeaderboardQueryInvalid for bad metric, got %v", err)
+	}
+
+	feed, err := svc.GroupFeed(context.Background(), group.ID, 3, service.GroupViewer{ChildID: "kid_mid"})
+	if err != nil {
+		t.Fatalf("GroupFeed() error = %v", err)
+	}
+	if len(feed.Items) != 3 {
+		t.Fatalf("expected feed limited to 3 items, got %+v", feed.Items)
+	}
+	for i := 1; i < len(feed.Items); i++ {
+		if feed.Items[i].CapturedAt.After(feed.Items[i-1].CapturedAt) {
+			t.Fatalf("expected newest first, got %+v", feed.Items)
+		}
+	}
+	all, err := svc.GroupFeed(context.Background(), group.ID, 0, service.GroupViewer{OwnerID: "parent_1"})
+	if err != nil || len(all.Items) != 4 {
+		t.Fatalf("expected 4 feed items since joining, got %d err=%v", len(all.Items), err)
+	}
+}
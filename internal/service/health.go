@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ling/internal/store"
+	"ling/internal/tracing"
+)
+
+// 依赖状态。
+const (
+	DependencyOK        = "ok"
+	DependencyFailing   = "failing"
+	DependencySkipped   = "skipped"
+	DependencyUnchecked = "unchecked"
+	DependencyDisabled  = "disabled"
+)
+
+// 就绪状态。
+const (
+	ReadinessReady        = "ready"
+	ReadinessNotReady     = "not_ready"
+	ReadinessShuttingDown = "shutting_down"
+)
+
+const (
+	storePingTimeout     = 2 * time.Second
+	upstreamProbeTimeout = 5 * time.Second
+)
+
+// DependencyStatus 是一个依赖的检查结果。Required 的依赖失败时服务不就绪；上游能力失败只会让对应功能降级
+// （扫描回退本地知识库、陪伴场景不出图等），不影响就绪。
+type DependencyStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Required  bool      `json:"required"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+	// Cached 表示结果来自上一次上游探测的缓存。
+	Cached bool   `json:"cached,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// Ready 报告服务是否可以接收流量。
+func (r ReadinessResponse) Ready() bool {
+	return r.Status == ReadinessReady
+}
+
+type probeCache struct {
+	results  []DependencyStatus
+	expireAt time.Time
+}
+
+// SetUpstreamProbeTTL 开启就绪检查中的上游能力探测，结果缓存 ttl，避免探针频繁请求上游；ttl <= 0 时不探测。
+func (s *Service) SetUpstreamProbeTTL(ttl time.Duration) {
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	s.probeTTL = ttl
+	s.probeCache = probeCache{}
+}
+
+// BeginShutdown 标记服务正在退出，之后的就绪检查都返回 shutting_down，让负载均衡先摘除流量再排空请求。
+func (s *Service) BeginShutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// Readiness 检查存储读写与（开启时）上游能力，返回每个依赖的状态。
+func (s *Service) Readiness(ctx context.Context) (_ ReadinessResponse, err error) {
+	ctx, span := startSpan(ctx, "Readiness")
+	defer func() { tracing.End(span, err) }()
+
+	deps := []DependencyStatus{s.checkStore(ctx)}
+	deps = append(deps, s.checkUpstreams(ctx)...)
+
+	status := ReadinessReady
+	for _, dep := range deps {
+		if dep.Required && dep.Status == DependencyFailing {
+			status = ReadinessNotReady
+		}
+	}
+	if s.shuttingDown.Load() {
+		status = ReadinessShuttingDown
+	}
+	return ReadinessResponse{Status: status, Dependencies: deps}, nil
+}
+
+func (s *Service) checkStore(ctx context.Context) DependencyStatus {
+	dep := DependencyStatus{Name: "store", Required: true, CheckedAt: time.Now().UTC()}
+	pinger, ok := s.storeFor(ctx).(store.Pinger)
+	if !ok {
+		dep.Status = DependencySkipped
+		return dep
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, storePingTimeout)
+	defer cancel()
+	began := time.Now()
+	err := pinger.Ping(pingCtx)
+	dep.LatencyMS = time.Since(began).Milliseconds()
+	if errors.Is(err, store.ErrPingUnsupported) {
+		dep.Status = DependencySkipped
+		return dep
+	}
+	if err != nil {
+		dep.Status = DependencyFailing
+		dep.Error = err.Error()
+		return dep
+	}
+	dep.Status = DependencyOK
+	return dep
+}
+
+// checkUpstreams 返回上游能力的探测结果。缓存未过期时直接复用；探测期间持有 probeMu，
+// 并发的就绪检查会等待同一次探测而不是各自请求上游。
+func (s *Service) checkUpstreams(ctx context.Context) []DependencyStatus {
+	if s.llm == nil {
+		return []DependencyStatus{{Name: "llm", Status: DependencyDisabled, CheckedAt: time.Now().UTC()}}
+	}
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	if s.probeTTL <= 0 {
+		return []DependencyStatus{{Name: "llm", Status: DependencyUnchecked, CheckedAt: time.Now().UTC()}}
+	}
+	if time.Now().Before(s.probeCache.expireAt) {
+		cached := make([]DependencyStatus, len(s.probeCache.results))
+		for i, dep := range s.probeCache.results {
+			dep.Cached = true
+			cached[i] = dep
+		}
+		return cached
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, upstreamProbeTimeout)
+	defer cancel()
+	checkedAt := time.Now().UTC()
+	began := time.Now()
+	results := s.llm.Probe(probeCtx)
+	latency := time.Since(began).Milliseconds()
+
+	deps := make([]DependencyStatus, 0, len(results))
+	for _, result := range results {
+		dep := DependencyStatus{Name: "llm." + result.Capability, CheckedAt: checkedAt}
+		switch {
+		case result.Skipped:
+			dep.Status = DependencySkipped
+		case result.Err != nil:
+			dep.Status = DependencyFailing
+			dep.LatencyMS = latency
+			dep.Error = result.Err.Error()
+		default:
+			dep.Status = DependencyOK
+			dep.LatencyMS = latency
+		}
+		deps = append(deps, dep)
+	}
+	// 调用方已断开时结果不可信，不写入缓存。
+	if ctx.Err() == nil {
+		s.probeCache = probeCache{results: deps, expireAt: time.Now().Add(s.probeTTL)}
+	}
+	return deps
+}
@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ling/internal/knowledge"
+	"ling/internal/llm"
+	"ling/internal/service"
+	"ling/internal/store"
+)
+
+type failingPingStore struct {
+	store.Store
+}
+
+func (failingPingStore) Ping(context.Context) error {
+	return errors.New("database is locked")
+}
+
+func TestReadinessChecksStore(t *testing.T) {
+	t.Parallel()
+
+	svc, _ := newTestService(t)
+	resp, err := svc.Readiness(context.Background())
+	if err != nil {
+		t.Fatalf("Readiness() error = %v", err)
+	}
+	if !resp.Ready() {
+		t.Fatalf("expected ready, got %+v", resp)
+	}
+	if len(resp.Dependencies) != 2 {
+		t.Fatalf("expected store and llm dependencies, got %+v", resp.Dependencies)
+	}
+	if dep := resp.Dependencies[0]; dep.Name != "store" || dep.Status != service.DependencyOK || !dep.Required {
+		t.Fatalf("unexpected store status %+v", dep)
+	}
+	if dep := resp.Dependencies[1]; dep.Name != "llm" || dep.Status != service.DependencyDisabled {
+		t.Fatalf("unexpected llm status %+v", dep)
+	}
+
+	svc.BeginShutdown()
+	resp, _ = svc.Readiness(context.Background())
+	if resp.Status != service.ReadinessShuttingDown {
+		t.Fatalf("expected shutting_down after BeginShutdown, got %q", resp.Status)
+	}
+}
+
+func TestReadinessFailsWhenStorePingFails(t *testing.T) {
+	t.Parallel()
+
+	_, st := newTestService(t)
+	svc := service.New(failingPingStore{Store: st}, knowledge.BaseKnowledge)
+	resp, err := svc.Readiness(context.Background())
+	if err != nil {
+		t.Fatalf("Readiness() error = %v", err)
+	}
+	if resp.Status != service.ReadinessNotReady {
+		t.Fatalf("expected not_ready, got %+v", resp)
+	}
+	if dep := resp.Dependencies[0]; dep.Status != service.DependencyFailing || dep.Error != "database is locked" {
+		t.Fatalf("expected failing store with error, got %+v", dep)
+	}
+}
+
+type pinglessStore struct {
+	store.Store
+}
+
+func TestReadinessSkipsStoreWithoutPing(t *testing.T) {
+	t.Parallel()
+
+	_, st := newTestService(t)
+	observed := store.Observe(pinglessStore{Store: st}, func(context.Context, string, time.Time, error) {})
+	svc := service.New(observed, knowledge.BaseKnowledge)
+	resp, err := svc.Readiness(context.Background())
+	if err != nil {
+		t.Fatalf("Readiness() error = %v", err)
+	}
+	if dep := resp.Dependencies[0]; dep.Status != service.DependencySkipped {
+		t.Fatalf("expected store without Ping to be skipped, got %+v", dep)
+	}
+	if !resp.Ready() {
+		t.Fatalf("a store that cannot be checked must not make the service unready, got %+v", resp)
+	}
+}
+
+func TestReadinessCachesUpstreamProbes(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	client, err := llm.NewClient(llm.Config{
+		APIKey:       "expired",
+		BaseURL:      upstream.URL,
+		ImageBaseURL: upstream.URL,
+		VoiceBaseURL: upstream.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	svc, _ := newTestService(t)
+	svc.SetLLMClient(client)
+	svc.SetUpstreamProbeTTL(time.Minute)
+
+	first, _ := svc.Readiness(context.Background())
+	if !first.Ready() {
+		t.Fatalf("upstream failures must not make the service unready, got %+v", first)
+	}
+	chat := first.Dependencies[1]
+	if chat.Name != "llm.chat" || chat.Status != service.DependencyFailing || chat.Required || chat.Cached {
+		t.Fatalf("unexpected chat probe status %+v", chat)
+	}
+
+	second, _ := svc.Readiness(context.Background())
+	if !second.Dependencies[1].Cached {
+		t.Fatalf("expected cached probe result, got %+v", second.Dependencies[1])
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected one upstream probe, got %d", got)
+	}
+}
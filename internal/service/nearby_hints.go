@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ling/internal/i18n"
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+const (
+	// nearbyCellDegrees 为附近提示使用的网格边长（约 1.1 公里），查询点先对齐到网格中心，再取周围 3×3 个网格。
+	nearbyCellDegrees = 0.01
+	nearbyCellRadius  = 1
+	// nearbyMinChildren 为 k-匿名阈值：区域内和每种对象都至少要有这么多个其他孩子收集过才会出现在提示里。
+	nearbyMinChildren = 3
+	// nearbyCommonChildren 及以上记为 common，否则记为 seen；接口不返回精确人数。
+	nearbyCommonChildren = 10
+	// nearbyCaptureDelay 内的收集不参与统计，避免从提示变化推断某个孩子刚刚去过哪里。
+	nearbyCaptureDelay = 24 * time.Hour
+
+	defaultNearbyHintLimit = 5
+	maxNearbyHintLimit     = 20
+
+	nearbyPopularityCommon = "common"
+	nearbyPopularitySeen   = "seen"
+)
+
+// NearbyHints 根据粗略位置，返回附近其他孩子常收集、而该孩子尚未收集的对象类型，
+// 优先推荐能补齐勋章的对象。统计只使用聚合后的匿名数据，并受 k-匿名阈值与时间延迟约束。
+// locale 为请求语言（如 Accept-Language），孩子资料中的设置优先。
+func (s *Service) NearbyHints(ctx context.Context, childID string, lat float64, lng float64, limit int, locale string) (_ NearbyHints, err error) {
+	ctx, traceSpan := startSpan(ctx, "NearbyHints")
+	defer func() { tracing.End(traceSpan, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	loc := s.locale(ctx, childID, locale)
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return NearbyHints{}, ErrLocationInvalid
+	}
+	if limit <= 0 {
+		limit = defaultNearbyHintLimit
+	}
+	if limit > maxNearbyHintLimit {
+		limit = maxNearbyHintLimit
+	}
+
+	cellLat := math.Floor(lat / nearbyCellDegrees)
+	cellLng := math.Floor(lng / nearbyCellDegrees)
+	center := model.GeoPoint{
+		Latitude:  roundCoord((cellLat + 0.5) * nearbyCellDegrees),
+		Longitude: roundCoord((cellLng + 0.5) * nearbyCellDegrees),
+	}
+	span := (float64(nearbyCellRadius) + 0.5) * nearbyCellDegrees
+	result := NearbyHints{
+		ChildID: childID,
+		Area:    center,
+		RadiusM: math.Round(span * metersPerDegreeLat),
+		Hints:   []NearbyHint{},
+	}
+
+	captures, err := st.ListCapturesInArea(
+		center.Latitude-span, math.Max(-180, center.Longitude-span),
+		center.Latitude+span, math.Min(180, center.Longitude+span),
+	)
+	if err != nil {
+		return NearbyHints{}, err
+	}
+	own, err := st.ListCapturesByChild(childID)
+	if err != nil {
+		return NearbyHints{}, err
+	}
+	owned := make(map[string]bool, len(own))
+	for _, capture := range own {
+		owned[capture.ObjectType] = true
+	}
+
+	cutoff := time.Now().Add(-nearbyCaptureDelay)
+	contributors := make(map[string]bool)
+	childrenByType := make(map[string]map[string]bool)
+	for _, capture := range captures {
+		if capture.ChildID == childID || capture.CapturedAt.After(cutoff) {
+			continue
+		}
+		contributors[capture.ChildID] = true
+		if owned[capture.ObjectType] {
+			continue
+		}
+		if childrenByType[capture.ObjectType] == nil {
+			childrenByType[capture.ObjectType] = make(map[string]bool)
+		}
+		childrenByType[capture.ObjectType][capture.ChildID] = true
+	}
+	if len(contributors) < nearbyMinChildren {
+		return result, nil
+	}
+
+	advancer, err := s.newBadgeAdvancer(ctx, childID, own)
+	if err != nil {
+		return NearbyHints{}, err
+	}
+	for objectType, children := range childrenByType {
+		if len(children) < nearbyMinChildren {
+			continue
+		}
+		hint := NearbyHint{
+			ObjectType: objectType,
+			ObjectName: s.objectName(objectType, loc),
+			Popularity: nearbyPopularitySeen,
+			Badge:      advancer.closest(childID, objectType),
+		}
+		if len(children) >= nearbyCommonChildren {
+			hint.Popularity = nearbyPopularityCommon
+		}
+		hint.Hint = nearbyHintText(hint, loc)
+		result.Hints = append(result.Hints, hint)
+	}
+
+	sort.Slice(result.Hints, func(i, j int) bool {
+		a, b := result.Hints[i], result.Hints[j]
+		if (a.Badge != nil) != (b.Badge != nil) {
+			return a.Badge != nil
+		}
+		if a.Badge != nil && a.Badge.Remaining != b.Badge.Remaining {
+			return a.Badge.Remaining < b.Badge.Remaining
+		}
+		if na, nb := len(childrenByType[a.ObjectType]), len(childrenByType[b.ObjectType]); na != nb {
+			return na > nb
+		}
+		return a.ObjectType < b.ObjectType
+	})
+	if len(result.Hints) > limit {
+		result.Hints = result.Hints[:limit]
+	}
+	return result, nil
+}
+
+// badgeAdvancer 通过模拟“再收集一次该对象”判断它能推进哪些尚未点亮的勋章。
+type badgeAdvancer struct {
+	rules    []BadgeRule
+	captures []model.Capture
+	base     map[string]criterionResult
+	open     map[string]model.PokedexBadge
+	now      time.Time
+}
+
+func (s *Service) newBadgeAdvancer(ctx context.Context, childID string, captures []model.Capture) (*badgeAdvancer, error) {
+	badges, err := s.PokedexBadges(ctx, childID, "")
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[string]model.PokedexBadge, len(badges))
+	for _, badge := range badges {
+		if !badge.Unlocked && badge.Available {
+			open[badge.ID] = badge
+		}
+	}
+	rules, _ := s.badgeCatalog()
+	return &badgeAdvancer{
+		rules:    rules,
+		captures: captures,
+		base:     evaluateBadgeRules(rules, &badgeEvalContext{captures: captures}),
+		open:     open,
+		now:      time.Now(),
+	}, nil
+}
+
+// closest 返回收集该对象后进度会增加、且离点亮最近的勋章。
+func (a *badgeAdvancer) closest(childID string, objectType string) *NearbyHintBadge {
+	if len(a.open) == 0 {
+		return nil
+	}
+	simulated := append(append([]model.Capture(nil), a.captures...), model.Capture{
+		ChildID:    childID,
+		ObjectType: objectType,
+		CapturedAt: a.now,
+	})
+	after := evaluateBadgeRules(a.rules, &badgeEvalContext{captures: simulated})
+
+	var best *NearbyHintBadge
+	for _, rule := range a.rules {
+		badge, ok := a.open[rule.ID]
+		before := a.base[rule.ID]
+		if !ok || after[rule.ID].Progress <= before.Progress {
+			continue
+		}
+		remaining := before.Target - before.Progress
+		if best == nil || remaining < best.Remaining ||
+			(remaining == best.Remaining && before.Progress > best.Progress) {
+			best = &NearbyHintBadge{
+				ID:        rule.ID,
+				Name:      badge.Name,
+				Progress:  before.Progress,
+				Target:    before.Target,
+				Remaining: remaining,
+			}
+		}
+	}
+	return best
+}
+
+func nearbyHintText(hint NearbyHint, locale i18n.Locale) string {
+	switch {
+	case hint.Badge != nil && hint.Badge.Remaining == 1:
+		return i18n.Text(locale, "nearby.hint.last_one", hint.ObjectName, hint.Badge.Name)
+	case hint.Badge != nil:
+		return i18n.Text(locale, "nearby.hint.progress", hint.ObjectName, hint.Badge.Name, hint.Badge.Progress, hint.Badge.Target)
+	default:
+		return i18n.Text(locale, "nearby.hint.seen", hint.ObjectName)
+	}
+}
@@ -0,0 +1,123 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/service"
+	"ling/internal/store"
+)
+
+func addLocatedCapture(t *testing.T, st *store.JSONStore, childID string, objectType string, at time.Time, lat float64, lng float64) {
+	t.Helper()
+	err := st.AddCapture(model.Capture{
+		ID:         fmt.Sprintf("cap_%s_%s_%d", childID, objectType, at.UnixNano()),
+		ChildID:    childID,
+		SpiritID:   "spirit_" + objectType,
+		ObjectType: objectType,
+		CapturedAt: at,
+		Location:   &model.GeoPoint{Latitude: lat, Longitude: lng},
+	})
+	if err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+}
+
+func TestNearbyHintsRankLastMissingBadgeExampleFirst(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	old := time.Now().Add(-72 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+
+	// 孩子已经收集了植物界的 9 个示例，只差草坪。
+	for _, example := range []string{"向日葵", "多肉植物", "松树", "银杏叶", "捕蝇草", "灵芝", "荷花", "枫叶", "玫瑰"} {
+		addLocatedCapture(t, st, "kid_self", example, old, 40.0, 116.0)
+	}
+	for i, kid := range []string{"kid_a", "kid_b", "kid_c"} {
+		lat := 31.231 + float64(i)*0.002
+		addLocatedCapture(t, st, kid, "manhole", old, lat, 121.472)
+		addLocatedCapture(t, st, kid, "草坪", old, lat, 121.474)
+		addLocatedCapture(t, st, kid, "松树", old, lat, 121.474)
+		addLocatedCapture(t, st, kid, "bench", recent, lat, 121.474)
+	}
+	addLocatedCapture(t, st, "kid_a", "road_sign", old, 31.231, 121.473)
+	addLocatedCapture(t, st, "kid_b", "road_sign", old, 31.231, 121.473)
+	addLocatedCapture(t, st, "kid_d", "fountain", old, 31.5, 121.9)
+
+	resp, err := svc.NearbyHints(context.Background(), "kid_self", 31.2345, 121.4731, 0, "")
+	if err != nil {
+		t.Fatalf("NearbyHints() error = %v", err)
+	}
+	if resp.Area.Latitude != 31.235 || resp.Area.Longitude != 121.475 {
+		t.Fatalf("expected area snapped to grid center, got %+v", resp.Area)
+	}
+	if len(resp.Hints) != 2 {
+		t.Fatalf("expected hints for 草坪 and manhole only, got %+v", resp.Hints)
+	}
+	first := resp.Hints[0]
+	if first.ObjectType != "草坪" || first.Badge == nil || first.Badge.ID != "badge_01_plantae" || first.Badge.Remaining != 1 {
+		t.Fatalf("expected the last missing plantae example first, got %+v", first)
+	}
+	if first.Popularity != "seen" {
+		t.Fatalf("expected bucketed popularity, got %q", first.Popularity)
+	}
+	if second := resp.Hints[1]; second.ObjectType != "manhole" || second.Badge != nil {
+		t.Fatalf("expected manhole without badge progress, got %+v", second)
+	}
+}
+
+func TestNearbyHintsRequireEnoughContributors(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	old := time.Now().Add(-72 * time.Hour)
+	for _, kid := range []string{"kid_a", "kid_b"} {
+		addLocatedCapture(t, st, kid, "manhole", old, 31.231, 121.472)
+	}
+
+	resp, err := svc.NearbyHints(context.Background(), "kid_self", 31.231, 121.472, 5, "")
+	if err != nil {
+		t.Fatalf("NearbyHints() error = %v", err)
+	}
+	if len(resp.Hints) != 0 {
+		t.Fatalf("expected no hints below the privacy threshold, got %+v", resp.Hints)
+	}
+
+	if _, err := svc.NearbyHints(context.Background(), "kid_self", 91, 121.472, 5, ""); !errors.Is(err, service.ErrLocationInvalid) {
+		t.Fatalf("expected ErrLocationInvalid, got %v", err)
+	}
+}
+
+func TestNearbyHintsFollowLocale(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	old := time.Now().Add(-72 * time.Hour)
+	for _, kid := range []string{"kid_a", "kid_b", "kid_c"} {
+		addLocatedCapture(t, st, kid, "manhole", old, 31.231, 121.472)
+	}
+	if err := st.SaveChildProfile(model.ChildProfile{ChildID: "kid_self", Locale: "zh-TW"}); err != nil {
+		t.Fatalf("SaveChildProfile() error = %v", err)
+	}
+
+	en, err := svc.NearbyHints(context.Background(), "kid_guest", 31.231, 121.472, 5, "en-US")
+	if err != nil {
+		t.Fatalf("NearbyHints() error = %v", err)
+	}
+	if len(en.Hints) != 1 || en.Hints[0].ObjectName != "manhole cover" || en.Hints[0].Hint != "Young explorers nearby often find the manhole cover. Go and look for one!" {
+		t.Fatalf("expected an english hint, got %+v", en.Hints)
+	}
+
+	tw, err := svc.NearbyHints(context.Background(), "kid_self", 31.231, 121.472, 5, "en")
+	if err != nil {
+		t.Fatalf("NearbyHints() error = %v", err)
+	}
+	if len(tw.Hints) != 1 || tw.Hints[0].ObjectName != "人孔蓋" || tw.Hints[0].Hint != "附近的小探險家常發現人孔蓋，去找找看吧！" {
+		t.Fatalf("expected the profile locale to win, got %+v", tw.Hints)
+	}
+}
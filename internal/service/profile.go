@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ling/internal/i18n"
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+// Profile 返回孩子的偏好设置，未设置过时返回空设置。
+func (s *Service) Profile(ctx context.Context, childID string) (_ model.ChildProfile, err error) {
+	ctx, span := startSpan(ctx, "Profile")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	profile, ok, err := st.GetChildProfile(childID)
+	if err != nil {
+		return model.ChildProfile{}, err
+	}
+	if !ok {
+		return model.ChildProfile{ChildID: childID}, nil
+	}
+	return profile, nil
+}
+
+// UpdateProfile 保存孩子的偏好设置，语言标签会归一为支持的语言。
+func (s *Service) UpdateProfile(ctx context.Context, req ProfileRequest) (_ model.ChildProfile, err error) {
+	ctx, span := startSpan(ctx, "UpdateProfile")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID := strings.TrimSpace(req.ChildID)
+	if childID == "" {
+		childID = "guest"
+	}
+	locale := ""
+	if raw := strings.TrimSpace(req.Locale); raw != "" {
+		parsed, ok := i18n.Parse(raw)
+		if !ok {
+			return model.ChildProfile{}, fmt.Errorf("%w: %s", ErrLocaleUnsupported, raw)
+		}
+		locale = string(parsed)
+	}
+	profile := model.ChildProfile{
+		ChildID:   childID,
+		Locale:    locale,
+		UpdatedAt: time.Now(),
+	}
+	if err := st.SaveChildProfile(profile); err != nil {
+		return model.ChildProfile{}, err
+	}
+	return profile, nil
+}
+
+// Locale 按与业务接口相同的顺序决定语言，供接口层在业务层尚未确定语言时本地化错误文案。
+func (s *Service) Locale(ctx context.Context, childID string, hint string) i18n.Locale {
+	return s.locale(ctx, childID, hint)
+}
+
+// locale 决定本次请求的语言：孩子资料里的设置优先，其次是请求携带的 locale（通常来自
+// Accept-Language），都没有时为简体中文。读取资料失败不影响请求本身，按未设置处理。
+// 结果同时记入请求的语言记录，错误响应沿用同一语言。
+func (s *Service) locale(ctx context.Context, childID string, hint string) i18n.Locale {
+	locale := s.resolveLocale(ctx, childID, hint)
+	i18n.SetRequestLocale(ctx, locale)
+	return locale
+}
+
+func (s *Service) resolveLocale(ctx context.Context, childID string, hint string) i18n.Locale {
+	st := s.storeFor(ctx)
+	if childID = strings.TrimSpace(childID); childID != "" {
+		if profile, ok, err := st.GetChildProfile(childID); err == nil && ok {
+			if locale, ok := i18n.Parse(profile.Locale); ok {
+				return locale
+			}
+		}
+	}
+	return i18n.Negotiate(hint)
+}
+
+// objectName 返回对象在该语言下的展示名：知识库的 DisplayNames 优先，
+// 中文回退到内置译名，其他语言使用对象类型本身。
+func (s *Service) objectName(objectType string, locale i18n.Locale) string {
+	if name := strings.TrimSpace(s.items[objectType].DisplayNames[string(locale)]); name != "" {
+		return name
+	}
+	if locale.Chinese() {
+		return objectTypeToChinese(objectType)
+	}
+	return strings.ReplaceAll(objectType, "_", " ")
+}
+
+// catalogText 返回规则文件条目在该语言下的文案：条目声明了目录键且目录里有该语言的译文时用译文，
+// 否则用规则文件里的原文（内置规则的原文为简体中文）。
+func catalogText(locale i18n.Locale, key string, fallback string) string {
+	if key != "" {
+		if message, ok := i18n.Lookup(locale, key); ok {
+			return message
+		}
+	}
+	return fallback
+}
+
+// catalogSubKey 拼出条目目录键下的子键；条目没有声明键时返回空串。
+func catalogSubKey(key string, field string) string {
+	if key == "" {
+		return ""
+	}
+	return key + "." + field
+}
+
+// ttsLanguage 为默认语言保留配置的 VoiceLangCode，其他语言按 locale 指定朗读语言。
+func ttsLanguage(locale i18n.Locale) string {
+	if locale == i18n.Default {
+		return ""
+	}
+	return locale.TTSLanguage()
+}
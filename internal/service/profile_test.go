@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ling/internal/service"
+)
+
+func TestScanAndAnswerFollowRequestLocale(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
+		ChildID:       "kid_en",
+		ChildAge:      8,
+		DetectedLabel: "mailbox",
+		Locale:        "en-US,en;q=0.9",
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if scanResp.ObjectName != "mailbox" {
+		t.Fatalf("expected english object name, got %q", scanResp.ObjectName)
+	}
+	if !strings.Contains(scanResp.Fact, "mailbox") || !strings.HasPrefix(scanResp.Dialogues[0], "Hi, I'm") {
+		t.Fatalf("expected english content, got fact=%q dialogues=%v", scanResp.Fact, scanResp.Dialogues)
+	}
+	if !strings.Contains(scanResp.Spirit.Intro, "city spirit") {
+		t.Fatalf("expected english spirit intro, got %q", scanResp.Spirit.Intro)
+	}
+
+	session, _, err := st.GetSession(scanResp.SessionID)
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	answerResp, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
+		SessionID: scanResp.SessionID,
+		ChildID:   "kid_en",
+		Answer:    session.QuizA,
+		Locale:    "en",
+	})
+	if err != nil {
+		t.Fatalf("SubmitAnswer() error = %v", err)
+	}
+	if answerResp.Message != "Correct! You caught the spirit." {
+		t.Fatalf("unexpected answer message %q", answerResp.Message)
+	}
+
+	zhResp, err := svc.Scan(context.Background(), service.ScanRequest{ChildID: "kid_zh", ChildAge: 8, DetectedLabel: "mailbox"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if zhResp.ObjectName != "邮箱" || zhResp.CacheHit {
+		t.Fatalf("expected a separate simplified chinese scan, got name=%q cache_hit=%v", zhResp.ObjectName, zhResp.CacheHit)
+	}
+}
+
+func TestProfileLocaleOverridesRequestLocale(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+
+	if _, err := svc.UpdateProfile(context.Background(), service.ProfileRequest{ChildID: "kid_tw", Locale: "fr"}); !errors.Is(err, service.ErrLocaleUnsupported) {
+		t.Fatalf("expected ErrLocaleUnsupported, got %v", err)
+	}
+	profile, err := svc.UpdateProfile(context.Background(), service.ProfileRequest{ChildID: "kid_tw", Locale: "zh-HK"})
+	if err != nil {
+		t.Fatalf("UpdateProfile() error = %v", err)
+	}
+	if profile.Locale != "zh-TW" {
+		t.Fatalf("expected locale normalised to zh-TW, got %q", profile.Locale)
+	}
+
+	captureObject(t, svc, st, "kid_tw", "tree")
+	report, err := svc.DailyReport(context.Background(), "kid_tw", time.Now(), "en")
+	if err != nil {
+		t.Fatalf("DailyReport() error = %v", err)
+	}
+	if !strings.Contains(report.GeneratedText, "個精靈") {
+		t.Fatalf("expected traditional chinese summary, got %q", report.GeneratedText)
+	}
+	if len(report.Suggestions) == 0 || !strings.Contains(report.Suggestions[0], "樹") {
+		t.Fatalf("expected traditional chinese suggestions, got %v", report.Suggestions)
+	}
+
+	if _, err := svc.UpdateProfile(context.Background(), service.ProfileRequest{ChildID: "kid_tw"}); err != nil {
+		t.Fatalf("UpdateProfile() clear error = %v", err)
+	}
+	report, err = svc.DailyReport(context.Background(), "kid_tw", time.Now(), "en")
+	if err != nil {
+		t.Fatalf("DailyReport() error = %v", err)
+	}
+	if !strings.HasPrefix(report.GeneratedText, "Today kid_tw caught") {
+		t.Fatalf("expected english summary after clearing profile, got %q", report.GeneratedText)
+	}
+}
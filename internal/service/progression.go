@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+const (
+	progressEventXPGained       = "xp_gained"
+	progressEventLevelUp        = "level_up"
+	progressEventStreakStarted  = "streak_started"
+	progressEventStreakExtended = "streak_extended"
+	progressEventStreakGrace    = "streak_grace_used"
+	progressEventStreakReset    = "streak_reset"
+
+	xpReasonCapture         = "capture"
+	xpReasonFirstObjectType = "first_object_type"
+	xpReasonFirstTryCorrect = "first_try_correct"
+	xpReasonCompanionChat   = "companion_chat"
+	xpReasonQuestReward     = "quest_reward"
+
+	progressDateLayout = "2006-01-02"
+)
+
+//go:embed progression_rules.json
+var progressionRulesRawJSON []byte
+
+type progressionRules struct {
+	XP struct {
+		Capture         int `json:"capture"`
+		FirstObjectType int `json:"first_object_type"`
+		FirstTryCorrect int `json:"first_try_correct"`
+		CompanionChat   int `json:"companion_chat"`
+	} `json:"xp"`
+	CompanionChatDailyLimit int `json:"companion_chat_daily_limit"`
+	Streak                  struct {
+		GraceDays int `json:"grace_days"`
+	} `json:"streak"`
+	Levels []progressionLevel `json:"levels"`
+}
+
+type progressionLevel struct {
+	Level int    `json:"level"`
+	Name  string `json:"name"`
+	MinXP int    `json:"min_xp"`
+}
+
+type xpAward struct {
+	Reason string
+	XP     int
+}
+
+func loadProgressionRules() progressionRules {
+	raw := progressionRulesRawJSON
+	if path := strings.TrimSpace(os.Getenv("CITYLING_PROGRESSION_RULES_FILE")); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			raw = data
+		}
+	}
+	var rules progressionRules
+	if err := json.Unmarshal(raw, &rules); err != nil || len(rules.Levels) == 0 {
+		_ = json.Unmarshal(progressionRulesRawJSON, &rules)
+	}
+	sort.Slice(rules.Levels, func(i, j int) bool {
+		return rules.Levels[i].MinXP < rules.Levels[j].MinXP
+	})
+	if rules.Streak.GraceDays < 0 {
+		rules.Streak.GraceDays = 0
+	}
+	return rules
+}
+
+func (r progressionRules) levelFor(xp int) progressionLevel {
+	current := progressionLevel{Level: 1}
+	for _, level := range r.Levels {
+		if xp >= level.MinXP {
+			current = level
+		}
+	}
+	return current
+}
+
+func (r progressionRules) nextLevel(level int) (progressionLevel, bool) {
+	for _, candidate := range r.Levels {
+		if candidate.Level > level {
+			return candidate, true
+		}
+	}
+	return progressionLevel{}, false
+}
+
+func (s *Service) Progress(ctx context.Context, childID string) (_ ProgressProfile, err error) {
+	ctx, span := startSpan(ctx, "Progress")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	progress, _, err := st.GetProgress(childID)
+	if err != nil {
+		return ProgressProfile{}, err
+	}
+	progress.ChildID = childID
+	return s.progressProfile(progress, time.Now()), nil
+}
+
+func (s *Service) progressProfile(progress model.ChildProgress, now time.Time) ProgressProfile {
+	rules := s.progressionRules
+	level := rules.levelFor(progress.XP)
+	profile := ProgressProfile{
+		ChildID:        progress.ChildID,
+		XP:             progress.XP,
+		Level:          level.Level,
+		LevelName:      level.Name,
+		CurrentStreak:  progress.CurrentStreak,
+		LongestStreak:  progress.LongestStreak,
+		LastActiveDate: progress.LastActiveDate,
+		GraceDays:      rules.Streak.GraceDays,
+	}
+	if next, ok := rules.nextLevel(level.Level); ok {
+		profile.NextLevel = next.Level
+		profile.NextLevelName = next.Name
+		profile.NextLevelXP = next.MinXP
+		profile.XPToNextLevel = next.MinXP - progress.XP
+	}
+	if gap, ok := progressDayGap(progress.LastActiveDate, now); ok {
+		switch {
+		case gap > rules.Streak.GraceDays+1:
+			// 已超过宽限期，连续天数在下次探索时会重新计数。
+			profile.CurrentStreak = 0
+		case gap >= 1:
+			profile.StreakAtRisk = true
+		}
+	}
+	return profile
+}
+
+// applyProgress 结算一次行为带来的经验值；exploring 为 true 时同时推进每日连续探索天数。
+func (s *Service) applyProgress(ctx context.Context, childID string, now time.Time, awards []xpAward, exploring bool) (*ProgressUpdate, error) {
+	st := s.storeFor(ctx)
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	progress, _, err := st.GetProgress(childID)
+	if err != nil {
+		return nil, err
+	}
+	progress.ChildID = childID
+	rules := s.progressionRules
+	before := rules.levelFor(progress.XP)
+	today := now.Format(progressDateLayout)
+
+	update := &ProgressUpdate{Events: make([]model.ProgressEvent, 0, len(awards)+2)}
+	for _, award := range awards {
+		xp := award.XP
+		if award.Reason == xpReasonCompanionChat {
+			if progress.CompanionChatDate != today {
+				progress.CompanionChatDate = today
+				progress.CompanionChatAwards = 0
+			}
+			if rules.CompanionChatDailyLimit > 0 && progress.CompanionChatAwards >= rules.CompanionChatDailyLimit {
+				continue
+			}
+			progress.CompanionChatAwards++
+		}
+		if xp <= 0 {
+			continue
+		}
+		progress.XP += xp
+		update.XPGained += xp
+		update.Events = append(update.Events, model.ProgressEvent{
+			Type:   progressEventXPGained,
+			Reason: award.Reason,
+			XP:     xp,
+		})
+	}
+
+	if exploring {
+		if event, ok := advanceStreak(&progress, now, rules.Streak.GraceDays); ok {
+			update.Events = append(update.Events, event)
+		}
+	}
+
+	after := rules.levelFor(progress.XP)
+	for _, level := range rules.Levels {
+		if level.Level > before.Level && level.Level <= after.Level {
+			update.Events = append(update.Events, model.ProgressEvent{
+				Type:      progressEventLevelUp,
+				Level:     level.Level,
+				LevelName: level.Name,
+			})
+		}
+	}
+	progress.Level = after.Level
+	progress.UpdatedAt = now
+	if err := st.SaveProgress(progress); err != nil {
+		return nil, err
+	}
+
+	update.XP = progress.XP
+	update.Level = after.Level
+	update.LevelName = after.Name
+	update.CurrentStreak = progress.CurrentStreak
+	return update, nil
+}
+
+func advanceStreak(progress *model.ChildProgress, now time.Time, graceDays int) (model.ProgressEvent, bool) {
+	today := now.Format(progressDateLayout)
+	gap, ok := progressDayGap(progress.LastActiveDate, now)
+	if ok && gap <= 0 {
+		return model.ProgressEvent{}, false
+	}
+
+	event := model.ProgressEvent{}
+	switch {
+	case !ok:
+		progress.CurrentStreak = 1
+		event.Type = progressEventStreakStarted
+	case gap == 1:
+		progress.CurrentStreak++
+		event.Type = progressEventStreakExtended
+	case gap <= graceDays+1:
+		progress.CurrentStreak++
+		event.Type = progressEventStreakGrace
+	default:
+		progress.CurrentStreak = 1
+		event.Type = progressEventStreakReset
+	}
+	progress.LastActiveDate = today
+	if progress.CurrentStreak > progress.LongestStreak {
+		progress.LongestStreak = progress.CurrentStreak
+	}
+	event.Streak = progress.CurrentStreak
+	return event, true
+}
+
+func progressDayGap(lastActiveDate string, now time.Time) (int, bool) {
+	if strings.TrimSpace(lastActiveDate) == "" {
+		return 0, false
+	}
+	last, err := time.ParseInLocation(progressDateLayout, lastActiveDate, now.Location())
+	if err != nil {
+		return 0, false
+	}
+	today, _ := time.ParseInLocation(progressDateLayout, now.Format(progressDateLayout), now.Location())
+	return int(math.Round(today.Sub(last).Hours() / 24)), true
+}
+
+// addCaptureWithAwards 保存捕捉并算出应得的经验奖励。
+// 判断"首次捕捉该类型"与写入捕捉必须在 progressMu 内完成，否则并发的两次同类捕捉都会拿到首次奖励。
+func (s *Service) addCaptureWithAwards(ctx context.Context, capture model.Capture, firstTry bool) ([]xpAward, error) {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	st := s.storeFor(ctx)
+	captures, err := st.ListCapturesByChild(capture.ChildID)
+	if err != nil {
+		return nil, err
+	}
+	awards := captureAwards(s.progressionRules, captures, capture.ObjectType, firstTry)
+	if err := st.AddCapture(capture); err != nil {
+		return nil, err
+	}
+	return awards, nil
+}
+
+func captureAwards(rules progressionRules, captures []model.Capture, objectType string, firstTry bool) []xpAward {
+	awards := []xpAward{{Reason: xpReasonCapture, XP: rules.XP.Capture}}
+	seen := false
+	for _, capture := range captures {
+		if capture.ObjectType == objectType {
+			seen = true
+			break
+		}
+	}
+	if !seen {
+		awards = append(awards, xpAward{Reason: xpReasonFirstObjectType, XP: rules.XP.FirstObjectType})
+	}
+	if firstTry {
+		awards = append(awards, xpAward{Reason: xpReasonFirstTryCorrect, XP: rules.XP.FirstTryCorrect})
+	}
+	return awards
+}
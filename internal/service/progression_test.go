@@ -0,0 +1,196 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/service"
+	"ling/internal/store"
+)
+
+func TestProgressAwardsXPLevelsAndStreak(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+
+	first := captureObject(t, svc, st, "kid_xp", "tree")
+	if first.Progress == nil {
+		t.Fatalf("expected progress update in answer response")
+	}
+	// capture(10) + first_object_type(20) + first_try_correct(5)
+	if first.Progress.XPGained != 35 {
+		t.Fatalf("expected 35 xp for first capture, got %d", first.Progress.XPGained)
+	}
+	if !hasProgressEvent(first.Progress.Events, "streak_started") {
+		t.Fatalf("expected streak_started event, got %+v", first.Progress.Events)
+	}
+
+	second := captureObject(t, svc, st, "kid_xp", "mailbox")
+	if second.Progress.XP != 70 || second.Progress.Level != 2 {
+		t.Fatalf("expected level 2 with 70 xp, got %+v", second.Progress)
+	}
+	if !hasProgressEvent(second.Progress.Events, "level_up") {
+		t.Fatalf("expected level_up event, got %+v", second.Progress.Events)
+	}
+	if second.Progress.CurrentStreak != 1 {
+		t.Fatalf("expected same-day capture to keep streak at 1, got %d", second.Progress.CurrentStreak)
+	}
+
+	profile, err := svc.Progress(context.Background(), "kid_xp")
+	if err != nil {
+		t.Fatalf("Progress() error = %v", err)
+	}
+	if profile.XP != 70 || profile.NextLevel != 3 || profile.XPToNextLevel != 80 {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+}
+
+func TestProgressStreakUsesGraceDay(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+
+	if err := st.SaveProgress(model.ChildProgress{
+		ChildID:        "kid_grace",
+		XP:             10,
+		CurrentStreak:  4,
+		LongestStreak:  4,
+		LastActiveDate: time.Now().AddDate(0, 0, -2).Format("2006-01-02"),
+	}); err != nil {
+		t.Fatalf("SaveProgress() error = %v", err)
+	}
+
+	resp := captureObject(t, svc, st, "kid_grace", "tree")
+	if !hasProgressEvent(resp.Progress.Events, "streak_grace_used") {
+		t.Fatalf("expected grace day to keep streak, got %+v", resp.Progress.Events)
+	}
+	if resp.Progress.CurrentStreak != 5 {
+		t.Fatalf("expected streak 5, got %d", resp.Progress.CurrentStreak)
+	}
+
+	if err := st.SaveProgress(model.ChildProgress{
+		ChildID:        "kid_grace",
+		CurrentStreak:  5,
+		LongestStreak:  5,
+		LastActiveDate: time.Now().AddDate(0, 0, -3).Format("2006-01-02"),
+	}); err != nil {
+		t.Fatalf("SaveProgress() error = %v", err)
+	}
+	resp = captureObject(t, svc, st, "kid_grace", "mailbox")
+	if !hasProgressEvent(resp.Progress.Events, "streak_reset") || resp.Progress.CurrentStreak != 1 {
+		t.Fatalf("expected streak reset after two missed days, got %+v", resp.Progress)
+	}
+}
+
+func TestProgressBlankWrongAnswerStillLosesFirstTryBonus(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{ChildID: "kid_blank", ChildAge: 8, DetectedLabel: "tree"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	wrong, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{SessionID: scanResp.SessionID, ChildID: "kid_blank", Answer: "   "})
+	if err != nil || wrong.Correct {
+		t.Fatalf("expected blank answer to be wrong, got %+v err=%v", wrong, err)
+	}
+	session, _, err := st.GetSession(scanResp.SessionID)
+	if err != nil {
+		t.Fatalf("GetSession() error = %v", err)
+	}
+	if session.Attempts != 1 {
+		t.Fatalf("expected 1 recorded attempt, got %d", session.Attempts)
+	}
+	resp, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{SessionID: scanResp.SessionID, ChildID: "kid_blank", Answer: session.QuizA})
+	if err != nil {
+		t.Fatalf("SubmitAnswer() error = %v", err)
+	}
+	// capture(10) + first_object_type(20)，不含 first_try_correct
+	if resp.Progress == nil || resp.Progress.XPGained != 30 {
+		t.Fatalf("expected 30 xp without first-try bonus, got %+v", resp.Progress)
+	}
+}
+
+func TestProgressConcurrentCapturesAwardFirstTypeOnce(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+
+	const n = 4
+	sessions := make([]model.ScanSession, n)
+	for i := range sessions {
+		scanResp, err := svc.Scan(context.Background(), service.ScanRequest{ChildID: "kid_race", ChildAge: 8, DetectedLabel: "tree"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		session, _, err := st.GetSession(scanResp.SessionID)
+		if err != nil {
+			t.Fatalf("GetSession() error = %v", err)
+		}
+		sessions[i] = session
+	}
+
+	var wg sync.WaitGroup
+	responses := make([]service.AnswerResponse, n)
+	errs := make([]error, n)
+	for i, session := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = svc.SubmitAnswer(context.Background(), service.AnswerRequest{SessionID: session.ID, ChildID: "kid_race", Answer: session.QuizA})
+		}()
+	}
+	wg.Wait()
+
+	firstType := 0
+	for i, resp := range responses {
+		if errs[i] != nil {
+			t.Fatalf("SubmitAnswer() error = %v", errs[i])
+		}
+		for _, event := range resp.Progress.Events {
+			if event.Reason == "first_object_type" {
+				firstType++
+			}
+		}
+	}
+	if firstType != 1 {
+		t.Fatalf("expected exactly one first_object_type award, got %d", firstType)
+	}
+}
+
+func captureObject(t *testing.T, svc *service.Service, st *store.JSONStore, childID string, label string) service.AnswerResponse {
+	t.Helper()
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
+		ChildID:       childID,
+		ChildAge:      8,
+		DetectedLabel: label,
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	session, ok, err := st.GetSession(scanResp.SessionID)
+	if err != nil || !ok {
+		t.Fatalf("GetSession() error = %v, ok=%v", err, ok)
+	}
+	resp, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
+		SessionID: scanResp.SessionID,
+		ChildID:   childID,
+		Answer:    session.QuizA,
+	})
+	if err != nil {
+		t.Fatalf("SubmitAnswer() error = %v", err)
+	}
+	if !resp.Captured {
+		t.Fatalf("expected %s to be captured, got %+v", label, resp)
+	}
+	return resp
+}
+
+func hasProgressEvent(events []model.ProgressEvent, eventType string) bool {
+	for _, event := range events {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
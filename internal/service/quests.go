@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"ling/internal/i18n"
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+const (
+	questTypeCapture         = "capture"
+	questTypeFirstTryCorrect = "first_try_correct"
+	questTypeCompanionChat   = "companion_chat"
+
+	defaultQuestsPerDay = 3
+)
+
+//go:embed quest_templates.json
+var questTemplatesRawJSON []byte
+
+// QuestTemplate 描述一类每日任务：capture 统计匹配 keywords 的收集，first_try_correct 统计首次作答即答对，
+// companion_chat 统计与匹配 keywords 的精灵聊天；keywords 为空表示不限对象。
+// Key 为文案目录中的键前缀，按 <key>.title 与 <key>.description 查找译文，目录里没有该语言时使用 title 与 description 原文。
+type QuestTemplate struct {
+	ID          string   `json:"id"`
+	Key         string   `json:"key,omitempty"`
+	Type        string   `json:"type"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Count       int      `json:"count"`
+	RewardXP    int      `json:"reward_xp"`
+}
+
+type questCatalog struct {
+	QuestsPerDay int             `json:"quests_per_day"`
+	Templates    []QuestTemplate `json:"templates"`
+}
+
+// questEvent 是一次可能推进任务进度的行为。
+type questEvent struct {
+	Type       string
+	ObjectType string
+	CaptureID  string
+}
+
+func loadQuestCatalog() questCatalog {
+	raw := questTemplatesRawJSON
+	if path := strings.TrimSpace(os.Getenv("CITYLING_QUEST_TEMPLATES_FILE")); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			raw = data
+		}
+	}
+	catalog, err := parseQuestCatalog(raw)
+	if err != nil {
+		slog.Warn("load quest templates failed, using built-in templates", "err", err)
+		catalog, _ = parseQuestCatalog(questTemplatesRawJSON)
+	}
+	return catalog
+}
+
+func parseQuestCatalog(raw []byte) (questCatalog, error) {
+	var catalog questCatalog
+	if err := json.Unmarshal(raw, &catalog); err != nil {
+		return questCatalog{}, err
+	}
+	templates := make([]QuestTemplate, 0, len(catalog.Templates))
+	seen := make(map[string]bool, len(catalog.Templates))
+	for _, tpl := range catalog.Templates {
+		tpl.ID = strings.TrimSpace(tpl.ID)
+		if tpl.ID == "" || seen[tpl.ID] {
+			return questCatalog{}, fmt.Errorf("任务模板 ID %q 为空或重复", tpl.ID)
+		}
+		switch tpl.Type {
+		case questTypeCapture, questTypeFirstTryCorrect, questTypeCompanionChat:
+		default:
+			return questCatalog{}, fmt.Errorf("任务模板 %s 的类型 %q 不受支持", tpl.ID, tpl.Type)
+		}
+		// 关键词与勋章共用同一套归一化规则，归一化后为空的关键词无法匹配任何对象。
+		keywords := make([]string, 0, len(tpl.Keywords))
+		for _, keyword := range tpl.Keywords {
+			if normalizeBadgeToken(keyword) != "" {
+				keywords = append(keywords, strings.TrimSpace(keyword))
+			}
+		}
+		if len(tpl.Keywords) > 0 && len(keywords) == 0 {
+			return questCatalog{}, fmt.Errorf("任务模板 %s 的关键词全部无效", tpl.ID)
+		}
+		tpl.Keywords = keywords
+		if tpl.Count <= 0 {
+			tpl.Count = 1
+		}
+		seen[tpl.ID] = true
+		templates = append(templates, tpl)
+	}
+	if len(templates) == 0 {
+		return questCatalog{}, fmt.Errorf("没有可用的任务模板")
+	}
+	catalog.Templates = templates
+	if catalog.QuestsPerDay <= 0 {
+		catalog.QuestsPerDay = defaultQuestsPerDay
+	}
+	if catalog.QuestsPerDay > len(templates) {
+		catalog.QuestsPerDay = len(templates)
+	}
+	return catalog, nil
+}
+
+// Quests 返回孩子今天的任务，当天第一次查询时按模板生成；locale 为语言提示，孩子资料中的设置优先。
+func (s *Service) Quests(ctx context.Context, childID string, locale string) (_ QuestBoard, err error) {
+	ctx, span := startSpan(ctx, "Quests")
+	defer func() { tracing.End(span, err) }()
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	s.questMu.Lock()
+	defer s.questMu.Unlock()
+
+	now := time.Now()
+	quests, err := s.dailyQuestsLocked(ctx, childID, now)
+	if err != nil {
+		return QuestBoard{}, err
+	}
+	quests = s.localizeQuests(quests, s.locale(ctx, childID, locale))
+	return QuestBoard{ChildID: childID, Date: now.Format(progressDateLayout), Quests: quests}, nil
+}
+
+// ClaimQuest 领取已完成任务的奖励经验值，并返回计入该任务的收集。
+func (s *Service) ClaimQuest(ctx context.Context, questID string, req QuestClaimRequest) (_ QuestClaimResponse, err error) {
+	ctx, span := startSpan(ctx, "ClaimQuest")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	s.questMu.Lock()
+	quest, ok, err := st.GetQuest(strings.TrimSpace(questID))
+	if err != nil {
+		s.questMu.Unlock()
+		return QuestClaimResponse{}, err
+	}
+	childID := strings.TrimSpace(req.ChildID)
+	if !ok || (childID != "" && quest.ChildID != childID) {
+		s.questMu.Unlock()
+		return QuestClaimResponse{}, ErrQuestNotFound
+	}
+	if quest.ClaimedAt != nil {
+		s.questMu.Unlock()
+		return QuestClaimResponse{}, ErrQuestAlreadyClaimed
+	}
+	if quest.CompletedAt == nil {
+		s.questMu.Unlock()
+		return QuestClaimResponse{}, ErrQuestNotCompleted
+	}
+	now := time.Now()
+	quest.ClaimedAt = &now
+	err = st.SaveQuest(quest)
+	s.questMu.Unlock()
+	if err != nil {
+		return QuestClaimResponse{}, err
+	}
+
+	progress, err := s.applyProgress(ctx, quest.ChildID, now, []xpAward{{Reason: xpReasonQuestReward, XP: quest.RewardXP}}, false)
+	if err != nil {
+		return QuestClaimResponse{}, err
+	}
+	quest = s.localizeQuests([]model.Quest{quest}, s.locale(ctx, quest.ChildID, req.Locale))[0]
+	resp := QuestClaimResponse{Quest: quest, Progress: progress}
+	if len(quest.CaptureIDs) > 0 {
+		captures, err := st.ListCapturesByChild(quest.ChildID)
+		if err != nil {
+			return QuestClaimResponse{}, err
+		}
+		wanted := make(map[string]bool, len(quest.CaptureIDs))
+		for _, id := range quest.CaptureIDs {
+			wanted[id] = true
+		}
+		for _, capture := range captures {
+			if wanted[capture.ID] {
+				resp.Captures = append(resp.Captures, capture)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// advanceQuests 用本次行为推进孩子今天的任务，返回因此刚完成的任务。
+func (s *Service) advanceQuests(ctx context.Context, childID string, now time.Time, events []questEvent) ([]model.Quest, error) {
+	st := s.storeFor(ctx)
+	if len(events) == 0 {
+		return nil, nil
+	}
+	s.questMu.Lock()
+	defer s.questMu.Unlock()
+
+	quests, err := s.dailyQuestsLocked(ctx, childID, now)
+	if err != nil {
+		return nil, err
+	}
+	var completed []model.Quest
+	for _, quest := range quests {
+		if quest.CompletedAt != nil {
+			continue
+		}
+		changed := false
+		for _, event := range events {
+			if !questMatches(quest, event) {
+				continue
+			}
+			quest.Progress++
+			if event.CaptureID != "" {
+				quest.CaptureIDs = append(quest.CaptureIDs, event.CaptureID)
+			}
+			changed = true
+			if quest.Progress >= quest.Target {
+				at := now
+				quest.CompletedAt = &at
+				completed = append(completed, quest)
+				break
+			}
+		}
+		if !changed {
+			continue
+		}
+		if err := st.SaveQuest(quest); err != nil {
+			return nil, err
+		}
+	}
+	return completed, nil
+}
+
+func questMatches(quest model.Quest, event questEvent) bool {
+	if quest.Type != event.Type {
+		return false
+	}
+	if len(quest.Keywords) == 0 {
+		return true
+	}
+	for _, keyword := range quest.Keywords {
+		if objectMatchesKeyword(event.ObjectType, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// dailyQuestsLocked 返回孩子当天的任务，不存在时生成；调用方需持有 questMu。
+func (s *Service) dailyQuestsLocked(ctx context.Context, childID string, now time.Time) ([]model.Quest, error) {
+	st := s.storeFor(ctx)
+	date := now.Format(progressDateLayout)
+	quests, err := st.ListQuestsByChild(childID, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(quests) > 0 {
+		return quests, nil
+	}
+	for i, tpl := range pickQuestTemplates(s.questCatalog, childID, date) {
+		quest := model.Quest{
+			ID:          s.newID("quest"),
+			ChildID:     childID,
+			Date:        date,
+			TemplateID:  tpl.ID,
+			Type:        tpl.Type,
+			Title:       tpl.Title,
+			Description: tpl.Description,
+			Keywords:    append([]string(nil), tpl.Keywords...),
+			Target:      tpl.Count,
+			RewardXP:    tpl.RewardXP,
+			// 同一批任务按模板顺序稍微错开创建时间，保证列表顺序稳定。
+			CreatedAt: now.Add(time.Duration(i) * time.Millisecond),
+		}
+		if err := st.SaveQuest(quest); err != nil {
+			return nil, err
+		}
+		quests = append(quests, quest)
+	}
+	return quests, nil
+}
+
+// localizeQuests 按 locale 改写任务的标题和描述。任务保存的是生成时的文案，
+// 模板已下线或没有声明目录键时保持不变。
+func (s *Service) localizeQuests(quests []model.Quest, locale i18n.Locale) []model.Quest {
+	for i, quest := range quests {
+		tpl, ok := s.questCatalog.template(quest.TemplateID)
+		if !ok || tpl.Key == "" {
+			continue
+		}
+		quests[i].Title = catalogText(locale, tpl.Key+".title", quest.Title)
+		if quest.Description != "" {
+			quests[i].Description = catalogText(locale, tpl.Key+".description", quest.Description)
+		}
+	}
+	return quests
+}
+
+func (c questCatalog) template(id string) (QuestTemplate, bool) {
+	for _, tpl := range c.Templates {
+		if tpl.ID == id {
+			return tpl, true
+		}
+	}
+	return QuestTemplate{}, false
+}
+
+// pickQuestTemplates 以孩子和日期为种子挑选当天的模板：同一天结果稳定，并优先覆盖不同类型。
+func pickQuestTemplates(catalog questCatalog, childID string, date string) []QuestTemplate {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(childID + "|" + date))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	shuffled := append([]QuestTemplate(nil), catalog.Templates...)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	picked := make([]QuestTemplate, 0, catalog.QuestsPerDay)
+	usedTypes := make(map[string]bool)
+	usedIDs := make(map[string]bool)
+	for _, tpl := range shuffled {
+		if len(picked) == catalog.QuestsPerDay {
+			break
+		}
+		if !usedTypes[tpl.Type] {
+			picked = append(picked, tpl)
+			usedTypes[tpl.Type] = true
+			usedIDs[tpl.ID] = true
+		}
+	}
+	for _, tpl := range shuffled {
+		if len(picked) == catalog.QuestsPerDay {
+			break
+		}
+		if !usedIDs[tpl.ID] {
+			picked = append(picked, tpl)
+			usedIDs[tpl.ID] = true
+		}
+	}
+	return picked
+}
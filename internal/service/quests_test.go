@@ -0,0 +1,153 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+func TestQuestsAreGeneratedOncePerDay(t *testing.T) {
+	t.Parallel()
+
+	svc, _ := newTestService(t)
+	board, err := svc.Quests(context.Background(), "kid_quest", "")
+	if err != nil {
+		t.Fatalf("Quests() error = %v", err)
+	}
+	if len(board.Quests) != 3 {
+		t.Fatalf("expected 3 quests, got %+v", board.Quests)
+	}
+	types := make(map[string]bool)
+	ids := make(map[string]bool)
+	for _, quest := range board.Quests {
+		types[quest.Type] = true
+		if ids[quest.ID] {
+			t.Fatalf("duplicate quest ID %q in %+v", quest.ID, board.Quests)
+		}
+		ids[quest.ID] = true
+		if quest.Target <= 0 || quest.RewardXP <= 0 || quest.Date != board.Date {
+			t.Fatalf("unexpected quest %+v", quest)
+		}
+	}
+	if len(types) != 3 {
+		t.Fatalf("expected one quest of each type, got %v", types)
+	}
+
+	again, err := svc.Quests(context.Background(), "kid_quest", "")
+	if err != nil {
+		t.Fatalf("Quests() second call error = %v", err)
+	}
+	for i := range board.Quests {
+		if again.Quests[i].ID != board.Quests[i].ID {
+			t.Fatalf("expected the same quests on the same day, got %+v", again.Quests)
+		}
+	}
+}
+
+func TestQuestProgressFromAnswersAndClaim(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	// 找一个当天拿到“任意收集”任务的孩子，便于用任意对象推进收集任务。
+	var childID string
+	var board service.QuestBoard
+	for i := 0; i < 50 && childID == ""; i++ {
+		candidate := fmt.Sprintf("kid_quest_%d", i)
+		b, err := svc.Quests(context.Background(), candidate, "")
+		if err != nil {
+			t.Fatalf("Quests() error = %v", err)
+		}
+		if quest := questOfType(b, "capture"); quest != nil && len(quest.Keywords) == 0 {
+			childID, board = candidate, b
+		}
+	}
+	if childID == "" {
+		t.Fatal("expected some child to receive the collector quest")
+	}
+	captureQuest := questOfType(board, "capture")
+	firstTryQuest := questOfType(board, "first_try_correct")
+	chatQuest := questOfType(board, "companion_chat")
+
+	completed := make(map[string]bool)
+	var captureIDs []string
+	for i := 0; i < 3; i++ {
+		resp := captureObject(t, svc, st, childID, "tree")
+		captureIDs = append(captureIDs, resp.Capture.ID)
+		for _, quest := range resp.CompletedQuests {
+			completed[quest.ID] = true
+		}
+	}
+	if !completed[captureQuest.ID] || !completed[firstTryQuest.ID] {
+		t.Fatalf("expected capture and first-try quests to complete, got %v", completed)
+	}
+
+	claim, err := svc.ClaimQuest(context.Background(), captureQuest.ID, service.QuestClaimRequest{ChildID: childID})
+	if err != nil {
+		t.Fatalf("ClaimQuest() error = %v", err)
+	}
+	if claim.Quest.ClaimedAt == nil || len(claim.Captures) != len(captureIDs) {
+		t.Fatalf("expected claimed quest with %d captures, got %+v", len(captureIDs), claim)
+	}
+	if claim.Progress == nil || claim.Progress.XPGained != captureQuest.RewardXP {
+		t.Fatalf("expected %d reward XP, got %+v", captureQuest.RewardXP, claim.Progress)
+	}
+	if !hasXPReason(claim.Progress.Events, "quest_reward") {
+		t.Fatalf("expected quest_reward event, got %+v", claim.Progress.Events)
+	}
+
+	if _, err := svc.ClaimQuest(context.Background(), captureQuest.ID, service.QuestClaimRequest{ChildID: childID}); !errors.Is(err, service.ErrQuestAlreadyClaimed) {
+		t.Fatalf("expected ErrQuestAlreadyClaimed, got %v", err)
+	}
+	if _, err := svc.ClaimQuest(context.Background(), chatQuest.ID, service.QuestClaimRequest{ChildID: childID}); !errors.Is(err, service.ErrQuestNotCompleted) {
+		t.Fatalf("expected ErrQuestNotCompleted, got %v", err)
+	}
+	if _, err := svc.ClaimQuest(context.Background(), firstTryQuest.ID, service.QuestClaimRequest{ChildID: "someone_else"}); !errors.Is(err, service.ErrQuestNotFound) {
+		t.Fatalf("expected ErrQuestNotFound for another child, got %v", err)
+	}
+}
+
+func TestQuestsFollowLocale(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+
+	zh, err := svc.Quests(context.Background(), "kid_quest_en", "")
+	if err != nil {
+		t.Fatalf("Quests() error = %v", err)
+	}
+	en, err := svc.Quests(context.Background(), "kid_quest_en", "en")
+	if err != nil {
+		t.Fatalf("Quests() error = %v", err)
+	}
+	for i, quest := range en.Quests {
+		if quest.ID != zh.Quests[i].ID {
+			t.Fatalf("expected the same quests in every language, got %s and %s", quest.ID, zh.Quests[i].ID)
+		}
+		if quest.Title == zh.Quests[i].Title || strings.IndexFunc(quest.Title, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0 {
+			t.Fatalf("expected an english title for %s, got %q", quest.TemplateID, quest.Title)
+		}
+	}
+}
+
+func questOfType(board service.QuestBoard, questType string) *model.Quest {
+	for i := range board.Quests {
+		if board.Quests[i].Type == questType {
+			return &board.Quests[i]
+		}
+	}
+	return nil
+}
+
+func hasXPReason(events []model.ProgressEvent, reason string) bool {
+	for _, event := range events {
+		if event.Type == "xp_gained" && event.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
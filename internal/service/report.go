@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ling/internal/i18n"
+	"ling/internal/llm"
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+const (
+	ReportPeriodWeekly  = "weekly"
+	ReportPeriodMonthly = "monthly"
+
+	narrativeSourceLLM      = "llm"
+	narrativeSourceTemplate = "template"
+
+	reportCacheTTL = 6 * time.Hour
+)
+
+type reportCacheEntry struct {
+	fingerprint string
+	report      any
+	expireAt    time.Time
+}
+
+// reportPeriodRange 返回 anchor 所在周期的起止时间（左闭右开），周从周一开始。
+func reportPeriodRange(period string, anchor time.Time) (time.Time, time.Time, error) {
+	day := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, anchor.Location())
+	switch period {
+	case ReportPeriodWeekly:
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7), nil
+	case ReportPeriodMonthly:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, ErrReportPeriodInvalid
+	}
+}
+
+func previousPeriodStart(period string, start time.Time) time.Time {
+	if period == ReportPeriodMonthly {
+		return start.AddDate(0, -1, 0)
+	}
+	return start.AddDate(0, 0, -7)
+}
+
+// PeriodReport 生成周报或月报，并与上一个周期对比；统计数据不变时直接复用缓存，避免重复调用大模型。
+// locale 为请求语言（如 Accept-Language），孩子资料中的设置优先。
+func (s *Service) PeriodReport(ctx context.Context, childID string, period string, anchor time.Time, locale string) (_ model.PeriodReport, err error) {
+	ctx, span := startSpan(ctx, "PeriodReport")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	period = strings.ToLower(strings.TrimSpace(period))
+	loc := s.locale(ctx, childID, locale)
+	start, end, err := reportPeriodRange(period, anchor)
+	if err != nil {
+		return model.PeriodReport{}, err
+	}
+	prevStart := previousPeriodStart(period, start)
+
+	captures, err := st.ListCapturesByChild(childID)
+	if err != nil {
+		return model.PeriodReport{}, err
+	}
+	sessions, err := st.ListSessionsByChild(childID)
+	if err != nil {
+		return model.PeriodReport{}, err
+	}
+	unlocks, err := st.ListBadgeUnlocksByChild(childID)
+	if err != nil {
+		return model.PeriodReport{}, err
+	}
+
+	current := periodStats(captures, sessions, unlocks, start, end)
+	previous := periodStats(captures, sessions, unlocks, prevStart, start)
+	cacheKey := childID + "|" + period + "|" + current.StartDate + "|" + string(loc)
+	fingerprint := fmt.Sprintf("%v|%v", current, previous)
+	if cached, ok := s.cachedReport(cacheKey, fingerprint).(model.PeriodReport); ok {
+		return cached, nil
+	}
+
+	report := model.PeriodReport{
+		Period:   period,
+		ChildID:  childID,
+		Current:  current,
+		Previous: previous,
+		Trend: model.PeriodTrend{
+			Captures:            current.Captures - previous.Captures,
+			DistinctObjectTypes: current.DistinctObjectTypes - previous.DistinctObjectTypes,
+			NewBadges:           len(current.NewBadges) - len(previous.NewBadges),
+			QuizAccuracy:        roundRatio(current.QuizAccuracy - previous.QuizAccuracy),
+			ActiveDays:          current.ActiveDays - previous.ActiveDays,
+		},
+		GeneratedAt: time.Now(),
+	}
+	report.GeneratedText = periodReportTemplate(report, loc)
+	report.NarrativeSource = narrativeSourceTemplate
+	if s.llm != nil {
+		parent, err := s.llm.GenerateParentReport(ctx, llm.ParentReportRequest{
+			PeriodLabel: periodLabel(period, current, loc),
+			ChildName:   childID,
+			ChildAge:    latestChildAge(sessions),
+			Stats:       periodReportStats(report, loc),
+			Highlights:  s.periodReportHighlights(captures, start, end, loc),
+			Language:    string(loc),
+		})
+		if err == nil {
+			report.GeneratedText = parent.Summary
+			report.Suggestions = parent.Suggestions
+			report.NarrativeSource = narrativeSourceLLM
+		}
+	}
+
+	// 模板兜底的报告不缓存，大模型恢复后可以重新生成。
+	if report.NarrativeSource == narrativeSourceLLM {
+		s.storeReport(cacheKey, fingerprint, report)
+	}
+	return report, nil
+}
+
+func (s *Service) cachedReport(key string, fingerprint string) any {
+	s.reportMu.Lock()
+	defer s.reportMu.Unlock()
+	entry, ok := s.reportCache[key]
+	if !ok || entry.fingerprint != fingerprint || time.Now().After(entry.expireAt) {
+		return nil
+	}
+	return entry.report
+}
+
+func (s *Service) storeReport(key string, fingerprint string, report any) {
+	s.reportMu.Lock()
+	defer s.reportMu.Unlock()
+	now := time.Now()
+	for k, entry := range s.reportCache {
+		if now.After(entry.expireAt) {
+			delete(s.reportCache, k)
+		}
+	}
+	s.reportCache[key] = reportCacheEntry{
+		fingerprint: fingerprint,
+		report:      report,
+		expireAt:    now.Add(reportCacheTTL),
+	}
+}
+
+func periodStats(captures []model.Capture, sessions []model.ScanSession, unlocks []model.BadgeUnlock, start time.Time, end time.Time) model.PeriodStats {
+	inRange := func(t time.Time) bool {
+		return !t.Before(start) && t.Before(end)
+	}
+	stats := model.PeriodStats{
+		StartDate:   start.Format(progressDateLayout),
+		EndDate:     end.AddDate(0, 0, -1).Format(progressDateLayout),
+		ObjectTypes: []string{},
+		NewBadges:   []string{},
+	}
+	objectTypes := make(map[string]struct{})
+	activeDays := make(map[string]struct{})
+	for _, capture := range captures {
+		if !inRange(capture.CapturedAt) {
+			continue
+		}
+		stats.Captures++
+		objectTypes[capture.ObjectType] = struct{}{}
+		activeDays[capture.CapturedAt.In(start.Location()).Format(progressDateLayout)] = struct{}{}
+	}
+	for objectType := range objectTypes {
+		stats.ObjectTypes = append(stats.ObjectTypes, objectType)
+	}
+	sort.Strings(stats.ObjectTypes)
+	stats.DistinctObjectTypes = len(stats.ObjectTypes)
+
+	for _, session := range sessions {
+		if strings.TrimSpace(session.AnswerGiven) == "" || !inRange(session.CreatedAt) {
+			continue
+		}
+		stats.QuizAnswered++
+		if session.Captured {
+			stats.QuizCorrect++
+		}
+		activeDays[session.CreatedAt.In(start.Location()).Format(progressDateLayout)] = struct{}{}
+	}
+	if stats.QuizAnswered > 0 {
+		stats.QuizAccuracy = roundRatio(float64(stats.QuizCorrect) / float64(stats.QuizAnswered))
+	}
+	stats.ActiveDays = len(activeDays)
+
+	for _, unlock := range unlocks {
+		// 没有触发会话的记录是功能上线前补记的历史勋章，不算作本周期新获得。
+		if unlock.SessionID == "" || !inRange(unlock.UnlockedAt) {
+			continue
+		}
+		stats.NewBadges = append(stats.NewBadges, unlock.BadgeName)
+	}
+	return stats
+}
+
+func roundRatio(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func latestChildAge(sessions []model.ScanSession) int {
+	for _, session := range sessions {
+		if session.ChildAge > 0 {
+			return session.ChildAge
+		}
+	}
+	return 0
+}
+
+func periodLabel(period string, stats model.PeriodStats, locale i18n.Locale) string {
+	return i18n.Text(locale, "report.period."+period, stats.StartDate, stats.EndDate)
+}
+
+func previousPeriodName(period string, locale i18n.Locale) string {
+	return i18n.Text(locale, "report.previous."+period)
+}
+
+func periodReportStats(report model.PeriodReport, locale i18n.Locale) []string {
+	cur, prev := report.Current, report.Previous
+	prevName := previousPeriodName(report.Period, locale)
+	stats := []string{
+		i18n.Text(locale, "report.stats.captures", cur.Captures, prevName, prev.Captures),
+		i18n.Text(locale, "report.stats.object_types", cur.DistinctObjectTypes, prevName, prev.DistinctObjectTypes),
+		i18n.Text(locale, "report.stats.active_days", cur.ActiveDays, prevName, prev.ActiveDays),
+	}
+	if cur.QuizAnswered > 0 || prev.QuizAnswered > 0 {
+		stats = append(stats, i18n.Text(locale, "report.stats.quiz",
+			cur.QuizAnswered, percent(cur.QuizAccuracy), prevName, percent(prev.QuizAccuracy)))
+	}
+	if len(cur.NewBadges) > 0 {
+		stats = append(stats, i18n.Text(locale, "report.stats.new_badges", strings.Join(cur.NewBadges, i18n.Text(locale, "report.list_separator"))))
+	}
+	return stats
+}
+
+func (s *Service) periodReportHighlights(captures []model.Capture, start time.Time, end time.Time, locale i18n.Locale) []string {
+	const limit = 8
+	highlights := make([]string, 0, limit)
+	seen := make(map[string]struct{})
+	for _, capture := range captures {
+		if capture.CapturedAt.Before(start) || !capture.CapturedAt.Before(end) {
+			continue
+		}
+		if _, ok := seen[capture.ObjectType]; ok {
+			continue
+		}
+		seen[capture.ObjectType] = struct{}{}
+		highlights = append(highlights, i18n.Text(locale, "report.highlight.capture", capture.SpiritName, s.objectName(capture.ObjectType, locale), capture.Fact))
+		if len(highlights) == limit {
+			break
+		}
+	}
+	return highlights
+}
+
+func periodReportTemplate(report model.PeriodReport, locale i18n.Locale) string {
+	cur, prev := report.Current, report.Previous
+	sentences := []string{i18n.Text(locale, "report.template.summary",
+		periodLabel(report.Period, cur, locale), report.ChildID, cur.Captures, cur.DistinctObjectTypes, cur.ActiveDays)}
+	if cur.QuizAnswered > 0 {
+		sentences = append(sentences, i18n.Text(locale, "report.template.quiz", cur.QuizAnswered, percent(cur.QuizAccuracy)))
+	}
+	if len(cur.NewBadges) > 0 {
+		sentences = append(sentences, i18n.Text(locale, "report.template.new_badges", strings.Join(cur.NewBadges, i18n.Text(locale, "report.list_separator"))))
+	}
+	prevName := previousPeriodName(report.Period, locale)
+	switch delta := report.Trend.Captures; {
+	case prev.Captures == 0 && cur.Captures == 0:
+	case delta > 0:
+		sentences = append(sentences, i18n.Text(locale, "report.template.more", prevName, delta))
+	case delta < 0:
+		sentences = append(sentences, i18n.Text(locale, "report.template.fewer", prevName, -delta))
+	default:
+		sentences = append(sentences, i18n.Text(locale, "report.template.same", prevName))
+	}
+	return strings.Join(sentences, i18n.Text(locale, "report.sentence_separator"))
+}
+
+func percent(ratio float64) int {
+	return int(math.Round(ratio * 100))
+}
+
+// dailyBadgeUnlocks 返回当天点亮的勋章，并用当前图片清单补全缺失的图片地址。
+func (s *Service) dailyBadgeUnlocks(ctx context.Context, childID string, day time.Time) ([]model.BadgeUnlock, error) {
+	st := s.storeFor(ctx)
+	unlocks, err := st.ListBadgeUnlocksByChild(childID)
+	if err != nil {
+		return nil, err
+	}
+	rules, images := s.badgeCatalog()
+	date := day.Format(progressDateLayout)
+	result := make([]model.BadgeUnlock, 0)
+	for _, unlock := range unlocks {
+		if unlock.SessionID == "" || unlock.UnlockedAt.In(day.Location()).Format(progressDateLayout) != date {
+			continue
+		}
+		if strings.TrimSpace(unlock.ImageURL) == "" {
+			if idx := badgeIndex(rules, unlock.BadgeID); idx >= 0 {
+				unlock.ImageURL = resolveBadgeImage(rules[idx], images)
+			}
+		}
+		result = append(result, unlock)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].UnlockedAt.Before(result[j].UnlockedAt)
+	})
+	return result, nil
+}
+
+// narrateDailyReport 用剧情文案模型把当天的收集、知识点和陪伴对话写成给家长看的摘要，失败时保留模板文案。
+func (s *Service) narrateDailyReport(ctx context.Context, report model.DailyReport, day time.Time, locale i18n.Locale) (model.DailyReport, error) {
+	st := s.storeFor(ctx)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	messages, err := st.ListCompanionMessagesByChild(report.ChildID, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+	captureIDs := make([]string, 0, len(report.Captures))
+	for _, capture := range report.Captures {
+		captureIDs = append(captureIDs, capture.ID)
+	}
+	cacheKey := "daily|" + report.ChildID + "|" + report.Date + "|" + string(locale)
+	fingerprint := fmt.Sprintf("%v|%d|%d", captureIDs, len(report.NewBadges), len(messages))
+	if cached, ok := s.cachedReport(cacheKey, fingerprint).(model.DailyReport); ok {
+		return cached, nil
+	}
+
+	sessions, err := st.ListSessionsByChild(report.ChildID)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
+	highlights := make([]string, 0, len(report.Captures)+dailyChatHighlightLimit)
+	for _, capture := range report.Captures {
+		highlights = append(highlights, i18n.Text(locale, "report.highlight.daily_capture", capture.SpiritName, s.objectName(capture.ObjectType, locale), capture.Fact))
+	}
+	for _, badge := range report.NewBadges {
+		highlights = append(highlights, i18n.Text(locale, "report.highlight.badge", badge.BadgeName))
+	}
+	highlights = append(highlights, s.companionChatHighlights(messages, locale)...)
+
+	parent, err := s.llm.GenerateParentReport(ctx, llm.ParentReportRequest{
+		PeriodLabel: i18n.Text(locale, "report.period.today", report.Date),
+		ChildName:   report.ChildID,
+		ChildAge:    latestChildAge(sessions),
+		Stats: []string{
+			i18n.Text(locale, "report.daily.captures", report.TotalCaptured),
+			i18n.Text(locale, "report.daily.knowledge", len(report.KnowledgePoints)),
+			i18n.Text(locale, "report.daily.chats", len(messages)),
+		},
+		Highlights: highlights,
+		Language:   string(locale),
+	})
+	if err != nil {
+		return report, nil
+	}
+	report.GeneratedText = parent.Summary
+	if len(parent.Suggestions) > 0 {
+		report.Suggestions = parent.Suggestions
+	}
+	report.NarrativeSource = narrativeSourceLLM
+	s.storeReport(cacheKey, fingerprint, report)
+	return report, nil
+}
+
+const dailyChatHighlightLimit = 6
+
+// companionChatHighlights 选取当天最近几轮对话，优先保留孩子说得较多的轮次。
+func (s *Service) companionChatHighlights(messages []model.CompanionMessage, locale i18n.Locale) []string {
+	if len(messages) > dailyChatHighlightLimit {
+		picked := append([]model.CompanionMessage(nil), messages...)
+		sort.SliceStable(picked, func(i, j int) bool {
+			return len([]rune(picked[i].ChildMessage)) > len([]rune(picked[j].ChildMessage))
+		})
+		picked = picked[:dailyChatHighlightLimit]
+		sort.SliceStable(picked, func(i, j int) bool {
+			return picked[i].CreatedAt.Before(picked[j].CreatedAt)
+		})
+		messages = picked
+	}
+	highlights := make([]string, 0, len(messages))
+	for _, message := range messages {
+		name := strings.TrimSpace(message.CharacterName)
+		if name == "" {
+			name = i18n.Text(locale, "report.companion")
+		}
+		highlights = append(highlights, i18n.Text(locale, "report.highlight.chat",
+			name, s.objectName(message.ObjectType, locale), message.ChildMessage, message.ReplyText))
+	}
+	return highlights
+}
+
+func (s *Service) dailyTemplateSuggestions(captures []model.Capture, locale i18n.Locale) []string {
+	if len(captures) == 0 {
+		return nil
+	}
+	latest := captures[0]
+	for _, capture := range captures[1:] {
+		if capture.CapturedAt.After(latest.CapturedAt) {
+			latest = capture
+		}
+	}
+	return []string{
+		i18n.Text(locale, "report.suggestion.find", s.objectName(latest.ObjectType, locale)),
+		i18n.Text(locale, "report.suggestion.retell", latest.SpiritName),
+	}
+}
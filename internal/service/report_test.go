@@ -0,0 +1,282 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ling/internal/llm"
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+func TestWeeklyReportComparesWithPreviousWeek(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	childID := "kid_weekly"
+	// 2026-02-11 是周三，所在周为 02-09 至 02-15。
+	anchor := time.Date(2026, 2, 11, 12, 0, 0, 0, time.Local)
+	add := func(id string, objectType string, at time.Time) {
+		t.Helper()
+		if err := st.AddCapture(model.Capture{ID: id, ChildID: childID, SpiritName: "精灵", ObjectType: objectType, Fact: "事实", CapturedAt: at}); err != nil {
+			t.Fatalf("AddCapture() error = %v", err)
+		}
+	}
+	add("c1", "tree", anchor.AddDate(0, 0, -7))
+	add("c2", "tree", anchor.AddDate(0, 0, -2))
+	add("c3", "mailbox", anchor.AddDate(0, 0, -1))
+	add("c4", "tree", anchor)
+	for i, captured := range []bool{true, true, false} {
+		err := st.SaveSession(model.ScanSession{
+			ID:          "sess_" + string(rune('a'+i)),
+			ChildID:     childID,
+			ChildAge:    7,
+			CreatedAt:   anchor,
+			AnswerGiven: "answer",
+			Captured:    captured,
+		})
+		if err != nil {
+			t.Fatalf("SaveSession() error = %v", err)
+		}
+	}
+
+	report, err := svc.PeriodReport(context.Background(), childID, service.ReportPeriodWeekly, anchor, "")
+	if err != nil {
+		t.Fatalf("PeriodReport() error = %v", err)
+	}
+	if report.Current.StartDate != "2026-02-09" || report.Current.EndDate != "2026-02-15" || report.Previous.StartDate != "2026-02-02" {
+		t.Fatalf("unexpected period range: current=%+v previous=%+v", report.Current, report.Previous)
+	}
+	if report.Current.Captures != 3 || report.Current.DistinctObjectTypes != 2 || report.Current.ActiveDays != 3 {
+		t.Fatalf("unexpected current stats: %+v", report.Current)
+	}
+	if report.Previous.Captures != 1 || report.Trend.Captures != 2 {
+		t.Fatalf("unexpected trend: previous=%+v trend=%+v", report.Previous, report.Trend)
+	}
+	if report.Current.QuizAnswered != 3 || report.Current.QuizCorrect != 2 || report.Current.QuizAccuracy != 0.667 {
+		t.Fatalf("unexpected quiz stats: %+v", report.Current)
+	}
+	if report.NarrativeSource != "template" || !strings.Contains(report.GeneratedText, "多收集了 2 个") {
+		t.Fatalf("expected template narrative with trend, got %q (%s)", report.GeneratedText, report.NarrativeSource)
+	}
+
+	if _, err := svc.PeriodReport(context.Background(), childID, "yearly", anchor, ""); err != service.ErrReportPeriodInvalid {
+		t.Fatalf("expected ErrReportPeriodInvalid, got %v", err)
+	}
+}
+
+func TestMonthlyReportUsesLLMNarrativeAndCaches(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"summary\":\"这个月孩子认识了大树，观察越来越细致。\",\"suggestions\":[\"周末一起捡落叶\",\"问问孩子树为什么会掉叶子\"]}"}}]}`))
+	}))
+	defer server.Close()
+	client, err := llm.NewClient(llm.Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	svc.SetLLMClient(client)
+
+	anchor := time.Date(2026, 3, 15, 10, 0, 0, 0, time.Local)
+	if err := st.AddCapture(model.Capture{ID: "m1", ChildID: "kid_monthly", ObjectType: "tree", CapturedAt: anchor}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+
+	first, err := svc.PeriodReport(context.Background(), "kid_monthly", service.ReportPeriodMonthly, anchor, "")
+	if err != nil {
+		t.Fatalf("PeriodReport() error = %v", err)
+	}
+	if first.NarrativeSource != "llm" || len(first.Suggestions) != 2 || first.Current.StartDate != "2026-03-01" || first.Previous.StartDate != "2026-02-01" {
+		t.Fatalf("unexpected monthly report: %+v", first)
+	}
+	if _, err := svc.PeriodReport(context.Background(), "kid_monthly", service.ReportPeriodMonthly, anchor.AddDate(0, 0, 3), ""); err != nil {
+		t.Fatalf("PeriodReport() error = %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected cached report to skip the LLM, got %d calls", calls.Load())
+	}
+	if _, err := svc.PeriodReport(context.Background(), "kid_monthly", service.ReportPeriodMonthly, anchor, "en"); err != nil {
+		t.Fatalf("PeriodReport() error = %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected another locale to bypass the cache, got %d calls", calls.Load())
+	}
+
+	if err := st.AddCapture(model.Capture{ID: "m2", ChildID: "kid_monthly", ObjectType: "mailbox", CapturedAt: anchor.Add(time.Hour)}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+	if _, err := svc.PeriodReport(context.Background(), "kid_monthly", service.ReportPeriodMonthly, anchor, ""); err != nil {
+		t.Fatalf("PeriodReport() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected new activity to invalidate the cache, got %d calls", calls.Load())
+	}
+}
+
+func TestPeriodReportFollowsLocale(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	childID := "kid_weekly_en"
+	anchor := time.Date(2026, 2, 11, 12, 0, 0, 0, time.Local)
+	if err := st.SaveChildProfile(model.ChildProfile{ChildID: childID, Locale: "en"}); err != nil {
+		t.Fatalf("SaveChildProfile() error = %v", err)
+	}
+	if err := st.AddCapture(model.Capture{ID: "c1", ChildID: childID, SpiritName: "Grandpa Tree", ObjectType: "tree", Fact: "Trees breathe through leaves", CapturedAt: anchor}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+
+	fallback, err := svc.PeriodReport(context.Background(), childID, service.ReportPeriodWeekly, anchor, "")
+	if err != nil {
+		t.Fatalf("PeriodReport() error = %v", err)
+	}
+	if !strings.HasPrefix(fallback.GeneratedText, "This week (2026-02-09 to 2026-02-15) kid_weekly_en caught 1 spirits") || !strings.Contains(fallback.GeneratedText, "1 more than last week") {
+		t.Fatalf("expected an english template narrative, got %q", fallback.GeneratedText)
+	}
+
+	var prompt atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		prompt.Store(string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"summary\":\"A great week outdoors.\",\"suggestions\":[\"Collect leaves together\"]}"}}]}`))
+	}))
+	defer server.Close()
+	client, err := llm.NewClient(llm.Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	svc.SetLLMClient(client)
+
+	if _, err := svc.PeriodReport(context.Background(), childID, service.ReportPeriodWeekly, anchor, ""); err != nil {
+		t.Fatalf("PeriodReport() error = %v", err)
+	}
+	sent, _ := prompt.Load().(string)
+	for _, want := range []string{"English", "This week (2026-02-09 to 2026-02-15)", "Caught spirits 1 times (last week: 0)", "Grandpa Tree (tree)"} {
+		if !strings.Contains(sent, want) {
+			t.Fatalf("expected prompt to contain %q, got %s", want, sent)
+		}
+	}
+	if strings.Contains(sent, "本周") || strings.Contains(sent, "（树）") {
+		t.Fatalf("expected no simplified chinese labels in the prompt, got %s", sent)
+	}
+}
+
+func TestDailyReportNarratesCapturesAndChats(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	childID := "kid_daily"
+	day := time.Date(2026, 2, 13, 10, 0, 0, 0, time.Local)
+	if err := st.AddCapture(model.Capture{ID: "c1", ChildID: childID, SpiritName: "树爷爷", ObjectType: "tree", Fact: "树会通过叶子呼吸", CapturedAt: day}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+	if err := st.AddCompanionMessage(model.CompanionMessage{ID: "chat_1", ChildID: childID, ObjectType: "tree", CharacterName: "树爷爷", ChildMessage: "你冬天冷不冷？", ReplyText: "我穿着厚厚的树皮呢。", CreatedAt: day.Add(time.Hour)}); err != nil {
+		t.Fatalf("AddCompanionMessage() error = %v", err)
+	}
+
+	fallback, err := svc.DailyReport(context.Background(), childID, day, "")
+	if err != nil {
+		t.Fatalf("DailyReport() error = %v", err)
+	}
+	if fallback.NarrativeSource != "template" || len(fallback.Suggestions) != 2 || !strings.Contains(fallback.Suggestions[1], "树爷爷") {
+		t.Fatalf("expected template narrative with suggestions, got %+v", fallback)
+	}
+
+	var prompt atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		prompt.Store(string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"summary\":\"今天孩子和树爷爷聊起了冬天。\",\"suggestions\":[\"一起摸摸树皮\",\"问问孩子树怎么过冬\"]}"}}]}`))
+	}))
+	defer server.Close()
+	client, err := llm.NewClient(llm.Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	svc.SetLLMClient(client)
+
+	report, err := svc.DailyReport(context.Background(), childID, day, "")
+	if err != nil {
+		t.Fatalf("DailyReport() error = %v", err)
+	}
+	if report.NarrativeSource != "llm" || report.GeneratedText != "今天孩子和树爷爷聊起了冬天。" || len(report.Suggestions) != 2 {
+		t.Fatalf("expected llm narrative, got %+v", report)
+	}
+	sent, _ := prompt.Load().(string)
+	if !strings.Contains(sent, "你冬天冷不冷？") || !strings.Contains(sent, "树会通过叶子呼吸") {
+		t.Fatalf("expected prompt to include chat and facts, got %s", sent)
+	}
+}
+
+func TestDailyReportPromptFollowsLocale(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	childID := "kid_daily_en"
+	day := time.Date(2026, 2, 13, 10, 0, 0, 0, time.Local)
+	if err := st.AddCapture(model.Capture{ID: "c1", ChildID: childID, SpiritName: "Grandpa Tree", ObjectType: "manhole", Fact: "Manholes lead to pipes", CapturedAt: day}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+	if err := st.AddCompanionMessage(model.CompanionMessage{ID: "chat_1", ChildID: childID, ObjectType: "manhole", ChildMessage: "Is it dark down there?", ReplyText: "Very dark!", CreatedAt: day.Add(time.Hour)}); err != nil {
+		t.Fatalf("AddCompanionMessage() error = %v", err)
+	}
+
+	var prompt atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		prompt.Store(string(body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"summary\":\"A curious day.\",\"suggestions\":[]}"}}]}`))
+	}))
+	defer server.Close()
+	client, err := llm.NewClient(llm.Config{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	svc.SetLLMClient(client)
+
+	if _, err := svc.DailyReport(context.Background(), childID, day, "en"); err != nil {
+		t.Fatalf("DailyReport() error = %v", err)
+	}
+	sent, _ := prompt.Load().(string)
+	for _, want := range []string{"Today (2026-02-13)", "Caught 1 spirits", "Caught Grandpa Tree (manhole cover)", "While chatting with the companion about the manhole cover"} {
+		if !strings.Contains(sent, want) {
+			t.Fatalf("expected prompt to contain %q, got %s", want, sent)
+		}
+	}
+	if strings.Contains(sent, "井盖") || strings.Contains(sent, "陪伴角色") {
+		t.Fatalf("expected no simplified chinese names in the prompt, got %s", sent)
+	}
+}
+
+func TestDailyReportListsBadgesUnlockedThatDay(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+	childID := "kid_daily_badges"
+	day := time.Date(2026, 2, 13, 10, 0, 0, 0, time.Local)
+	for _, unlock := range []model.BadgeUnlock{
+		{ID: "u1", ChildID: childID, BadgeID: "badge_01_plantae", BadgeName: "植物界", SessionID: "sess_1", UnlockedAt: day},
+		{ID: "u2", ChildID: childID, BadgeID: "badge_02_terrestrial_animal", BadgeName: "陆地动物", UnlockedAt: day, Seen: true},
+		{ID: "u3", ChildID: childID, BadgeID: "badge_03_aviary_insect", BadgeName: "飞行生物", SessionID: "sess_2", UnlockedAt: day.AddDate(0, 0, -1)},
+	} {
+		if err := st.SaveBadgeUnlock(unlock); err != nil {
+			t.Fatalf("SaveBadgeUnlock() error = %v", err)
+		}
+	}
+
+	report, err := svc.DailyReport(context.Background(), childID, day, "")
+	if err != nil {
+		t.Fatalf("DailyReport() error = %v", err)
+	}
+	if len(report.NewBadges) != 1 || report.NewBadges[0].ID != "u1" {
+		t.Fatalf("expected only the badge unlocked by an answer that day, got %+v", report.NewBadges)
+	}
+}
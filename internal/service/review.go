@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"ling/internal/i18n"
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+const (
+	reviewInitialEase     = 2.5
+	reviewMinEase         = 1.3
+	reviewQualityCorrect  = 4
+	reviewQualityWrong    = 1
+	reviewPassQuality     = 3
+	reviewDefaultDueLimit = 10
+	reviewMaxDueLimit     = 50
+)
+
+func (s *Service) DueReviews(ctx context.Context, childID string, now time.Time, limit int) (_ []ReviewDueItem, err error) {
+	ctx, span := startSpan(ctx, "DueReviews")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	if limit <= 0 {
+		limit = reviewDefaultDueLimit
+	}
+	if limit > reviewMaxDueLimit {
+		limit = reviewMaxDueLimit
+	}
+	items, err := st.ListReviewItemsByChild(childID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ReviewDueItem, 0, limit)
+	for _, item := range items {
+		if item.DueAt.After(now) {
+			continue
+		}
+		result = append(result, ReviewDueItem{
+			ReviewID:    item.ID,
+			ObjectType:  item.ObjectType,
+			SpiritName:  item.SpiritName,
+			Question:    item.Question,
+			Fact:        item.Fact,
+			Repetitions: item.Repetitions,
+			DueAt:       item.DueAt,
+		})
+		if len(result) >= limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (s *Service) SubmitReview(ctx context.Context, req ReviewAnswerRequest) (_ ReviewAnswerResponse, err error) {
+	ctx, span := startSpan(ctx, "SubmitReview")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID := strings.TrimSpace(req.ChildID)
+	if childID == "" {
+		childID = "guest"
+	}
+	locale := s.locale(ctx, childID, req.Locale)
+	reviewID := strings.TrimSpace(req.ReviewID)
+	if reviewID == "" {
+		return ReviewAnswerResponse{}, ErrReviewNotFound
+	}
+	item, ok, err := st.GetReviewItem(reviewID)
+	if err != nil {
+		return ReviewAnswerResponse{}, err
+	}
+	if !ok || item.ChildID != childID {
+		return ReviewAnswerResponse{}, ErrReviewNotFound
+	}
+	rawAnswer := strings.TrimSpace(req.Answer)
+	if rawAnswer == "" {
+		return ReviewAnswerResponse{}, ErrReviewAnswerEmpty
+	}
+
+	correct := isAnswerCorrect(normalizeAnswer(rawAnswer), item.Answer)
+	if s.llm != nil {
+		if judged, err := s.judgeAnswerByLLM(ctx, model.ScanSession{QuizQ: item.Question}, rawAnswer); err == nil {
+			correct = judged
+		}
+	}
+
+	quality := reviewQualityWrong
+	message := i18n.Text(locale, "review.incorrect")
+	if correct {
+		quality = reviewQualityCorrect
+		message = i18n.Text(locale, "review.correct")
+	}
+	// 复习只更新排期，不产生新的收集记录。
+	item = scheduleReview(item, quality, time.Now())
+	if err := st.SaveReviewItem(item); err != nil {
+		return ReviewAnswerResponse{}, err
+	}
+
+	return ReviewAnswerResponse{
+		ReviewID:     item.ID,
+		Correct:      correct,
+		Message:      message,
+		EaseFactor:   item.EaseFactor,
+		IntervalDays: item.IntervalDays,
+		Repetitions:  item.Repetitions,
+		NextDueAt:    item.DueAt,
+	}, nil
+}
+
+func (s *Service) scheduleReviewForCapture(ctx context.Context, session model.ScanSession, capture model.Capture) error {
+	st := s.storeFor(ctx)
+	question := strings.TrimSpace(session.QuizQ)
+	answer := strings.TrimSpace(session.QuizA)
+	if question == "" || answer == "" {
+		return nil
+	}
+	existing, err := st.ListReviewItemsByChild(capture.ChildID)
+	if err != nil {
+		return err
+	}
+	for _, item := range existing {
+		if item.Question == question {
+			// 同一道题已在复习队列中，重复收集不重置排期。
+			return nil
+		}
+	}
+
+	item := model.ReviewItem{
+		ID:         s.newID("review"),
+		ChildID:    capture.ChildID,
+		CaptureID:  capture.ID,
+		SpiritName: capture.SpiritName,
+		ObjectType: capture.ObjectType,
+		Fact:       capture.Fact,
+		Question:   question,
+		Answer:     answer,
+		EaseFactor: reviewInitialEase,
+		DueAt:      capture.CapturedAt.Add(24 * time.Hour),
+		CreatedAt:  capture.CapturedAt,
+	}
+	return st.SaveReviewItem(item)
+}
+
+// scheduleReview 按 SM-2 算法根据作答质量（0-5）更新易度因子与复习间隔。
+func scheduleReview(item model.ReviewItem, quality int, now time.Time) model.ReviewItem {
+	if quality < 0 {
+		quality = 0
+	}
+	if quality > 5 {
+		quality = 5
+	}
+	if item.EaseFactor <= 0 {
+		item.EaseFactor = reviewInitialEase
+	}
+
+	if quality < reviewPassQuality {
+		item.Repetitions = 0
+		item.IntervalDays = 1
+		item.Lapses++
+	} else {
+		item.Repetitions++
+		switch item.Repetitions {
+		case 1:
+			item.IntervalDays = 1
+		case 2:
+			item.IntervalDays = 6
+		default:
+			item.IntervalDays = int(math.Round(float64(item.IntervalDays) * item.EaseFactor))
+		}
+	}
+
+	miss := float64(5 - quality)
+	item.EaseFactor += 0.1 - miss*(0.08+miss*0.02)
+	if item.EaseFactor < reviewMinEase {
+		item.EaseFactor = reviewMinEase
+	}
+	item.LastReviewedAt = now
+	item.DueAt = now.Add(time.Duration(item.IntervalDays) * 24 * time.Hour)
+	return item
+}
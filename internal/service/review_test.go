@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ling/internal/service"
+)
+
+func TestReviewScheduledAfterCaptureAndDoesNotCapture(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
+		ChildID:       "kid_review",
+		ChildAge:      8,
+		DetectedLabel: "mailbox",
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	session, ok, err := st.GetSession(scanResp.SessionID)
+	if err != nil || !ok {
+		t.Fatalf("GetSession() error = %v, ok=%v", err, ok)
+	}
+	if _, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
+		SessionID: scanResp.SessionID,
+		ChildID:   "kid_review",
+		Answer:    session.QuizA,
+	}); err != nil {
+		t.Fatalf("SubmitAnswer() error = %v", err)
+	}
+
+	due, err := svc.DueReviews(context.Background(), "kid_review", time.Now(), 0)
+	if err != nil {
+		t.Fatalf("DueReviews() error = %v", err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("expected no review due on capture day, got %d", len(due))
+	}
+
+	due, err = svc.DueReviews(context.Background(), "kid_review", time.Now().Add(25*time.Hour), 0)
+	if err != nil {
+		t.Fatalf("DueReviews() error = %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("expected 1 review due next day, got %d", len(due))
+	}
+	if due[0].Question != session.QuizQ {
+		t.Fatalf("expected review question %q, got %q", session.QuizQ, due[0].Question)
+	}
+
+	correct, err := svc.SubmitReview(context.Background(), service.ReviewAnswerRequest{
+		ReviewID: due[0].ReviewID,
+		ChildID:  "kid_review",
+		Answer:   session.QuizA,
+	})
+	if err != nil {
+		t.Fatalf("SubmitReview() error = %v", err)
+	}
+	if !correct.Correct || correct.Repetitions != 1 || correct.IntervalDays != 1 {
+		t.Fatalf("expected first successful repetition with 1 day interval, got %+v", correct)
+	}
+
+	wrong, err := svc.SubmitReview(context.Background(), service.ReviewAnswerRequest{
+		ReviewID: due[0].ReviewID,
+		ChildID:  "kid_review",
+		Answer:   "完全不对的答案",
+	})
+	if err != nil {
+		t.Fatalf("SubmitReview() error = %v", err)
+	}
+	if wrong.Correct || wrong.Repetitions != 0 || wrong.IntervalDays != 1 {
+		t.Fatalf("expected lapse to reset repetitions, got %+v", wrong)
+	}
+	if wrong.EaseFactor >= correct.EaseFactor {
+		t.Fatalf("expected ease factor to drop after wrong answer, got %v -> %v", correct.EaseFactor, wrong.EaseFactor)
+	}
+
+	captures, err := st.ListCapturesByChild("kid_review")
+	if err != nil {
+		t.Fatalf("ListCapturesByChild() error = %v", err)
+	}
+	if len(captures) != 1 {
+		t.Fatalf("expected review answers not to add captures, got %d", len(captures))
+	}
+}
+
+func TestSubmitReviewRejectsOtherChild(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+
+	_, err := svc.SubmitReview(context.Background(), service.ReviewAnswerRequest{
+		ReviewID: "review_missing",
+		ChildID:  "kid_other",
+		Answer:   "x",
+	})
+	if err != service.ErrReviewNotFound {
+		t.Fatalf("expected ErrReviewNotFound, got %v", err)
+	}
+}
+
+func TestSubmitReviewFeedbackFollowsLocale(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+
+	captureObject(t, svc, st, "kid_review_en", "mailbox")
+	due, err := svc.DueReviews(context.Background(), "kid_review_en", time.Now().Add(25*time.Hour), 0)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("DueReviews() = %d items, err=%v", len(due), err)
+	}
+	resp, err := svc.SubmitReview(context.Background(), service.ReviewAnswerRequest{
+		ReviewID: due[0].ReviewID,
+		ChildID:  "kid_review_en",
+		Answer:   "完全不对的答案",
+		Locale:   "en",
+	})
+	if err != nil {
+		t.Fatalf("SubmitReview() error = %v", err)
+	}
+	if resp.Message != "Not quite. Let's review this one again tomorrow." {
+		t.Fatalf("expected english feedback, got %q", resp.Message)
+	}
+}
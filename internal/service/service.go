@@ -2,147 +2,97 @@ package service
 
 import (
 	"context"
+	cryptorand "crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"net"
+	"net/http"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"ling/internal/delivery"
+	"ling/internal/i18n"
 	"ling/internal/llm"
+	"ling/internal/metrics"
 	"ling/internal/model"
+	"ling/internal/report"
 	"ling/internal/store"
+	"ling/internal/tracing"
 )
 
 var (
-	ErrUnsupportedObject = errors.New("暂不支持该识别对象")
-	ErrSessionNotFound   = errors.New("未找到对应的扫描会话")
-	ErrAlreadyCaptured   = errors.New("该会话已完成收集")
-	ErrLLMUnavailable    = errors.New("未配置大模型能力")
-	ErrImageRequired     = errors.New("请提供 image_base64 或 image_url")
-	ErrScanInputRequired = errors.New("请提供 detected_label 或 image_base64/image_url")
-	ErrContentGenerate   = errors.New("学习内容生成服务暂不可用，请稍后重试")
-	ErrInvalidChildAge   = errors.New("child_age 必须在 3 到 15 之间")
-	ErrObjectTypeMissing = errors.New("请提供 object_type")
-	ErrChildMessageEmpty = errors.New("请提供 child_message")
-	ErrStoryTextMissing  = errors.New("请提供 text")
-	ErrMediaUnavailable  = errors.New("角色形象或语音能力暂不可用")
-	ErrImageUpload       = errors.New("图片上传失败")
-	ErrCompanionTimeout  = errors.New("剧情回复生成超时，请稍后再试")
+	// 请求层面的通用错误，由 HTTP 处理器在调用服务前使用。
+	ErrRequestBodyInvalid = NewError(CodeRequestBodyInvalid, http.StatusBadRequest, "请求体格式不正确")
+	ErrQueryInvalid       = NewError(CodeQueryInvalid, http.StatusBadRequest, "查询参数不正确")
+	ErrAdminDisabled      = NewError(CodeAdminDisabled, http.StatusForbidden, "管理接口未启用，请配置 CITYLING_ADMIN_TOKEN")
+	ErrAdminUnauthorized  = NewError(CodeAdminUnauthorized, http.StatusUnauthorized, "管理令牌无效")
+	ErrLocaleUnsupported  = NewError(CodeLocaleUnsupported, http.StatusBadRequest, "不支持的语言，可选 zh-CN、en、zh-TW")
+	ErrRequestTimeout     = NewError(CodeRequestTimeout, http.StatusGatewayTimeout, "请求处理超时，请稍后重试")
+	ErrRequestCanceled    = NewError(CodeRequestCanceled, StatusClientClosedRequest, "客户端已断开连接")
+	ErrRateLimited        = NewError(CodeRateLimited, http.StatusTooManyRequests, "请求过于频繁，请稍后再试")
+
+	ErrUnsupportedObject   = NewError(CodeObjectUnsupported, http.StatusBadRequest, "暂不支持该识别对象")
+	ErrSessionNotFound     = NewError(CodeSessionNotFound, http.StatusNotFound, "未找到对应的扫描会话")
+	ErrAlreadyCaptured     = NewError(CodeAlreadyCaptured, http.StatusConflict, "该会话已完成收集")
+	ErrLLMUnavailable      = NewError(CodeLLMUnavailable, http.StatusServiceUnavailable, "未配置大模型能力")
+	ErrImageRequired       = NewError(CodeImageRequired, http.StatusBadRequest, "请提供 image_base64 或 image_url")
+	ErrScanInputRequired   = NewError(CodeScanInputRequired, http.StatusBadRequest, "请提供 detected_label 或 image_base64/image_url")
+	ErrContentGenerate     = NewError(CodeContentUnavailable, http.StatusServiceUnavailable, "学习内容生成服务暂不可用，请稍后重试")
+	ErrInvalidChildAge     = NewError(CodeChildAgeInvalid, http.StatusBadRequest, "child_age 必须在 3 到 15 之间").WithDetails(map[string]any{"min": 3, "max": 15})
+	ErrObjectTypeMissing   = NewError(CodeObjectTypeRequired, http.StatusBadRequest, "请提供 object_type")
+	ErrChildMessageEmpty   = NewError(CodeChildMessageRequired, http.StatusBadRequest, "请提供 child_message")
+	ErrStoryTextMissing    = NewError(CodeTextRequired, http.StatusBadRequest, "请提供 text")
+	ErrMediaUnavailable    = NewError(CodeMediaUnavailable, http.StatusServiceUnavailable, "角色形象或语音能力暂不可用")
+	ErrImageUpload         = NewError(CodeImageUploadFailed, http.StatusServiceUnavailable, "图片上传失败")
+	ErrCompanionTimeout    = NewError(CodeCompanionTimeout, http.StatusGatewayTimeout, "剧情回复生成超时，请稍后再试")
+	ErrReviewNotFound      = NewError(CodeReviewNotFound, http.StatusNotFound, "未找到对应的复习题目")
+	ErrReviewAnswerEmpty   = NewError(CodeAnswerRequired, http.StatusBadRequest, "请提供 answer")
+	ErrBadgeNotFound       = NewError(CodeBadgeNotFound, http.StatusNotFound, "未找到对应的勋章")
+	ErrBadgeExists         = NewError(CodeBadgeExists, http.StatusConflict, "勋章 ID 已存在")
+	ErrBadgeInvalid        = NewError(CodeBadgeInvalid, http.StatusBadRequest, "勋章配置无效")
+	ErrBadgeReadOnly       = NewError(CodeBadgeReadOnly, http.StatusConflict, "未配置勋章规则文件，无法修改勋章")
+	ErrReportPeriodInvalid = NewError(CodeReportPeriodInvalid, http.StatusBadRequest, "period 必须是 weekly 或 monthly")
+
+	ErrDeliveryNotFound           = NewError(CodeDeliveryNotFound, http.StatusNotFound, "未找到对应的推送订阅")
+	ErrDeliveryInvalid            = NewError(CodeDeliveryInvalid, http.StatusBadRequest, "推送订阅配置无效")
+	ErrDeliveryChannelUnavailable = NewError(CodeDeliveryChannelUnavailable, http.StatusBadRequest, "推送方式未启用")
+
+	ErrLocationInvalid = NewError(CodeLocationInvalid, http.StatusBadRequest, "latitude/longitude 必须成对提供且在有效范围内")
+	ErrMapQueryInvalid = NewError(CodeMapQueryInvalid, http.StatusBadRequest, "地图查询参数无效")
+
+	ErrQuestNotFound       = NewError(CodeQuestNotFound, http.StatusNotFound, "未找到对应的任务")
+	ErrQuestNotCompleted   = NewError(CodeQuestNotCompleted, http.StatusConflict, "任务尚未完成，暂时不能领取奖励")
+	ErrQuestAlreadyClaimed = NewError(CodeQuestAlreadyClaimed, http.StatusConflict, "任务奖励已领取")
+
+	ErrGroupNotFound           = NewError(CodeGroupNotFound, http.StatusNotFound, "未找到对应的群组")
+	ErrGroupInvalid            = NewError(CodeGroupInvalid, http.StatusBadRequest, "群组参数无效")
+	ErrGroupForbidden          = NewError(CodeGroupForbidden, http.StatusForbidden, "没有权限访问该群组")
+	ErrInviteCodeInvalid       = NewError(CodeInviteCodeInvalid, http.StatusNotFound, "邀请码无效或已失效")
+	ErrLeaderboardQueryInvalid = NewError(CodeLeaderboardQueryInvalid, http.StatusBadRequest, "排行榜查询参数无效")
+
+	ErrCaptureNotFound = NewError(CodeCaptureNotFound, http.StatusNotFound, "未找到对应的收集记录")
+	ErrShareInvalid    = NewError(CodeShareInvalid, http.StatusBadRequest, "分享参数无效")
+	ErrShareNotFound   = NewError(CodeShareNotFound, http.StatusNotFound, "分享链接不存在或已失效")
+	ErrShareExpired    = NewError(CodeShareExpired, http.StatusGone, "分享链接已过期")
+	ErrTradeNotFound   = NewError(CodeTradeNotFound, http.StatusNotFound, "未找到对应的交换")
+	ErrTradeInvalid    = NewError(CodeTradeInvalid, http.StatusBadRequest, "交换参数无效")
+	ErrTradeForbidden  = NewError(CodeTradeForbidden, http.StatusForbidden, "没有权限处理该交换")
+	ErrTradeConflict   = NewError(CodeTradeConflict, http.StatusConflict, "交换已处理，或精灵已不在原主人手中")
 )
 
-type ScanRequest struct {
-	ChildID       string `json:"child_id"`
-	ChildAge      int    `json:"child_age"`
-	DetectedLabel string `json:"detected_label"`
-	ImageBase64   string `json:"image_base64,omitempty"`
-	ImageURL      string `json:"image_url,omitempty"`
-}
-
-type ScanResponse struct {
-	SessionID  string       `json:"session_id"`
-	ObjectType string       `json:"object_type"`
-	Spirit     model.Spirit `json:"spirit"`
-	Fact       string       `json:"fact"`
-	Quiz       string       `json:"quiz"`
-	Dialogues  []string     `json:"dialogues"`
-	CacheHit   bool         `json:"cache_hit"`
-}
-
-type ScanImageRequest struct {
-	ChildID     string `json:"child_id"`
-	ChildAge    int    `json:"child_age"`
-	ImageBase64 string `json:"image_base64,omitempty"`
-	ImageURL    string `json:"image_url,omitempty"`
-}
-
-type ScanImageResponse struct {
-	DetectedLabel   string `json:"detected_label"`
-	DetectedLabelEn string `json:"detected_label_en"`
-	RawLabel        string `json:"raw_label"`
-	Reason          string `json:"reason,omitempty"`
-}
-
-type AnswerRequest struct {
-	SessionID string `json:"session_id"`
-	ChildID   string `json:"child_id"`
-	Answer    string `json:"answer"`
-}
-
-type AnswerResponse struct {
-	Correct  bool           `json:"correct"`
-	Captured bool           `json:"captured"`
-	Message  string         `json:"message"`
-	Capture  *model.Capture `json:"capture,omitempty"`
-}
-
-type CompanionSceneRequest struct {
-	ChildID           string `json:"child_id"`
-	ChildAge          int    `json:"child_age"`
-	ObjectType        string `json:"object_type"`
-	Weather           string `json:"weather,omitempty"`
-	Environment       string `json:"environment,omitempty"`
-	ObjectTraits      string `json:"object_traits,omitempty"`
-	SourceImageBase64 string `json:"source_image_base64,omitempty"`
-	SourceImageURL    string `json:"source_image_url,omitempty"`
-}
-
-type CompanionSceneResponse struct {
-	CharacterName        string `json:"character_name"`
-	CharacterPersonality string `json:"character_personality"`
-	DialogText           string `json:"dialog_text"`
-	ImagePrompt          string `json:"image_prompt"`
-	CharacterImageURL    string `json:"character_image_url"`
-	CharacterImageBase64 string `json:"character_image_base64,omitempty"`
-	CharacterImageMIME   string `json:"character_image_mime_type,omitempty"`
-	VoiceAudioBase64     string `json:"voice_audio_base64"`
-	VoiceMimeType        string `json:"voice_mime_type"`
-}
-
-type CompanionChatRequest struct {
-	ChildID              string   `json:"child_id"`
-	ChildAge             int      `json:"child_age"`
-	ObjectType           string   `json:"object_type"`
-	CharacterName        string   `json:"character_name"`
-	CharacterPersonality string   `json:"character_personality,omitempty"`
-	Weather              string   `json:"weather,omitempty"`
-	Environment          string   `json:"environment,omitempty"`
-	ObjectTraits         string   `json:"object_traits,omitempty"`
-	History              []string `json:"history,omitempty"`
-	ChildMessage         string   `json:"child_message"`
-}
-
-type CompanionChatResponse struct {
-	ReplyText        string `json:"reply_text"`
-	VoiceAudioBase64 string `json:"voice_audio_base64"`
-	VoiceMimeType    string `json:"voice_mime_type"`
-}
-
-type CompanionVoiceRequest struct {
-	ChildID    string `json:"child_id"`
-	ChildAge   int    `json:"child_age"`
-	ObjectType string `json:"object_type"`
-	Text       string `json:"text"`
-}
-
-type CompanionVoiceResponse struct {
-	VoiceAudioBase64 string `json:"voice_audio_base64"`
-	VoiceMimeType    string `json:"voice_mime_type"`
-}
-
 type UploadImageRequest struct {
 	FileName string
 	Bytes    []byte
 }
 
-type UploadImageResponse struct {
-	ImageURL string `json:"image_url"`
-}
-
 type cacheEntry struct {
 	ObjectType string
 	Spirit     model.Spirit
@@ -159,15 +109,51 @@ type Service struct {
 	aliases map[string]string
 	llm     *llm.Client
 
-	badgeRules    []badgeRule
-	badgeImageURL map[string]string
+	// badgeRules 与 badgeImageURL 可在运行时整体替换，读取时需持有 catalogMu。
+	catalogMu      sync.RWMutex
+	badgeRules     []BadgeRule
+	badgeImageURL  map[string]string
+	badgeRulesFile string
+	badgeMu        sync.Mutex
+
+	progressionRules progressionRules
+	progressMu       sync.Mutex
+
+	spiritEvolution spiritEvolutionRules
+	// spiritMu 串行化专属精灵的创建、成长与形象更新。
+	spiritMu sync.Mutex
+
+	locationPrecision int
+
+	questCatalog questCatalog
+	questMu      sync.Mutex
+
+	// groupMu 串行化邀请码生成与成员变更。
+	groupMu sync.Mutex
+	tradeMu sync.Mutex
+
+	reportMu    sync.Mutex
+	reportCache map[string]reportCacheEntry
+
+	// deliveryMu 串行化定时投递与订阅修改，避免同一订阅被重复推送。
+	deliveryMu       sync.Mutex
+	deliveryChannels map[string]delivery.Channel
+	renderer         *report.Renderer
 
 	cacheMu  sync.RWMutex
 	cache    map[string]cacheEntry
 	cacheTTL time.Duration
 
+	// probeMu 保护上游探测缓存，同时串行化探测本身。
+	probeMu      sync.Mutex
+	probeTTL     time.Duration
+	probeCache   probeCache
+	shuttingDown atomic.Bool
+
 	rngMu sync.Mutex
 	rng   *rand.Rand
+	// idSeq 为 newID 在随机源不可用时的后备后缀。
+	idSeq atomic.Uint64
 }
 
 func New(st store.Store, knowledge []model.KnowledgeItem) *Service {
@@ -188,8 +174,17 @@ func New(st store.Store, knowledge []model.KnowledgeItem) *Service {
 		badgeRules:    loadBadgeRules(),
 		badgeImageURL: loadBadgeImageURLMap(),
 		cache:         make(map[string]cacheEntry),
+		reportCache:   make(map[string]reportCacheEntry),
+		renderer:      report.NewRenderer(report.DefaultPalette()),
 		cacheTTL:      5 * time.Minute,
 		rng:           rand.New(rand.NewSource(time.Now().UnixNano())),
+
+		progressionRules: loadProgressionRules(),
+		questCatalog:     loadQuestCatalog(),
+		spiritEvolution:  loadSpiritEvolutionRules(),
+		deliveryChannels: make(map[string]delivery.Channel),
+
+		locationPrecision: defaultLocationPrecision,
 	}
 }
 
@@ -197,14 +192,16 @@ func (s *Service) SetLLMClient(client *llm.Client) {
 	s.llm = client
 }
 
-func (s *Service) ScanImage(req ScanImageRequest) (ScanImageResponse, error) {
+func (s *Service) ScanImage(ctx context.Context, req ScanImageRequest) (_ ScanImageResponse, err error) {
+	ctx, span := startSpan(ctx, "ScanImage")
+	defer func() { tracing.End(span, err) }()
 	if s.llm == nil {
 		return ScanImageResponse{}, ErrLLMUnavailable
 	}
 	if strings.TrimSpace(req.ImageBase64) == "" && strings.TrimSpace(req.ImageURL) == "" {
 		return ScanImageResponse{}, ErrImageRequired
 	}
-	result, err := s.llm.RecognizeObject(context.Background(), req.ImageBase64, req.ImageURL)
+	result, err := s.llm.RecognizeObject(ctx, req.ImageBase64, req.ImageURL)
 	if err != nil {
 		return ScanImageResponse{}, err
 	}
@@ -216,14 +213,22 @@ func (s *Service) ScanImage(req ScanImageRequest) (ScanImageResponse, error) {
 	}, nil
 }
 
-func (s *Service) Scan(req ScanRequest) (ScanResponse, error) {
+func (s *Service) Scan(ctx context.Context, req ScanRequest) (_ ScanResponse, err error) {
+	ctx, span := startSpan(ctx, "Scan")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
 	childID := strings.TrimSpace(req.ChildID)
 	if childID == "" {
 		childID = "guest"
 	}
+	locale := s.locale(ctx, childID, req.Locale)
 	if req.ChildAge < 3 || req.ChildAge > 15 {
 		return ScanResponse{}, ErrInvalidChildAge
 	}
+	location, err := s.scanLocation(req)
+	if err != nil {
+		return ScanResponse{}, err
+	}
 
 	detectedLabel := strings.TrimSpace(req.DetectedLabel)
 	hasImage := strings.TrimSpace(req.ImageBase64) != "" || strings.TrimSpace(req.ImageURL) != ""
@@ -231,7 +236,7 @@ func (s *Service) Scan(req ScanRequest) (ScanResponse, error) {
 		if s.llm == nil {
 			return ScanResponse{}, ErrLLMUnavailable
 		}
-		recognized, err := s.llm.RecognizeObject(context.Background(), req.ImageBase64, req.ImageURL)
+		recognized, err := s.llm.RecognizeObject(ctx, req.ImageBase64, req.ImageURL)
 		if err != nil {
 			return ScanResponse{}, err
 		}
@@ -247,22 +252,21 @@ func (s *Service) Scan(req ScanRequest) (ScanResponse, error) {
 		// 不在知识库中，使用原始标签作为 objectType（允许任意物体）
 		objectType = normalizeLabel(detectedLabel)
 	}
+	annotate(ctx, childID, objectType)
 
-	cacheKey := objectType + "|" + strconv.Itoa(ageBucket(req.ChildAge))
+	cacheKey := objectType + "|" + strconv.Itoa(ageBucket(req.ChildAge)) + "|" + string(locale)
 	entry, hit := s.getCache(cacheKey)
 	if !hit {
 		item := s.items[objectType]
-		spirit := s.generateSpirit(objectType, req.ChildAge)
-		if err := s.store.SaveSpirit(spirit); err != nil {
-			return ScanResponse{}, err
-		}
+		// 缓存里的精灵只是蓝本，孩子的专属精灵在下面按孩子解析。
+		spirit := s.generateSpirit(objectType, req.ChildAge, locale)
 
 		// 优先使用 LLM 生成内容，如果不在知识库中或 LLM 失败，再使用知识库
 		var fact string
 		var quiz model.QuizItem
 		var dialogues []string
 
-		generated, err := s.generateLearningByLLM(objectType, req.ChildAge, spirit)
+		generated, err := s.generateLearningByLLM(ctx, objectType, req.ChildAge, spirit, locale)
 		if err == nil {
 			// LLM 生成成功，使用 LLM 内容
 			fact = generated.Fact
@@ -271,14 +275,28 @@ func (s *Service) Scan(req ScanRequest) (ScanResponse, error) {
 				Answer:   generated.QuizA,
 			}
 			dialogues = generated.Dialogues
+			metrics.ContentSource.Inc("learning", "llm")
 		} else {
+			if ctx.Err() != nil {
+				// 请求已取消或超时：不再生成兜底内容，避免把兜底内容写进缓存。
+				return ScanResponse{}, ctx.Err()
+			}
+			if s.llm != nil {
+				slog.WarnContext(ctx, "learning content fallback", "object_type", objectType, "locale", string(locale), "err", err)
+			}
 			// LLM 生成失败，先尝试知识库；若仍不可用则使用本地模板兜底，避免 scan 直接失败。
-			fact = s.pick(item.Facts)
-			quiz = s.pickQuiz(item.Quiz)
+			// 内置知识库只有中文内容，其他语言直接使用本地模板。
+			source := "knowledge_base"
+			if locale.Chinese() {
+				fact = s.pick(item.Facts)
+				quiz = s.pickQuiz(item.Quiz)
+			}
 			if fact == "" || quiz.Question == "" || strings.TrimSpace(quiz.Answer) == "" {
-				fact, quiz = s.defaultLearningContent(objectType)
+				fact, quiz = s.defaultLearningContent(objectType, locale)
+				source = "template"
 			}
-			dialogues = s.generateDialogues(spirit, req.ChildAge, fact, quiz.Question)
+			metrics.ContentSource.Inc("learning", source)
+			dialogues = s.generateDialogues(spirit, req.ChildAge, fact, quiz.Question, locale)
 		}
 
 		entry = cacheEntry{
@@ -293,30 +311,38 @@ func (s *Service) Scan(req ScanRequest) (ScanResponse, error) {
 		s.putCache(cacheKey, entry)
 	}
 
+	spirit, err := s.childSpirit(ctx, childID, objectType, entry.Spirit, locale)
+	if err != nil {
+		return ScanResponse{}, err
+	}
+
 	session := model.ScanSession{
 		ID:         s.newID("sess"),
 		ChildID:    childID,
 		ChildAge:   req.ChildAge,
 		ObjectType: objectType,
-		SpiritID:   entry.Spirit.ID,
+		SpiritID:   spirit.ID,
 		QuizQ:      entry.QuizQ,
 		QuizA:      strings.ToLower(strings.TrimSpace(entry.QuizA)),
 		Fact:       entry.Fact,
 		CreatedAt:  time.Now(),
 		CacheHit:   hit,
+		Location:   location,
 	}
-	if err := s.store.SaveSession(session); err != nil {
+	if err := st.SaveSession(session); err != nil {
 		return ScanResponse{}, err
 	}
-	dialogues := entry.Dialogues
+	slog.DebugContext(ctx, "scan session saved", "session_id", session.ID, "child_id", childID, "object_type", objectType, "cache_hit", hit)
+	dialogues := personalizeDialogues(entry.Dialogues, entry.Spirit.Name, spirit.Name)
 	if len(dialogues) == 0 {
-		dialogues = s.generateDialogues(entry.Spirit, req.ChildAge, entry.Fact, entry.QuizQ)
+		dialogues = s.generateDialogues(spirit, req.ChildAge, entry.Fact, entry.QuizQ, locale)
 	}
 
 	return ScanResponse{
 		SessionID:  session.ID,
 		ObjectType: objectType,
-		Spirit:     entry.Spirit,
+		ObjectName: s.objectName(objectType, locale),
+		Spirit:     spirit,
 		Fact:       entry.Fact,
 		Quiz:       entry.QuizQ,
 		Dialogues:  dialogues,
@@ -324,7 +350,10 @@ func (s *Service) Scan(req ScanRequest) (ScanResponse, error) {
 	}, nil
 }
 
-func (s *Service) GenerateCompanionScene(req CompanionSceneRequest) (CompanionSceneResponse, error) {
+func (s *Service) GenerateCompanionScene(ctx context.Context, req CompanionSceneRequest) (_ CompanionSceneResponse, err error) {
+	ctx, span := startSpan(ctx, "GenerateCompanionScene")
+	defer func() { tracing.End(span, err) }()
+	locale := s.locale(ctx, req.ChildID, req.Locale)
 	if req.ChildAge < 3 || req.ChildAge > 15 {
 		return CompanionSceneResponse{}, ErrInvalidChildAge
 	}
@@ -335,12 +364,13 @@ func (s *Service) GenerateCompanionScene(req CompanionSceneRequest) (CompanionSc
 	if s.llm == nil {
 		return CompanionSceneResponse{}, ErrLLMUnavailable
 	}
+	annotate(ctx, req.ChildID, objectType)
 
 	sourceImageBase64 := strings.TrimSpace(req.SourceImageBase64)
 	sourceImageURL := strings.TrimSpace(req.SourceImageURL)
 	if sourceImageURL == "" && sourceImageBase64 != "" {
 		// 向后兼容：旧客户端仍传 base64 时，先上传成 URL，避免下游继续使用 base64。
-		uploaded, err := s.uploadBase64ToPublicURL(sourceImageBase64, "source.jpg")
+		uploaded, err := s.uploadBase64ToPublicURL(ctx, sourceImageBase64, "source.jpg")
 		if err == nil {
 			sourceImageURL = uploaded
 		}
@@ -355,24 +385,35 @@ func (s *Service) GenerateCompanionScene(req CompanionSceneRequest) (CompanionSc
 		objectTraits = ""
 	}
 
-	scene, err := s.llm.GenerateCompanionScene(context.Background(), llm.CompanionSceneRequest{
+	scene, err := s.llm.GenerateCompanionScene(ctx, llm.CompanionSceneRequest{
 		ObjectType:   objectType,
 		ChildAge:     req.ChildAge,
 		Weather:      weather,
 		Environment:  environment,
 		ObjectTraits: objectTraits,
+		Language:     string(locale),
 	})
-	if err != nil {
+	if err == nil {
+		metrics.ContentSource.Inc("companion_scene", "llm")
+	} else {
+		slog.WarnContext(ctx, "companion scene fallback", "object_type", objectType, "err", err)
+		metrics.ContentSource.Inc("companion_scene", "template")
 		scene = s.defaultCompanionScene(
 			objectType,
 			req.ChildAge,
 			weather,
 			environment,
 			objectTraits,
+			locale,
 		)
 	}
-	scene.CharacterName = normalizeCompanionCharacterName(scene.CharacterName, objectType)
-	scene.DialogText = ensureCompanionEmotionHook(scene.DialogText, scene.CharacterName, objectType)
+	if locale == i18n.ZhCN {
+		scene.CharacterName = normalizeCompanionCharacterName(scene.CharacterName, objectType)
+		scene.DialogText = ensureCompanionEmotionHook(scene.DialogText, scene.CharacterName, objectType)
+	} else if strings.TrimSpace(scene.CharacterName) == "" {
+		// 开场钩子与角色名校正都基于简体中文文案，其他语言只补齐角色名。
+		scene.CharacterName = s.objectName(objectType, locale)
+	}
 
 	imagePrompt := ensureInteractiveGazePrompt(scene.ImagePrompt)
 	if sourceImageURL != "" || sourceImageBase64 != "" {
@@ -381,6 +422,9 @@ func (s *Service) GenerateCompanionScene(req CompanionSceneRequest) (CompanionSc
 			strings.TrimSpace(objectTypeToChinese(objectType)),
 		)
 	}
+	if hint := s.spiritImageHint(ctx, strings.TrimSpace(req.SpiritID)); hint != "" {
+		imagePrompt += "角色形象：" + hint + "。"
+	}
 
 	sourceImageRef := sourceImageURL
 	if sourceImageRef == "" {
@@ -395,26 +439,44 @@ func (s *Service) GenerateCompanionScene(req CompanionSceneRequest) (CompanionSc
 		mimeType   string
 		voiceErr   error
 	)
+	// 形象与语音并发生成：请求取消或超时时两路一起停止；任一路失败时整体已无法成功，另一路也随之取消。
+	mediaCtx, cancelMedia := context.WithCancel(ctx)
+	defer cancelMedia()
 	var mediaWG sync.WaitGroup
 	mediaWG.Add(2)
 	go func() {
 		defer mediaWG.Done()
+		ctx, span := tracing.Start(mediaCtx, "companion.image")
+		defer func() { tracing.End(span, imageErr) }()
 		imageURL, imageErr = s.llm.GenerateCharacterImage(
-			context.Background(),
+			ctx,
 			imagePrompt,
 			sourceImageRef,
 		)
+		if imageErr != nil {
+			cancelMedia()
+		}
 	}()
 	go func() {
 		defer mediaWG.Done()
+		ctx, span := tracing.Start(mediaCtx, "companion.tts")
+		defer func() { tracing.End(span, voiceErr) }()
 		audioBytes, mimeType, voiceErr = s.llm.SynthesizeSpeech(
-			context.Background(),
+			ctx,
 			scene.DialogText,
 			objectType,
+			ttsLanguage(locale),
 		)
+		if voiceErr != nil {
+			cancelMedia()
+		}
 	}()
 	mediaWG.Wait()
 
+	if imageErr != nil && voiceErr != nil && ctx.Err() == nil && errors.Is(imageErr, context.Canceled) {
+		// 形象生成是因语音失败而被取消的，返回语音的错误。
+		imageErr = nil
+	}
 	if imageErr != nil {
 		if errors.Is(imageErr, llm.ErrImageCapabilityUnavailable) {
 			return CompanionSceneResponse{}, ErrMediaUnavailable
@@ -428,7 +490,9 @@ func (s *Service) GenerateCompanionScene(req CompanionSceneRequest) (CompanionSc
 		return CompanionSceneResponse{}, voiceErr
 	}
 
-	imageBytes, imageMIME, err := s.llm.DownloadImage(context.Background(), imageURL)
+	downloadCtx, downloadSpan := tracing.Start(ctx, "companion.download")
+	imageBytes, imageMIME, err := s.llm.DownloadImage(downloadCtx, imageURL)
+	tracing.End(downloadSpan, err)
 	var imageBase64 string
 	if err == nil && imageBytes != nil && len(imageBytes) > 0 {
 		imageBase64 = base64.StdEncoding.EncodeToString(imageBytes)
@@ -441,6 +505,9 @@ func (s *Service) GenerateCompanionScene(req CompanionSceneRequest) (CompanionSc
 		// data URL 已在 base64 字段回传，避免重复放大响应体
 		imageURL = ""
 	}
+	if spiritID := strings.TrimSpace(req.SpiritID); spiritID != "" && imageURL != "" {
+		s.rememberSpiritImage(ctx, spiritID, imageURL)
+	}
 
 	return CompanionSceneResponse{
 		CharacterName:        scene.CharacterName,
@@ -455,21 +522,23 @@ func (s *Service) GenerateCompanionScene(req CompanionSceneRequest) (CompanionSc
 	}, nil
 }
 
-func (s *Service) UploadImage(req UploadImageRequest) (UploadImageResponse, error) {
+func (s *Service) UploadImage(ctx context.Context, req UploadImageRequest) (_ UploadImageResponse, err error) {
+	ctx, span := startSpan(ctx, "UploadImage")
+	defer func() { tracing.End(span, err) }()
 	if len(req.Bytes) == 0 {
 		return UploadImageResponse{}, ErrImageRequired
 	}
 	if s.llm == nil {
 		return UploadImageResponse{}, ErrLLMUnavailable
 	}
-	url, err := s.llm.UploadImageBytesToPublicURL(context.Background(), req.Bytes, req.FileName)
+	url, err := s.llm.UploadImageBytesToPublicURL(ctx, req.Bytes, req.FileName)
 	if err != nil {
 		return UploadImageResponse{}, fmt.Errorf("%w: %v", ErrImageUpload, err)
 	}
 	return UploadImageResponse{ImageURL: strings.TrimSpace(url)}, nil
 }
 
-func (s *Service) uploadBase64ToPublicURL(base64Image string, fileName string) (string, error) {
+func (s *Service) uploadBase64ToPublicURL(ctx context.Context, base64Image string, fileName string) (string, error) {
 	trimmed := strings.TrimSpace(base64Image)
 	if trimmed == "" {
 		return "", ErrImageRequired
@@ -485,7 +554,7 @@ func (s *Service) uploadBase64ToPublicURL(base64Image string, fileName string) (
 	if err != nil {
 		return "", err
 	}
-	resp, err := s.UploadImage(UploadImageRequest{
+	resp, err := s.UploadImage(ctx, UploadImageRequest{
 		FileName: fileName,
 		Bytes:    raw,
 	})
@@ -495,7 +564,11 @@ func (s *Service) uploadBase64ToPublicURL(base64Image string, fileName string) (
 	return resp.ImageURL, nil
 }
 
-func (s *Service) ChatCompanion(req CompanionChatRequest) (CompanionChatResponse, error) {
+func (s *Service) ChatCompanion(ctx context.Context, req CompanionChatRequest) (_ CompanionChatResponse, err error) {
+	ctx, span := startSpan(ctx, "ChatCompanion")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	locale := s.locale(ctx, req.ChildID, req.Locale)
 	if req.ChildAge < 3 || req.ChildAge > 15 {
 		return CompanionChatResponse{}, ErrInvalidChildAge
 	}
@@ -510,8 +583,9 @@ func (s *Service) ChatCompanion(req CompanionChatRequest) (CompanionChatResponse
 	if s.llm == nil {
 		return CompanionChatResponse{}, ErrLLMUnavailable
 	}
+	annotate(ctx, req.ChildID, objectType)
 
-	reply, err := s.llm.GenerateCompanionReply(context.Background(), llm.CompanionReplyRequest{
+	reply, err := s.llm.GenerateCompanionReply(ctx, llm.CompanionReplyRequest{
 		ObjectType:           objectType,
 		ChildAge:             req.ChildAge,
 		CharacterName:        strings.TrimSpace(req.CharacterName),
@@ -521,6 +595,7 @@ func (s *Service) ChatCompanion(req CompanionChatRequest) (CompanionChatResponse
 		ObjectTraits:         strings.TrimSpace(req.ObjectTraits),
 		History:              req.History,
 		ChildMessage:         childMessage,
+		Language:             string(locale),
 	})
 	if err != nil {
 		if isTimeoutError(err) {
@@ -528,9 +603,12 @@ func (s *Service) ChatCompanion(req CompanionChatRequest) (CompanionChatResponse
 		}
 		return CompanionChatResponse{}, err
 	}
-	replyText := ensureCompanionEmotionHook(reply.ReplyText, strings.TrimSpace(req.CharacterName), objectType)
+	replyText := strings.TrimSpace(reply.ReplyText)
+	if locale == i18n.ZhCN {
+		replyText = ensureCompanionEmotionHook(reply.ReplyText, strings.TrimSpace(req.CharacterName), objectType)
+	}
 
-	audioBytes, mimeType, err := s.llm.SynthesizeSpeech(context.Background(), replyText, objectType)
+	audioBytes, mimeType, err := s.llm.SynthesizeSpeech(ctx, replyText, objectType, ttsLanguage(locale))
 	if err != nil {
 		if errors.Is(err, llm.ErrVoiceCapabilityUnavailable) {
 			return CompanionChatResponse{}, ErrMediaUnavailable
@@ -538,10 +616,38 @@ func (s *Service) ChatCompanion(req CompanionChatRequest) (CompanionChatResponse
 		return CompanionChatResponse{}, err
 	}
 
+	var progress *ProgressUpdate
+	var completedQuests []model.Quest
+	if childID := strings.TrimSpace(req.ChildID); childID != "" {
+		now := time.Now()
+		// 保存对话内容，供每日报告提炼亲子沟通的亮点。
+		if err := st.AddCompanionMessage(model.CompanionMessage{
+			ID:            s.newID("chat"),
+			ChildID:       childID,
+			ObjectType:    objectType,
+			CharacterName: strings.TrimSpace(req.CharacterName),
+			ChildMessage:  childMessage,
+			ReplyText:     replyText,
+			CreatedAt:     now,
+		}); err != nil {
+			return CompanionChatResponse{}, err
+		}
+		progress, err = s.applyProgress(ctx, childID, now, []xpAward{{Reason: xpReasonCompanionChat, XP: s.progressionRules.XP.CompanionChat}}, false)
+		if err != nil {
+			return CompanionChatResponse{}, err
+		}
+		completedQuests, err = s.advanceQuests(ctx, childID, now, []questEvent{{Type: questTypeCompanionChat, ObjectType: objectType}})
+		if err != nil {
+			return CompanionChatResponse{}, err
+		}
+	}
+
 	return CompanionChatResponse{
 		ReplyText:        replyText,
 		VoiceAudioBase64: base64.StdEncoding.EncodeToString(audioBytes),
 		VoiceMimeType:    mimeType,
+		Progress:         progress,
+		CompletedQuests:  s.localizeQuests(completedQuests, locale),
 	}, nil
 }
 
@@ -556,7 +662,10 @@ func isTimeoutError(err error) bool {
 	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
-func (s *Service) SynthesizeCompanionVoice(req CompanionVoiceRequest) (CompanionVoiceResponse, error) {
+func (s *Service) SynthesizeCompanionVoice(ctx context.Context, req CompanionVoiceRequest) (_ CompanionVoiceResponse, err error) {
+	ctx, span := startSpan(ctx, "SynthesizeCompanionVoice")
+	defer func() { tracing.End(span, err) }()
+	locale := s.locale(ctx, req.ChildID, req.Locale)
 	if req.ChildAge < 3 || req.ChildAge > 15 {
 		return CompanionVoiceResponse{}, ErrInvalidChildAge
 	}
@@ -572,7 +681,8 @@ func (s *Service) SynthesizeCompanionVoice(req CompanionVoiceRequest) (Companion
 		return CompanionVoiceResponse{}, ErrLLMUnavailable
 	}
 
-	audioBytes, mimeType, err := s.llm.SynthesizeSpeech(context.Background(), text, objectType)
+	annotate(ctx, req.ChildID, objectType)
+	audioBytes, mimeType, err := s.llm.SynthesizeSpeech(ctx, text, objectType, ttsLanguage(locale))
 	if err != nil {
 		if errors.Is(err, llm.ErrVoiceCapabilityUnavailable) {
 			return CompanionVoiceResponse{}, ErrMediaUnavailable
@@ -585,8 +695,11 @@ func (s *Service) SynthesizeCompanionVoice(req CompanionVoiceRequest) (Companion
 	}, nil
 }
 
-func (s *Service) SubmitAnswer(req AnswerRequest) (AnswerResponse, error) {
-	session, ok, err := s.store.GetSession(req.SessionID)
+func (s *Service) SubmitAnswer(ctx context.Context, req AnswerRequest) (_ AnswerResponse, err error) {
+	ctx, span := startSpan(ctx, "SubmitAnswer")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	session, ok, err := st.GetSession(req.SessionID)
 	if err != nil {
 		return AnswerResponse{}, err
 	}
@@ -597,79 +710,147 @@ func (s *Service) SubmitAnswer(req AnswerRequest) (AnswerResponse, error) {
 		return AnswerResponse{}, ErrAlreadyCaptured
 	}
 
+	annotate(ctx, session.ChildID, session.ObjectType)
+	locale := s.locale(ctx, session.ChildID, req.Locale)
 	rawAnswer := strings.TrimSpace(req.Answer)
 	answer := normalizeAnswer(rawAnswer)
 	correct := isAnswerCorrect(answer, session.QuizA)
+	judgeSource := "rule"
 	if s.llm != nil {
-		if judged, err := s.judgeAnswerByLLM(session, rawAnswer); err == nil {
+		if judged, err := s.judgeAnswerByLLM(ctx, session, rawAnswer); err == nil {
 			correct = judged
+			judgeSource = "llm"
+		} else {
+			slog.WarnContext(ctx, "answer judge fallback", "session_id", session.ID, "err", err)
 		}
 	}
+	metrics.ContentSource.Inc("answer_judge", judgeSource)
+	// Attempts 记录已提交的作答次数；旧会话没有该字段，只能退回看是否记录过 AnswerGiven。
+	firstTry := session.Attempts == 0 && session.AnswerGiven == ""
+	session.Attempts++
 	if !correct {
 		session.AnswerGiven = answer
-		if err := s.store.UpdateSession(session); err != nil {
+		if err := st.UpdateSession(session); err != nil {
 			return AnswerResponse{}, err
 		}
 		return AnswerResponse{
 			Correct:  false,
 			Captured: false,
-			Message:  "答案不正确，再扫描一次获取新题目吧。",
+			Message:  i18n.Text(locale, "answer.incorrect"),
 		}, nil
 	}
-
 	if !s.isObjectTrackedByBadge(session.ObjectType) {
 		session.Captured = true
 		session.CapturedAt = time.Now()
 		session.AnswerGiven = answer
-		if err := s.store.UpdateSession(session); err != nil {
+		if err := st.UpdateSession(session); err != nil {
 			return AnswerResponse{}, err
 		}
+		var awards []xpAward
+		if firstTry {
+			awards = append(awards, xpAward{Reason: xpReasonFirstTryCorrect, XP: s.progressionRules.XP.FirstTryCorrect})
+		}
+		// 会话已标记为已作答，之后的结算失败只记日志：返回错误会让重试拿到 ALREADY_CAPTURED。
+		progress, err := s.applyProgress(ctx, session.ChildID, session.CapturedAt, awards, false)
+		if err != nil {
+			slog.WarnContext(ctx, "post-answer step failed", "step", "progress", "session_id", session.ID, "err", err)
+		}
+		newBadges, err := s.recordBadgeUnlocks(ctx, session, nil, session.CapturedAt)
+		if err != nil {
+			slog.WarnContext(ctx, "post-answer step failed", "step", "badges", "session_id", session.ID, "err", err)
+		}
+		completedQuests, err := s.advanceQuests(ctx, session.ChildID, session.CapturedAt, answerQuestEvents(session, nil, firstTry))
+		if err != nil {
+			slog.WarnContext(ctx, "post-answer step failed", "step", "quests", "session_id", session.ID, "err", err)
+		}
 		return AnswerResponse{
-			Correct:  true,
-			Captured: false,
-			Message:  "回答正确，已记录识别结果；该对象不在勋章收集范围内。",
+			Correct:         true,
+			Captured:        false,
+			Message:         i18n.Text(locale, "answer.recorded"),
+			Progress:        progress,
+			NewBadges:       newBadges,
+			CompletedQuests: s.localizeQuests(completedQuests, locale),
 		}, nil
 	}
 
-	spiritName := "未知精灵"
-	if spirit, exists, err := s.store.GetSpirit(session.SpiritID); err == nil && exists {
-		spiritName = spirit.Name
+	spirit, err := s.sessionSpirit(ctx, session, locale)
+	if err != nil {
+		return AnswerResponse{}, err
 	}
 
 	capture := model.Capture{
 		ID:         s.newID("cap"),
 		ChildID:    session.ChildID,
-		SpiritID:   session.SpiritID,
-		SpiritName: spiritName,
+		SpiritID:   spirit.ID,
+		SpiritName: spirit.Name,
 		ObjectType: session.ObjectType,
 		Fact:       session.Fact,
 		CapturedAt: time.Now(),
+		Location:   session.Location,
 	}
-	if err := s.store.AddCapture(capture); err != nil {
+	awards, err := s.addCaptureWithAwards(ctx, capture, firstTry)
+	if err != nil {
 		return AnswerResponse{}, err
 	}
+	slog.DebugContext(ctx, "capture saved", "capture_id", capture.ID, "child_id", capture.ChildID, "object_type", capture.ObjectType, "spirit_id", capture.SpiritID)
 
 	session.Captured = true
 	session.CapturedAt = capture.CapturedAt
 	session.AnswerGiven = answer
-	if err := s.store.UpdateSession(session); err != nil {
+	if err := st.UpdateSession(session); err != nil {
 		return AnswerResponse{}, err
 	}
+	// 捕捉和会话都已落库，之后的复习、进化、经验、勋章、任务结算失败只记日志：
+	// 返回错误会让客户端重试时拿到 ALREADY_CAPTURED，反而把这次捕捉的结果整个丢掉。
+	if err := s.scheduleReviewForCapture(ctx, session, capture); err != nil {
+		slog.WarnContext(ctx, "post-capture step failed", "step", "review", "capture_id", capture.ID, "err", err)
+	}
+	evolution, err := s.evolveSpirit(ctx, spirit.ID, locale)
+	if err != nil {
+		slog.WarnContext(ctx, "post-capture step failed", "step", "spirit_evolution", "capture_id", capture.ID, "err", err)
+	}
+	progress, err := s.applyProgress(ctx, session.ChildID, capture.CapturedAt, awards, true)
+	if err != nil {
+		slog.WarnContext(ctx, "post-capture step failed", "step", "progress", "capture_id", capture.ID, "err", err)
+	}
+	newBadges, err := s.recordBadgeUnlocks(ctx, session, &capture, capture.CapturedAt)
+	if err != nil {
+		slog.WarnContext(ctx, "post-capture step failed", "step", "badges", "capture_id", capture.ID, "err", err)
+	}
+	completedQuests, err := s.advanceQuests(ctx, session.ChildID, capture.CapturedAt, answerQuestEvents(session, &capture, firstTry))
+	if err != nil {
+		slog.WarnContext(ctx, "post-capture step failed", "step", "quests", "capture_id", capture.ID, "err", err)
+	}
 
 	return AnswerResponse{
-		Correct:  true,
-		Captured: true,
-		Message:  "回答正确，已成功收集精灵。",
-		Capture:  &capture,
+		Correct:         true,
+		Captured:        true,
+		Message:         i18n.Text(locale, "answer.captured"),
+		Capture:         &capture,
+		Progress:        progress,
+		NewBadges:       newBadges,
+		CompletedQuests: s.localizeQuests(completedQuests, locale),
+		SpiritEvolution: evolution,
 	}, nil
 }
 
-func (s *Service) judgeAnswerByLLM(session model.ScanSession, givenAnswer string) (bool, error) {
+func answerQuestEvents(session model.ScanSession, capture *model.Capture, firstTry bool) []questEvent {
+	var events []questEvent
+	if capture != nil {
+		events = append(events, questEvent{Type: questTypeCapture, ObjectType: capture.ObjectType, CaptureID: capture.ID})
+	}
+	if firstTry {
+		events = append(events, questEvent{Type: questTypeFirstTryCorrect, ObjectType: session.ObjectType})
+	}
+	return events
+}
+
+func (s *Service) judgeAnswerByLLM(ctx context.Context, session model.ScanSession, givenAnswer string) (bool, error) {
 	if s.llm == nil {
 		return false, ErrLLMUnavailable
 	}
 	result, err := s.llm.JudgeAnswer(
-		context.Background(),
+		ctx,
 		session.QuizQ,
 		givenAnswer,
 	)
@@ -679,37 +860,53 @@ func (s *Service) judgeAnswerByLLM(session model.ScanSession, givenAnswer string
 	return result.Correct, nil
 }
 
-func (s *Service) Pokedex(childID string) ([]model.PokedexEntry, error) {
+func (s *Service) Pokedex(ctx context.Context, childID string) (_ []model.PokedexEntry, err error) {
+	ctx, span := startSpan(ctx, "Pokedex")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
 	childID = strings.TrimSpace(childID)
 	if childID == "" {
 		childID = "guest"
 	}
-	captures, err := s.store.ListCapturesByChild(childID)
+	captures, err := st.ListCapturesByChild(childID)
 	if err != nil {
 		return nil, err
 	}
 
+	// 同一对象的收集归到孩子的专属精灵下；升级前的数据可能分散在多只精灵上。
 	agg := make(map[string]model.PokedexEntry)
 	for _, capture := range captures {
-		entry, ok := agg[capture.SpiritID]
-		if !ok {
-			entry = model.PokedexEntry{
-				SpiritID:   capture.SpiritID,
-				SpiritName: capture.SpiritName,
-				ObjectType: capture.ObjectType,
-				Captures:   0,
-				LastSeenAt: capture.CapturedAt,
-			}
-		}
-		entry.Captures++
-		if capture.CapturedAt.After(entry.LastSeenAt) {
+		entry, ok := agg[capture.ObjectType]
+		if !ok || capture.CapturedAt.After(entry.LastSeenAt) {
+			entry.SpiritID = capture.SpiritID
+			entry.SpiritName = capture.SpiritName
+			entry.ObjectType = capture.ObjectType
 			entry.LastSeenAt = capture.CapturedAt
 		}
-		agg[capture.SpiritID] = entry
+		entry.Captures++
+		agg[capture.ObjectType] = entry
 	}
 
 	result := make([]model.PokedexEntry, 0, len(agg))
-	for _, entry := range agg {
+	for objectType, entry := range agg {
+		spirit, ok, err := st.GetSpiritByChild(childID, objectType)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			entry.SpiritID = spirit.ID
+			entry.SpiritName = spirit.Name
+			entry.Level = spirit.Level
+			entry.Stage = spirit.Stage
+			entry.StageName = spirit.StageName
+			entry.ImageURL = spirit.ImageURL
+		} else {
+			// 交换得来的精灵还没有专属记录，按收集次数推算等级。
+			entry.Level = s.spiritEvolution.levelFor(entry.Captures)
+			stage := s.spiritEvolution.stageFor(entry.Level)
+			entry.Stage = stage.Stage
+			entry.StageName = stage.Name
+		}
 		result = append(result, entry)
 	}
 
@@ -720,12 +917,17 @@ func (s *Service) Pokedex(childID string) ([]model.PokedexEntry, error) {
 	return result, nil
 }
 
-func (s *Service) DailyReport(childID string, day time.Time) (model.DailyReport, error) {
+// DailyReport 生成孩子某天的学习日报；locale 为请求语言（如 Accept-Language），孩子资料中的设置优先。
+func (s *Service) DailyReport(ctx context.Context, childID string, day time.Time, locale string) (_ model.DailyReport, err error) {
+	ctx, span := startSpan(ctx, "DailyReport")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
 	childID = strings.TrimSpace(childID)
 	if childID == "" {
 		childID = "guest"
 	}
-	captures, err := s.store.ListCapturesByChildAndDate(childID, day)
+	loc := s.locale(ctx, childID, locale)
+	captures, err := st.ListCapturesByChildAndDate(childID, day)
 	if err != nil {
 		return model.DailyReport{}, err
 	}
@@ -739,23 +941,29 @@ func (s *Service) DailyReport(childID string, day time.Time) (model.DailyReport,
 		knowledgePoints = append(knowledgePoints, point)
 	}
 	sort.Strings(knowledgePoints)
+	newBadges, err := s.dailyBadgeUnlocks(ctx, childID, day)
+	if err != nil {
+		return model.DailyReport{}, err
+	}
 
-	summary := fmt.Sprintf(
-		"今天 %s 共收集了 %d 个精灵，学习了 %d 条知识点。",
-		childID,
-		len(captures),
-		len(knowledgePoints),
-	)
+	summary := i18n.Text(loc, "report.daily_summary", childID, len(captures), len(knowledgePoints))
 
-	return model.DailyReport{
+	report := model.DailyReport{
 		Date:            day.Format("2006-01-02"),
 		ChildID:         childID,
 		TotalCaptured:   len(captures),
 		Captures:        captures,
 		KnowledgePoints: knowledgePoints,
+		NewBadges:       newBadges,
 		GeneratedText:   summary,
+		Suggestions:     s.dailyTemplateSuggestions(captures, loc),
+		NarrativeSource: narrativeSourceTemplate,
 		GeneratedAt:     time.Now(),
-	}, nil
+	}
+	if s.llm == nil || len(captures) == 0 {
+		return report, nil
+	}
+	return s.narrateDailyReport(ctx, report, day, loc)
 }
 
 func (s *Service) resolveObjectType(label string) (string, bool) {
@@ -796,14 +1004,17 @@ func (s *Service) getCache(key string) (cacheEntry, bool) {
 	entry, ok := s.cache[key]
 	s.cacheMu.RUnlock()
 	if !ok {
+		metrics.ScanCacheLookups.Inc("miss")
 		return cacheEntry{}, false
 	}
 	if time.Now().After(entry.ExpireAt) {
 		s.cacheMu.Lock()
 		delete(s.cache, key)
 		s.cacheMu.Unlock()
+		metrics.ScanCacheLookups.Inc("miss")
 		return cacheEntry{}, false
 	}
+	metrics.ScanCacheLookups.Inc("hit")
 	return entry, true
 }
 
@@ -813,32 +1024,57 @@ func (s *Service) putCache(key string, entry cacheEntry) {
 	s.cache[key] = entry
 }
 
+// newID 生成带前缀的记录 ID。时间戳便于排查，随机后缀保证同一批次内（如一次生成多条任务）
+// 在时钟精度较粗的平台上也不会重复，避免 INSERT OR REPLACE 覆盖已有记录。
 func (s *Service) newID(prefix string) string {
-	return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	suffix := make([]byte, 6)
+	if _, err := cryptorand.Read(suffix); err != nil {
+		return fmt.Sprintf("%s_%d_%d", prefix, time.Now().UnixNano(), s.idSeq.Add(1))
+	}
+	return fmt.Sprintf("%s_%d_%s", prefix, time.Now().UnixNano(), hex.EncodeToString(suffix))
 }
 
-func (s *Service) generateSpirit(objectType string, age int) model.Spirit {
-	names := map[string][]string{
+// spiritNames 为各语言下的精灵候选名，未收录的对象使用 "" 键下的通用名。
+var spiritNames = map[i18n.Locale]map[string][]string{
+	i18n.ZhCN: {
 		"manhole":       {"井井", "盖盖", "小阀"},
 		"mailbox":       {"邮邮", "信信", "小筒"},
 		"tree":          {"木木", "叶叶", "芽芽"},
 		"road_sign":     {"路路", "标标", "向向"},
 		"traffic_light": {"红灯灯", "绿闪闪", "信号宝"},
+		"":              {"小灵"},
+	},
+	i18n.ZhTW: {
+		"manhole":       {"井井", "蓋蓋", "小閥"},
+		"mailbox":       {"郵郵", "信信", "小筒"},
+		"tree":          {"木木", "葉葉", "芽芽"},
+		"road_sign":     {"路路", "標標", "向向"},
+		"traffic_light": {"紅燈燈", "綠閃閃", "信號寶"},
+		"":              {"小靈"},
+	},
+	i18n.En: {
+		"manhole":       {"Manny", "Lidley", "Valvo"},
+		"mailbox":       {"Posty", "Letty", "Boxby"},
+		"tree":          {"Woody", "Leafy", "Sprout"},
+		"road_sign":     {"Signy", "Arrow", "Pointer"},
+		"traffic_light": {"Blinky", "Glowy", "Signal"},
+		"":              {"Sparky"},
+	},
+}
+
+func (s *Service) generateSpirit(objectType string, age int, locale i18n.Locale) model.Spirit {
+	names := spiritNames[locale]
+	if names == nil {
+		names = spiritNames[i18n.Default]
 	}
-	personalityByAge := map[int]string{
-		1: "活泼好奇",
-		2: "勇敢友善",
-		3: "爱思考有创意",
-	}
-	bucket := ageBucket(age)
 	choices := names[objectType]
 	if len(choices) == 0 {
-		choices = []string{"小灵"}
+		choices = names[""]
 	}
 
 	name := s.pick(choices)
-	personality := personalityByAge[bucket]
-	intro := fmt.Sprintf("我是%s，来自%s的城市精灵，一起学习吧。", name, objectTypeToChinese(objectType))
+	personality := i18n.Text(locale, "spirit.personality."+strconv.Itoa(ageBucket(age)))
+	intro := i18n.Text(locale, "spirit.intro", name, s.objectName(objectType, locale))
 
 	return model.Spirit{
 		ID:          s.newID("spirit"),
@@ -850,35 +1086,36 @@ func (s *Service) generateSpirit(objectType string, age int) model.Spirit {
 	}
 }
 
-func (s *Service) generateDialogues(spirit model.Spirit, age int, fact string, quiz string) []string {
-	ageTone := "我们来一起探索城市吧。"
+func (s *Service) generateDialogues(spirit model.Spirit, age int, fact string, quiz string, locale i18n.Locale) []string {
+	var ageTone string
 	switch {
 	case age <= 6:
-		ageTone = "慢慢来，我们一步一步发现城市秘密。"
+		ageTone = i18n.Text(locale, "dialogue.tone.young")
 	case age <= 9:
-		ageTone = "你已经是很棒的小探索家啦。"
+		ageTone = i18n.Text(locale, "dialogue.tone.middle")
 	default:
-		ageTone = "我们用观察和思考来解锁更多知识点。"
+		ageTone = i18n.Text(locale, "dialogue.tone.older")
 	}
 
 	return []string{
-		fmt.Sprintf("嗨，我是%s，性格是%s。", spirit.Name, spirit.Personality),
+		i18n.Text(locale, "dialogue.greeting", spirit.Name, spirit.Personality),
 		ageTone,
-		fmt.Sprintf("我刚发现一个线索：%s", fact),
-		fmt.Sprintf("轮到你回答：%s", quiz),
+		i18n.Text(locale, "dialogue.clue", fact),
+		i18n.Text(locale, "dialogue.quiz", quiz),
 	}
 }
 
-func (s *Service) generateLearningByLLM(objectType string, age int, spirit model.Spirit) (llm.LearningContent, error) {
+func (s *Service) generateLearningByLLM(ctx context.Context, objectType string, age int, spirit model.Spirit, locale i18n.Locale) (llm.LearningContent, error) {
 	if s.llm == nil {
 		return llm.LearningContent{}, ErrLLMUnavailable
 	}
 	generated, err := s.llm.GenerateLearningContent(
-		context.Background(),
+		ctx,
 		objectType,
 		age,
 		spirit.Name,
 		spirit.Personality,
+		string(locale),
 	)
 	if err != nil {
 		return llm.LearningContent{}, err
@@ -891,7 +1128,7 @@ func (s *Service) generateLearningByLLM(objectType string, age int, spirit model
 	return generated, nil
 }
 
-func (s *Service) defaultCompanionScene(objectType string, age int, weather string, environment string, traits string) llm.CompanionScene {
+func (s *Service) defaultCompanionScene(objectType string, age int, weather string, environment string, traits string, locale i18n.Locale) llm.CompanionScene {
 	objectName := companionObjectName(objectType)
 
 	bucket := ageBucket(age)
@@ -916,7 +1153,6 @@ func (s *Service) defaultCompanionScene(objectType string, age int, weather stri
 		traits = "圆润可爱"
 	}
 
-	dialogText := fmt.Sprintf("哎呀，你终于看到我啦，我是%s，我现在正开心地和你打招呼呢。今天我们一起从我身上的小细节开始观察吧！", characterName)
 	imagePrompt := fmt.Sprintf(
 		"儿童向二次元卡通插画，拟人化%s角色，性格%s，场景为%s的%s，物体特征%s，柔和光线，主角清晰，角色视线看向镜头，适合儿童",
 		objectName,
@@ -925,6 +1161,12 @@ func (s *Service) defaultCompanionScene(objectType string, age int, weather stri
 		environment,
 		traits,
 	)
+	if locale != i18n.ZhCN {
+		// 图片提示词只给模型看，保持中文；角色名与性格按请求语言输出。
+		characterName = s.objectName(objectType, locale)
+		personality = i18n.Text(locale, "spirit.personality."+strconv.Itoa(bucket))
+	}
+	dialogText := i18n.Text(locale, "companion.opening", characterName)
 
 	return llm.CompanionScene{
 		CharacterName:        characterName,
@@ -1095,14 +1337,14 @@ func isSentenceBreak(r rune) bool {
 	}
 }
 
-func (s *Service) defaultLearningContent(objectType string) (string, model.QuizItem) {
-	objectName := strings.TrimSpace(objectTypeToChinese(objectType))
+func (s *Service) defaultLearningContent(objectType string, locale i18n.Locale) (string, model.QuizItem) {
+	objectName := strings.TrimSpace(s.objectName(objectType, locale))
 	if objectName == "" {
-		objectName = "这个物体"
+		objectName = i18n.Text(locale, "learning.this_object")
 	}
-	fact := fmt.Sprintf("%s是我们生活中常见的事物，认真观察它的外形和用途，就能发现很多小知识。", objectName)
+	fact := i18n.Text(locale, "learning.fact", objectName)
 	quiz := model.QuizItem{
-		Question: "小挑战：我们刚刚认识的物体叫什么名字？",
+		Question: i18n.Text(locale, "learning.quiz"),
 		Answer:   objectName,
 	}
 	return fact, quiz
@@ -1,6 +1,7 @@
 package service_test
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -24,7 +25,7 @@ func TestScanAndCaptureFlow(t *testing.T) {
 
 	svc, st := newTestService(t)
 
-	scanResp, err := svc.Scan(service.ScanRequest{
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
 		ChildID:       "kid_1",
 		ChildAge:      8,
 		DetectedLabel: "mailbox",
@@ -50,7 +51,7 @@ func TestScanAndCaptureFlow(t *testing.T) {
 		t.Fatalf("expected session to be stored")
 	}
 
-	answerResp, err := svc.SubmitAnswer(service.AnswerRequest{
+	answerResp, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
 		SessionID: scanResp.SessionID,
 		ChildID:   "kid_1",
 		Answer:    session.QuizA,
@@ -62,7 +63,7 @@ func TestScanAndCaptureFlow(t *testing.T) {
 		t.Fatalf("expected capture success, got %+v", answerResp)
 	}
 
-	pokedex, err := svc.Pokedex("kid_1")
+	pokedex, err := svc.Pokedex(context.Background(), "kid_1")
 	if err != nil {
 		t.Fatalf("Pokedex() error = %v", err)
 	}
@@ -71,11 +72,56 @@ func TestScanAndCaptureFlow(t *testing.T) {
 	}
 }
 
+// sideEffectFailingStore 让捕捉之后的复习、经验、任务写入全部失败。
+type sideEffectFailingStore struct {
+	store.Store
+}
+
+var errSideEffect = errors.New("side effect write failed")
+
+func (sideEffectFailingStore) SaveReviewItem(model.ReviewItem) error  { return errSideEffect }
+func (sideEffectFailingStore) SaveProgress(model.ChildProgress) error { return errSideEffect }
+func (sideEffectFailingStore) SaveQuest(model.Quest) error            { return errSideEffect }
+
+func TestSubmitAnswerKeepsCaptureWhenSideEffectsFail(t *testing.T) {
+	t.Parallel()
+	_, st := newTestService(t)
+	svc := service.New(sideEffectFailingStore{Store: st}, knowledge.BaseKnowledge)
+
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
+		ChildID:       "kid_side",
+		ChildAge:      8,
+		DetectedLabel: "mailbox",
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	session, ok, err := st.GetSession(scanResp.SessionID)
+	if err != nil || !ok {
+		t.Fatalf("GetSession() error = %v, ok=%v", err, ok)
+	}
+
+	answerResp, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
+		SessionID: scanResp.SessionID,
+		ChildID:   "kid_side",
+		Answer:    session.QuizA,
+	})
+	if err != nil {
+		t.Fatalf("SubmitAnswer() error = %v", err)
+	}
+	if !answerResp.Captured || answerResp.Capture == nil {
+		t.Fatalf("expected capture to succeed despite side-effect failures, got %+v", answerResp)
+	}
+	if answerResp.Progress != nil {
+		t.Fatalf("expected no progress update when saving progress fails, got %+v", answerResp.Progress)
+	}
+}
+
 func TestUnknownObjectFallsBackToTemplateContent(t *testing.T) {
 	t.Parallel()
 	svc, _ := newTestService(t)
 
-	resp, err := svc.Scan(service.ScanRequest{
+	resp, err := svc.Scan(context.Background(), service.ScanRequest{
 		ChildID:       "kid_2",
 		ChildAge:      7,
 		DetectedLabel: "spaceship",
@@ -95,7 +141,7 @@ func TestSubmitAnswerOutsideBadgeScopeDoesNotCapturePokedex(t *testing.T) {
 	t.Parallel()
 	svc, st := newTestService(t)
 
-	scanResp, err := svc.Scan(service.ScanRequest{
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
 		ChildID:       "kid_outside",
 		ChildAge:      8,
 		DetectedLabel: "spaceship_console",
@@ -108,7 +154,7 @@ func TestSubmitAnswerOutsideBadgeScopeDoesNotCapturePokedex(t *testing.T) {
 		t.Fatalf("GetSession() error = %v, ok=%v", err, ok)
 	}
 
-	answerResp, err := svc.SubmitAnswer(service.AnswerRequest{
+	answerResp, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
 		SessionID: scanResp.SessionID,
 		ChildID:   "kid_outside",
 		Answer:    session.QuizA,
@@ -123,7 +169,7 @@ func TestSubmitAnswerOutsideBadgeScopeDoesNotCapturePokedex(t *testing.T) {
 		t.Fatalf("expected non-badge object not to be captured in pokedex")
 	}
 
-	pokedex, err := svc.Pokedex("kid_outside")
+	pokedex, err := svc.Pokedex(context.Background(), "kid_outside")
 	if err != nil {
 		t.Fatalf("Pokedex() error = %v", err)
 	}
@@ -136,7 +182,7 @@ func TestPokedexBadgesRequireFullCollectionToUnlock(t *testing.T) {
 	t.Parallel()
 	svc, st := newTestService(t)
 
-	scanResp, err := svc.Scan(service.ScanRequest{
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
 		ChildID:       "kid_badge",
 		ChildAge:      8,
 		DetectedLabel: "tree",
@@ -148,7 +194,7 @@ func TestPokedexBadgesRequireFullCollectionToUnlock(t *testing.T) {
 	if err != nil || !ok {
 		t.Fatalf("GetSession() error = %v, ok=%v", err, ok)
 	}
-	if _, err := svc.SubmitAnswer(service.AnswerRequest{
+	if _, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
 		SessionID: scanResp.SessionID,
 		ChildID:   "kid_badge",
 		Answer:    session.QuizA,
@@ -156,7 +202,7 @@ func TestPokedexBadgesRequireFullCollectionToUnlock(t *testing.T) {
 		t.Fatalf("SubmitAnswer() error = %v", err)
 	}
 
-	badges, err := svc.PokedexBadges("kid_badge")
+	badges, err := svc.PokedexBadges(context.Background(), "kid_badge", "")
 	if err != nil {
 		t.Fatalf("PokedexBadges() error = %v", err)
 	}
@@ -185,7 +231,7 @@ func TestDailyReport(t *testing.T) {
 	t.Parallel()
 	svc, st := newTestService(t)
 
-	scanResp, err := svc.Scan(service.ScanRequest{
+	scanResp, err := svc.Scan(context.Background(), service.ScanRequest{
 		ChildID:       "kid_3",
 		ChildAge:      6,
 		DetectedLabel: "tree",
@@ -197,7 +243,7 @@ func TestDailyReport(t *testing.T) {
 	if err != nil || !ok {
 		t.Fatalf("GetSession() error = %v, ok=%v", err, ok)
 	}
-	if _, err := svc.SubmitAnswer(service.AnswerRequest{
+	if _, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{
 		SessionID: scanResp.SessionID,
 		ChildID:   "kid_3",
 		Answer:    session.QuizA,
@@ -205,7 +251,7 @@ func TestDailyReport(t *testing.T) {
 		t.Fatalf("SubmitAnswer() error = %v", err)
 	}
 
-	report, err := svc.DailyReport("kid_3", time.Now())
+	report, err := svc.DailyReport(context.Background(), "kid_3", time.Now(), "")
 	if err != nil {
 		t.Fatalf("DailyReport() error = %v", err)
 	}
@@ -221,7 +267,7 @@ func TestGenerateCompanionSceneRequiresLLM(t *testing.T) {
 	t.Parallel()
 	svc, _ := newTestService(t)
 
-	_, err := svc.GenerateCompanionScene(service.CompanionSceneRequest{
+	_, err := svc.GenerateCompanionScene(context.Background(), service.CompanionSceneRequest{
 		ChildID:    "kid_4",
 		ChildAge:   8,
 		ObjectType: "路灯",
@@ -238,7 +284,7 @@ func TestGenerateCompanionSceneMissingObjectType(t *testing.T) {
 	t.Parallel()
 	svc, _ := newTestService(t)
 
-	_, err := svc.GenerateCompanionScene(service.CompanionSceneRequest{
+	_, err := svc.GenerateCompanionScene(context.Background(), service.CompanionSceneRequest{
 		ChildID:  "kid_5",
 		ChildAge: 8,
 	})
@@ -254,7 +300,7 @@ func TestGenerateCompanionSceneInvalidAge(t *testing.T) {
 	t.Parallel()
 	svc, _ := newTestService(t)
 
-	_, err := svc.GenerateCompanionScene(service.CompanionSceneRequest{
+	_, err := svc.GenerateCompanionScene(context.Background(), service.CompanionSceneRequest{
 		ChildID:    "kid_6",
 		ChildAge:   2,
 		ObjectType: "路灯",
@@ -319,7 +365,7 @@ func TestGenerateCompanionSceneFallsBackWhenSceneLLMFailed(t *testing.T) {
 	}
 	svc.SetLLMClient(client)
 
-	resp, err := svc.GenerateCompanionScene(service.CompanionSceneRequest{
+	resp, err := svc.GenerateCompanionScene(context.Background(), service.CompanionSceneRequest{
 		ChildID:    "kid_fallback",
 		ChildAge:   8,
 		ObjectType: "猫",
@@ -357,6 +403,75 @@ func TestGenerateCompanionSceneFallsBackWhenSceneLLMFailed(t *testing.T) {
 	}
 }
 
+func TestGenerateCompanionSceneCancelsMediaWhenClientGoesAway(t *testing.T) {
+	t.Parallel()
+	svc, _ := newTestService(t)
+
+	arrived := make(chan string, 2)
+	cancelled := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var leg string
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/compatible-mode/v1/chat/completions":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/byteplus/images/generations":
+			leg = "image"
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/services/aigc/multimodal-generation/generation":
+			leg = "tts"
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			return
+		}
+		// 读完请求体后服务端才会感知连接断开。
+		_, _ = io.Copy(io.Discard, r.Body)
+		arrived <- leg
+		select {
+		case <-r.Context().Done():
+			cancelled <- leg
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client, err := llm.NewClient(llm.Config{
+		APIKey:       "test-key",
+		BaseURL:      server.URL,
+		ImageBaseURL: server.URL,
+		VoiceBaseURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	svc.SetLLMClient(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-arrived
+		<-arrived
+		cancel()
+	}()
+	started := time.Now()
+	_, err = svc.GenerateCompanionScene(ctx, service.CompanionSceneRequest{
+		ChildID:    "kid_gone",
+		ChildAge:   8,
+		ObjectType: "猫",
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Fatalf("expected scene generation to stop once the client went away, took %v", elapsed)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-cancelled:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected both upstream media requests to be cancelled")
+		}
+	}
+}
+
 func TestGenerateCompanionSceneImageToImageIgnoresEnvironmentFields(t *testing.T) {
 	t.Parallel()
 	svc, _ := newTestService(t)
@@ -414,7 +529,7 @@ func TestGenerateCompanionSceneImageToImageIgnoresEnvironmentFields(t *testing.T
 	}
 	svc.SetLLMClient(client)
 
-	resp, err := svc.GenerateCompanionScene(service.CompanionSceneRequest{
+	resp, err := svc.GenerateCompanionScene(context.Background(), service.CompanionSceneRequest{
 		ChildID:           "kid_i2i",
 		ChildAge:          8,
 		ObjectType:        "猫",
@@ -511,7 +626,7 @@ func TestGenerateCompanionSceneSupportsB64JSONImageResponse(t *testing.T) {
 	}
 	svc.SetLLMClient(client)
 
-	resp, err := svc.GenerateCompanionScene(service.CompanionSceneRequest{
+	resp, err := svc.GenerateCompanionScene(context.Background(), service.CompanionSceneRequest{
 		ChildID:    "kid_b64",
 		ChildAge:   8,
 		ObjectType: "猫",
@@ -534,7 +649,7 @@ func TestChatCompanionRequiresLLM(t *testing.T) {
 	t.Parallel()
 	svc, _ := newTestService(t)
 
-	_, err := svc.ChatCompanion(service.CompanionChatRequest{
+	_, err := svc.ChatCompanion(context.Background(), service.CompanionChatRequest{
 		ChildID:      "kid_7",
 		ChildAge:     8,
 		ObjectType:   "路灯",
@@ -552,7 +667,7 @@ func TestChatCompanionMissingMessage(t *testing.T) {
 	t.Parallel()
 	svc, _ := newTestService(t)
 
-	_, err := svc.ChatCompanion(service.CompanionChatRequest{
+	_, err := svc.ChatCompanion(context.Background(), service.CompanionChatRequest{
 		ChildID:    "kid_8",
 		ChildAge:   8,
 		ObjectType: "路灯",
@@ -590,7 +705,7 @@ func TestChatCompanionTimeoutMappedError(t *testing.T) {
 	}
 	svc.SetLLMClient(client)
 
-	_, err = svc.ChatCompanion(service.CompanionChatRequest{
+	_, err = svc.ChatCompanion(context.Background(), service.CompanionChatRequest{
 		ChildID:      "kid_timeout_1",
 		ChildAge:     8,
 		ObjectType:   "猫",
@@ -603,7 +718,7 @@ func TestChatCompanionTimeoutMappedError(t *testing.T) {
 
 func TestChatCompanionAddsEmotionHookForReply(t *testing.T) {
 	t.Parallel()
-	svc, _ := newTestService(t)
+	svc, st := newTestService(t)
 
 	var mockAudioURL string
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -635,7 +750,7 @@ func TestChatCompanionAddsEmotionHookForReply(t *testing.T) {
 	}
 	svc.SetLLMClient(client)
 
-	resp, err := svc.ChatCompanion(service.CompanionChatRequest{
+	resp, err := svc.ChatCompanion(context.Background(), service.CompanionChatRequest{
 		ChildID:              "kid_chat_1",
 		ChildAge:             8,
 		ObjectType:           "路灯",
@@ -655,6 +770,14 @@ func TestChatCompanionAddsEmotionHookForReply(t *testing.T) {
 	if strings.TrimSpace(resp.VoiceAudioBase64) == "" {
 		t.Fatalf("expected non-empty voice base64")
 	}
+	now := time.Now()
+	messages, err := st.ListCompanionMessagesByChild("kid_chat_1", now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ListCompanionMessagesByChild() error = %v", err)
+	}
+	if len(messages) != 1 || messages[0].ChildMessage != "你会亮多久？" || messages[0].ReplyText != resp.ReplyText {
+		t.Fatalf("expected chat turn to be stored, got %+v", messages)
+	}
 }
 
 func newTestService(t *testing.T) (*service.Service, *store.JSONStore) {
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"ling/internal/i18n"
+	"ling/internal/model"
+)
+
+const defaultSpiritMaxLevel = 10
+
+//go:embed spirit_evolution.json
+var spiritEvolutionRawJSON []byte
+
+// spiritEvolutionRules 描述专属精灵的成长：每次收集升一级，到达阶段门槛时换新形象，
+// 到达解锁等级时获得新的性格特点和自我介绍。
+type spiritEvolutionRules struct {
+	MaxLevel int            `json:"max_level"`
+	Stages   []spiritStage  `json:"stages"`
+	Unlocks  []spiritUnlock `json:"unlocks"`
+}
+
+type spiritStage struct {
+	Stage int    `json:"stage"`
+	Name  string `json:"name"`
+	// Key 为阶段名在文案目录中的键，目录里没有该语言时使用 Name。
+	Key      string `json:"key,omitempty"`
+	MinLevel int    `json:"min_level"`
+	// ImageHint 非空时，进入该阶段会重新生成角色形象。
+	ImageHint string `json:"image_hint,omitempty"`
+}
+
+// spiritUnlock 的 intro 支持 {name} 与 {object} 占位符。Key 为文案目录中的键前缀，
+// 按 <key>.trait 与 <key>.intro 查找译文，目录里没有该语言时使用 trait 与 intro 原文。
+type spiritUnlock struct {
+	Level int    `json:"level"`
+	Key   string `json:"key,omitempty"`
+	Trait string `json:"trait,omitempty"`
+	Intro string `json:"intro,omitempty"`
+}
+
+func loadSpiritEvolutionRules() spiritEvolutionRules {
+	raw := spiritEvolutionRawJSON
+	if path := strings.TrimSpace(os.Getenv("CITYLING_SPIRIT_EVOLUTION_FILE")); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			raw = data
+		}
+	}
+	var rules spiritEvolutionRules
+	if err := json.Unmarshal(raw, &rules); err != nil || len(rules.Stages) == 0 {
+		rules = spiritEvolutionRules{}
+		_ = json.Unmarshal(spiritEvolutionRawJSON, &rules)
+	}
+	if rules.MaxLevel <= 0 {
+		rules.MaxLevel = defaultSpiritMaxLevel
+	}
+	sort.Slice(rules.Stages, func(i, j int) bool {
+		return rules.Stages[i].MinLevel < rules.Stages[j].MinLevel
+	})
+	sort.SliceStable(rules.Unlocks, func(i, j int) bool {
+		return rules.Unlocks[i].Level < rules.Unlocks[j].Level
+	})
+	return rules
+}
+
+func (r spiritEvolutionRules) levelFor(captures int) int {
+	if captures < 0 {
+		return 0
+	}
+	return min(captures, r.MaxLevel)
+}
+
+// stageFor 返回等级所在的阶段；还没收集过的精灵处于第一阶段。
+func (r spiritEvolutionRules) stageFor(level int) spiritStage {
+	if len(r.Stages) == 0 {
+		return spiritStage{Stage: 1}
+	}
+	current := r.Stages[0]
+	for _, stage := range r.Stages {
+		if level >= stage.MinLevel {
+			current = stage
+		}
+	}
+	return current
+}
+
+// grow 把精灵的收集次数设为 captures，并返回这次新解锁的性格特点和自我介绍；
+// 阶段名与新文案使用 locale，objectName 为对象在该语言下的展示名。
+func (r spiritEvolutionRules) grow(spirit *model.Spirit, captures int, locale i18n.Locale, objectName string) ([]string, []string) {
+	previous := spirit.Level
+	spirit.Captures = captures
+	spirit.Level = r.levelFor(captures)
+	stage := r.stageFor(spirit.Level)
+	spirit.Stage = stage.Stage
+	spirit.StageName = catalogText(locale, stage.Key, stage.Name)
+
+	var traits, intros []string
+	for _, unlock := range r.Unlocks {
+		if unlock.Level <= previous || unlock.Level > spirit.Level {
+			continue
+		}
+		if trait := strings.TrimSpace(catalogText(locale, catalogSubKey(unlock.Key, "trait"), unlock.Trait)); trait != "" {
+			traits = append(traits, trait)
+		}
+		if intro := strings.TrimSpace(catalogText(locale, catalogSubKey(unlock.Key, "intro"), unlock.Intro)); intro != "" {
+			intros = append(intros, strings.NewReplacer(
+				"{name}", spirit.Name,
+				"{object}", objectName,
+			).Replace(intro))
+		}
+	}
+	spirit.Traits = append(spirit.Traits, traits...)
+	spirit.IntroLines = append(spirit.IntroLines, intros...)
+	return traits, intros
+}
+
+// childSpirit 返回孩子在该对象上的专属精灵；第一次遇到时以 template 为蓝本创建，
+// 并按已有的同类收集补齐成长，兼容升级前的数据。
+func (s *Service) childSpirit(ctx context.Context, childID string, objectType string, template model.Spirit, locale i18n.Locale) (model.Spirit, error) {
+	s.spiritMu.Lock()
+	defer s.spiritMu.Unlock()
+	return s.childSpiritLocked(ctx, childID, objectType, template, locale)
+}
+
+func (s *Service) childSpiritLocked(ctx context.Context, childID string, objectType string, template model.Spirit, locale i18n.Locale) (model.Spirit, error) {
+	st := s.storeFor(ctx)
+	spirit, ok, err := st.GetSpiritByChild(childID, objectType)
+	if err != nil || ok {
+		return spirit, err
+	}
+	captures, err := st.ListCapturesByChild(childID)
+	if err != nil {
+		return model.Spirit{}, err
+	}
+	existing := 0
+	for _, capture := range captures {
+		if capture.ObjectType == objectType {
+			existing++
+		}
+	}
+
+	spirit = model.Spirit{
+		ID:          s.newID("spirit"),
+		ChildID:     childID,
+		Name:        template.Name,
+		ObjectType:  objectType,
+		Personality: template.Personality,
+		Intro:       template.Intro,
+		ImageURL:    template.ImageURL,
+		CreatedAt:   time.Now(),
+	}
+	s.spiritEvolution.grow(&spirit, existing, locale, s.objectName(objectType, locale))
+	spirit.ImageStage = spirit.Stage
+	if err := st.SaveSpirit(spirit); err != nil {
+		return model.Spirit{}, err
+	}
+	return spirit, nil
+}
+
+// sessionSpirit 返回本次收集归属的专属精灵；旧会话指向的共享精灵会被迁移为孩子的专属精灵。
+func (s *Service) sessionSpirit(ctx context.Context, session model.ScanSession, locale i18n.Locale) (model.Spirit, error) {
+	st := s.storeFor(ctx)
+	s.spiritMu.Lock()
+	defer s.spiritMu.Unlock()
+
+	spirit, ok, err := st.GetSpirit(session.SpiritID)
+	if err != nil {
+		return model.Spirit{}, err
+	}
+	if ok && spirit.ChildID == session.ChildID {
+		return spirit, nil
+	}
+	if !ok {
+		spirit = s.generateSpirit(session.ObjectType, session.ChildAge, locale)
+	}
+	return s.childSpiritLocked(ctx, session.ChildID, session.ObjectType, spirit, locale)
+}
+
+// evolveSpirit 在一次成功收集后让精灵成长；进入带新形象的阶段且配置了大模型时在后台生成新形象。
+func (s *Service) evolveSpirit(ctx context.Context, spiritID string, locale i18n.Locale) (*SpiritEvolution, error) {
+	st := s.storeFor(ctx)
+	s.spiritMu.Lock()
+	spirit, ok, err := st.GetSpirit(spiritID)
+	if err != nil || !ok {
+		s.spiritMu.Unlock()
+		return nil, err
+	}
+	previousLevel, previousStage := spirit.Level, spirit.Stage
+	traits, intros := s.spiritEvolution.grow(&spirit, spirit.Captures+1, locale, s.objectName(spirit.ObjectType, locale))
+	err = st.SaveSpirit(spirit)
+	s.spiritMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	evolution := &SpiritEvolution{
+		SpiritID:      spirit.ID,
+		SpiritName:    spirit.Name,
+		PreviousLevel: previousLevel,
+		Level:         spirit.Level,
+		Stage:         spirit.Stage,
+		StageName:     spirit.StageName,
+		StageUp:       spirit.Stage > previousStage,
+		NewTraits:     traits,
+		NewIntroLines: intros,
+	}
+	stage := s.spiritEvolution.stageFor(spirit.Level)
+	if evolution.StageUp && stage.ImageHint != "" && s.llm != nil {
+		evolution.ImagePending = true
+		go s.refreshSpiritImage(context.WithoutCancel(ctx), spirit.ID, stage)
+	}
+	return evolution, nil
+}
+
+// refreshSpiritImage 以当前形象为参考生成新阶段的形象；失败只记日志，下次进入新阶段或剧情生成时还会更新。
+func (s *Service) refreshSpiritImage(ctx context.Context, spiritID string, stage spiritStage) {
+	ctx, span := startSpan(ctx, "refreshSpiritImage")
+	defer span.End()
+	st := s.storeFor(ctx)
+	spirit, ok, err := st.GetSpirit(spiritID)
+	if err != nil || !ok {
+		return
+	}
+	prompt := fmt.Sprintf(
+		"为城市精灵“%s”（%s）生成新的角色形象：%s。童话儿童绘本风，柔和光线，主体居中，禁止文字、水印、logo。",
+		spirit.Name,
+		objectTypeToChinese(spirit.ObjectType),
+		stage.ImageHint,
+	)
+	imageURL, err := s.llm.GenerateCharacterImage(ctx, prompt, spirit.ImageURL)
+	if err != nil {
+		slog.WarnContext(ctx, "refresh spirit image failed", "spirit_id", spiritID, "stage", stage.Stage, "err", err)
+		return
+	}
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(imageURL)), "data:image/") {
+		// 不把 data URL 写进存储，等剧情接口生成可公开访问的形象。
+		return
+	}
+	s.saveSpiritImage(ctx, spiritID, imageURL, stage.Stage)
+}
+
+// rememberSpiritImage 记录剧情生成的角色形象，对应精灵当前所处的阶段。
+func (s *Service) rememberSpiritImage(ctx context.Context, spiritID string, imageURL string) {
+	s.saveSpiritImage(ctx, spiritID, imageURL, 0)
+}
+
+// saveSpiritImage 保存精灵形象；stage 为 0 表示对应精灵当前阶段。较早阶段的形象不会覆盖较新的。
+func (s *Service) saveSpiritImage(ctx context.Context, spiritID string, imageURL string, stage int) {
+	st := s.storeFor(ctx)
+	s.spiritMu.Lock()
+	defer s.spiritMu.Unlock()
+	spirit, ok, err := st.GetSpirit(spiritID)
+	if err != nil || !ok {
+		return
+	}
+	if stage == 0 {
+		stage = spirit.Stage
+	}
+	if stage < spirit.ImageStage {
+		return
+	}
+	spirit.ImageURL = imageURL
+	spirit.ImageStage = stage
+	if err := st.SaveSpirit(spirit); err != nil {
+		slog.WarnContext(ctx, "save spirit image failed", "spirit_id", spiritID, "err", err)
+	}
+}
+
+// spiritImageHint 返回精灵当前阶段的形象提示，用于剧情生成时保持形象与成长阶段一致。
+func (s *Service) spiritImageHint(ctx context.Context, spiritID string) string {
+	st := s.storeFor(ctx)
+	spirit, ok, err := st.GetSpirit(spiritID)
+	if err != nil || !ok {
+		return ""
+	}
+	return s.spiritEvolution.stageFor(spirit.Level).ImageHint
+}
+
+// personalizeDialogues 把缓存对白里的蓝本精灵名替换成孩子专属精灵的名字。
+func personalizeDialogues(dialogues []string, templateName string, name string) []string {
+	if templateName == "" || templateName == name {
+		return dialogues
+	}
+	result := make([]string, len(dialogues))
+	for i, line := range dialogues {
+		result[i] = strings.ReplaceAll(line, templateName, name)
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+func TestRepeatCapturesEvolveOneSpirit(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	// 升级前的收集挂在共享精灵上，仍计入专属精灵的成长。
+	if err := st.AddCapture(model.Capture{ID: "cap_legacy", ChildID: "kid_evo", SpiritID: "spirit_legacy", SpiritName: "旧精灵", ObjectType: "mailbox", CapturedAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("AddCapture() error = %v", err)
+	}
+
+	var evolutions []*service.SpiritEvolution
+	var spiritID string
+	for i := 0; i < 2; i++ {
+		resp := captureObject(t, svc, st, "kid_evo", "mailbox")
+		if spiritID == "" {
+			spiritID = resp.Capture.SpiritID
+		}
+		if resp.Capture.SpiritID != spiritID {
+			t.Fatalf("expected repeat captures to keep spirit %s, got %s", spiritID, resp.Capture.SpiritID)
+		}
+		if resp.SpiritEvolution == nil || resp.SpiritEvolution.SpiritID != spiritID {
+			t.Fatalf("expected spirit evolution for %s, got %+v", spiritID, resp.SpiritEvolution)
+		}
+		evolutions = append(evolutions, resp.SpiritEvolution)
+	}
+
+	first, second := evolutions[0], evolutions[1]
+	if first.PreviousLevel != 1 || first.Level != 2 || first.StageUp || len(first.NewTraits) != 1 {
+		t.Fatalf("unexpected first evolution %+v", first)
+	}
+	if second.Level != 3 || !second.StageUp || second.Stage != 2 || second.StageName != "成长" || len(second.NewIntroLines) != 1 {
+		t.Fatalf("expected a stage-up at level 3, got %+v", second)
+	}
+	if second.ImagePending {
+		t.Fatal("expected no image refresh without an LLM client")
+	}
+
+	spirit, ok, err := st.GetSpiritByChild("kid_evo", "mailbox")
+	if err != nil || !ok || spirit.ID != spiritID {
+		t.Fatalf("GetSpiritByChild() = %+v ok=%v err=%v", spirit, ok, err)
+	}
+	if spirit.Captures != 3 || len(spirit.Traits) != 2 || len(spirit.IntroLines) != 2 {
+		t.Fatalf("unexpected spirit growth %+v", spirit)
+	}
+
+	other := captureObject(t, svc, st, "kid_other", "mailbox")
+	if other.Capture.SpiritID == spiritID || other.SpiritEvolution.Level != 1 {
+		t.Fatalf("expected another child to get a fresh spirit, got %+v", other.SpiritEvolution)
+	}
+
+	pokedex, err := svc.Pokedex(context.Background(), "kid_evo")
+	if err != nil {
+		t.Fatalf("Pokedex() error = %v", err)
+	}
+	if len(pokedex) != 1 {
+		t.Fatalf("expected one pokedex entry per object, got %+v", pokedex)
+	}
+	entry := pokedex[0]
+	if entry.SpiritID != spiritID || entry.Captures != 3 || entry.Level != 3 || entry.Stage != 2 || entry.StageName != "成长" {
+		t.Fatalf("unexpected pokedex entry %+v", entry)
+	}
+}
+
+func TestSpiritEvolutionFollowsLocale(t *testing.T) {
+	t.Parallel()
+	svc, st := newTestService(t)
+
+	var last *service.SpiritEvolution
+	for i := 0; i < 2; i++ {
+		scanResp, err := svc.Scan(context.Background(), service.ScanRequest{ChildID: "kid_evo_en", ChildAge: 8, DetectedLabel: "mailbox", Locale: "en"})
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		session, _, err := st.GetSession(scanResp.SessionID)
+		if err != nil {
+			t.Fatalf("GetSession() error = %v", err)
+		}
+		resp, err := svc.SubmitAnswer(context.Background(), service.AnswerRequest{SessionID: session.ID, ChildID: "kid_evo_en", Answer: session.QuizA, Locale: "en"})
+		if err != nil || !resp.Captured {
+			t.Fatalf("SubmitAnswer() = %+v, err=%v", resp, err)
+		}
+		last = resp.SpiritEvolution
+	}
+	if last.StageName != "Sprout" || len(last.NewTraits) != 1 || last.NewTraits[0] != "Knows you" {
+		t.Fatalf("expected english stage and trait, got %+v", last)
+	}
+	if len(last.NewIntroLines) != 1 || !strings.Contains(last.NewIntroLines[0], "mailbox") {
+		t.Fatalf("expected english intro naming the object, got %q", last.NewIntroLines)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/tracing"
+)
+
+const (
+	defaultShareDays = 30
+	maxShareDays     = 365
+	shareTokenBytes  = 18
+)
+
+// CreateSpiritShare 为孩子自己的一条收集生成只读分享链接。
+func (s *Service) CreateSpiritShare(ctx context.Context, req SpiritShareRequest) (_ model.SpiritShare, err error) {
+	ctx, span := startSpan(ctx, "CreateSpiritShare")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID := strings.TrimSpace(req.ChildID)
+	if childID == "" {
+		childID = "guest"
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = defaultShareDays
+	}
+	if days < 0 || days > maxShareDays {
+		return model.SpiritShare{}, fmt.Errorf("%w: expires_in_days 必须在 1 到 %d 之间", ErrShareInvalid, maxShareDays)
+	}
+	capture, err := s.ownedCapture(ctx, childID, req.CaptureID)
+	if err != nil {
+		return model.SpiritShare{}, err
+	}
+	token, err := newShareToken()
+	if err != nil {
+		return model.SpiritShare{}, err
+	}
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, days)
+	share := model.SpiritShare{
+		Token:     token,
+		ChildID:   childID,
+		CaptureID: capture.ID,
+		CreatedAt: now,
+		ExpiresAt: &expiresAt,
+	}
+	if err := st.SaveSpiritShare(share); err != nil {
+		return model.SpiritShare{}, err
+	}
+	return share, nil
+}
+
+// RevokeSpiritShare 让分享链接立即失效，只有创建者可以操作。
+func (s *Service) RevokeSpiritShare(ctx context.Context, token string, childID string) (err error) {
+	ctx, span := startSpan(ctx, "RevokeSpiritShare")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	share, ok, err := st.GetSpiritShare(strings.TrimSpace(token))
+	if err != nil {
+		return err
+	}
+	// 不是自己的链接也报不存在，避免借此探测别人的分享。
+	if !ok || share.ChildID != childID {
+		return ErrShareNotFound
+	}
+	if share.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	share.RevokedAt = &now
+	return st.SaveSpiritShare(share)
+}
+
+// SpiritCard 返回分享链接对应的精灵卡片；链接被撤销或收集已交换给别人时视为不存在。
+func (s *Service) SpiritCard(ctx context.Context, token string) (_ model.SpiritCard, err error) {
+	ctx, span := startSpan(ctx, "SpiritCard")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	share, ok, err := st.GetSpiritShare(strings.TrimSpace(token))
+	if err != nil {
+		return model.SpiritCard{}, err
+	}
+	if !ok || share.RevokedAt != nil {
+		return model.SpiritCard{}, ErrShareNotFound
+	}
+	if share.ExpiresAt != nil && time.Now().After(*share.ExpiresAt) {
+		return model.SpiritCard{}, ErrShareExpired.WithDetails(map[string]any{"expired_at": share.ExpiresAt})
+	}
+	capture, ok, err := st.GetCapture(share.CaptureID)
+	if err != nil {
+		return model.SpiritCard{}, err
+	}
+	if !ok || capture.ChildID != share.ChildID {
+		return model.SpiritCard{}, ErrShareNotFound
+	}
+
+	card := model.SpiritCard{
+		Token:      share.Token,
+		SpiritName: capture.SpiritName,
+		ObjectType: capture.ObjectType,
+		Fact:       capture.Fact,
+		CapturedAt: capture.CapturedAt,
+		ExpiresAt:  share.ExpiresAt,
+	}
+	if spirit, exists, err := st.GetSpirit(capture.SpiritID); err != nil {
+		return model.SpiritCard{}, err
+	} else if exists {
+		card.Personality = spirit.Personality
+		card.Intro = spirit.Intro
+		card.CharacterImageURL = spirit.ImageURL
+	}
+	return card, nil
+}
+
+func (s *Service) ownedCapture(ctx context.Context, childID string, captureID string) (model.Capture, error) {
+	st := s.storeFor(ctx)
+	captureID = strings.TrimSpace(captureID)
+	if captureID == "" {
+		return model.Capture{}, ErrCaptureNotFound
+	}
+	capture, ok, err := st.GetCapture(captureID)
+	if err != nil {
+		return model.Capture{}, err
+	}
+	if !ok || capture.ChildID != childID {
+		return model.Capture{}, ErrCaptureNotFound
+	}
+	return capture, nil
+}
+
+func newShareToken() (string, error) {
+	buf := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"ling/internal/logging"
+	"ling/internal/store"
+	"ling/internal/tracing"
+)
+
+// startSpan 为 Service 方法开始一个 Service.<name> span。
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "Service."+name)
+}
+
+// annotate 把孩子与物体类型同时记到访问日志字段和当前 span 上。
+func annotate(ctx context.Context, childID string, objectType string) {
+	logging.AddFields(ctx, "child_id", childID, "object_type", objectType)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("child_id", childID),
+		attribute.String("object_type", objectType),
+	)
+}
+
+// storeFor 返回绑定了 ctx 的存储，经它发出的存储调用会记成 ctx 中 span 的子 span。
+func (s *Service) storeFor(ctx context.Context) store.Store {
+	return store.WithContext(s.store, ctx)
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/store"
+	"ling/internal/tracing"
+	"ling/pkg/api"
+)
+
+const (
+	TradeStatusPending   = "pending"
+	TradeStatusAccepted  = "accepted"
+	TradeStatusDeclined  = "declined"
+	TradeStatusCancelled = "cancelled"
+
+	TradeActionAccept  = api.TradeActionAccept
+	TradeActionDecline = api.TradeActionDecline
+	TradeActionCancel  = api.TradeActionCancel
+)
+
+// ProposeTrade 发起一次交换，对方接受前双方的收集都不会变化。
+func (s *Service) ProposeTrade(ctx context.Context, req TradeProposal) (_ TradeDetail, err error) {
+	ctx, span := startSpan(ctx, "ProposeTrade")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	proposerID := strings.TrimSpace(req.ChildID)
+	recipientID := strings.TrimSpace(req.RecipientID)
+	if proposerID == "" || recipientID == "" || strings.TrimSpace(req.GroupID) == "" {
+		return TradeDetail{}, fmt.Errorf("%w: 请提供 group_id、child_id 与 recipient_id", ErrTradeInvalid)
+	}
+	if proposerID == recipientID {
+		return TradeDetail{}, fmt.Errorf("%w: 不能和自己交换", ErrTradeInvalid)
+	}
+	group, err := s.tradeGroup(ctx, req.GroupID, proposerID, recipientID)
+	if err != nil {
+		return TradeDetail{}, err
+	}
+	offered, err := s.ownedCapture(ctx, proposerID, req.CaptureID)
+	if err != nil {
+		return TradeDetail{}, err
+	}
+	requested, err := s.ownedCapture(ctx, recipientID, req.RecipientCaptureID)
+	if err != nil {
+		return TradeDetail{}, err
+	}
+
+	trade := model.Trade{
+		ID:                 s.newID("trade"),
+		GroupID:            group.ID,
+		ProposerID:         proposerID,
+		ProposerCaptureID:  offered.ID,
+		RecipientID:        recipientID,
+		RecipientCaptureID: requested.ID,
+		Status:             TradeStatusPending,
+		CreatedAt:          time.Now(),
+	}
+	if err := st.SaveTrade(trade); err != nil {
+		return TradeDetail{}, err
+	}
+	return TradeDetail{Trade: trade, Offered: tradeCaptureView(offered), Requested: tradeCaptureView(requested)}, nil
+}
+
+// RespondTrade 处理待定的交换：对方可以 accept 或 decline，发起人可以 cancel。
+// 接受时两条收集的归属在存储层同一事务内互换。
+func (s *Service) RespondTrade(ctx context.Context, id string, action string, req TradeActionRequest) (_ TradeDetail, err error) {
+	ctx, span := startSpan(ctx, "RespondTrade")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID := strings.TrimSpace(req.ChildID)
+	s.tradeMu.Lock()
+	defer s.tradeMu.Unlock()
+
+	trade, ok, err := st.GetTrade(strings.TrimSpace(id))
+	if err != nil {
+		return TradeDetail{}, err
+	}
+	if !ok || childID == "" || (trade.ProposerID != childID && trade.RecipientID != childID) {
+		return TradeDetail{}, ErrTradeNotFound
+	}
+	if trade.Status != TradeStatusPending {
+		return TradeDetail{}, ErrTradeConflict
+	}
+
+	var actorID, status string
+	switch strings.TrimSpace(action) {
+	case TradeActionAccept:
+		actorID, status = trade.RecipientID, TradeStatusAccepted
+	case TradeActionDecline:
+		actorID, status = trade.RecipientID, TradeStatusDeclined
+	case TradeActionCancel:
+		actorID, status = trade.ProposerID, TradeStatusCancelled
+	default:
+		return TradeDetail{}, fmt.Errorf("%w: action 必须是 accept、decline 或 cancel", ErrTradeInvalid)
+	}
+	if childID != actorID {
+		return TradeDetail{}, ErrTradeForbidden
+	}
+
+	now := time.Now()
+	trade.Status = status
+	trade.RespondedAt = &now
+	if status != TradeStatusAccepted {
+		if err := st.SaveTrade(trade); err != nil {
+			return TradeDetail{}, err
+		}
+		return s.tradeDetail(ctx, trade)
+	}
+	// 期间有人退群也不能再完成交换。
+	if _, err := s.tradeGroup(ctx, trade.GroupID, trade.ProposerID, trade.RecipientID); err != nil {
+		return TradeDetail{}, err
+	}
+	if err := st.AcceptTrade(trade); err != nil {
+		if errors.Is(err, store.ErrTradeConflict) {
+			return TradeDetail{}, ErrTradeConflict
+		}
+		return TradeDetail{}, err
+	}
+	return s.tradeDetail(ctx, trade)
+}
+
+// Trades 返回孩子发起或收到的交换，status 为空时返回全部。
+func (s *Service) Trades(ctx context.Context, childID string, status string) (_ []TradeDetail, err error) {
+	ctx, span := startSpan(ctx, "Trades")
+	defer func() { tracing.End(span, err) }()
+	st := s.storeFor(ctx)
+	childID = strings.TrimSpace(childID)
+	if childID == "" {
+		childID = "guest"
+	}
+	status = strings.TrimSpace(status)
+	switch status {
+	case "", TradeStatusPending, TradeStatusAccepted, TradeStatusDeclined, TradeStatusCancelled:
+	default:
+		return nil, fmt.Errorf("%w: status 不受支持", ErrTradeInvalid)
+	}
+	trades, err := st.ListTradesByChild(childID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]TradeDetail, 0, len(trades))
+	for _, trade := range trades {
+		if status != "" && trade.Status != status {
+			continue
+		}
+		detail, err := s.tradeDetail(ctx, trade)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, detail)
+	}
+	return result, nil
+}
+
+// tradeGroup 确认双方都是群组成员；发起人不在群内返回 ErrTradeForbidden。
+func (s *Service) tradeGroup(ctx context.Context, groupID string, proposerID string, recipientID string) (model.Group, error) {
+	group, members, err := s.viewableGroup(ctx, groupID, GroupViewer{ChildID: proposerID})
+	if errors.Is(err, ErrGroupForbidden) {
+		return model.Group{}, ErrTradeForbidden
+	}
+	if err != nil {
+		return model.Group{}, err
+	}
+	for _, member := range members {
+		if member.ChildID == recipientID {
+			return group, nil
+		}
+	}
+	return model.Group{}, fmt.Errorf("%w: 对方不在该群组中", ErrTradeForbidden)
+}
+
+func (s *Service) tradeDetail(ctx context.Context, trade model.Trade) (TradeDetail, error) {
+	offered, err := s.tradeCapture(ctx, trade.ProposerCaptureID)
+	if err != nil {
+		return TradeDetail{}, err
+	}
+	requested, err := s.tradeCapture(ctx, trade.RecipientCaptureID)
+	if err != nil {
+		return TradeDetail{}, err
+	}
+	return TradeDetail{Trade: trade, Offered: offered, Requested: requested}, nil
+}
+
+func (s *Service) tradeCapture(ctx context.Context, id string) (*model.Capture, error) {
+	st := s.storeFor(ctx)
+	capture, ok, err := st.GetCapture(id)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return tradeCaptureView(capture), nil
+}
+
+func tradeCaptureView(capture model.Capture) *model.Capture {
+	capture.Location = nil
+	return &capture
+}
@@ -0,0 +1,189 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ling/internal/model"
+	"ling/internal/service"
+)
+
+func TestSpiritShareCardAndRevoke(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	capture := captureObject(t, svc, st, "kid_share", "tree").Capture
+	if _, err := svc.CreateSpiritShare(context.Background(), service.SpiritShareRequest{ChildID: "someone_else", CaptureID: capture.ID}); !errors.Is(err, service.ErrCaptureNotFound) {
+		t.Fatalf("expected ErrCaptureNotFound for another child's capture, got %v", err)
+	}
+	if _, err := svc.CreateSpiritShare(context.Background(), service.SpiritShareRequest{ChildID: "kid_share", CaptureID: capture.ID, ExpiresInDays: 400}); !errors.Is(err, service.ErrShareInvalid) {
+		t.Fatalf("expected ErrShareInvalid for long expiry, got %v", err)
+	}
+
+	share, err := svc.CreateSpiritShare(context.Background(), service.SpiritShareRequest{ChildID: "kid_share", CaptureID: capture.ID})
+	if err != nil {
+		t.Fatalf("CreateSpiritShare() error = %v", err)
+	}
+	if share.Token == "" || share.ExpiresAt == nil {
+		t.Fatalf("unexpected share %+v", share)
+	}
+
+	card, err := svc.SpiritCard(context.Background(), share.Token)
+	if err != nil {
+		t.Fatalf("SpiritCard() error = %v", err)
+	}
+	if card.SpiritName != capture.SpiritName || card.Fact != capture.Fact || card.Personality == "" || card.Intro == "" {
+		t.Fatalf("unexpected card %+v for capture %+v", card, capture)
+	}
+
+	if err := svc.RevokeSpiritShare(context.Background(), share.Token, "someone_else"); !errors.Is(err, service.ErrShareNotFound) {
+		t.Fatalf("expected only the creator to revoke, got %v", err)
+	}
+	if err := svc.RevokeSpiritShare(context.Background(), share.Token, "kid_share"); err != nil {
+		t.Fatalf("RevokeSpiritShare() error = %v", err)
+	}
+	if _, err := svc.SpiritCard(context.Background(), share.Token); !errors.Is(err, service.ErrShareNotFound) {
+		t.Fatalf("expected revoked share to be gone, got %v", err)
+	}
+
+	expiredAt := time.Now().Add(-time.Minute)
+	if err := st.SaveSpiritShare(model.SpiritShare{Token: "expired", ChildID: "kid_share", CaptureID: capture.ID, CreatedAt: time.Now(), ExpiresAt: &expiredAt}); err != nil {
+		t.Fatalf("SaveSpiritShare() error = %v", err)
+	}
+	if _, err := svc.SpiritCard(context.Background(), "expired"); !errors.Is(err, service.ErrShareExpired) {
+		t.Fatalf("expected ErrShareExpired, got %v", err)
+	}
+}
+
+func TestTradeSwapsCapturesWithinGroup(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	group, err := svc.CreateGroup(context.Background(), service.GroupRequest{Name: "我们家", OwnerID: "parent"})
+	if err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+	for _, childID := range []string{"kid_a", "kid_b"} {
+		if _, err := svc.JoinGroup(context.Background(), service.GroupJoinRequest{InviteCode: group.InviteCode, ChildID: childID}); err != nil {
+			t.Fatalf("JoinGroup() error = %v", err)
+		}
+	}
+	tree := captureObject(t, svc, st, "kid_a", "tree").Capture
+	mailbox := captureObject(t, svc, st, "kid_b", "mailbox").Capture
+	outsider := captureObject(t, svc, st, "kid_c", "manhole").Capture
+
+	share, err := svc.CreateSpiritShare(context.Background(), service.SpiritShareRequest{ChildID: "kid_a", CaptureID: tree.ID})
+	if err != nil {
+		t.Fatalf("CreateSpiritShare() error = %v", err)
+	}
+
+	if _, err := svc.ProposeTrade(context.Background(), service.TradeProposal{GroupID: group.ID, ChildID: "kid_a", CaptureID: tree.ID, RecipientID: "kid_c", RecipientCaptureID: outsider.ID}); !errors.Is(err, service.ErrTradeForbidden) {
+		t.Fatalf("expected ErrTradeForbidden for a recipient outside the group, got %v", err)
+	}
+	if _, err := svc.ProposeTrade(context.Background(), service.TradeProposal{GroupID: group.ID, ChildID: "kid_a", CaptureID: mailbox.ID, RecipientID: "kid_b", RecipientCaptureID: tree.ID}); !errors.Is(err, service.ErrCaptureNotFound) {
+		t.Fatalf("expected ErrCaptureNotFound when offering someone else's capture, got %v", err)
+	}
+
+	trade, err := svc.ProposeTrade(context.Background(), service.TradeProposal{GroupID: group.ID, ChildID: "kid_a", CaptureID: tree.ID, RecipientID: "kid_b", RecipientCaptureID: mailbox.ID})
+	if err != nil {
+		t.Fatalf("ProposeTrade() error = %v", err)
+	}
+	if trade.Status != service.TradeStatusPending || trade.Offered == nil || trade.Requested == nil {
+		t.Fatalf("unexpected trade %+v", trade)
+	}
+	if _, err := svc.RespondTrade(context.Background(), trade.ID, service.TradeActionAccept, service.TradeActionRequest{ChildID: "kid_a"}); !errors.Is(err, service.ErrTradeForbidden) {
+		t.Fatalf("expected the proposer not to accept their own trade, got %v", err)
+	}
+
+	accepted, err := svc.RespondTrade(context.Background(), trade.ID, service.TradeActionAccept, service.TradeActionRequest{ChildID: "kid_b"})
+	if err != nil {
+		t.Fatalf("RespondTrade(accept) error = %v", err)
+	}
+	if accepted.Status != service.TradeStatusAccepted || accepted.RespondedAt == nil {
+		t.Fatalf("unexpected accepted trade %+v", accepted)
+	}
+	if accepted.Offered.ChildID != "kid_b" || accepted.Requested.ChildID != "kid_a" {
+		t.Fatalf("expected captures to swap owners, got %+v", accepted)
+	}
+
+	pokedexA, err := svc.Pokedex(context.Background(), "kid_a")
+	if err != nil {
+		t.Fatalf("Pokedex() error = %v", err)
+	}
+	if len(pokedexA) != 1 || pokedexA[0].ObjectType != mailbox.ObjectType {
+		t.Fatalf("expected kid_a to own only the mailbox spirit, got %+v", pokedexA)
+	}
+	pokedexB, err := svc.Pokedex(context.Background(), "kid_b")
+	if err != nil {
+		t.Fatalf("Pokedex() error = %v", err)
+	}
+	if len(pokedexB) != 1 || pokedexB[0].ObjectType != tree.ObjectType {
+		t.Fatalf("expected kid_b to own only the tree spirit, got %+v", pokedexB)
+	}
+
+	if _, err := svc.SpiritCard(context.Background(), share.Token); !errors.Is(err, service.ErrShareNotFound) {
+		t.Fatalf("expected share links to stop working after the capture changed hands, got %v", err)
+	}
+	if _, err := svc.RespondTrade(context.Background(), trade.ID, service.TradeActionDecline, service.TradeActionRequest{ChildID: "kid_b"}); !errors.Is(err, service.ErrTradeConflict) {
+		t.Fatalf("expected ErrTradeConflict for a settled trade, got %v", err)
+	}
+
+	trades, err := svc.Trades(context.Background(), "kid_a", service.TradeStatusAccepted)
+	if err != nil || len(trades) != 1 || trades[0].ID != trade.ID {
+		t.Fatalf("Trades(kid_a, accepted) = %+v err=%v", trades, err)
+	}
+}
+
+func TestTradeConflictsWhenCaptureAlreadyMoved(t *testing.T) {
+	t.Parallel()
+
+	svc, st := newTestService(t)
+	group, err := svc.CreateGroup(context.Background(), service.GroupRequest{Name: "三年二班", Kind: "classroom", OwnerID: "teacher"})
+	if err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+	for _, childID := range []string{"kid_a", "kid_b", "kid_c"} {
+		if _, err := svc.JoinGroup(context.Background(), service.GroupJoinRequest{InviteCode: group.InviteCode, ChildID: childID}); err != nil {
+			t.Fatalf("JoinGroup() error = %v", err)
+		}
+	}
+	tree := captureObject(t, svc, st, "kid_a", "tree").Capture
+	mailbox := captureObject(t, svc, st, "kid_b", "mailbox").Capture
+	manhole := captureObject(t, svc, st, "kid_c", "manhole").Capture
+
+	// kid_a 把同一只精灵同时提给两个人，先接受的一方成交，另一笔随之冲突。
+	toB, err := svc.ProposeTrade(context.Background(), service.TradeProposal{GroupID: group.ID, ChildID: "kid_a", CaptureID: tree.ID, RecipientID: "kid_b", RecipientCaptureID: mailbox.ID})
+	if err != nil {
+		t.Fatalf("ProposeTrade(to b) error = %v", err)
+	}
+	toC, err := svc.ProposeTrade(context.Background(), service.TradeProposal{GroupID: group.ID, ChildID: "kid_a", CaptureID: tree.ID, RecipientID: "kid_c", RecipientCaptureID: manhole.ID})
+	if err != nil {
+		t.Fatalf("ProposeTrade(to c) error = %v", err)
+	}
+	if _, err := svc.RespondTrade(context.Background(), toB.ID, service.TradeActionAccept, service.TradeActionRequest{ChildID: "kid_b"}); err != nil {
+		t.Fatalf("RespondTrade(accept b) error = %v", err)
+	}
+	if _, err := svc.RespondTrade(context.Background(), toC.ID, service.TradeActionAccept, service.TradeActionRequest{ChildID: "kid_c"}); !errors.Is(err, service.ErrTradeConflict) {
+		t.Fatalf("expected ErrTradeConflict, got %v", err)
+	}
+	captures, err := st.ListCapturesByChild("kid_c")
+	if err != nil {
+		t.Fatalf("ListCapturesByChild() error = %v", err)
+	}
+	if len(captures) != 1 || captures[0].ID != manhole.ID {
+		t.Fatalf("expected kid_c's capture untouched after the conflict, got %+v", captures)
+	}
+
+	cancelled, err := svc.RespondTrade(context.Background(), toC.ID, service.TradeActionCancel, service.TradeActionRequest{ChildID: "kid_a"})
+	if err != nil {
+		t.Fatalf("RespondTrade(cancel) error = %v", err)
+	}
+	if cancelled.Status != service.TradeStatusCancelled {
+		t.Fatalf("expected cancelled trade, got %+v", cancelled)
+	}
+	if _, err := svc.RespondTrade(context.Background(), toC.ID, "swap", service.TradeActionRequest{ChildID: "kid_a"}); !errors.Is(err, service.ErrTradeConflict) {
+		t.Fatalf("expected settled trades to reject further actions, got %v", err)
+	}
+}
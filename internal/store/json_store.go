@@ -1,10 +1,12 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,9 +14,26 @@ import (
 )
 
 type fileState struct {
-	Spirits  map[string]model.Spirit      `json:"spirits"`
-	Sessions map[string]model.ScanSession `json:"sessions"`
-	Captures []model.Capture              `json:"captures"`
+	Spirits  map[string]model.Spirit        `json:"spirits"`
+	Sessions map[string]model.ScanSession   `json:"sessions"`
+	Captures []model.Capture                `json:"captures"`
+	Reviews  map[string]model.ReviewItem    `json:"reviews"`
+	Progress map[string]model.ChildProgress `json:"progress"`
+	Unlocks  map[string]model.BadgeUnlock   `json:"badge_unlocks"`
+	Messages []model.CompanionMessage       `json:"companion_messages"`
+
+	Subscriptions map[string]model.DeliverySubscription `json:"delivery_subscriptions"`
+	Attempts      []model.DeliveryAttempt               `json:"delivery_attempts"`
+
+	Quests map[string]model.Quest `json:"quests"`
+
+	Groups  map[string]model.Group `json:"groups"`
+	Members []model.GroupMember    `json:"group_members"`
+
+	Shares map[string]model.SpiritShare `json:"spirit_shares"`
+	Trades map[string]model.Trade       `json:"trades"`
+
+	Profiles map[string]model.ChildProfile `json:"child_profiles"`
 }
 
 type JSONStore struct {
@@ -30,6 +49,23 @@ func NewJSONStore(filePath string) (*JSONStore, error) {
 			Spirits:  make(map[string]model.Spirit),
 			Sessions: make(map[string]model.ScanSession),
 			Captures: make([]model.Capture, 0),
+			Reviews:  make(map[string]model.ReviewItem),
+			Progress: make(map[string]model.ChildProgress),
+			Unlocks:  make(map[string]model.BadgeUnlock),
+			Messages: make([]model.CompanionMessage, 0),
+
+			Subscriptions: make(map[string]model.DeliverySubscription),
+			Attempts:      make([]model.DeliveryAttempt, 0),
+
+			Quests: make(map[string]model.Quest),
+
+			Groups:  make(map[string]model.Group),
+			Members: make([]model.GroupMember, 0),
+
+			Shares: make(map[string]model.SpiritShare),
+			Trades: make(map[string]model.Trade),
+
+			Profiles: make(map[string]model.ChildProfile),
 		},
 	}
 	if err := s.load(); err != nil {
@@ -52,6 +88,23 @@ func (s *JSONStore) GetSpirit(id string) (model.Spirit, bool, error) {
 	return spirit, ok, nil
 }
 
+func (s *JSONStore) GetSpiritByChild(childID string, objectType string) (model.Spirit, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var found model.Spirit
+	ok := false
+	for _, spirit := range s.state.Spirits {
+		if spirit.ChildID != childID || spirit.ObjectType != objectType {
+			continue
+		}
+		// 与 SQLite 保持一致：若有多只，取最早创建的一只。
+		if !ok || spirit.CreatedAt.Before(found.CreatedAt) || (spirit.CreatedAt.Equal(found.CreatedAt) && spirit.ID < found.ID) {
+			found, ok = spirit, true
+		}
+	}
+	return found, ok, nil
+}
+
 func (s *JSONStore) SaveSession(session model.ScanSession) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -76,6 +129,21 @@ func (s *JSONStore) UpdateSession(session model.ScanSession) error {
 	return s.persistLocked()
 }
 
+func (s *JSONStore) ListSessionsByChild(childID string) ([]model.ScanSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.ScanSession, 0)
+	for _, session := range s.state.Sessions {
+		if session.ChildID == childID {
+			result = append(result, session)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
 func (s *JSONStore) AddCapture(capture model.Capture) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -83,6 +151,17 @@ func (s *JSONStore) AddCapture(capture model.Capture) error {
 	return s.persistLocked()
 }
 
+func (s *JSONStore) GetCapture(id string) (model.Capture, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, capture := range s.state.Captures {
+		if capture.ID == id {
+			return capture, true, nil
+		}
+	}
+	return model.Capture{}, false, nil
+}
+
 func (s *JSONStore) ListCapturesByChild(childID string) ([]model.Capture, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -95,6 +174,20 @@ func (s *JSONStore) ListCapturesByChild(childID string) ([]model.Capture, error)
 	return result, nil
 }
 
+func (s *JSONStore) ListCapturesInArea(minLat float64, minLng float64, maxLat float64, maxLng float64) ([]model.Capture, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.Capture, 0)
+	for _, capture := range s.state.Captures {
+		loc := capture.Location
+		if loc == nil || loc.Latitude < minLat || loc.Latitude > maxLat || loc.Longitude < minLng || loc.Longitude > maxLng {
+			continue
+		}
+		result = append(result, capture)
+	}
+	return result, nil
+}
+
 func (s *JSONStore) ListCapturesByChildAndDate(childID string, day time.Time) ([]model.Capture, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -109,6 +202,384 @@ func (s *JSONStore) ListCapturesByChildAndDate(childID string, day time.Time) ([
 	return result, nil
 }
 
+func (s *JSONStore) SaveReviewItem(item model.ReviewItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Reviews[item.ID] = item
+	return s.persistLocked()
+}
+
+func (s *JSONStore) GetReviewItem(id string) (model.ReviewItem, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.state.Reviews[id]
+	return item, ok, nil
+}
+
+func (s *JSONStore) ListReviewItemsByChild(childID string) ([]model.ReviewItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.ReviewItem, 0)
+	for _, item := range s.state.Reviews {
+		if item.ChildID == childID {
+			result = append(result, item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DueAt.Before(result[j].DueAt)
+	})
+	return result, nil
+}
+
+func (s *JSONStore) GetProgress(childID string) (model.ChildProgress, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	progress, ok := s.state.Progress[childID]
+	return progress, ok, nil
+}
+
+func (s *JSONStore) SaveProgress(progress model.ChildProgress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Progress[progress.ChildID] = progress
+	return s.persistLocked()
+}
+
+func (s *JSONStore) SaveBadgeUnlock(unlock model.BadgeUnlock) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Unlocks[unlock.ID] = unlock
+	return s.persistLocked()
+}
+
+func (s *JSONStore) ListBadgeUnlocksByChild(childID string) ([]model.BadgeUnlock, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.BadgeUnlock, 0)
+	for _, unlock := range s.state.Unlocks {
+		if unlock.ChildID == childID {
+			result = append(result, unlock)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UnlockedAt.Before(result[j].UnlockedAt)
+	})
+	return result, nil
+}
+
+func (s *JSONStore) AddCompanionMessage(message model.CompanionMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Messages = append(s.state.Messages, message)
+	return s.persistLocked()
+}
+
+func (s *JSONStore) ListCompanionMessagesByChild(childID string, start time.Time, end time.Time) ([]model.CompanionMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.CompanionMessage, 0)
+	for _, message := range s.state.Messages {
+		if message.ChildID != childID || message.CreatedAt.Before(start) || !message.CreatedAt.Before(end) {
+			continue
+		}
+		result = append(result, message)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (s *JSONStore) SaveDeliverySubscription(sub model.DeliverySubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Subscriptions[sub.ID] = sub
+	return s.persistLocked()
+}
+
+func (s *JSONStore) GetDeliverySubscription(id string) (model.DeliverySubscription, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sub, ok := s.state.Subscriptions[id]
+	return sub, ok, nil
+}
+
+func (s *JSONStore) ListDeliverySubscriptions() ([]model.DeliverySubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.DeliverySubscription, 0, len(s.state.Subscriptions))
+	for _, sub := range s.state.Subscriptions {
+		result = append(result, sub)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (s *JSONStore) DeleteDeliverySubscription(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.state.Subscriptions, id)
+	return s.persistLocked()
+}
+
+func (s *JSONStore) AddDeliveryAttempt(attempt model.DeliveryAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Attempts = append(s.state.Attempts, attempt)
+	return s.persistLocked()
+}
+
+func (s *JSONStore) ListDeliveryAttempts(subscriptionID string, limit int) ([]model.DeliveryAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.DeliveryAttempt, 0)
+	for i := len(s.state.Attempts) - 1; i >= 0; i-- {
+		attempt := s.state.Attempts[i]
+		if subscriptionID != "" && attempt.SubscriptionID != subscriptionID {
+			continue
+		}
+		result = append(result, attempt)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (s *JSONStore) SaveQuest(quest model.Quest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Quests[quest.ID] = quest
+	return s.persistLocked()
+}
+
+func (s *JSONStore) GetQuest(id string) (model.Quest, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	quest, ok := s.state.Quests[id]
+	return quest, ok, nil
+}
+
+func (s *JSONStore) ListQuestsByChild(childID string, date string) ([]model.Quest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.Quest, 0)
+	for _, quest := range s.state.Quests {
+		if quest.ChildID == childID && quest.Date == date {
+			result = append(result, quest)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (s *JSONStore) SaveGroup(group model.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Groups[group.ID] = group
+	return s.persistLocked()
+}
+
+func (s *JSONStore) GetGroup(id string) (model.Group, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	group, ok := s.state.Groups[id]
+	return group, ok, nil
+}
+
+func (s *JSONStore) GetGroupByInviteCode(code string) (model.Group, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, group := range s.state.Groups {
+		if group.InviteCode == code {
+			return group, true, nil
+		}
+	}
+	return model.Group{}, false, nil
+}
+
+func (s *JSONStore) ListGroups(ownerID string) ([]model.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.Group, 0)
+	for _, group := range s.state.Groups {
+		if ownerID == "" || group.OwnerID == ownerID {
+			result = append(result, group)
+		}
+	}
+	sortGroups(result)
+	return result, nil
+}
+
+func (s *JSONStore) SaveGroupMember(member model.GroupMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.state.Members {
+		if existing.GroupID == member.GroupID && existing.ChildID == member.ChildID {
+			s.state.Members[i] = member
+			return s.persistLocked()
+		}
+	}
+	s.state.Members = append(s.state.Members, member)
+	return s.persistLocked()
+}
+
+func (s *JSONStore) DeleteGroupMember(groupID string, childID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	members := s.state.Members[:0]
+	for _, member := range s.state.Members {
+		if member.GroupID != groupID || member.ChildID != childID {
+			members = append(members, member)
+		}
+	}
+	s.state.Members = members
+	return s.persistLocked()
+}
+
+func (s *JSONStore) ListGroupMembers(groupID string) ([]model.GroupMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.GroupMember, 0)
+	for _, member := range s.state.Members {
+		if member.GroupID == groupID {
+			result = append(result, member)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].JoinedAt.Before(result[j].JoinedAt)
+	})
+	return result, nil
+}
+
+func (s *JSONStore) ListGroupsByChild(childID string) ([]model.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.Group, 0)
+	for _, member := range s.state.Members {
+		if member.ChildID != childID {
+			continue
+		}
+		if group, ok := s.state.Groups[member.GroupID]; ok {
+			result = append(result, group)
+		}
+	}
+	sortGroups(result)
+	return result, nil
+}
+
+func sortGroups(groups []model.Group) {
+	sort.Slice(groups, func(i, j int) bool {
+		if !groups[i].CreatedAt.Equal(groups[j].CreatedAt) {
+			return groups[i].CreatedAt.Before(groups[j].CreatedAt)
+		}
+		return groups[i].ID < groups[j].ID
+	})
+}
+
+func (s *JSONStore) SaveSpiritShare(share model.SpiritShare) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Shares[share.Token] = share
+	return s.persistLocked()
+}
+
+func (s *JSONStore) GetSpiritShare(token string) (model.SpiritShare, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	share, ok := s.state.Shares[token]
+	return share, ok, nil
+}
+
+func (s *JSONStore) SaveTrade(trade model.Trade) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Trades[trade.ID] = trade
+	return s.persistLocked()
+}
+
+func (s *JSONStore) GetTrade(id string) (model.Trade, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	trade, ok := s.state.Trades[id]
+	return trade, ok, nil
+}
+
+func (s *JSONStore) ListTradesByChild(childID string) ([]model.Trade, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]model.Trade, 0)
+	for _, trade := range s.state.Trades {
+		if trade.ProposerID == childID || trade.RecipientID == childID {
+			result = append(result, trade)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].ID > result[j].ID
+	})
+	return result, nil
+}
+
+func (s *JSONStore) AcceptTrade(trade model.Trade) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.state.Trades[trade.ID]
+	if !ok || stored.Status != "pending" {
+		return ErrTradeConflict
+	}
+	proposerIdx, recipientIdx := -1, -1
+	for i, capture := range s.state.Captures {
+		switch {
+		case capture.ID == trade.ProposerCaptureID && capture.ChildID == trade.ProposerID:
+			proposerIdx = i
+		case capture.ID == trade.RecipientCaptureID && capture.ChildID == trade.RecipientID:
+			recipientIdx = i
+		}
+	}
+	if proposerIdx < 0 || recipientIdx < 0 {
+		return ErrTradeConflict
+	}
+
+	proposerCapture, recipientCapture := s.state.Captures[proposerIdx], s.state.Captures[recipientIdx]
+	s.state.Captures[proposerIdx].ChildID = trade.RecipientID
+	s.state.Captures[proposerIdx].Location = nil
+	s.state.Captures[recipientIdx].ChildID = trade.ProposerID
+	s.state.Captures[recipientIdx].Location = nil
+	s.state.Trades[trade.ID] = trade
+	if err := s.persistLocked(); err != nil {
+		// 写盘失败时回滚内存状态，保证两条收集要么都换手要么都不变。
+		s.state.Captures[proposerIdx] = proposerCapture
+		s.state.Captures[recipientIdx] = recipientCapture
+		s.state.Trades[trade.ID] = stored
+		return err
+	}
+	return nil
+}
+
+func (s *JSONStore) SaveChildProfile(profile model.ChildProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Profiles[profile.ChildID] = profile
+	return s.persistLocked()
+}
+
+func (s *JSONStore) GetChildProfile(childID string) (model.ChildProfile, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	profile, ok := s.state.Profiles[childID]
+	return profile, ok, nil
+}
+
 func (s *JSONStore) load() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -132,10 +603,65 @@ func (s *JSONStore) load() error {
 	if state.Captures == nil {
 		state.Captures = make([]model.Capture, 0)
 	}
+	if state.Reviews == nil {
+		state.Reviews = make(map[string]model.ReviewItem)
+	}
+	if state.Progress == nil {
+		state.Progress = make(map[string]model.ChildProgress)
+	}
+	if state.Unlocks == nil {
+		state.Unlocks = make(map[string]model.BadgeUnlock)
+	}
+	if state.Messages == nil {
+		state.Messages = make([]model.CompanionMessage, 0)
+	}
+	if state.Subscriptions == nil {
+		state.Subscriptions = make(map[string]model.DeliverySubscription)
+	}
+	if state.Attempts == nil {
+		state.Attempts = make([]model.DeliveryAttempt, 0)
+	}
+	if state.Quests == nil {
+		state.Quests = make(map[string]model.Quest)
+	}
+	if state.Groups == nil {
+		state.Groups = make(map[string]model.Group)
+	}
+	if state.Members == nil {
+		state.Members = make([]model.GroupMember, 0)
+	}
+	if state.Shares == nil {
+		state.Shares = make(map[string]model.SpiritShare)
+	}
+	if state.Trades == nil {
+		state.Trades = make(map[string]model.Trade)
+	}
+	if state.Profiles == nil {
+		state.Profiles = make(map[string]model.ChildProfile)
+	}
 	s.state = state
 	return nil
 }
 
+// Ping 在数据文件所在目录写入并删除一个临时文件，确认后续持久化不会因目录不可写而失败。
+func (s *JSONStore) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.filePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".ping-*")
+	if err != nil {
+		return err
+	}
+	_, writeErr := f.Write([]byte("ok"))
+	closeErr := f.Close()
+	removeErr := os.Remove(f.Name())
+	return errors.Join(writeErr, closeErr, removeErr)
+}
+
 func (s *JSONStore) persistLocked() error {
 	if err := os.MkdirAll(filepath.Dir(s.filePath), 0o755); err != nil {
 		return err
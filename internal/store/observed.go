@@ -0,0 +1,370 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"ling/internal/model"
+)
+
+// Observer 在每次存储操作结束后被调用，operation 为方法名，err 为该操作返回的错误；
+// ctx 为经 WithContext 绑定的上下文，未绑定时为 context.Background()。
+type Observer func(ctx context.Context, operation string, start time.Time, err error)
+
+// Observe 包装 st，让每次操作都经过 observer（如记录耗时指标、链路追踪）。除 Ping 外，Close 等 Store 以外的方法不会透传，
+// 需要关闭存储时应持有原始实例。
+func Observe(st Store, observer Observer) Store {
+	return &observedStore{next: st, observe: observer, ctx: context.Background()}
+}
+
+// WithContext 返回绑定 ctx 的存储视图，之后的操作都以 ctx 通知 observer，便于把存储操作挂到当前请求下。
+// st 不是 Observe 返回的存储时原样返回。
+func WithContext(st Store, ctx context.Context) Store {
+	observed, ok := st.(*observedStore)
+	if !ok || ctx == nil {
+		return st
+	}
+	bound := *observed
+	bound.ctx = ctx
+	return &bound
+}
+
+type observedStore struct {
+	next    Store
+	observe Observer
+	ctx     context.Context
+}
+
+// Ping 透传给原始存储；原始存储不支持自检时返回 ErrPingUnsupported。
+func (s *observedStore) Ping(ctx context.Context) error {
+	pinger, ok := s.next.(Pinger)
+	if !ok {
+		return ErrPingUnsupported
+	}
+	began := time.Now()
+	err := pinger.Ping(ctx)
+	s.observe(ctx, "Ping", began, err)
+	return err
+}
+
+func (s *observedStore) SaveSpirit(spirit model.Spirit) error {
+	began := time.Now()
+	err := s.next.SaveSpirit(spirit)
+	s.observe(s.ctx, "SaveSpirit", began, err)
+	return err
+}
+
+func (s *observedStore) GetSpirit(id string) (model.Spirit, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetSpirit(id)
+	s.observe(s.ctx, "GetSpirit", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) GetSpiritByChild(childID string, objectType string) (model.Spirit, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetSpiritByChild(childID, objectType)
+	s.observe(s.ctx, "GetSpiritByChild", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) SaveSession(session model.ScanSession) error {
+	began := time.Now()
+	err := s.next.SaveSession(session)
+	s.observe(s.ctx, "SaveSession", began, err)
+	return err
+}
+
+func (s *observedStore) GetSession(id string) (model.ScanSession, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetSession(id)
+	s.observe(s.ctx, "GetSession", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) UpdateSession(session model.ScanSession) error {
+	began := time.Now()
+	err := s.next.UpdateSession(session)
+	s.observe(s.ctx, "UpdateSession", began, err)
+	return err
+}
+
+func (s *observedStore) ListSessionsByChild(childID string) ([]model.ScanSession, error) {
+	began := time.Now()
+	v, err := s.next.ListSessionsByChild(childID)
+	s.observe(s.ctx, "ListSessionsByChild", began, err)
+	return v, err
+}
+
+func (s *observedStore) AddCapture(capture model.Capture) error {
+	began := time.Now()
+	err := s.next.AddCapture(capture)
+	s.observe(s.ctx, "AddCapture", began, err)
+	return err
+}
+
+func (s *observedStore) GetCapture(id string) (model.Capture, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetCapture(id)
+	s.observe(s.ctx, "GetCapture", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) ListCapturesByChild(childID string) ([]model.Capture, error) {
+	began := time.Now()
+	v, err := s.next.ListCapturesByChild(childID)
+	s.observe(s.ctx, "ListCapturesByChild", began, err)
+	return v, err
+}
+
+func (s *observedStore) ListCapturesByChildAndDate(childID string, day time.Time) ([]model.Capture, error) {
+	began := time.Now()
+	v, err := s.next.ListCapturesByChildAndDate(childID, day)
+	s.observe(s.ctx, "ListCapturesByChildAndDate", began, err)
+	return v, err
+}
+
+func (s *observedStore) ListCapturesInArea(minLat float64, minLng float64, maxLat float64, maxLng float64) ([]model.Capture, error) {
+	began := time.Now()
+	v, err := s.next.ListCapturesInArea(minLat, minLng, maxLat, maxLng)
+	s.observe(s.ctx, "ListCapturesInArea", began, err)
+	return v, err
+}
+
+func (s *observedStore) SaveReviewItem(item model.ReviewItem) error {
+	began := time.Now()
+	err := s.next.SaveReviewItem(item)
+	s.observe(s.ctx, "SaveReviewItem", began, err)
+	return err
+}
+
+func (s *observedStore) GetReviewItem(id string) (model.ReviewItem, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetReviewItem(id)
+	s.observe(s.ctx, "GetReviewItem", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) ListReviewItemsByChild(childID string) ([]model.ReviewItem, error) {
+	began := time.Now()
+	v, err := s.next.ListReviewItemsByChild(childID)
+	s.observe(s.ctx, "ListReviewItemsByChild", began, err)
+	return v, err
+}
+
+func (s *observedStore) GetProgress(childID string) (model.ChildProgress, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetProgress(childID)
+	s.observe(s.ctx, "GetProgress", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) SaveProgress(progress model.ChildProgress) error {
+	began := time.Now()
+	err := s.next.SaveProgress(progress)
+	s.observe(s.ctx, "SaveProgress", began, err)
+	return err
+}
+
+func (s *observedStore) SaveBadgeUnlock(unlock model.BadgeUnlock) error {
+	began := time.Now()
+	err := s.next.SaveBadgeUnlock(unlock)
+	s.observe(s.ctx, "SaveBadgeUnlock", began, err)
+	return err
+}
+
+func (s *observedStore) ListBadgeUnlocksByChild(childID string) ([]model.BadgeUnlock, error) {
+	began := time.Now()
+	v, err := s.next.ListBadgeUnlocksByChild(childID)
+	s.observe(s.ctx, "ListBadgeUnlocksByChild", began, err)
+	return v, err
+}
+
+func (s *observedStore) AddCompanionMessage(message model.CompanionMessage) error {
+	began := time.Now()
+	err := s.next.AddCompanionMessage(message)
+	s.observe(s.ctx, "AddCompanionMessage", began, err)
+	return err
+}
+
+func (s *observedStore) ListCompanionMessagesByChild(childID string, start time.Time, end time.Time) ([]model.CompanionMessage, error) {
+	began := time.Now()
+	v, err := s.next.ListCompanionMessagesByChild(childID, start, end)
+	s.observe(s.ctx, "ListCompanionMessagesByChild", began, err)
+	return v, err
+}
+
+func (s *observedStore) SaveDeliverySubscription(sub model.DeliverySubscription) error {
+	began := time.Now()
+	err := s.next.SaveDeliverySubscription(sub)
+	s.observe(s.ctx, "SaveDeliverySubscription", began, err)
+	return err
+}
+
+func (s *observedStore) GetDeliverySubscription(id string) (model.DeliverySubscription, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetDeliverySubscription(id)
+	s.observe(s.ctx, "GetDeliverySubscription", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) ListDeliverySubscriptions() ([]model.DeliverySubscription, error) {
+	began := time.Now()
+	v, err := s.next.ListDeliverySubscriptions()
+	s.observe(s.ctx, "ListDeliverySubscriptions", began, err)
+	return v, err
+}
+
+func (s *observedStore) DeleteDeliverySubscription(id string) error {
+	began := time.Now()
+	err := s.next.DeleteDeliverySubscription(id)
+	s.observe(s.ctx, "DeleteDeliverySubscription", began, err)
+	return err
+}
+
+func (s *observedStore) AddDeliveryAttempt(attempt model.DeliveryAttempt) error {
+	began := time.Now()
+	err := s.next.AddDeliveryAttempt(attempt)
+	s.observe(s.ctx, "AddDeliveryAttempt", began, err)
+	return err
+}
+
+func (s *observedStore) ListDeliveryAttempts(subscriptionID string, limit int) ([]model.DeliveryAttempt, error) {
+	began := time.Now()
+	v, err := s.next.ListDeliveryAttempts(subscriptionID, limit)
+	s.observe(s.ctx, "ListDeliveryAttempts", began, err)
+	return v, err
+}
+
+func (s *observedStore) SaveQuest(quest model.Quest) error {
+	began := time.Now()
+	err := s.next.SaveQuest(quest)
+	s.observe(s.ctx, "SaveQuest", began, err)
+	return err
+}
+
+func (s *observedStore) GetQuest(id string) (model.Quest, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetQuest(id)
+	s.observe(s.ctx, "GetQuest", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) ListQuestsByChild(childID string, date string) ([]model.Quest, error) {
+	began := time.Now()
+	v, err := s.next.ListQuestsByChild(childID, date)
+	s.observe(s.ctx, "ListQuestsByChild", began, err)
+	return v, err
+}
+
+func (s *observedStore) SaveGroup(group model.Group) error {
+	began := time.Now()
+	err := s.next.SaveGroup(group)
+	s.observe(s.ctx, "SaveGroup", began, err)
+	return err
+}
+
+func (s *observedStore) GetGroup(id string) (model.Group, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetGroup(id)
+	s.observe(s.ctx, "GetGroup", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) GetGroupByInviteCode(code string) (model.Group, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetGroupByInviteCode(code)
+	s.observe(s.ctx, "GetGroupByInviteCode", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) ListGroups(ownerID string) ([]model.Group, error) {
+	began := time.Now()
+	v, err := s.next.ListGroups(ownerID)
+	s.observe(s.ctx, "ListGroups", began, err)
+	return v, err
+}
+
+func (s *observedStore) SaveGroupMember(member model.GroupMember) error {
+	began := time.Now()
+	err := s.next.SaveGroupMember(member)
+	s.observe(s.ctx, "SaveGroupMember", began, err)
+	return err
+}
+
+func (s *observedStore) DeleteGroupMember(groupID string, childID string) error {
+	began := time.Now()
+	err := s.next.DeleteGroupMember(groupID, childID)
+	s.observe(s.ctx, "DeleteGroupMember", began, err)
+	return err
+}
+
+func (s *observedStore) ListGroupMembers(groupID string) ([]model.GroupMember, error) {
+	began := time.Now()
+	v, err := s.next.ListGroupMembers(groupID)
+	s.observe(s.ctx, "ListGroupMembers", began, err)
+	return v, err
+}
+
+func (s *observedStore) ListGroupsByChild(childID string) ([]model.Group, error) {
+	began := time.Now()
+	v, err := s.next.ListGroupsByChild(childID)
+	s.observe(s.ctx, "ListGroupsByChild", began, err)
+	return v, err
+}
+
+func (s *observedStore) SaveSpiritShare(share model.SpiritShare) error {
+	began := time.Now()
+	err := s.next.SaveSpiritShare(share)
+	s.observe(s.ctx, "SaveSpiritShare", began, err)
+	return err
+}
+
+func (s *observedStore) GetSpiritShare(token string) (model.SpiritShare, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetSpiritShare(token)
+	s.observe(s.ctx, "GetSpiritShare", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) SaveTrade(trade model.Trade) error {
+	began := time.Now()
+	err := s.next.SaveTrade(trade)
+	s.observe(s.ctx, "SaveTrade", began, err)
+	return err
+}
+
+func (s *observedStore) GetTrade(id string) (model.Trade, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetTrade(id)
+	s.observe(s.ctx, "GetTrade", began, err)
+	return v, ok, err
+}
+
+func (s *observedStore) ListTradesByChild(childID string) ([]model.Trade, error) {
+	began := time.Now()
+	v, err := s.next.ListTradesByChild(childID)
+	s.observe(s.ctx, "ListTradesByChild", began, err)
+	return v, err
+}
+
+func (s *observedStore) AcceptTrade(trade model.Trade) error {
+	began := time.Now()
+	err := s.next.AcceptTrade(trade)
+	s.observe(s.ctx, "AcceptTrade", began, err)
+	return err
+}
+
+func (s *observedStore) SaveChildProfile(profile model.ChildProfile) error {
+	began := time.Now()
+	err := s.next.SaveChildProfile(profile)
+	s.observe(s.ctx, "SaveChildProfile", began, err)
+	return err
+}
+
+func (s *observedStore) GetChildProfile(childID string) (model.ChildProfile, bool, error) {
+	began := time.Now()
+	v, ok, err := s.next.GetChildProfile(childID)
+	s.observe(s.ctx, "GetChildProfile", began, err)
+	return v, ok, err
+}
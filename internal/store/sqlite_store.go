@@ -1,8 +1,11 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -29,6 +32,10 @@ func NewSQLiteStore(filePath string) (*SQLiteStore, error) {
 		_ = db.Close()
 		return nil, err
 	}
+	if err := st.migrateSchema(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 	return st, nil
 }
 
@@ -36,53 +43,102 @@ func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
 
+// Ping 确认数据库可连接且能提交一次写入，数据库被锁或磁盘只读时会返回错误。
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO health_checks (id, checked_at) VALUES ('readyz', ?)
+		ON CONFLICT(id) DO UPDATE SET checked_at = excluded.checked_at`,
+		toTS(time.Now().UTC()),
+	)
+	return err
+}
+
+const spiritColumns = `id, child_id, name, object_type, personality, intro, image_url, created_at,
+	capture_count, level, stage, stage_name, traits, intro_lines, image_stage`
+
 func (s *SQLiteStore) SaveSpirit(spirit model.Spirit) error {
 	_, err := s.db.Exec(`
 		INSERT OR REPLACE INTO spirits
-		(id, name, object_type, personality, intro, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)`,
+		(`+spiritColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		spirit.ID,
+		spirit.ChildID,
 		spirit.Name,
 		spirit.ObjectType,
 		spirit.Personality,
 		spirit.Intro,
+		spirit.ImageURL,
 		toTS(spirit.CreatedAt),
+		spirit.Captures,
+		spirit.Level,
+		spirit.Stage,
+		spirit.StageName,
+		toJSONList(spirit.Traits),
+		toJSONList(spirit.IntroLines),
+		spirit.ImageStage,
 	)
 	return err
 }
 
-func (s *SQLiteStore) GetSpirit(id string) (model.Spirit, bool, error) {
-	row := s.db.QueryRow(`
-		SELECT id, name, object_type, personality, intro, created_at
-		FROM spirits
-		WHERE id = ?`,
-		id,
-	)
+func scanSpirit(row rowScanner) (model.Spirit, error) {
 	var spirit model.Spirit
-	var createdAt string
-	err := row.Scan(
+	var createdAt, traits, introLines string
+	if err := row.Scan(
 		&spirit.ID,
+		&spirit.ChildID,
 		&spirit.Name,
 		&spirit.ObjectType,
 		&spirit.Personality,
 		&spirit.Intro,
+		&spirit.ImageURL,
 		&createdAt,
-	)
+		&spirit.Captures,
+		&spirit.Level,
+		&spirit.Stage,
+		&spirit.StageName,
+		&traits,
+		&introLines,
+		&spirit.ImageStage,
+	); err != nil {
+		return model.Spirit{}, err
+	}
+	spirit.CreatedAt = fromTS(createdAt)
+	spirit.Traits = fromJSONList(traits)
+	spirit.IntroLines = fromJSONList(introLines)
+	return spirit, nil
+}
+
+func (s *SQLiteStore) GetSpirit(id string) (model.Spirit, bool, error) {
+	return s.getSpirit(`SELECT `+spiritColumns+` FROM spirits WHERE id = ?`, id)
+}
+
+func (s *SQLiteStore) GetSpiritByChild(childID string, objectType string) (model.Spirit, bool, error) {
+	return s.getSpirit(`SELECT `+spiritColumns+` FROM spirits
+		WHERE child_id = ? AND object_type = ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT 1`, childID, objectType)
+}
+
+func (s *SQLiteStore) getSpirit(query string, args ...any) (model.Spirit, bool, error) {
+	spirit, err := scanSpirit(s.db.QueryRow(query, args...))
 	if errors.Is(err, sql.ErrNoRows) {
 		return model.Spirit{}, false, nil
 	}
 	if err != nil {
 		return model.Spirit{}, false, err
 	}
-	spirit.CreatedAt = fromTS(createdAt)
 	return spirit, true, nil
 }
 
 func (s *SQLiteStore) SaveSession(session model.ScanSession) error {
 	_, err := s.db.Exec(`
 		INSERT INTO sessions
-		(id, child_id, child_age, object_type, spirit_id, quiz_q, quiz_a, fact, created_at, cache_hit, captured, captured_at, answer_given)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		(id, child_id, child_age, object_type, spirit_id, quiz_q, quiz_a, fact, created_at, cache_hit, captured, captured_at, answer_given, attempts,
+		 latitude, longitude, location_accuracy)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		session.ID,
 		session.ChildID,
 		session.ChildAge,
@@ -96,24 +152,68 @@ func (s *SQLiteStore) SaveSession(session model.ScanSession) error {
 		boolToInt(session.Captured),
 		nullableTS(session.CapturedAt),
 		session.AnswerGiven,
+		session.Attempts,
+		latitudeOf(session.Location),
+		longitudeOf(session.Location),
+		accuracyOf(session.Location),
 	)
 	return err
 }
 
 func (s *SQLiteStore) GetSession(id string) (model.ScanSession, bool, error) {
 	row := s.db.QueryRow(`
-		SELECT id, child_id, child_age, object_type, spirit_id, quiz_q, quiz_a, fact, created_at, cache_hit, captured, captured_at, answer_given
+		SELECT id, child_id, child_age, object_type, spirit_id, quiz_q, quiz_a, fact, created_at, cache_hit, captured, captured_at, answer_given, attempts,
+		       latitude, longitude, location_accuracy
 		FROM sessions
 		WHERE id = ?`,
 		id,
 	)
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ScanSession{}, false, nil
+	}
+	if err != nil {
+		return model.ScanSession{}, false, err
+	}
+	return session, true, nil
+}
+
+func (s *SQLiteStore) ListSessionsByChild(childID string) ([]model.ScanSession, error) {
+	rows, err := s.db.Query(`
+		SELECT id, child_id, child_age, object_type, spirit_id, quiz_q, quiz_a, fact, created_at, cache_hit, captured, captured_at, answer_given, attempts,
+		       latitude, longitude, location_accuracy
+		FROM sessions
+		WHERE child_id = ?
+		ORDER BY created_at DESC`,
+		childID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.ScanSession
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
+func scanSession(row rowScanner) (model.ScanSession, error) {
 	var session model.ScanSession
 	var createdAt string
 	var cacheHit int
 	var captured int
 	var capturedAt sql.NullString
-	err := row.Scan(
+	var lat, lng, accuracy sql.NullFloat64
+	if err := row.Scan(
 		&session.ID,
 		&session.ChildID,
 		&session.ChildAge,
@@ -127,13 +227,14 @@ func (s *SQLiteStore) GetSession(id string) (model.ScanSession, bool, error) {
 		&captured,
 		&capturedAt,
 		&session.AnswerGiven,
-	)
-	if errors.Is(err, sql.ErrNoRows) {
-		return model.ScanSession{}, false, nil
-	}
-	if err != nil {
-		return model.ScanSession{}, false, err
+		&session.Attempts,
+		&lat,
+		&lng,
+		&accuracy,
+	); err != nil {
+		return model.ScanSession{}, err
 	}
+	session.Location = geoPointFrom(lat, lng, accuracy)
 
 	session.CreatedAt = fromTS(createdAt)
 	session.CacheHit = intToBool(cacheHit)
@@ -141,14 +242,14 @@ func (s *SQLiteStore) GetSession(id string) (model.ScanSession, bool, error) {
 	if capturedAt.Valid && capturedAt.String != "" {
 		session.CapturedAt = fromTS(capturedAt.String)
 	}
-
-	return session, true, nil
+	return session, nil
 }
 
 func (s *SQLiteStore) UpdateSession(session model.ScanSession) error {
 	result, err := s.db.Exec(`
 		UPDATE sessions
-		SET child_id = ?, child_age = ?, object_type = ?, spirit_id = ?, quiz_q = ?, quiz_a = ?, fact = ?, created_at = ?, cache_hit = ?, captured = ?, captured_at = ?, answer_given = ?
+		SET child_id = ?, child_age = ?, object_type = ?, spirit_id = ?, quiz_q = ?, quiz_a = ?, fact = ?, created_at = ?, cache_hit = ?, captured = ?, captured_at = ?, answer_given = ?, attempts = ?,
+		    latitude = ?, longitude = ?, location_accuracy = ?
 		WHERE id = ?`,
 		session.ChildID,
 		session.ChildAge,
@@ -162,6 +263,10 @@ func (s *SQLiteStore) UpdateSession(session model.ScanSession) error {
 		boolToInt(session.Captured),
 		nullableTS(session.CapturedAt),
 		session.AnswerGiven,
+		session.Attempts,
+		latitudeOf(session.Location),
+		longitudeOf(session.Location),
+		accuracyOf(session.Location),
 		session.ID,
 	)
 	if err != nil {
@@ -180,8 +285,8 @@ func (s *SQLiteStore) UpdateSession(session model.ScanSession) error {
 func (s *SQLiteStore) AddCapture(capture model.Capture) error {
 	_, err := s.db.Exec(`
 		INSERT INTO captures
-		(id, child_id, spirit_id, spirit_name, object_type, fact, captured_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		(id, child_id, spirit_id, spirit_name, object_type, fact, captured_at, latitude, longitude, location_accuracy)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		capture.ID,
 		capture.ChildID,
 		capture.SpiritID,
@@ -189,13 +294,33 @@ func (s *SQLiteStore) AddCapture(capture model.Capture) error {
 		capture.ObjectType,
 		capture.Fact,
 		toTS(capture.CapturedAt),
+		latitudeOf(capture.Location),
+		longitudeOf(capture.Location),
+		accuracyOf(capture.Location),
 	)
 	return err
 }
 
+func (s *SQLiteStore) GetCapture(id string) (model.Capture, bool, error) {
+	row := s.db.QueryRow(`
+		SELECT id, child_id, spirit_id, spirit_name, object_type, fact, captured_at, latitude, longitude, location_accuracy
+		FROM captures
+		WHERE id = ?`,
+		id,
+	)
+	capture, err := scanCapture(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Capture{}, false, nil
+	}
+	if err != nil {
+		return model.Capture{}, false, err
+	}
+	return capture, true, nil
+}
+
 func (s *SQLiteStore) ListCapturesByChild(childID string) ([]model.Capture, error) {
 	rows, err := s.db.Query(`
-		SELECT id, child_id, spirit_id, spirit_name, object_type, fact, captured_at
+		SELECT id, child_id, spirit_id, spirit_name, object_type, fact, captured_at, latitude, longitude, location_accuracy
 		FROM captures
 		WHERE child_id = ?
 		ORDER BY captured_at DESC`,
@@ -208,20 +333,37 @@ func (s *SQLiteStore) ListCapturesByChild(childID string) ([]model.Capture, erro
 
 	var result []model.Capture
 	for rows.Next() {
-		var capture model.Capture
-		var capturedAt string
-		if err := rows.Scan(
-			&capture.ID,
-			&capture.ChildID,
-			&capture.SpiritID,
-			&capture.SpiritName,
-			&capture.ObjectType,
-			&capture.Fact,
-			&capturedAt,
-		); err != nil {
+		capture, err := scanCapture(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, capture)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) ListCapturesInArea(minLat float64, minLng float64, maxLat float64, maxLng float64) ([]model.Capture, error) {
+	rows, err := s.db.Query(`
+		SELECT id, child_id, spirit_id, spirit_name, object_type, fact, captured_at, latitude, longitude, location_accuracy
+		FROM captures
+		WHERE latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?
+		ORDER BY captured_at DESC`,
+		minLat, maxLat, minLng, maxLng,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.Capture
+	for rows.Next() {
+		capture, err := scanCapture(rows)
+		if err != nil {
 			return nil, err
 		}
-		capture.CapturedAt = fromTS(capturedAt)
 		result = append(result, capture)
 	}
 	if err := rows.Err(); err != nil {
@@ -230,12 +372,35 @@ func (s *SQLiteStore) ListCapturesByChild(childID string) ([]model.Capture, erro
 	return result, nil
 }
 
+func scanCapture(row rowScanner) (model.Capture, error) {
+	var capture model.Capture
+	var capturedAt string
+	var lat, lng, accuracy sql.NullFloat64
+	if err := row.Scan(
+		&capture.ID,
+		&capture.ChildID,
+		&capture.SpiritID,
+		&capture.SpiritName,
+		&capture.ObjectType,
+		&capture.Fact,
+		&capturedAt,
+		&lat,
+		&lng,
+		&accuracy,
+	); err != nil {
+		return model.Capture{}, err
+	}
+	capture.CapturedAt = fromTS(capturedAt)
+	capture.Location = geoPointFrom(lat, lng, accuracy)
+	return capture, nil
+}
+
 func (s *SQLiteStore) ListCapturesByChildAndDate(childID string, day time.Time) ([]model.Capture, error) {
 	start := day.In(time.Local).Truncate(24 * time.Hour)
 	end := start.Add(24 * time.Hour)
 
 	rows, err := s.db.Query(`
-		SELECT id, child_id, spirit_id, spirit_name, object_type, fact, captured_at
+		SELECT id, child_id, spirit_id, spirit_name, object_type, fact, captured_at, latitude, longitude, location_accuracy
 		FROM captures
 		WHERE child_id = ? AND captured_at >= ? AND captured_at < ?
 		ORDER BY captured_at DESC`,
@@ -250,20 +415,10 @@ func (s *SQLiteStore) ListCapturesByChildAndDate(childID string, day time.Time)
 
 	var result []model.Capture
 	for rows.Next() {
-		var capture model.Capture
-		var capturedAt string
-		if err := rows.Scan(
-			&capture.ID,
-			&capture.ChildID,
-			&capture.SpiritID,
-			&capture.SpiritName,
-			&capture.ObjectType,
-			&capture.Fact,
-			&capturedAt,
-		); err != nil {
+		capture, err := scanCapture(rows)
+		if err != nil {
 			return nil, err
 		}
-		capture.CapturedAt = fromTS(capturedAt)
 		result = append(result, capture)
 	}
 	if err := rows.Err(); err != nil {
@@ -272,63 +427,1234 @@ func (s *SQLiteStore) ListCapturesByChildAndDate(childID string, day time.Time)
 	return result, nil
 }
 
-func (s *SQLiteStore) initSchema() error {
+func (s *SQLiteStore) SaveReviewItem(item model.ReviewItem) error {
 	_, err := s.db.Exec(`
-		PRAGMA journal_mode=WAL;
-		CREATE TABLE IF NOT EXISTS spirits (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL,
-			object_type TEXT NOT NULL,
-			personality TEXT NOT NULL,
-			intro TEXT NOT NULL,
-			created_at TEXT NOT NULL
-		);
-		CREATE TABLE IF NOT EXISTS sessions (
-			id TEXT PRIMARY KEY,
-			child_id TEXT NOT NULL,
-			child_age INTEGER NOT NULL,
-			object_type TEXT NOT NULL,
-			spirit_id TEXT NOT NULL,
-			quiz_q TEXT NOT NULL,
-			quiz_a TEXT NOT NULL,
-			fact TEXT NOT NULL,
-			created_at TEXT NOT NULL,
-			cache_hit INTEGER NOT NULL,
-			captured INTEGER NOT NULL,
-			captured_at TEXT,
-			answer_given TEXT NOT NULL DEFAULT ''
-		);
-		CREATE TABLE IF NOT EXISTS captures (
-			id TEXT PRIMARY KEY,
-			child_id TEXT NOT NULL,
-			spirit_id TEXT NOT NULL,
-			spirit_name TEXT NOT NULL,
-			object_type TEXT NOT NULL,
-			fact TEXT NOT NULL,
-			captured_at TEXT NOT NULL
-		);
-		CREATE INDEX IF NOT EXISTS idx_captures_child_time ON captures(child_id, captured_at);
-	`)
+		INSERT OR REPLACE INTO reviews
+		(id, child_id, capture_id, spirit_name, object_type, fact, question, answer, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.ID,
+		item.ChildID,
+		item.CaptureID,
+		item.SpiritName,
+		item.ObjectType,
+		item.Fact,
+		item.Question,
+		item.Answer,
+		item.EaseFactor,
+		item.IntervalDays,
+		item.Repetitions,
+		item.Lapses,
+		toTS(item.DueAt),
+		nullableTS(item.LastReviewedAt),
+		toTS(item.CreatedAt),
+	)
 	return err
 }
 
-func toTS(t time.Time) string {
-	return t.UTC().Format(time.RFC3339Nano)
+func (s *SQLiteStore) GetReviewItem(id string) (model.ReviewItem, bool, error) {
+	row := s.db.QueryRow(`
+		SELECT id, child_id, capture_id, spirit_name, object_type, fact, question, answer, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at, created_at
+		FROM reviews
+		WHERE id = ?`,
+		id,
+	)
+	item, err := scanReviewItem(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ReviewItem{}, false, nil
+	}
+	if err != nil {
+		return model.ReviewItem{}, false, err
+	}
+	return item, true, nil
 }
 
-func nullableTS(t time.Time) any {
-	if t.IsZero() {
-		return nil
+func (s *SQLiteStore) ListReviewItemsByChild(childID string) ([]model.ReviewItem, error) {
+	rows, err := s.db.Query(`
+		SELECT id, child_id, capture_id, spirit_name, object_type, fact, question, answer, ease_factor, interval_days, repetitions, lapses, due_at, last_reviewed_at, created_at
+		FROM reviews
+		WHERE child_id = ?
+		ORDER BY due_at ASC`,
+		childID,
+	)
+	if err != nil {
+		return nil, err
 	}
-	return toTS(t)
+	defer rows.Close()
+
+	var result []model.ReviewItem
+	for rows.Next() {
+		item, err := scanReviewItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-func fromTS(v string) time.Time {
-	t, err := time.Parse(time.RFC3339Nano, v)
+func (s *SQLiteStore) GetProgress(childID string) (model.ChildProgress, bool, error) {
+	row := s.db.QueryRow(`
+		SELECT child_id, xp, level, current_streak, longest_streak, last_active_date, companion_chat_date, companion_chat_awards, updated_at
+		FROM progress
+		WHERE child_id = ?`,
+		childID,
+	)
+	var progress model.ChildProgress
+	var updatedAt string
+	err := row.Scan(
+		&progress.ChildID,
+		&progress.XP,
+		&progress.Level,
+		&progress.CurrentStreak,
+		&progress.LongestStreak,
+		&progress.LastActiveDate,
+		&progress.CompanionChatDate,
+		&progress.CompanionChatAwards,
+		&updatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ChildProgress{}, false, nil
+	}
 	if err != nil {
-		return time.Time{}
+		return model.ChildProgress{}, false, err
 	}
-	return t
+	progress.UpdatedAt = fromTS(updatedAt)
+	return progress, true, nil
+}
+
+func (s *SQLiteStore) SaveProgress(progress model.ChildProgress) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO progress
+		(child_id, xp, level, current_streak, longest_streak, last_active_date, companion_chat_date, companion_chat_awards, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		progress.ChildID,
+		progress.XP,
+		progress.Level,
+		progress.CurrentStreak,
+		progress.LongestStreak,
+		progress.LastActiveDate,
+		progress.CompanionChatDate,
+		progress.CompanionChatAwards,
+		toTS(progress.UpdatedAt),
+	)
+	return err
+}
+
+func (s *SQLiteStore) SaveBadgeUnlock(unlock model.BadgeUnlock) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO badge_unlocks
+		(id, child_id, badge_id, badge_name, image_url, capture_id, session_id, unlocked_at, seen, seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		unlock.ID,
+		unlock.ChildID,
+		unlock.BadgeID,
+		unlock.BadgeName,
+		unlock.ImageURL,
+		unlock.CaptureID,
+		unlock.SessionID,
+		toTS(unlock.UnlockedAt),
+		boolToInt(unlock.Seen),
+		nullableTS(unlock.SeenAt),
+	)
+	return err
+}
+
+func (s *SQLiteStore) ListBadgeUnlocksByChild(childID string) ([]model.BadgeUnlock, error) {
+	rows, err := s.db.Query(`
+		SELECT id, child_id, badge_id, badge_name, image_url, capture_id, session_id, unlocked_at, seen, seen_at
+		FROM badge_unlocks
+		WHERE child_id = ?
+		ORDER BY unlocked_at ASC`,
+		childID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.BadgeUnlock
+	for rows.Next() {
+		var unlock model.BadgeUnlock
+		var unlockedAt string
+		var seen int
+		var seenAt sql.NullString
+		if err := rows.Scan(
+			&unlock.ID,
+			&unlock.ChildID,
+			&unlock.BadgeID,
+			&unlock.BadgeName,
+			&unlock.ImageURL,
+			&unlock.CaptureID,
+			&unlock.SessionID,
+			&unlockedAt,
+			&seen,
+			&seenAt,
+		); err != nil {
+			return nil, err
+		}
+		unlock.UnlockedAt = fromTS(unlockedAt)
+		unlock.Seen = intToBool(seen)
+		if seenAt.Valid && seenAt.String != "" {
+			unlock.SeenAt = fromTS(seenAt.String)
+		}
+		result = append(result, unlock)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) AddCompanionMessage(message model.CompanionMessage) error {
+	_, err := s.db.Exec(`
+		INSERT INTO companion_messages
+		(id, child_id, object_type, character_name, child_message, reply_text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		message.ID,
+		message.ChildID,
+		message.ObjectType,
+		message.CharacterName,
+		message.ChildMessage,
+		message.ReplyText,
+		toTS(message.CreatedAt),
+	)
+	return err
+}
+
+func (s *SQLiteStore) ListCompanionMessagesByChild(childID string, start time.Time, end time.Time) ([]model.CompanionMessage, error) {
+	rows, err := s.db.Query(`
+		SELECT id, child_id, object_type, character_name, child_message, reply_text, created_at
+		FROM companion_messages
+		WHERE child_id = ? AND created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC`,
+		childID,
+		toTS(start),
+		toTS(end),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.CompanionMessage
+	for rows.Next() {
+		var message model.CompanionMessage
+		var createdAt string
+		if err := rows.Scan(
+			&message.ID,
+			&message.ChildID,
+			&message.ObjectType,
+			&message.CharacterName,
+			&message.ChildMessage,
+			&message.ReplyText,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		message.CreatedAt = fromTS(createdAt)
+		result = append(result, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReviewItem(row rowScanner) (model.ReviewItem, error) {
+	var item model.ReviewItem
+	var dueAt string
+	var lastReviewedAt sql.NullString
+	var createdAt string
+	if err := row.Scan(
+		&item.ID,
+		&item.ChildID,
+		&item.CaptureID,
+		&item.SpiritName,
+		&item.ObjectType,
+		&item.Fact,
+		&item.Question,
+		&item.Answer,
+		&item.EaseFactor,
+		&item.IntervalDays,
+		&item.Repetitions,
+		&item.Lapses,
+		&dueAt,
+		&lastReviewedAt,
+		&createdAt,
+	); err != nil {
+		return model.ReviewItem{}, err
+	}
+	item.DueAt = fromTS(dueAt)
+	if lastReviewedAt.Valid && lastReviewedAt.String != "" {
+		item.LastReviewedAt = fromTS(lastReviewedAt.String)
+	}
+	item.CreatedAt = fromTS(createdAt)
+	return item, nil
+}
+
+func (s *SQLiteStore) SaveDeliverySubscription(sub model.DeliverySubscription) error {
+	var nextAttemptAt any
+	if sub.NextAttemptAt != nil {
+		nextAttemptAt = toTS(*sub.NextAttemptAt)
+	}
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO delivery_subscriptions
+		(id, parent_id, child_id, channel, target, send_at, timezone, enabled,
+		 last_delivered_date, pending_date, pending_attempts, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.ID,
+		sub.ParentID,
+		sub.ChildID,
+		sub.Channel,
+		sub.Target,
+		sub.SendAt,
+		sub.Timezone,
+		boolToInt(sub.Enabled),
+		sub.LastDeliveredDate,
+		sub.PendingDate,
+		sub.PendingAttempts,
+		nextAttemptAt,
+		toTS(sub.CreatedAt),
+		toTS(sub.UpdatedAt),
+	)
+	return err
+}
+
+const deliverySubscriptionColumns = `id, parent_id, child_id, channel, target, send_at, timezone, enabled,
+	last_delivered_date, pending_date, pending_attempts, next_attempt_at, created_at, updated_at`
+
+func scanDeliverySubscription(row rowScanner) (model.DeliverySubscription, error) {
+	var sub model.DeliverySubscription
+	var enabled int
+	var nextAttemptAt sql.NullString
+	var createdAt, updatedAt string
+	if err := row.Scan(
+		&sub.ID,
+		&sub.ParentID,
+		&sub.ChildID,
+		&sub.Channel,
+		&sub.Target,
+		&sub.SendAt,
+		&sub.Timezone,
+		&enabled,
+		&sub.LastDeliveredDate,
+		&sub.PendingDate,
+		&sub.PendingAttempts,
+		&nextAttemptAt,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return model.DeliverySubscription{}, err
+	}
+	sub.Enabled = intToBool(enabled)
+	if nextAttemptAt.Valid {
+		t := fromTS(nextAttemptAt.String)
+		sub.NextAttemptAt = &t
+	}
+	sub.CreatedAt = fromTS(createdAt)
+	sub.UpdatedAt = fromTS(updatedAt)
+	return sub, nil
+}
+
+func (s *SQLiteStore) GetDeliverySubscription(id string) (model.DeliverySubscription, bool, error) {
+	row := s.db.QueryRow(`SELECT `+deliverySubscriptionColumns+` FROM delivery_subscriptions WHERE id = ?`, id)
+	sub, err := scanDeliverySubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.DeliverySubscription{}, false, nil
+	}
+	if err != nil {
+		return model.DeliverySubscription{}, false, err
+	}
+	return sub, true, nil
+}
+
+func (s *SQLiteStore) ListDeliverySubscriptions() ([]model.DeliverySubscription, error) {
+	rows, err := s.db.Query(`SELECT ` + deliverySubscriptionColumns + ` FROM delivery_subscriptions ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.DeliverySubscription
+	for rows.Next() {
+		sub, err := scanDeliverySubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) DeleteDeliverySubscription(id string) error {
+	_, err := s.db.Exec(`DELETE FROM delivery_subscriptions WHERE id = ?`, id)
+	return err
+}
+
+func (s *SQLiteStore) AddDeliveryAttempt(attempt model.DeliveryAttempt) error {
+	_, err := s.db.Exec(`
+		INSERT INTO delivery_attempts
+		(id, subscription_id, child_id, channel, target, report_date, trigger_type, attempt, status, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		attempt.ID,
+		attempt.SubscriptionID,
+		attempt.ChildID,
+		attempt.Channel,
+		attempt.Target,
+		attempt.ReportDate,
+		attempt.Trigger,
+		attempt.Attempt,
+		attempt.Status,
+		attempt.Error,
+		toTS(attempt.CreatedAt),
+	)
+	return err
+}
+
+func (s *SQLiteStore) ListDeliveryAttempts(subscriptionID string, limit int) ([]model.DeliveryAttempt, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query(`
+		SELECT id, subscription_id, child_id, channel, target, report_date, trigger_type, attempt, status, error, created_at
+		FROM delivery_attempts
+		WHERE ? = '' OR subscription_id = ?
+		ORDER BY created_at DESC, rowid DESC
+		LIMIT ?`,
+		subscriptionID,
+		subscriptionID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.DeliveryAttempt
+	for rows.Next() {
+		var attempt model.DeliveryAttempt
+		var createdAt string
+		if err := rows.Scan(
+			&attempt.ID,
+			&attempt.SubscriptionID,
+			&attempt.ChildID,
+			&attempt.Channel,
+			&attempt.Target,
+			&attempt.ReportDate,
+			&attempt.Trigger,
+			&attempt.Attempt,
+			&attempt.Status,
+			&attempt.Error,
+			&createdAt,
+		); err != nil {
+			return nil, err
+		}
+		attempt.CreatedAt = fromTS(createdAt)
+		result = append(result, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) SaveQuest(quest model.Quest) error {
+	var completedAt, claimedAt any
+	if quest.CompletedAt != nil {
+		completedAt = toTS(*quest.CompletedAt)
+	}
+	if quest.ClaimedAt != nil {
+		claimedAt = toTS(*quest.ClaimedAt)
+	}
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO quests
+		(id, child_id, quest_date, template_id, type, title, description, keywords,
+		 target, progress, reward_xp, capture_ids, completed_at, claimed_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		quest.ID,
+		quest.ChildID,
+		quest.Date,
+		quest.TemplateID,
+		quest.Type,
+		quest.Title,
+		quest.Description,
+		toJSONList(quest.Keywords),
+		quest.Target,
+		quest.Progress,
+		quest.RewardXP,
+		toJSONList(quest.CaptureIDs),
+		completedAt,
+		claimedAt,
+		toTS(quest.CreatedAt),
+	)
+	return err
+}
+
+const questColumns = `id, child_id, quest_date, template_id, type, title, description, keywords,
+	target, progress, reward_xp, capture_ids, completed_at, claimed_at, created_at`
+
+func scanQuest(row rowScanner) (model.Quest, error) {
+	var quest model.Quest
+	var keywords, captureIDs, createdAt string
+	var completedAt, claimedAt sql.NullString
+	if err := row.Scan(
+		&quest.ID,
+		&quest.ChildID,
+		&quest.Date,
+		&quest.TemplateID,
+		&quest.Type,
+		&quest.Title,
+		&quest.Description,
+		&keywords,
+		&quest.Target,
+		&quest.Progress,
+		&quest.RewardXP,
+		&captureIDs,
+		&completedAt,
+		&claimedAt,
+		&createdAt,
+	); err != nil {
+		return model.Quest{}, err
+	}
+	quest.Keywords = fromJSONList(keywords)
+	quest.CaptureIDs = fromJSONList(captureIDs)
+	if completedAt.Valid {
+		t := fromTS(completedAt.String)
+		quest.CompletedAt = &t
+	}
+	if claimedAt.Valid {
+		t := fromTS(claimedAt.String)
+		quest.ClaimedAt = &t
+	}
+	quest.CreatedAt = fromTS(createdAt)
+	return quest, nil
+}
+
+func (s *SQLiteStore) GetQuest(id string) (model.Quest, bool, error) {
+	row := s.db.QueryRow(`SELECT `+questColumns+` FROM quests WHERE id = ?`, id)
+	quest, err := scanQuest(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Quest{}, false, nil
+	}
+	if err != nil {
+		return model.Quest{}, false, err
+	}
+	return quest, true, nil
+}
+
+func (s *SQLiteStore) ListQuestsByChild(childID string, date string) ([]model.Quest, error) {
+	rows, err := s.db.Query(`SELECT `+questColumns+` FROM quests WHERE child_id = ? AND quest_date = ? ORDER BY created_at ASC, id ASC`, childID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]model.Quest, 0)
+	for rows.Next() {
+		quest, err := scanQuest(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, quest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SaveGroup 按 ID 更新群组；邀请码冲突时报错，而不是像 REPLACE 那样删掉另一个群组。
+func (s *SQLiteStore) SaveGroup(group model.Group) error {
+	_, err := s.db.Exec(`
+		INSERT INTO child_groups
+		(id, name, kind, owner_id, invite_code, hide_counts_under_age, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			kind = excluded.kind,
+			owner_id = excluded.owner_id,
+			invite_code = excluded.invite_code,
+			hide_counts_under_age = excluded.hide_counts_under_age,
+			updated_at = excluded.updated_at`,
+		group.ID,
+		group.Name,
+		group.Kind,
+		group.OwnerID,
+		group.InviteCode,
+		group.HideCountsUnderAge,
+		toTS(group.CreatedAt),
+		toTS(group.UpdatedAt),
+	)
+	return err
+}
+
+const groupColumns = `g.id, g.name, g.kind, g.owner_id, g.invite_code, g.hide_counts_under_age, g.created_at, g.updated_at`
+
+func scanGroup(row rowScanner) (model.Group, error) {
+	var group model.Group
+	var createdAt, updatedAt string
+	if err := row.Scan(
+		&group.ID,
+		&group.Name,
+		&group.Kind,
+		&group.OwnerID,
+		&group.InviteCode,
+		&group.HideCountsUnderAge,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return model.Group{}, err
+	}
+	group.CreatedAt = fromTS(createdAt)
+	group.UpdatedAt = fromTS(updatedAt)
+	return group, nil
+}
+
+func (s *SQLiteStore) GetGroup(id string) (model.Group, bool, error) {
+	return s.getGroupWhere(`g.id = ?`, id)
+}
+
+func (s *SQLiteStore) GetGroupByInviteCode(code string) (model.Group, bool, error) {
+	return s.getGroupWhere(`g.invite_code = ?`, code)
+}
+
+func (s *SQLiteStore) getGroupWhere(where string, arg any) (model.Group, bool, error) {
+	row := s.db.QueryRow(`SELECT `+groupColumns+` FROM child_groups g WHERE `+where, arg)
+	group, err := scanGroup(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Group{}, false, nil
+	}
+	if err != nil {
+		return model.Group{}, false, err
+	}
+	return group, true, nil
+}
+
+func (s *SQLiteStore) ListGroups(ownerID string) ([]model.Group, error) {
+	return s.queryGroups(`SELECT `+groupColumns+` FROM child_groups g
+		WHERE ? = '' OR g.owner_id = ?
+		ORDER BY g.created_at ASC, g.id ASC`, ownerID, ownerID)
+}
+
+func (s *SQLiteStore) ListGroupsByChild(childID string) ([]model.Group, error) {
+	return s.queryGroups(`SELECT `+groupColumns+` FROM child_groups g
+		JOIN group_members m ON m.group_id = g.id
+		WHERE m.child_id = ?
+		ORDER BY g.created_at ASC, g.id ASC`, childID)
+}
+
+func (s *SQLiteStore) queryGroups(query string, args ...any) ([]model.Group, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]model.Group, 0)
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) SaveGroupMember(member model.GroupMember) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO group_members (group_id, child_id, display_name, child_age, joined_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		member.GroupID,
+		member.ChildID,
+		member.DisplayName,
+		member.ChildAge,
+		toTS(member.JoinedAt),
+	)
+	return err
+}
+
+func (s *SQLiteStore) DeleteGroupMember(groupID string, childID string) error {
+	_, err := s.db.Exec(`DELETE FROM group_members WHERE group_id = ? AND child_id = ?`, groupID, childID)
+	return err
+}
+
+func (s *SQLiteStore) ListGroupMembers(groupID string) ([]model.GroupMember, error) {
+	rows, err := s.db.Query(`
+		SELECT group_id, child_id, display_name, child_age, joined_at
+		FROM group_members
+		WHERE group_id = ?
+		ORDER BY joined_at ASC`,
+		groupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]model.GroupMember, 0)
+	for rows.Next() {
+		var member model.GroupMember
+		var joinedAt string
+		if err := rows.Scan(&member.GroupID, &member.ChildID, &member.DisplayName, &member.ChildAge, &joinedAt); err != nil {
+			return nil, err
+		}
+		member.JoinedAt = fromTS(joinedAt)
+		result = append(result, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) SaveSpiritShare(share model.SpiritShare) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO spirit_shares
+		(token, child_id, capture_id, created_at, expires_at, revoked_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		share.Token,
+		share.ChildID,
+		share.CaptureID,
+		toTS(share.CreatedAt),
+		nullableTSPtr(share.ExpiresAt),
+		nullableTSPtr(share.RevokedAt),
+	)
+	return err
+}
+
+func (s *SQLiteStore) GetSpiritShare(token string) (model.SpiritShare, bool, error) {
+	row := s.db.QueryRow(`
+		SELECT token, child_id, capture_id, created_at, expires_at, revoked_at
+		FROM spirit_shares
+		WHERE token = ?`,
+		token,
+	)
+	var share model.SpiritShare
+	var createdAt string
+	var expiresAt, revokedAt sql.NullString
+	err := row.Scan(&share.Token, &share.ChildID, &share.CaptureID, &createdAt, &expiresAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.SpiritShare{}, false, nil
+	}
+	if err != nil {
+		return model.SpiritShare{}, false, err
+	}
+	share.CreatedAt = fromTS(createdAt)
+	share.ExpiresAt = timePtrFrom(expiresAt)
+	share.RevokedAt = timePtrFrom(revokedAt)
+	return share, true, nil
+}
+
+const tradeColumns = `id, group_id, proposer_id, proposer_capture_id, recipient_id, recipient_capture_id, status, created_at, responded_at`
+
+func (s *SQLiteStore) SaveTrade(trade model.Trade) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO trades
+		(`+tradeColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		trade.ID,
+		trade.GroupID,
+		trade.ProposerID,
+		trade.ProposerCaptureID,
+		trade.RecipientID,
+		trade.RecipientCaptureID,
+		trade.Status,
+		toTS(trade.CreatedAt),
+		nullableTSPtr(trade.RespondedAt),
+	)
+	return err
+}
+
+func scanTrade(row rowScanner) (model.Trade, error) {
+	var trade model.Trade
+	var createdAt string
+	var respondedAt sql.NullString
+	if err := row.Scan(
+		&trade.ID,
+		&trade.GroupID,
+		&trade.ProposerID,
+		&trade.ProposerCaptureID,
+		&trade.RecipientID,
+		&trade.RecipientCaptureID,
+		&trade.Status,
+		&createdAt,
+		&respondedAt,
+	); err != nil {
+		return model.Trade{}, err
+	}
+	trade.CreatedAt = fromTS(createdAt)
+	trade.RespondedAt = timePtrFrom(respondedAt)
+	return trade, nil
+}
+
+func (s *SQLiteStore) GetTrade(id string) (model.Trade, bool, error) {
+	row := s.db.QueryRow(`SELECT `+tradeColumns+` FROM trades WHERE id = ?`, id)
+	trade, err := scanTrade(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Trade{}, false, nil
+	}
+	if err != nil {
+		return model.Trade{}, false, err
+	}
+	return trade, true, nil
+}
+
+func (s *SQLiteStore) ListTradesByChild(childID string) ([]model.Trade, error) {
+	rows, err := s.db.Query(`SELECT `+tradeColumns+` FROM trades
+		WHERE proposer_id = ? OR recipient_id = ?
+		ORDER BY created_at DESC, id DESC`, childID, childID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]model.Trade, 0)
+	for rows.Next() {
+		trade, err := scanTrade(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, trade)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) AcceptTrade(trade model.Trade) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// 带上原状态和原主人作为条件，任何一步影响行数不为 1 都说明期间有并发修改。
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE trades SET status = ?, responded_at = ? WHERE id = ? AND status = 'pending'`,
+			[]any{trade.Status, nullableTSPtr(trade.RespondedAt), trade.ID}},
+		{`UPDATE captures SET child_id = ?, latitude = NULL, longitude = NULL, location_accuracy = NULL WHERE id = ? AND child_id = ?`,
+			[]any{trade.RecipientID, trade.ProposerCaptureID, trade.ProposerID}},
+		{`UPDATE captures SET child_id = ?, latitude = NULL, longitude = NULL, location_accuracy = NULL WHERE id = ? AND child_id = ?`,
+			[]any{trade.ProposerID, trade.RecipientCaptureID, trade.RecipientID}},
+	}
+	for _, step := range steps {
+		result, err := tx.Exec(step.query, step.args...)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected != 1 {
+			return ErrTradeConflict
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) SaveChildProfile(profile model.ChildProfile) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO child_profiles (child_id, locale, updated_at)
+		VALUES (?, ?, ?)`,
+		profile.ChildID,
+		profile.Locale,
+		toTS(profile.UpdatedAt),
+	)
+	return err
+}
+
+func (s *SQLiteStore) GetChildProfile(childID string) (model.ChildProfile, bool, error) {
+	row := s.db.QueryRow(`
+		SELECT child_id, locale, updated_at
+		FROM child_profiles
+		WHERE child_id = ?`,
+		childID,
+	)
+	var profile model.ChildProfile
+	var updatedAt string
+	err := row.Scan(&profile.ChildID, &profile.Locale, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ChildProfile{}, false, nil
+	}
+	if err != nil {
+		return model.ChildProfile{}, false, err
+	}
+	profile.UpdatedAt = fromTS(updatedAt)
+	return profile, true, nil
+}
+
+func (s *SQLiteStore) initSchema() error {
+	_, err := s.db.Exec(`
+		PRAGMA journal_mode=WAL;
+		CREATE TABLE IF NOT EXISTS spirits (
+			id TEXT PRIMARY KEY,
+			child_id TEXT NOT NULL DEFAULT '',
+			name TEXT NOT NULL,
+			object_type TEXT NOT NULL,
+			personality TEXT NOT NULL,
+			intro TEXT NOT NULL,
+			image_url TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			capture_count INTEGER NOT NULL DEFAULT 0,
+			level INTEGER NOT NULL DEFAULT 0,
+			stage INTEGER NOT NULL DEFAULT 0,
+			stage_name TEXT NOT NULL DEFAULT '',
+			traits TEXT NOT NULL DEFAULT '[]',
+			intro_lines TEXT NOT NULL DEFAULT '[]',
+			image_stage INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			child_id TEXT NOT NULL,
+			child_age INTEGER NOT NULL,
+			object_type TEXT NOT NULL,
+			spirit_id TEXT NOT NULL,
+			quiz_q TEXT NOT NULL,
+			quiz_a TEXT NOT NULL,
+			fact TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			cache_hit INTEGER NOT NULL,
+			captured INTEGER NOT NULL,
+			captured_at TEXT,
+			answer_given TEXT NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			latitude REAL,
+			longitude REAL,
+			location_accuracy REAL
+		);
+		CREATE TABLE IF NOT EXISTS captures (
+			id TEXT PRIMARY KEY,
+			child_id TEXT NOT NULL,
+			spirit_id TEXT NOT NULL,
+			spirit_name TEXT NOT NULL,
+			object_type TEXT NOT NULL,
+			fact TEXT NOT NULL,
+			captured_at TEXT NOT NULL,
+			latitude REAL,
+			longitude REAL,
+			location_accuracy REAL
+		);
+		CREATE INDEX IF NOT EXISTS idx_captures_child_time ON captures(child_id, captured_at);
+		CREATE INDEX IF NOT EXISTS idx_sessions_child_time ON sessions(child_id, created_at);
+		CREATE TABLE IF NOT EXISTS reviews (
+			id TEXT PRIMARY KEY,
+			child_id TEXT NOT NULL,
+			capture_id TEXT NOT NULL,
+			spirit_name TEXT NOT NULL,
+			object_type TEXT NOT NULL,
+			fact TEXT NOT NULL,
+			question TEXT NOT NULL,
+			answer TEXT NOT NULL,
+			ease_factor REAL NOT NULL,
+			interval_days INTEGER NOT NULL,
+			repetitions INTEGER NOT NULL,
+			lapses INTEGER NOT NULL,
+			due_at TEXT NOT NULL,
+			last_reviewed_at TEXT,
+			created_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_reviews_child_due ON reviews(child_id, due_at);
+		CREATE TABLE IF NOT EXISTS progress (
+			child_id TEXT PRIMARY KEY,
+			xp INTEGER NOT NULL,
+			level INTEGER NOT NULL,
+			current_streak INTEGER NOT NULL,
+			longest_streak INTEGER NOT NULL,
+			last_active_date TEXT NOT NULL DEFAULT '',
+			companion_chat_date TEXT NOT NULL DEFAULT '',
+			companion_chat_awards INTEGER NOT NULL DEFAULT 0,
+			updated_at TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS badge_unlocks (
+			id TEXT PRIMARY KEY,
+			child_id TEXT NOT NULL,
+			badge_id TEXT NOT NULL,
+			badge_name TEXT NOT NULL,
+			image_url TEXT NOT NULL DEFAULT '',
+			capture_id TEXT NOT NULL DEFAULT '',
+			session_id TEXT NOT NULL DEFAULT '',
+			unlocked_at TEXT NOT NULL,
+			seen INTEGER NOT NULL DEFAULT 0,
+			seen_at TEXT
+		);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_badge_unlocks_child_badge ON badge_unlocks(child_id, badge_id);
+		CREATE TABLE IF NOT EXISTS companion_messages (
+			id TEXT PRIMARY KEY,
+			child_id TEXT NOT NULL,
+			object_type TEXT NOT NULL,
+			character_name TEXT NOT NULL,
+			child_message TEXT NOT NULL,
+			reply_text TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_companion_messages_child_time ON companion_messages(child_id, created_at);
+		CREATE TABLE IF NOT EXISTS delivery_subscriptions (
+			id TEXT PRIMARY KEY,
+			parent_id TEXT NOT NULL DEFAULT '',
+			child_id TEXT NOT NULL,
+			channel TEXT NOT NULL,
+			target TEXT NOT NULL,
+			send_at TEXT NOT NULL,
+			timezone TEXT NOT NULL,
+			enabled INTEGER NOT NULL DEFAULT 1,
+			last_delivered_date TEXT NOT NULL DEFAULT '',
+			pending_date TEXT NOT NULL DEFAULT '',
+			pending_attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at TEXT,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS quests (
+			id TEXT PRIMARY KEY,
+			child_id TEXT NOT NULL,
+			quest_date TEXT NOT NULL,
+			template_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			keywords TEXT NOT NULL DEFAULT '[]',
+			target INTEGER NOT NULL,
+			progress INTEGER NOT NULL DEFAULT 0,
+			reward_xp INTEGER NOT NULL DEFAULT 0,
+			capture_ids TEXT NOT NULL DEFAULT '[]',
+			completed_at TEXT,
+			claimed_at TEXT,
+			created_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_quests_child_date ON quests(child_id, quest_date);
+		CREATE TABLE IF NOT EXISTS child_groups (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			owner_id TEXT NOT NULL,
+			invite_code TEXT NOT NULL UNIQUE,
+			hide_counts_under_age INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_child_groups_owner ON child_groups(owner_id);
+		CREATE TABLE IF NOT EXISTS group_members (
+			group_id TEXT NOT NULL,
+			child_id TEXT NOT NULL,
+			display_name TEXT NOT NULL DEFAULT '',
+			child_age INTEGER NOT NULL DEFAULT 0,
+			joined_at TEXT NOT NULL,
+			PRIMARY KEY (group_id, child_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_group_members_child ON group_members(child_id);
+		CREATE TABLE IF NOT EXISTS spirit_shares (
+			token TEXT PRIMARY KEY,
+			child_id TEXT NOT NULL,
+			capture_id TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			expires_at TEXT,
+			revoked_at TEXT
+		);
+		CREATE TABLE IF NOT EXISTS trades (
+			id TEXT PRIMARY KEY,
+			group_id TEXT NOT NULL,
+			proposer_id TEXT NOT NULL,
+			proposer_capture_id TEXT NOT NULL,
+			recipient_id TEXT NOT NULL,
+			recipient_capture_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			responded_at TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_trades_proposer ON trades(proposer_id, created_at);
+		CREATE INDEX IF NOT EXISTS idx_trades_recipient ON trades(recipient_id, created_at);
+		CREATE TABLE IF NOT EXISTS delivery_attempts (
+			id TEXT PRIMARY KEY,
+			subscription_id TEXT NOT NULL,
+			child_id TEXT NOT NULL,
+			channel TEXT NOT NULL,
+			target TEXT NOT NULL,
+			report_date TEXT NOT NULL,
+			trigger_type TEXT NOT NULL,
+			attempt INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_delivery_attempts_subscription_time ON delivery_attempts(subscription_id, created_at);
+		CREATE TABLE IF NOT EXISTS child_profiles (
+			child_id TEXT PRIMARY KEY,
+			locale TEXT NOT NULL DEFAULT '',
+			updated_at TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS health_checks (
+			id TEXT PRIMARY KEY,
+			checked_at TEXT NOT NULL
+		);
+	`)
+	return err
+}
+
+// migrateSchema 为旧版本数据库补齐后来新增的列；CREATE TABLE IF NOT EXISTS 不会修改已存在的表。
+func (s *SQLiteStore) migrateSchema() error {
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"sessions", "latitude", "REAL"},
+		{"sessions", "longitude", "REAL"},
+		{"sessions", "location_accuracy", "REAL"},
+		{"sessions", "attempts", "INTEGER NOT NULL DEFAULT 0"},
+		{"captures", "latitude", "REAL"},
+		{"captures", "longitude", "REAL"},
+		{"captures", "location_accuracy", "REAL"},
+		{"spirits", "image_url", "TEXT NOT NULL DEFAULT ''"},
+		{"spirits", "child_id", "TEXT NOT NULL DEFAULT ''"},
+		{"spirits", "capture_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"spirits", "level", "INTEGER NOT NULL DEFAULT 0"},
+		{"spirits", "stage", "INTEGER NOT NULL DEFAULT 0"},
+		{"spirits", "stage_name", "TEXT NOT NULL DEFAULT ''"},
+		{"spirits", "traits", "TEXT NOT NULL DEFAULT '[]'"},
+		{"spirits", "intro_lines", "TEXT NOT NULL DEFAULT '[]'"},
+		{"spirits", "image_stage", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, c := range columns {
+		exists, err := s.columnExists(c.table, c.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return err
+		}
+	}
+	// 位置列和精灵归属列可能由迁移补上，相关索引只能在此之后创建。
+	_, err := s.db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_captures_location ON captures(latitude, longitude);
+		CREATE INDEX IF NOT EXISTS idx_spirits_child_object ON spirits(child_id, object_type);
+	`)
+	return err
+}
+
+func (s *SQLiteStore) columnExists(table string, column string) (bool, error) {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultV   sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultV, &primaryKey); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+func latitudeOf(p *model.GeoPoint) any {
+	if p == nil {
+		return nil
+	}
+	return p.Latitude
+}
+
+func longitudeOf(p *model.GeoPoint) any {
+	if p == nil {
+		return nil
+	}
+	return p.Longitude
+}
+
+func accuracyOf(p *model.GeoPoint) any {
+	if p == nil {
+		return nil
+	}
+	return p.AccuracyM
+}
+
+func geoPointFrom(lat, lng, accuracy sql.NullFloat64) *model.GeoPoint {
+	if !lat.Valid || !lng.Valid {
+		return nil
+	}
+	return &model.GeoPoint{Latitude: lat.Float64, Longitude: lng.Float64, AccuracyM: accuracy.Float64}
+}
+
+func toTS(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func nullableTS(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return toTS(t)
+}
+
+func nullableTSPtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return nullableTS(*t)
+}
+
+func timePtrFrom(v sql.NullString) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := fromTS(v.String)
+	return &t
+}
+
+func fromTS(v string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// toJSONList 把字符串列表存为 JSON 文本列，nil 存为空数组。
+func toJSONList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	raw, _ := json.Marshal(values)
+	return string(raw)
+}
+
+func fromJSONList(raw string) []string {
+	var values []string
+	if err := json.Unmarshal([]byte(raw), &values); err != nil || len(values) == 0 {
+		return nil
+	}
+	return values
 }
 
 func boolToInt(v bool) int {
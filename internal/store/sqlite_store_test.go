@@ -1,6 +1,9 @@
 package store_test
 
 import (
+	"context"
+	"errors"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -65,9 +68,13 @@ func TestSQLiteStoreBasicFlow(t *testing.T) {
 	gotSession.Captured = true
 	gotSession.CapturedAt = now.Add(30 * time.Second)
 	gotSession.AnswerGiven = "A"
+	gotSession.Attempts = 2
 	if err := st.UpdateSession(gotSession); err != nil {
 		t.Fatalf("UpdateSession() error = %v", err)
 	}
+	if updated, _, err := st.GetSession(session.ID); err != nil || updated.Attempts != 2 {
+		t.Fatalf("expected attempts to round-trip, got %d err=%v", updated.Attempts, err)
+	}
 
 	capture := model.Capture{
 		ID:         "cap_1",
@@ -98,3 +105,587 @@ func TestSQLiteStoreBasicFlow(t *testing.T) {
 		t.Fatalf("expected 1 day capture, got %d", len(dayList))
 	}
 }
+
+func TestSQLiteStoreReviewItems(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	now := time.Now().UTC()
+	item := model.ReviewItem{
+		ID:         "review_1",
+		ChildID:    "kid",
+		CaptureID:  "cap_1",
+		ObjectType: "tree",
+		Question:   "Q",
+		Answer:     "A",
+		EaseFactor: 2.5,
+		DueAt:      now.Add(24 * time.Hour),
+		CreatedAt:  now,
+	}
+	if err := st.SaveReviewItem(item); err != nil {
+		t.Fatalf("SaveReviewItem() error = %v", err)
+	}
+	item.Repetitions = 1
+	item.LastReviewedAt = now
+	if err := st.SaveReviewItem(item); err != nil {
+		t.Fatalf("SaveReviewItem() update error = %v", err)
+	}
+
+	got, ok, err := st.GetReviewItem(item.ID)
+	if err != nil || !ok {
+		t.Fatalf("GetReviewItem() err=%v ok=%v", err, ok)
+	}
+	if got.Repetitions != 1 || got.EaseFactor != 2.5 || got.LastReviewedAt.IsZero() {
+		t.Fatalf("unexpected review item %+v", got)
+	}
+
+	list, err := st.ListReviewItemsByChild("kid")
+	if err != nil {
+		t.Fatalf("ListReviewItemsByChild() error = %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected 1 review item, got %d", len(list))
+	}
+}
+
+func TestSQLiteStoreProgress(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	if _, ok, err := st.GetProgress("kid"); err != nil || ok {
+		t.Fatalf("GetProgress() on empty store err=%v ok=%v", err, ok)
+	}
+	progress := model.ChildProgress{
+		ChildID:        "kid",
+		XP:             120,
+		Level:          2,
+		CurrentStreak:  3,
+		LongestStreak:  5,
+		LastActiveDate: "2026-02-13",
+		UpdatedAt:      time.Now().UTC(),
+	}
+	if err := st.SaveProgress(progress); err != nil {
+		t.Fatalf("SaveProgress() error = %v", err)
+	}
+	got, ok, err := st.GetProgress("kid")
+	if err != nil || !ok {
+		t.Fatalf("GetProgress() err=%v ok=%v", err, ok)
+	}
+	if got.XP != 120 || got.LongestStreak != 5 || got.LastActiveDate != "2026-02-13" {
+		t.Fatalf("unexpected progress %+v", got)
+	}
+}
+
+func TestSQLiteStoreBadgeUnlocks(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	now := time.Now().UTC()
+	unlock := model.BadgeUnlock{
+		ID:         "unlock_1",
+		ChildID:    "kid",
+		BadgeID:    "badge_01_plantae",
+		BadgeName:  "植物界",
+		CaptureID:  "cap_1",
+		SessionID:  "sess_1",
+		UnlockedAt: now,
+	}
+	if err := st.SaveBadgeUnlock(unlock); err != nil {
+		t.Fatalf("SaveBadgeUnlock() error = %v", err)
+	}
+	unlock.Seen = true
+	unlock.SeenAt = now.Add(time.Minute)
+	if err := st.SaveBadgeUnlock(unlock); err != nil {
+		t.Fatalf("SaveBadgeUnlock() update error = %v", err)
+	}
+
+	got, err := st.ListBadgeUnlocksByChild("kid")
+	if err != nil {
+		t.Fatalf("ListBadgeUnlocksByChild() error = %v", err)
+	}
+	if len(got) != 1 || !got[0].Seen || got[0].SeenAt.IsZero() || got[0].CaptureID != "cap_1" {
+		t.Fatalf("unexpected unlocks %+v", got)
+	}
+	if others, err := st.ListBadgeUnlocksByChild("other"); err != nil || len(others) != 0 {
+		t.Fatalf("expected no unlocks for other child, got %+v err=%v", others, err)
+	}
+}
+
+func TestSQLiteStoreLocations(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	now := time.Now().UTC()
+	location := &model.GeoPoint{Latitude: 31.23, Longitude: 121.474, AccuracyM: 65}
+	session := model.ScanSession{ID: "sess_geo", ChildID: "kid", ObjectType: "tree", CreatedAt: now, Location: location}
+	if err := st.SaveSession(session); err != nil {
+		t.Fatalf("SaveSession() error = %v", err)
+	}
+	gotSession, ok, err := st.GetSession(session.ID)
+	if err != nil || !ok {
+		t.Fatalf("GetSession() err=%v ok=%v", err, ok)
+	}
+	if gotSession.Location == nil || *gotSession.Location != *location {
+		t.Fatalf("expected session location %+v, got %+v", location, gotSession.Location)
+	}
+
+	for _, capture := range []model.Capture{
+		{ID: "cap_geo", ChildID: "kid", ObjectType: "tree", CapturedAt: now, Location: location},
+		{ID: "cap_plain", ChildID: "kid", ObjectType: "tree", CapturedAt: now.Add(time.Minute)},
+	} {
+		if err := st.AddCapture(capture); err != nil {
+			t.Fatalf("AddCapture() error = %v", err)
+		}
+	}
+	captures, err := st.ListCapturesByChild("kid")
+	if err != nil {
+		t.Fatalf("ListCapturesByChild() error = %v", err)
+	}
+	byID := make(map[string]model.Capture, len(captures))
+	for _, capture := range captures {
+		byID[capture.ID] = capture
+	}
+	if got := byID["cap_geo"].Location; got == nil || *got != *location {
+		t.Fatalf("expected capture location %+v, got %+v", location, got)
+	}
+	if byID["cap_plain"].Location != nil {
+		t.Fatalf("expected no location for plain capture, got %+v", byID["cap_plain"].Location)
+	}
+
+	inArea, err := st.ListCapturesInArea(31.2, 121.4, 31.3, 121.5)
+	if err != nil {
+		t.Fatalf("ListCapturesInArea() error = %v", err)
+	}
+	if len(inArea) != 1 || inArea[0].ID != "cap_geo" {
+		t.Fatalf("expected only the located capture in area, got %+v", inArea)
+	}
+	outside, err := st.ListCapturesInArea(40, 116, 41, 117)
+	if err != nil || len(outside) != 0 {
+		t.Fatalf("expected no captures outside area, got %d err=%v", len(outside), err)
+	}
+}
+
+func TestSQLiteStoreCompanionMessages(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	day := time.Date(2026, 2, 13, 0, 0, 0, 0, time.UTC)
+	for i, at := range []time.Time{day.Add(10 * time.Hour), day.Add(9 * time.Hour), day.AddDate(0, 0, 1)} {
+		err := st.AddCompanionMessage(model.CompanionMessage{
+			ID:           "chat_" + string(rune('a'+i)),
+			ChildID:      "kid",
+			ObjectType:   "tree",
+			ChildMessage: "你好",
+			ReplyText:    "你好呀",
+			CreatedAt:    at,
+		})
+		if err != nil {
+			t.Fatalf("AddCompanionMessage() error = %v", err)
+		}
+	}
+
+	got, err := st.ListCompanionMessagesByChild("kid", day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("ListCompanionMessagesByChild() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "chat_b" || got[1].ID != "chat_a" {
+		t.Fatalf("expected two messages in time order, got %+v", got)
+	}
+}
+
+func TestSQLiteStoreDeliveries(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	now := time.Now().UTC()
+	next := now.Add(5 * time.Minute)
+	sub := model.DeliverySubscription{
+		ID:              "sub_1",
+		ParentID:        "mum",
+		ChildID:         "kid",
+		Channel:         "email",
+		Target:          "mum@example.com",
+		SendAt:          "20:00",
+		Timezone:        "Asia/Shanghai",
+		Enabled:         true,
+		PendingDate:     "2026-02-13",
+		PendingAttempts: 1,
+		NextAttemptAt:   &next,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := st.SaveDeliverySubscription(sub); err != nil {
+		t.Fatalf("SaveDeliverySubscription() error = %v", err)
+	}
+	got, ok, err := st.GetDeliverySubscription("sub_1")
+	if err != nil || !ok {
+		t.Fatalf("GetDeliverySubscription() ok=%v err=%v", ok, err)
+	}
+	if !got.Enabled || got.NextAttemptAt == nil || !got.NextAttemptAt.Equal(next) || got.PendingAttempts != 1 {
+		t.Fatalf("unexpected subscription %+v", got)
+	}
+
+	for i, status := range []string{"retrying", "sent"} {
+		err := st.AddDeliveryAttempt(model.DeliveryAttempt{
+			ID:             "att_" + status,
+			SubscriptionID: "sub_1",
+			ChildID:        "kid",
+			Channel:        "email",
+			Target:         "mum@example.com",
+			ReportDate:     "2026-02-13",
+			Trigger:        "scheduled",
+			Attempt:        i + 1,
+			Status:         status,
+			CreatedAt:      now.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("AddDeliveryAttempt() error = %v", err)
+		}
+	}
+	attempts, err := st.ListDeliveryAttempts("sub_1", 1)
+	if err != nil {
+		t.Fatalf("ListDeliveryAttempts() error = %v", err)
+	}
+	if len(attempts) != 1 || attempts[0].Status != "sent" {
+		t.Fatalf("expected newest attempt first, got %+v", attempts)
+	}
+	if all, err := st.ListDeliveryAttempts("", 0); err != nil || len(all) != 2 {
+		t.Fatalf("expected all attempts, got %+v err=%v", all, err)
+	}
+
+	if err := st.DeleteDeliverySubscription("sub_1"); err != nil {
+		t.Fatalf("DeleteDeliverySubscription() error = %v", err)
+	}
+	if subs, err := st.ListDeliverySubscriptions(); err != nil || len(subs) != 0 {
+		t.Fatalf("expected no subscriptions, got %+v err=%v", subs, err)
+	}
+}
+
+func TestSQLiteStoreQuests(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	now := time.Now().UTC()
+	quests := []model.Quest{
+		{ID: "quest_b", ChildID: "kid", Date: "2026-02-13", TemplateID: "wheels", Type: "capture", Title: "找轮子", Keywords: []string{"轮", "car"}, Target: 2, RewardXP: 30, CreatedAt: now},
+		{ID: "quest_a", ChildID: "kid", Date: "2026-02-13", TemplateID: "first_try_3", Type: "first_try_correct", Title: "答对", Target: 3, RewardXP: 25, CreatedAt: now.Add(time.Millisecond)},
+		{ID: "quest_c", ChildID: "kid", Date: "2026-02-14", TemplateID: "chatty", Type: "companion_chat", Title: "聊天", Target: 3, RewardXP: 15, CreatedAt: now},
+	}
+	for _, quest := range quests {
+		if err := st.SaveQuest(quest); err != nil {
+			t.Fatalf("SaveQuest() error = %v", err)
+		}
+	}
+
+	completed := quests[0]
+	completed.Progress = 2
+	completed.CaptureIDs = []string{"cap_1", "cap_2"}
+	completed.CompletedAt = &now
+	if err := st.SaveQuest(completed); err != nil {
+		t.Fatalf("SaveQuest() update error = %v", err)
+	}
+
+	got, ok, err := st.GetQuest("quest_b")
+	if err != nil || !ok {
+		t.Fatalf("GetQuest() err=%v ok=%v", err, ok)
+	}
+	if got.Progress != 2 || len(got.Keywords) != 2 || len(got.CaptureIDs) != 2 || got.CompletedAt == nil || got.ClaimedAt != nil {
+		t.Fatalf("unexpected quest after round trip: %+v", got)
+	}
+
+	day, err := st.ListQuestsByChild("kid", "2026-02-13")
+	if err != nil {
+		t.Fatalf("ListQuestsByChild() error = %v", err)
+	}
+	if len(day) != 2 || day[0].ID != "quest_b" || day[1].ID != "quest_a" {
+		t.Fatalf("expected two quests in creation order, got %+v", day)
+	}
+	if day[1].Keywords != nil || day[1].CaptureIDs != nil {
+		t.Fatalf("expected empty lists to round trip as nil, got %+v", day[1])
+	}
+}
+
+func TestSQLiteStoreGroups(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	now := time.Now().UTC()
+	groups := []model.Group{
+		{ID: "group_a", Name: "三年二班", Kind: "classroom", OwnerID: "teacher", InviteCode: "ABCD2345", HideCountsUnderAge: 7, CreatedAt: now, UpdatedAt: now},
+		{ID: "group_b", Name: "我们家", Kind: "family", OwnerID: "parent", InviteCode: "WXYZ6789", CreatedAt: now.Add(time.Second), UpdatedAt: now},
+	}
+	for _, group := range groups {
+		if err := st.SaveGroup(group); err != nil {
+			t.Fatalf("SaveGroup() error = %v", err)
+		}
+	}
+	if err := st.SaveGroup(model.Group{ID: "group_c", Name: "重复", Kind: "family", OwnerID: "parent", InviteCode: "ABCD2345", CreatedAt: now, UpdatedAt: now}); err == nil {
+		t.Fatal("expected duplicate invite code to be rejected")
+	}
+
+	got, ok, err := st.GetGroupByInviteCode("ABCD2345")
+	if err != nil || !ok || got.ID != "group_a" || got.HideCountsUnderAge != 7 {
+		t.Fatalf("GetGroupByInviteCode() = %+v ok=%v err=%v", got, ok, err)
+	}
+	owned, err := st.ListGroups("parent")
+	if err != nil || len(owned) != 1 || owned[0].ID != "group_b" {
+		t.Fatalf("ListGroups(parent) = %+v err=%v", owned, err)
+	}
+	all, err := st.ListGroups("")
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListGroups(\"\") = %+v err=%v", all, err)
+	}
+
+	members := []model.GroupMember{
+		{GroupID: "group_a", ChildID: "kid_2", DisplayName: "小二", ChildAge: 8, JoinedAt: now.Add(time.Second)},
+		{GroupID: "group_a", ChildID: "kid_1", DisplayName: "小一", ChildAge: 6, JoinedAt: now},
+		{GroupID: "group_b", ChildID: "kid_1", DisplayName: "老大", JoinedAt: now},
+	}
+	for _, member := range members {
+		if err := st.SaveGroupMember(member); err != nil {
+			t.Fatalf("SaveGroupMember() error = %v", err)
+		}
+	}
+	renamed := members[0]
+	renamed.DisplayName = "二宝"
+	if err := st.SaveGroupMember(renamed); err != nil {
+		t.Fatalf("SaveGroupMember() update error = %v", err)
+	}
+
+	listed, err := st.ListGroupMembers("group_a")
+	if err != nil {
+		t.Fatalf("ListGroupMembers() error = %v", err)
+	}
+	if len(listed) != 2 || listed[0].ChildID != "kid_1" || listed[1].DisplayName != "二宝" {
+		t.Fatalf("expected members in join order with updated name, got %+v", listed)
+	}
+	byChild, err := st.ListGroupsByChild("kid_1")
+	if err != nil || len(byChild) != 2 {
+		t.Fatalf("ListGroupsByChild() = %+v err=%v", byChild, err)
+	}
+
+	if err := st.DeleteGroupMember("group_a", "kid_1"); err != nil {
+		t.Fatalf("DeleteGroupMember() error = %v", err)
+	}
+	byChild, err = st.ListGroupsByChild("kid_1")
+	if err != nil || len(byChild) != 1 || byChild[0].ID != "group_b" {
+		t.Fatalf("expected kid_1 to remain only in group_b, got %+v err=%v", byChild, err)
+	}
+}
+
+func TestSQLiteStoreSharesAndTrades(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = st.Close()
+	})
+
+	now := time.Now().UTC()
+	if err := st.SaveSpirit(model.Spirit{ID: "spirit_tree", Name: "木木", ObjectType: "tree", ImageURL: "https://example.com/mumu.png", CreatedAt: now}); err != nil {
+		t.Fatalf("SaveSpirit() error = %v", err)
+	}
+	spirit, ok, err := st.GetSpirit("spirit_tree")
+	if err != nil || !ok || spirit.ImageURL != "https://example.com/mumu.png" {
+		t.Fatalf("GetSpirit() = %+v ok=%v err=%v", spirit, ok, err)
+	}
+	grown := model.Spirit{ID: "spirit_kid_tree", ChildID: "kid_a", Name: "木木", ObjectType: "tree", Captures: 3, Level: 3, Stage: 2, StageName: "成长", Traits: []string{"认得你", "爱分享"}, IntroLines: []string{"木木记住你啦！"}, ImageStage: 1, CreatedAt: now}
+	if err := st.SaveSpirit(grown); err != nil {
+		t.Fatalf("SaveSpirit(grown) error = %v", err)
+	}
+	owned, ok, err := st.GetSpiritByChild("kid_a", "tree")
+	if err != nil || !ok || owned.ID != grown.ID || owned.Level != 3 || owned.StageName != "成长" || len(owned.Traits) != 2 || owned.ImageStage != 1 {
+		t.Fatalf("GetSpiritByChild() = %+v ok=%v err=%v", owned, ok, err)
+	}
+	if _, ok, err := st.GetSpiritByChild("kid_b", "tree"); err != nil || ok {
+		t.Fatalf("expected no spirit for kid_b, ok=%v err=%v", ok, err)
+	}
+
+	for _, capture := range []model.Capture{
+		{ID: "cap_a", ChildID: "kid_a", SpiritID: "spirit_tree", ObjectType: "tree", CapturedAt: now, Location: &model.GeoPoint{Latitude: 31.23, Longitude: 121.47}},
+		{ID: "cap_b", ChildID: "kid_b", SpiritID: "spirit_mailbox", ObjectType: "mailbox", CapturedAt: now},
+	} {
+		if err := st.AddCapture(capture); err != nil {
+			t.Fatalf("AddCapture() error = %v", err)
+		}
+	}
+	capture, ok, err := st.GetCapture("cap_a")
+	if err != nil || !ok || capture.ChildID != "kid_a" || capture.Location == nil {
+		t.Fatalf("GetCapture() = %+v ok=%v err=%v", capture, ok, err)
+	}
+
+	expiresAt := now.Add(time.Hour)
+	if err := st.SaveSpiritShare(model.SpiritShare{Token: "tok", ChildID: "kid_a", CaptureID: "cap_a", CreatedAt: now, ExpiresAt: &expiresAt}); err != nil {
+		t.Fatalf("SaveSpiritShare() error = %v", err)
+	}
+	share, ok, err := st.GetSpiritShare("tok")
+	if err != nil || !ok || share.ExpiresAt == nil || !share.ExpiresAt.Equal(expiresAt) || share.RevokedAt != nil {
+		t.Fatalf("GetSpiritShare() = %+v ok=%v err=%v", share, ok, err)
+	}
+
+	trade := model.Trade{ID: "trade_1", GroupID: "group", ProposerID: "kid_a", ProposerCaptureID: "cap_a", RecipientID: "kid_b", RecipientCaptureID: "cap_b", Status: "pending", CreatedAt: now}
+	if err := st.SaveTrade(trade); err != nil {
+		t.Fatalf("SaveTrade() error = %v", err)
+	}
+	stale := trade
+	stale.ID = "trade_2"
+	stale.CreatedAt = now.Add(time.Second)
+	if err := st.SaveTrade(stale); err != nil {
+		t.Fatalf("SaveTrade() error = %v", err)
+	}
+
+	accepted := trade
+	accepted.Status = "accepted"
+	accepted.RespondedAt = &now
+	if err := st.AcceptTrade(accepted); err != nil {
+		t.Fatalf("AcceptTrade() error = %v", err)
+	}
+	capture, _, _ = st.GetCapture("cap_a")
+	if capture.ChildID != "kid_b" || capture.Location != nil {
+		t.Fatalf("expected cap_a to move to kid_b without location, got %+v", capture)
+	}
+	got, _, err := st.GetTrade("trade_1")
+	if err != nil || got.Status != "accepted" || got.RespondedAt == nil {
+		t.Fatalf("GetTrade() = %+v err=%v", got, err)
+	}
+
+	// 第二笔交易引用的收集已经换手，必须整体失败且不留下部分修改。
+	stale.Status = "accepted"
+	stale.RespondedAt = &now
+	if err := st.AcceptTrade(stale); !errors.Is(err, store.ErrTradeConflict) {
+		t.Fatalf("expected ErrTradeConflict, got %v", err)
+	}
+	got, _, _ = st.GetTrade("trade_2")
+	if got.Status != "pending" {
+		t.Fatalf("expected stale trade to stay pending, got %+v", got)
+	}
+	if capture, _, _ := st.GetCapture("cap_b"); capture.ChildID != "kid_a" {
+		t.Fatalf("expected cap_b to stay with kid_a, got %+v", capture)
+	}
+
+	trades, err := st.ListTradesByChild("kid_b")
+	if err != nil {
+		t.Fatalf("ListTradesByChild() error = %v", err)
+	}
+	if len(trades) != 2 || trades[0].ID != "trade_2" {
+		t.Fatalf("expected newest trade first, got %+v", trades)
+	}
+}
+
+func TestSQLiteStoreChildProfile(t *testing.T) {
+	t.Parallel()
+
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	if _, ok, err := st.GetChildProfile("kid_1"); err != nil || ok {
+		t.Fatalf("expected missing profile, ok=%v err=%v", ok, err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := st.SaveChildProfile(model.ChildProfile{ChildID: "kid_1", Locale: "en", UpdatedAt: now}); err != nil {
+		t.Fatalf("SaveChildProfile() error = %v", err)
+	}
+	got, ok, err := st.GetChildProfile("kid_1")
+	if err != nil || !ok {
+		t.Fatalf("GetChildProfile() ok=%v err=%v", ok, err)
+	}
+	if got.Locale != "en" || !got.UpdatedAt.Equal(now) {
+		t.Fatalf("unexpected profile %+v", got)
+	}
+}
+
+func TestStoresPingWithWrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	sqliteStore, err := store.NewSQLiteStore(filepath.Join(dir, "cityling.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sqliteStore.Close()
+	})
+	jsonDir := filepath.Join(dir, "json")
+	jsonStore, err := store.NewJSONStore(filepath.Join(jsonDir, "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+
+	for name, st := range map[string]store.Store{"sqlite": sqliteStore, "json": jsonStore} {
+		pinger, ok := store.Observe(st, func(context.Context, string, time.Time, error) {}).(store.Pinger)
+		if !ok {
+			t.Fatalf("%s: observed store does not forward Ping", name)
+		}
+		if err := pinger.Ping(context.Background()); err != nil {
+			t.Fatalf("%s: Ping() error = %v", name, err)
+		}
+	}
+	if entries, _ := os.ReadDir(jsonDir); len(entries) != 0 {
+		t.Fatalf("expected JSON ping to clean up its temp file, found %d entries", len(entries))
+	}
+
+	_ = sqliteStore.Close()
+	if err := sqliteStore.Ping(context.Background()); err == nil {
+		t.Fatalf("expected Ping on a closed database to fail")
+	}
+}
@@ -1,20 +1,90 @@
 package store
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"ling/internal/model"
 )
 
+// ErrTradeConflict 表示交易已不是待处理状态，或涉及的收集已不归原主人所有。
+var ErrTradeConflict = errors.New("trade is no longer pending or captures changed owner")
+
+// ErrPingUnsupported 表示存储没有实现 Pinger，无法自检。
+var ErrPingUnsupported = errors.New("store does not support ping")
+
 type Store interface {
 	SaveSpirit(spirit model.Spirit) error
 	GetSpirit(id string) (model.Spirit, bool, error)
+	// GetSpiritByChild 返回孩子在某类对象上的专属精灵。
+	GetSpiritByChild(childID string, objectType string) (model.Spirit, bool, error)
 
 	SaveSession(session model.ScanSession) error
 	GetSession(id string) (model.ScanSession, bool, error)
 	UpdateSession(session model.ScanSession) error
+	ListSessionsByChild(childID string) ([]model.ScanSession, error)
 
 	AddCapture(capture model.Capture) error
+	GetCapture(id string) (model.Capture, bool, error)
 	ListCapturesByChild(childID string) ([]model.Capture, error)
 	ListCapturesByChildAndDate(childID string, day time.Time) ([]model.Capture, error)
+	// ListCapturesInArea 返回所有孩子在经纬度范围内（含边界）带位置的收集记录。
+	ListCapturesInArea(minLat float64, minLng float64, maxLat float64, maxLng float64) ([]model.Capture, error)
+
+	SaveReviewItem(item model.ReviewItem) error
+	GetReviewItem(id string) (model.ReviewItem, bool, error)
+	ListReviewItemsByChild(childID string) ([]model.ReviewItem, error)
+
+	GetProgress(childID string) (model.ChildProgress, bool, error)
+	SaveProgress(progress model.ChildProgress) error
+
+	SaveBadgeUnlock(unlock model.BadgeUnlock) error
+	ListBadgeUnlocksByChild(childID string) ([]model.BadgeUnlock, error)
+
+	AddCompanionMessage(message model.CompanionMessage) error
+	ListCompanionMessagesByChild(childID string, start time.Time, end time.Time) ([]model.CompanionMessage, error)
+
+	SaveDeliverySubscription(sub model.DeliverySubscription) error
+	GetDeliverySubscription(id string) (model.DeliverySubscription, bool, error)
+	ListDeliverySubscriptions() ([]model.DeliverySubscription, error)
+	DeleteDeliverySubscription(id string) error
+	AddDeliveryAttempt(attempt model.DeliveryAttempt) error
+	// ListDeliveryAttempts 按时间倒序返回投递记录；subscriptionID 为空时返回全部，limit <= 0 表示不限制。
+	ListDeliveryAttempts(subscriptionID string, limit int) ([]model.DeliveryAttempt, error)
+
+	SaveQuest(quest model.Quest) error
+	GetQuest(id string) (model.Quest, bool, error)
+	// ListQuestsByChild 按创建顺序返回孩子某天（YYYY-MM-DD）的任务。
+	ListQuestsByChild(childID string, date string) ([]model.Quest, error)
+
+	SaveGroup(group model.Group) error
+	GetGroup(id string) (model.Group, bool, error)
+	GetGroupByInviteCode(code string) (model.Group, bool, error)
+	// ListGroups 返回 ownerID 创建的群组；ownerID 为空时返回全部。
+	ListGroups(ownerID string) ([]model.Group, error)
+	SaveGroupMember(member model.GroupMember) error
+	DeleteGroupMember(groupID string, childID string) error
+	// ListGroupMembers 按加入时间返回群组成员。
+	ListGroupMembers(groupID string) ([]model.GroupMember, error)
+	ListGroupsByChild(childID string) ([]model.Group, error)
+
+	SaveSpiritShare(share model.SpiritShare) error
+	GetSpiritShare(token string) (model.SpiritShare, bool, error)
+
+	SaveTrade(trade model.Trade) error
+	GetTrade(id string) (model.Trade, bool, error)
+	// ListTradesByChild 按创建时间倒序返回孩子发起或收到的交易。
+	ListTradesByChild(childID string) ([]model.Trade, error)
+	// AcceptTrade 在同一事务内互换两条收集的归属（并清除位置）并保存交易；
+	// 交易不再待处理或收集归属已变化时返回 ErrTradeConflict。
+	AcceptTrade(trade model.Trade) error
+
+	SaveChildProfile(profile model.ChildProfile) error
+	GetChildProfile(childID string) (model.ChildProfile, bool, error)
+}
+
+// Pinger 由能够自检的存储实现，用于就绪探针：Ping 应确认存储可读写，而不只是连接仍打开。
+type Pinger interface {
+	Ping(ctx context.Context) error
 }
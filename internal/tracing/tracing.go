@@ -0,0 +1,120 @@
+// Package tracing 封装 OpenTelemetry 链路追踪：按配置安装 OTLP 或 stdout 导出器，并提供建 span 的小工具。
+// 未调用 Setup 时使用 OpenTelemetry 默认的空实现，建 span 几乎没有开销。
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	instrumentationName = "ling"
+)
+
+// Config 为链路追踪配置。
+type Config struct {
+	// Exporter 取 none、stdout 或 otlp，空串等同 none。
+	// otlp 使用 HTTP/protobuf 协议，地址等参数读取标准的 OTEL_EXPORTER_OTLP_* 环境变量。
+	Exporter string
+	// ServiceName 写入 service.name 资源属性。
+	ServiceName string
+	// SampleRatio 为根 span 的采样比例，取值 (0, 1]，其他值按 1 处理；有上游 span 时跟随上游的采样决定。
+	SampleRatio float64
+	// Writer 为 stdout 导出器的输出，默认 os.Stdout。
+	Writer io.Writer
+}
+
+// Setup 按配置安装全局 TracerProvider 与 W3C traceparent 传播器，返回用于刷新并关闭导出器的函数。
+// Exporter 为 none 时不安装任何东西，返回的函数什么也不做。
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(strings.TrimSpace(cfg.Exporter)) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		writer := cfg.Writer
+		if writer == nil {
+			writer = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(writer))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	serviceName := strings.TrimSpace(cfg.ServiceName)
+	if serviceName == "" {
+		serviceName = "cityling-backend"
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start 在 ctx 下开始一个 span，调用方负责结束它。
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span；err 非空时记录错误并把状态置为 Error。
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// ObserveStore 把一次已结束的存储操作记成 store.<operation> span。
+// 只有 ctx 中已有 span 时才记录，避免没有请求上下文的后台操作各自成为孤立的根 span。
+func ObserveStore(ctx context.Context, operation string, start time.Time, err error) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	_, span := otel.Tracer(instrumentationName).Start(ctx, "store."+operation,
+		trace.WithTimestamp(start),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.operation.name", operation)),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupStdoutExportsSpans(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var buf bytes.Buffer
+	shutdown, err := Setup(context.Background(), Config{Exporter: "stdout", ServiceName: "cityling-test", Writer: &buf})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	ctx, span := Start(context.Background(), "Service.Scan")
+	ObserveStore(ctx, "SaveSession", time.Now(), errors.New("disk full"))
+	End(span, nil)
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error = %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{`"Name":"Service.Scan"`, `"Name":"store.SaveSession"`, "cityling-test", "disk full"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("stdout exporter output missing %q", want)
+		}
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+		t.Fatalf("expected error for unknown exporter")
+	}
+	shutdown, err := Setup(context.Background(), Config{Exporter: "none"})
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Fatalf("expected none exporter to be a no-op, got %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+// Package api 定义 City Ling HTTP API 的请求与响应类型，由服务端与 Go SDK（pkg/client）共用。
+//
+// 本包只依赖标准库，引入 SDK 不会连带链接存储、对象存储、追踪导出等服务端依赖。
+package api
+
+// ErrorResponse 是所有错误响应的格式。error 保留给只认文案的旧客户端，新客户端应按 code 分支。
+type ErrorResponse struct {
+	Error   string         `json:"error"`
+	Code    string         `json:"code"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// HealthResponse 是 /healthz 与 /livez 的响应。
+type HealthResponse struct {
+	Status string `json:"status"`
+}
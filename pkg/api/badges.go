@@ -0,0 +1,80 @@
+package api
+
+import "time"
+
+type BadgeCatalogStatus struct {
+	Source     string    `json:"source"`
+	Badges     int       `json:"badges"`
+	Images     int       `json:"images"`
+	ReloadedAt time.Time `json:"reloaded_at"`
+}
+
+// BadgeCriterion 是勋章点亮条件的声明式描述，可以通过 all/any 组合成更复杂的规则。
+//
+//	collect_examples   收集 examples 中的 count 个（默认全部，examples 默认取勋章示例）
+//	distinct_objects   收集 count 种匹配 keywords 的不同对象（keywords 默认取勋章关键词与示例）
+//	captures_in_window 任意连续 window_days 天内收集 count 次匹配 keywords 的对象（keywords 为空表示不限）
+//	streak             连续 days 天都有收集（可用 keywords 限定对象）
+//	badges             点亮 badge_ids 中的 count 个勋章（默认全部）
+//	accuracy           累计作答至少 min_answers 题且正确率不低于 min_accuracy
+type BadgeCriterion struct {
+	Type        string           `json:"type"`
+	Keywords    []string         `json:"keywords,omitempty"`
+	Examples    []string         `json:"examples,omitempty"`
+	Count       int              `json:"count,omitempty"`
+	WindowDays  int              `json:"window_days,omitempty"`
+	Days        int              `json:"days,omitempty"`
+	BadgeIDs    []string         `json:"badge_ids,omitempty"`
+	MinAnswers  int              `json:"min_answers,omitempty"`
+	MinAccuracy float64          `json:"min_accuracy,omitempty"`
+	All         []BadgeCriterion `json:"all,omitempty"`
+	Any         []BadgeCriterion `json:"any,omitempty"`
+}
+
+type BadgeSeenRequest struct {
+	ChildID   string   `json:"child_id"`
+	UnlockIDs []string `json:"unlock_ids,omitempty"`
+}
+
+type BadgeSeenResponse struct {
+	ChildID string `json:"child_id"`
+	Marked  int    `json:"marked"`
+}
+
+type BadgeRule struct {
+	ID          string          `json:"id"`
+	CategoryID  string          `json:"category_id"`
+	Name        string          `json:"name"`
+	Code        string          `json:"code"`
+	Description string          `json:"description"`
+	RecordScope string          `json:"record_scope"`
+	Rule        string          `json:"rule,omitempty"`
+	Target      int             `json:"target,omitempty"`
+	ImageFile   string          `json:"image_file"`
+	ImageURL    string          `json:"image_url,omitempty"`
+	Keywords    []string        `json:"keywords"`
+	Examples    []string        `json:"examples"`
+	Criteria    *BadgeCriterion `json:"criteria,omitempty"`
+	// 限时勋章的开放时间窗口，窗口外的收集不计入进度。
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+}
+
+type BadgeRuleListResponse struct {
+	Badges []BadgeRule `json:"badges"`
+}
+
+type BadgeUnlockListResponse struct {
+	ChildID string        `json:"child_id"`
+	Unlocks []BadgeUnlock `json:"unlocks"`
+}
+
+type PokedexResponse struct {
+	ChildID string         `json:"child_id"`
+	Entries []PokedexEntry `json:"entries"`
+}
+
+type PokedexBadgeResponse struct {
+	ChildID string         `json:"child_id"`
+	Badges  []PokedexBadge `json:"badges"`
+}
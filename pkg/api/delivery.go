@@ -0,0 +1,20 @@
+package api
+
+type DeliverySubscriptionRequest struct {
+	ParentID string `json:"parent_id"`
+	ChildID  string `json:"child_id"`
+	Channel  string `json:"channel"`
+	Target   string `json:"target"`
+	SendAt   string `json:"send_at"`
+	Timezone string `json:"timezone"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+}
+
+type DeliverySubscriptionListResponse struct {
+	Channels      []string               `json:"channels"`
+	Subscriptions []DeliverySubscription `json:"subscriptions"`
+}
+
+type DeliveryAttemptListResponse struct {
+	Attempts []DeliveryAttempt `json:"attempts"`
+}
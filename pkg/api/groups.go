@@ -0,0 +1,70 @@
+package api
+
+import "time"
+
+type GroupRequest struct {
+	Name               string `json:"name"`
+	Kind               string `json:"kind"`
+	OwnerID            string `json:"owner_id"`
+	HideCountsUnderAge *int   `json:"hide_counts_under_age,omitempty"`
+}
+
+type GroupJoinRequest struct {
+	InviteCode  string `json:"invite_code"`
+	ChildID     string `json:"child_id"`
+	DisplayName string `json:"display_name"`
+	ChildAge    int    `json:"child_age"`
+}
+
+// GroupViewer 标识查看群组数据的人：群主用 OwnerID，成员用 ChildID。
+type GroupViewer struct {
+	ChildID string
+	OwnerID string
+}
+
+type GroupDetail struct {
+	Group
+	Members []GroupMember `json:"members"`
+}
+
+// LeaderboardEntry 为排行榜中的一行；对年龄较小的查看者隐藏数量时 Value 为空，只给出 1-3 颗星。
+type LeaderboardEntry struct {
+	Rank        int    `json:"rank"`
+	ChildID     string `json:"child_id"`
+	DisplayName string `json:"display_name"`
+	Value       *int   `json:"value,omitempty"`
+	Stars       int    `json:"stars"`
+	Me          bool   `json:"me,omitempty"`
+}
+
+type GroupLeaderboard struct {
+	GroupID string `json:"group_id"`
+	Metric  string `json:"metric"`
+	Window  string `json:"window"`
+	// Since 为统计窗口的起点；window=all 与 streak 指标不限时间。
+	Since        *time.Time         `json:"since,omitempty"`
+	CountsHidden bool               `json:"counts_hidden"`
+	Entries      []LeaderboardEntry `json:"entries"`
+}
+
+// GroupFeedItem 是群组动态中的一条收集，不包含位置。
+type GroupFeedItem struct {
+	ChildID     string    `json:"child_id"`
+	DisplayName string    `json:"display_name"`
+	SpiritName  string    `json:"spirit_name"`
+	ObjectType  string    `json:"object_type"`
+	CapturedAt  time.Time `json:"captured_at"`
+}
+
+type GroupFeed struct {
+	GroupID string          `json:"group_id"`
+	Items   []GroupFeedItem `json:"items"`
+}
+
+type InviteCodeRequest struct {
+	OwnerID string `json:"owner_id"`
+}
+
+type GroupListResponse struct {
+	Groups []Group `json:"groups"`
+}
@@ -0,0 +1,36 @@
+package api
+
+// MapQuery 描述地图查询条件：BBox（minLng, minLat, maxLng, maxLat）与 Center+RadiusM 二选一，都为空时返回全部带位置的收集。
+type MapQuery struct {
+	BBox    []float64
+	Center  *GeoPoint
+	RadiusM float64
+	Cluster bool
+	Zoom    int
+}
+
+// NearbyHintBadge 说明收集该对象能推进的勋章进度。
+type NearbyHintBadge struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Progress  int    `json:"progress"`
+	Target    int    `json:"target"`
+	Remaining int    `json:"remaining"`
+}
+
+// NearbyHint 是附近常见、但孩子还没收集过的对象。
+type NearbyHint struct {
+	ObjectType string           `json:"object_type"`
+	ObjectName string           `json:"object_name"`
+	Popularity string           `json:"popularity"`
+	Badge      *NearbyHintBadge `json:"badge,omitempty"`
+	Hint       string           `json:"hint"`
+}
+
+// NearbyHints 是附近提示的查询结果；Area 为对齐后的网格中心，不回显孩子的原始坐标。
+type NearbyHints struct {
+	ChildID string       `json:"child_id"`
+	Area    GeoPoint     `json:"area"`
+	RadiusM float64      `json:"radius_m"`
+	Hints   []NearbyHint `json:"hints"`
+}
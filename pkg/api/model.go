@@ -0,0 +1,280 @@
+package api
+
+import "time"
+
+// Spirit 是孩子在某类对象上的专属精灵：同一孩子重复收集同类对象时沿用同一只精灵并让它成长。
+// ChildID 为空的是升级前生成的旧精灵。
+type Spirit struct {
+	ID          string    `json:"id"`
+	ChildID     string    `json:"child_id,omitempty"`
+	Name        string    `json:"name"`
+	ObjectType  string    `json:"object_type"`
+	Personality string    `json:"personality"`
+	Intro       string    `json:"intro"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Captures   int      `json:"captures,omitempty"`
+	Level      int      `json:"level,omitempty"`
+	Stage      int      `json:"stage,omitempty"`
+	StageName  string   `json:"stage_name,omitempty"`
+	Traits     []string `json:"traits,omitempty"`
+	IntroLines []string `json:"intro_lines,omitempty"`
+	// ImageStage 为当前 ImageURL 对应的成长阶段，小于 Stage 时说明形象待更新。
+	ImageStage int `json:"image_stage,omitempty"`
+}
+
+type Capture struct {
+	ID         string    `json:"id"`
+	ChildID    string    `json:"child_id"`
+	SpiritID   string    `json:"spirit_id"`
+	SpiritName string    `json:"spirit_name"`
+	ObjectType string    `json:"object_type"`
+	Fact       string    `json:"fact"`
+	CapturedAt time.Time `json:"captured_at"`
+	Location   *GeoPoint `json:"location,omitempty"`
+}
+
+// GeoPoint 是一次扫描的位置。保存前会按配置降低精度，AccuracyM 至少为降精度带来的误差。
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	AccuracyM float64 `json:"accuracy_m,omitempty"`
+}
+
+type GeoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type GeoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   GeoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+type PokedexEntry struct {
+	SpiritID   string    `json:"spirit_id"`
+	SpiritName string    `json:"spirit_name"`
+	ObjectType string    `json:"object_type"`
+	Captures   int       `json:"captures"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Level      int       `json:"level"`
+	Stage      int       `json:"stage"`
+	StageName  string    `json:"stage_name,omitempty"`
+	ImageURL   string    `json:"image_url,omitempty"`
+}
+
+type PokedexBadge struct {
+	ID          string     `json:"id"`
+	CategoryID  string     `json:"category_id"`
+	Name        string     `json:"name"`
+	Code        string     `json:"code"`
+	Description string     `json:"description"`
+	RecordScope string     `json:"record_scope"`
+	Rule        string     `json:"rule"`
+	ImageURL    string     `json:"image_url"`
+	ImageFile   string     `json:"image_file"`
+	Unlocked    bool       `json:"unlocked"`
+	Progress    int        `json:"progress"`
+	Target      int        `json:"target"`
+	Examples    []string   `json:"examples,omitempty"`
+	Collected   []string   `json:"collected_examples,omitempty"`
+	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
+	// Available 为 false 表示限时勋章当前不在开放窗口内。
+	Available      bool       `json:"available"`
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+}
+
+type DailyReport struct {
+	Date            string        `json:"date"`
+	ChildID         string        `json:"child_id"`
+	TotalCaptured   int           `json:"total_captured"`
+	Captures        []Capture     `json:"captures"`
+	KnowledgePoints []string      `json:"knowledge_points"`
+	NewBadges       []BadgeUnlock `json:"new_badges"`
+	GeneratedText   string        `json:"generated_text"`
+	Suggestions     []string      `json:"suggestions,omitempty"`
+	NarrativeSource string        `json:"narrative_source"`
+	GeneratedAt     time.Time     `json:"generated_at"`
+}
+
+type PeriodStats struct {
+	StartDate           string   `json:"start_date"`
+	EndDate             string   `json:"end_date"`
+	Captures            int      `json:"captures"`
+	DistinctObjectTypes int      `json:"distinct_object_types"`
+	ObjectTypes         []string `json:"object_types"`
+	NewBadges           []string `json:"new_badges"`
+	QuizAnswered        int      `json:"quiz_answered"`
+	QuizCorrect         int      `json:"quiz_correct"`
+	QuizAccuracy        float64  `json:"quiz_accuracy"`
+	ActiveDays          int      `json:"active_days"`
+}
+
+type PeriodTrend struct {
+	Captures            int     `json:"captures"`
+	DistinctObjectTypes int     `json:"distinct_object_types"`
+	NewBadges           int     `json:"new_badges"`
+	QuizAccuracy        float64 `json:"quiz_accuracy"`
+	ActiveDays          int     `json:"active_days"`
+}
+
+type PeriodReport struct {
+	Period          string      `json:"period"`
+	ChildID         string      `json:"child_id"`
+	Current         PeriodStats `json:"current"`
+	Previous        PeriodStats `json:"previous"`
+	Trend           PeriodTrend `json:"trend"`
+	GeneratedText   string      `json:"generated_text"`
+	Suggestions     []string    `json:"suggestions,omitempty"`
+	NarrativeSource string      `json:"narrative_source"`
+	GeneratedAt     time.Time   `json:"generated_at"`
+}
+
+type ProgressEvent struct {
+	Type      string `json:"type"`
+	Reason    string `json:"reason,omitempty"`
+	XP        int    `json:"xp,omitempty"`
+	Level     int    `json:"level,omitempty"`
+	LevelName string `json:"level_name,omitempty"`
+	Streak    int    `json:"streak,omitempty"`
+}
+
+type BadgeUnlock struct {
+	ID         string    `json:"id"`
+	ChildID    string    `json:"child_id"`
+	BadgeID    string    `json:"badge_id"`
+	BadgeName  string    `json:"badge_name"`
+	ImageURL   string    `json:"image_url,omitempty"`
+	CaptureID  string    `json:"capture_id,omitempty"`
+	SessionID  string    `json:"session_id,omitempty"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+	Seen       bool      `json:"seen"`
+	SeenAt     time.Time `json:"seen_at,omitempty"`
+}
+
+// DeliverySubscription 描述一位家长订阅某个孩子日报的方式：每天在 Timezone 的 SendAt 时刻通过 Channel 推送到 Target。
+type DeliverySubscription struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id"`
+	ChildID  string `json:"child_id"`
+	Channel  string `json:"channel"`
+	Target   string `json:"target"`
+	SendAt   string `json:"send_at"`
+	Timezone string `json:"timezone"`
+	Enabled  bool   `json:"enabled"`
+
+	// LastDeliveredDate 为最近一次完成（成功或放弃重试）的报告日期；PendingDate 非空表示该日期仍在重试中。
+	LastDeliveredDate string     `json:"last_delivered_date,omitempty"`
+	PendingDate       string     `json:"pending_date,omitempty"`
+	PendingAttempts   int        `json:"pending_attempts,omitempty"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DeliveryAttempt struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	ChildID        string    `json:"child_id"`
+	Channel        string    `json:"channel"`
+	Target         string    `json:"target"`
+	ReportDate     string    `json:"report_date"`
+	Trigger        string    `json:"trigger"`
+	Attempt        int       `json:"attempt"`
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Quest 是按模板为孩子每天生成的短期任务；Progress 达到 Target 即完成，领取后发放 RewardXP。
+type Quest struct {
+	ID          string   `json:"id"`
+	ChildID     string   `json:"child_id"`
+	Date        string   `json:"date"`
+	TemplateID  string   `json:"template_id"`
+	Type        string   `json:"type"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Target      int      `json:"target"`
+	Progress    int      `json:"progress"`
+	RewardXP    int      `json:"reward_xp"`
+	// CaptureIDs 记录计入收集类任务进度的收集。
+	CaptureIDs  []string   `json:"capture_ids,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ClaimedAt   *time.Time `json:"claimed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Group 是家庭或班级群组：OwnerID 为创建者（家长或老师），孩子凭 InviteCode 加入。
+type Group struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	OwnerID    string `json:"owner_id"`
+	InviteCode string `json:"invite_code"`
+	// HideCountsUnderAge 大于 0 时，年龄小于该值的孩子在排行榜上只能看到名次和星级，看不到具体数量。
+	HideCountsUnderAge int       `json:"hide_counts_under_age,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type GroupMember struct {
+	GroupID     string    `json:"group_id"`
+	ChildID     string    `json:"child_id"`
+	DisplayName string    `json:"display_name"`
+	ChildAge    int       `json:"child_age,omitempty"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// SpiritShare 是一次收集的只读分享链接；收集被交换给别人后链接随之失效。
+type SpiritShare struct {
+	Token     string     `json:"token"`
+	ChildID   string     `json:"child_id"`
+	CaptureID string     `json:"capture_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SpiritCard 是分享页展示的精灵卡片，不包含孩子身份和拍摄位置。
+type SpiritCard struct {
+	Token             string     `json:"token"`
+	SpiritName        string     `json:"spirit_name"`
+	ObjectType        string     `json:"object_type"`
+	Personality       string     `json:"personality,omitempty"`
+	Intro             string     `json:"intro,omitempty"`
+	CharacterImageURL string     `json:"character_image_url,omitempty"`
+	Fact              string     `json:"fact"`
+	CapturedAt        time.Time  `json:"captured_at"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+}
+
+// Trade 是同一群组内两个孩子交换各自一条收集的提议，接受后两条收集的归属互换。
+type Trade struct {
+	ID                 string     `json:"id"`
+	GroupID            string     `json:"group_id"`
+	ProposerID         string     `json:"proposer_id"`
+	ProposerCaptureID  string     `json:"proposer_capture_id"`
+	RecipientID        string     `json:"recipient_id"`
+	RecipientCaptureID string     `json:"recipient_capture_id"`
+	Status             string     `json:"status"`
+	CreatedAt          time.Time  `json:"created_at"`
+	RespondedAt        *time.Time `json:"responded_at,omitempty"`
+}
+
+// ChildProfile 保存孩子的偏好设置；Locale 为空表示跟随请求的 Accept-Language。
+type ChildProfile struct {
+	ChildID   string    `json:"child_id"`
+	Locale    string    `json:"locale,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
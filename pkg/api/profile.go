@@ -0,0 +1,7 @@
+package api
+
+type ProfileRequest struct {
+	ChildID string `json:"child_id"`
+	// Locale 为 zh-CN、en 或 zh-TW；传空字符串表示清除设置，改为跟随请求的 Accept-Language。
+	Locale string `json:"locale"`
+}
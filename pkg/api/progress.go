@@ -0,0 +1,41 @@
+package api
+
+type ProgressUpdate struct {
+	XPGained      int             `json:"xp_gained"`
+	XP            int             `json:"xp"`
+	Level         int             `json:"level"`
+	LevelName     string          `json:"level_name"`
+	CurrentStreak int             `json:"current_streak"`
+	Events        []ProgressEvent `json:"events"`
+}
+
+type ProgressProfile struct {
+	ChildID        string `json:"child_id"`
+	XP             int    `json:"xp"`
+	Level          int    `json:"level"`
+	LevelName      string `json:"level_name"`
+	NextLevel      int    `json:"next_level,omitempty"`
+	NextLevelName  string `json:"next_level_name,omitempty"`
+	NextLevelXP    int    `json:"next_level_xp,omitempty"`
+	XPToNextLevel  int    `json:"xp_to_next_level"`
+	CurrentStreak  int    `json:"current_streak"`
+	LongestStreak  int    `json:"longest_streak"`
+	LastActiveDate string `json:"last_active_date,omitempty"`
+	StreakAtRisk   bool   `json:"streak_at_risk"`
+	GraceDays      int    `json:"grace_days"`
+}
+
+// SpiritEvolution 描述一次收集后专属精灵的成长。
+type SpiritEvolution struct {
+	SpiritID      string   `json:"spirit_id"`
+	SpiritName    string   `json:"spirit_name"`
+	PreviousLevel int      `json:"previous_level"`
+	Level         int      `json:"level"`
+	Stage         int      `json:"stage"`
+	StageName     string   `json:"stage_name"`
+	StageUp       bool     `json:"stage_up,omitempty"`
+	NewTraits     []string `json:"new_traits,omitempty"`
+	NewIntroLines []string `json:"new_intro_lines,omitempty"`
+	// ImagePending 为 true 表示新阶段的形象正在后台生成，完成后写入精灵的 image_url。
+	ImagePending bool `json:"image_pending,omitempty"`
+}
@@ -0,0 +1,19 @@
+package api
+
+type QuestBoard struct {
+	ChildID string  `json:"child_id"`
+	Date    string  `json:"date"`
+	Quests  []Quest `json:"quests"`
+}
+
+type QuestClaimRequest struct {
+	ChildID string `json:"child_id"`
+	Locale  string `json:"locale,omitempty"`
+}
+
+type QuestClaimResponse struct {
+	Quest Quest `json:"quest"`
+	// Captures 为计入该任务进度的收集，客户端可在领奖时回顾。
+	Captures []Capture       `json:"captures,omitempty"`
+	Progress *ProgressUpdate `json:"progress,omitempty"`
+}
@@ -0,0 +1,35 @@
+package api
+
+import "time"
+
+type ReviewDueItem struct {
+	ReviewID    string    `json:"review_id"`
+	ObjectType  string    `json:"object_type"`
+	SpiritName  string    `json:"spirit_name"`
+	Question    string    `json:"question"`
+	Fact        string    `json:"fact"`
+	Repetitions int       `json:"repetitions"`
+	DueAt       time.Time `json:"due_at"`
+}
+
+type ReviewAnswerRequest struct {
+	ReviewID string `json:"review_id"`
+	ChildID  string `json:"child_id"`
+	Answer   string `json:"answer"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+type ReviewAnswerResponse struct {
+	ReviewID     string    `json:"review_id"`
+	Correct      bool      `json:"correct"`
+	Message      string    `json:"message"`
+	EaseFactor   float64   `json:"ease_factor"`
+	IntervalDays int       `json:"interval_days"`
+	Repetitions  int       `json:"repetitions"`
+	NextDueAt    time.Time `json:"next_due_at"`
+}
+
+type ReviewDueResponse struct {
+	ChildID string          `json:"child_id"`
+	Items   []ReviewDueItem `json:"items"`
+}
@@ -0,0 +1,129 @@
+package api
+
+type ScanRequest struct {
+	ChildID       string `json:"child_id"`
+	ChildAge      int    `json:"child_age"`
+	DetectedLabel string `json:"detected_label"`
+	ImageBase64   string `json:"image_base64,omitempty"`
+	ImageURL      string `json:"image_url,omitempty"`
+
+	// 可选的拍摄位置，accuracy 为定位精度（米）。
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Accuracy  *float64 `json:"accuracy,omitempty"`
+
+	// Locale 为可选的语言（zh-CN、en、zh-TW），HTTP 层未传时取 Accept-Language；孩子资料中的设置优先。
+	Locale string `json:"locale,omitempty"`
+}
+
+type ScanResponse struct {
+	SessionID  string `json:"session_id"`
+	ObjectType string `json:"object_type"`
+	// ObjectName 为对象在本次请求语言下的展示名。
+	ObjectName string   `json:"object_name"`
+	Spirit     Spirit   `json:"spirit"`
+	Fact       string   `json:"fact"`
+	Quiz       string   `json:"quiz"`
+	Dialogues  []string `json:"dialogues"`
+	CacheHit   bool     `json:"cache_hit"`
+}
+
+type ScanImageRequest struct {
+	ChildID     string `json:"child_id"`
+	ChildAge    int    `json:"child_age"`
+	ImageBase64 string `json:"image_base64,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+type ScanImageResponse struct {
+	DetectedLabel   string `json:"detected_label"`
+	DetectedLabelEn string `json:"detected_label_en"`
+	RawLabel        string `json:"raw_label"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+type AnswerRequest struct {
+	SessionID string `json:"session_id"`
+	ChildID   string `json:"child_id"`
+	Answer    string `json:"answer"`
+	Locale    string `json:"locale,omitempty"`
+}
+
+type AnswerResponse struct {
+	Correct  bool            `json:"correct"`
+	Captured bool            `json:"captured"`
+	Message  string          `json:"message"`
+	Capture  *Capture        `json:"capture,omitempty"`
+	Progress *ProgressUpdate `json:"progress,omitempty"`
+	// NewBadges 为本次作答新点亮的勋章，客户端可据此展示点亮动画。
+	NewBadges []BadgeUnlock `json:"new_badges,omitempty"`
+	// CompletedQuests 为本次作答刚完成的每日任务，奖励需通过领取接口发放。
+	CompletedQuests []Quest `json:"completed_quests,omitempty"`
+	// SpiritEvolution 为本次收集后专属精灵的等级、阶段与新解锁内容。
+	SpiritEvolution *SpiritEvolution `json:"spirit_evolution,omitempty"`
+}
+
+type CompanionSceneRequest struct {
+	ChildID           string `json:"child_id"`
+	ChildAge          int    `json:"child_age"`
+	ObjectType        string `json:"object_type"`
+	Weather           string `json:"weather,omitempty"`
+	Environment       string `json:"environment,omitempty"`
+	ObjectTraits      string `json:"object_traits,omitempty"`
+	SourceImageBase64 string `json:"source_image_base64,omitempty"`
+	SourceImageURL    string `json:"source_image_url,omitempty"`
+	// SpiritID 可选；提供时生成的角色形象会保存到该精灵上，供分享卡片展示。
+	SpiritID string `json:"spirit_id,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+}
+
+type CompanionSceneResponse struct {
+	CharacterName        string `json:"character_name"`
+	CharacterPersonality string `json:"character_personality"`
+	DialogText           string `json:"dialog_text"`
+	ImagePrompt          string `json:"image_prompt"`
+	CharacterImageURL    string `json:"character_image_url"`
+	CharacterImageBase64 string `json:"character_image_base64,omitempty"`
+	CharacterImageMIME   string `json:"character_image_mime_type,omitempty"`
+	VoiceAudioBase64     string `json:"voice_audio_base64"`
+	VoiceMimeType        string `json:"voice_mime_type"`
+}
+
+type CompanionChatRequest struct {
+	ChildID              string   `json:"child_id"`
+	ChildAge             int      `json:"child_age"`
+	ObjectType           string   `json:"object_type"`
+	CharacterName        string   `json:"character_name"`
+	CharacterPersonality string   `json:"character_personality,omitempty"`
+	Weather              string   `json:"weather,omitempty"`
+	Environment          string   `json:"environment,omitempty"`
+	ObjectTraits         string   `json:"object_traits,omitempty"`
+	History              []string `json:"history,omitempty"`
+	ChildMessage         string   `json:"child_message"`
+	Locale               string   `json:"locale,omitempty"`
+}
+
+type CompanionChatResponse struct {
+	ReplyText        string          `json:"reply_text"`
+	VoiceAudioBase64 string          `json:"voice_audio_base64"`
+	VoiceMimeType    string          `json:"voice_mime_type"`
+	Progress         *ProgressUpdate `json:"progress,omitempty"`
+	CompletedQuests  []Quest         `json:"completed_quests,omitempty"`
+}
+
+type CompanionVoiceRequest struct {
+	ChildID    string `json:"child_id"`
+	ChildAge   int    `json:"child_age"`
+	ObjectType string `json:"object_type"`
+	Text       string `json:"text"`
+	Locale     string `json:"locale,omitempty"`
+}
+
+type CompanionVoiceResponse struct {
+	VoiceAudioBase64 string `json:"voice_audio_base64"`
+	VoiceMimeType    string `json:"voice_mime_type"`
+}
+
+type UploadImageResponse struct {
+	ImageURL string `json:"image_url"`
+}
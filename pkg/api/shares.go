@@ -0,0 +1,8 @@
+package api
+
+type SpiritShareRequest struct {
+	ChildID   string `json:"child_id"`
+	CaptureID string `json:"capture_id"`
+	// ExpiresInDays 为链接有效天数，默认 30 天。
+	ExpiresInDays int `json:"expires_in_days,omitempty"`
+}
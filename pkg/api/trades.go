@@ -0,0 +1,33 @@
+package api
+
+// 交换操作，用于 TradeActionRequest 对应的接受、拒绝与撤回接口。
+const (
+	TradeActionAccept  = "accept"
+	TradeActionDecline = "decline"
+	TradeActionCancel  = "cancel"
+)
+
+// TradeProposal 由 ChildID 发起：用自己的 CaptureID 换 RecipientID 的 RecipientCaptureID，双方须在同一群组。
+type TradeProposal struct {
+	GroupID            string `json:"group_id"`
+	ChildID            string `json:"child_id"`
+	CaptureID          string `json:"capture_id"`
+	RecipientID        string `json:"recipient_id"`
+	RecipientCaptureID string `json:"recipient_capture_id"`
+}
+
+type TradeActionRequest struct {
+	ChildID string `json:"child_id"`
+}
+
+// TradeDetail 附带双方交换的收集，收集中不包含位置。
+type TradeDetail struct {
+	Trade
+	Offered   *Capture `json:"offered,omitempty"`
+	Requested *Capture `json:"requested,omitempty"`
+}
+
+type TradeListResponse struct {
+	ChildID string        `json:"child_id"`
+	Trades  []TradeDetail `json:"trades"`
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// 管理接口需要 Config.AdminToken，未配置时服务端返回 ADMIN_UNAUTHORIZED 或 ADMIN_DISABLED。
+
+func (c *Client) AdminBadges(ctx context.Context) (BadgeRuleListResponse, error) {
+	var resp BadgeRuleListResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/admin/badges"}, &resp)
+	return resp, err
+}
+
+func (c *Client) AdminBadge(ctx context.Context, badgeID string) (BadgeRule, error) {
+	var resp BadgeRule
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/admin/badges/{id}", pathParams: []string{badgeID}}, &resp)
+	return resp, err
+}
+
+func (c *Client) AdminCreateBadge(ctx context.Context, rule BadgeRule) (BadgeRule, error) {
+	var resp BadgeRule
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/admin/badges", body: rule}, &resp)
+	return resp, err
+}
+
+func (c *Client) AdminUpdateBadge(ctx context.Context, badgeID string, rule BadgeRule) (BadgeRule, error) {
+	var resp BadgeRule
+	err := c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       "/api/v1/admin/badges/{id}",
+		pathParams: []string{badgeID},
+		body:       rule,
+	}, &resp)
+	return resp, err
+}
+
+func (c *Client) AdminDeleteBadge(ctx context.Context, badgeID string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/api/v1/admin/badges/{id}", pathParams: []string{badgeID}}, nil)
+}
+
+func (c *Client) AdminReloadBadges(ctx context.Context) (BadgeCatalogStatus, error) {
+	var resp BadgeCatalogStatus
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/admin/badges/reload"}, &resp)
+	return resp, err
+}
+
+func (c *Client) AdminDeliverySubscriptions(ctx context.Context) (DeliverySubscriptionListResponse, error) {
+	var resp DeliverySubscriptionListResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/admin/deliveries/subscriptions"}, &resp)
+	return resp, err
+}
+
+func (c *Client) AdminCreateDeliverySubscription(ctx context.Context, req DeliverySubscriptionRequest) (DeliverySubscription, error) {
+	var resp DeliverySubscription
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/admin/deliveries/subscriptions", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) AdminUpdateDeliverySubscription(ctx context.Context, subscriptionID string, req DeliverySubscriptionRequest) (DeliverySubscription, error) {
+	var resp DeliverySubscription
+	err := c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       "/api/v1/admin/deliveries/subscriptions/{id}",
+		pathParams: []string{subscriptionID},
+		body:       req,
+	}, &resp)
+	return resp, err
+}
+
+func (c *Client) AdminDeleteDeliverySubscription(ctx context.Context, subscriptionID string) error {
+	return c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       "/api/v1/admin/deliveries/subscriptions/{id}",
+		pathParams: []string{subscriptionID},
+	}, nil)
+}
+
+// AdminSendDelivery 立即推送订阅当天的日报，推送结果见返回记录的 Status。
+func (c *Client) AdminSendDelivery(ctx context.Context, subscriptionID string) (DeliveryAttempt, error) {
+	var resp DeliveryAttempt
+	err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/api/v1/admin/deliveries/subscriptions/{id}/send",
+		pathParams: []string{subscriptionID},
+	}, &resp)
+	return resp, err
+}
+
+// AdminDeliveryAttempts 查询投递记录，参数为空或 limit <= 0 时不过滤、使用服务端默认条数。
+func (c *Client) AdminDeliveryAttempts(ctx context.Context, subscriptionID string, status string, limit int) (DeliveryAttemptListResponse, error) {
+	values := url.Values{}
+	if subscriptionID != "" {
+		values.Set("subscription_id", subscriptionID)
+	}
+	if status != "" {
+		values.Set("status", status)
+	}
+	setLimit(values, limit)
+	var resp DeliveryAttemptListResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/admin/deliveries/attempts", query: values}, &resp)
+	return resp, err
+}
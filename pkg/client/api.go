@@ -0,0 +1,434 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Health 调用 /healthz。
+func (c *Client) Health(ctx context.Context) (HealthResponse, error) {
+	var resp HealthResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/healthz"}, &resp)
+	return resp, err
+}
+
+func (c *Client) Scan(ctx context.Context, req ScanRequest) (ScanResponse, error) {
+	var resp ScanResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/scan", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) ScanImage(ctx context.Context, req ScanImageRequest) (ScanImageResponse, error) {
+	var resp ScanImageResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/scan/image", body: req}, &resp)
+	return resp, err
+}
+
+// UploadImage 以 multipart 表单上传图片，返回可用于 ScanRequest.ImageURL 的公网地址。
+func (c *Client) UploadImage(ctx context.Context, fileName string, image io.Reader) (UploadImageResponse, error) {
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return UploadImageResponse{}, fmt.Errorf("cityling: build upload form: %w", err)
+	}
+	if _, err := io.Copy(part, image); err != nil {
+		return UploadImageResponse{}, fmt.Errorf("cityling: read upload image: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return UploadImageResponse{}, fmt.Errorf("cityling: build upload form: %w", err)
+	}
+	var resp UploadImageResponse
+	err = c.do(ctx, request{
+		method:      http.MethodPost,
+		path:        "/api/v1/media/upload",
+		body:        form.Bytes(),
+		contentType: writer.FormDataContentType(),
+	}, &resp)
+	return resp, err
+}
+
+func (c *Client) Answer(ctx context.Context, req AnswerRequest) (AnswerResponse, error) {
+	var resp AnswerResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/answer", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) CompanionScene(ctx context.Context, req CompanionSceneRequest) (CompanionSceneResponse, error) {
+	var resp CompanionSceneResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/companion/scene", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) CompanionChat(ctx context.Context, req CompanionChatRequest) (CompanionChatResponse, error) {
+	var resp CompanionChatResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/companion/chat", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) CompanionVoice(ctx context.Context, req CompanionVoiceRequest) (CompanionVoiceResponse, error) {
+	var resp CompanionVoiceResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/companion/voice", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) Pokedex(ctx context.Context, childID string) (PokedexResponse, error) {
+	var resp PokedexResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/pokedex", query: childQuery(childID)}, &resp)
+	return resp, err
+}
+
+func (c *Client) PokedexBadges(ctx context.Context, childID string) (PokedexBadgeResponse, error) {
+	var resp PokedexBadgeResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/pokedex/badges", query: childQuery(childID)}, &resp)
+	return resp, err
+}
+
+func (c *Client) UnseenBadgeUnlocks(ctx context.Context, childID string) (BadgeUnlockListResponse, error) {
+	var resp BadgeUnlockListResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/pokedex/badges/unseen", query: childQuery(childID)}, &resp)
+	return resp, err
+}
+
+func (c *Client) MarkBadgeUnlocksSeen(ctx context.Context, req BadgeSeenRequest) (BadgeSeenResponse, error) {
+	var resp BadgeSeenResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/pokedex/badges/seen", body: req}, &resp)
+	return resp, err
+}
+
+// DailyReportQuery 的 Date 为零值时查询今天；Locale 为空时跟随 Config.AcceptLanguage。
+type DailyReportQuery struct {
+	ChildID string
+	Date    time.Time
+	Locale  string
+}
+
+func (q DailyReportQuery) values() url.Values {
+	values := childQuery(q.ChildID)
+	if !q.Date.IsZero() {
+		values.Set("date", q.Date.Format(dateLayout))
+	}
+	if q.Locale != "" {
+		values.Set("locale", q.Locale)
+	}
+	return values
+}
+
+func (c *Client) DailyReport(ctx context.Context, query DailyReportQuery) (DailyReport, error) {
+	var resp DailyReport
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/report/daily", query: query.values()}, &resp)
+	return resp, err
+}
+
+// DailyReportDocument 返回可直接分享的日报文件，format 为 html 或 pdf。
+func (c *Client) DailyReportDocument(ctx context.Context, query DailyReportQuery, format string) ([]byte, error) {
+	accept := ""
+	switch format {
+	case "html":
+		accept = "text/html"
+	case "pdf":
+		accept = "application/pdf"
+	default:
+		return nil, fmt.Errorf("cityling: unsupported report format %q", format)
+	}
+	values := query.values()
+	values.Set("format", format)
+	return c.send(ctx, request{method: http.MethodGet, path: "/api/v1/report/daily", query: values, accept: accept})
+}
+
+// WeeklyReport 查询 date 所在周的周报，date 为零值时查询本周。
+func (c *Client) WeeklyReport(ctx context.Context, childID string, date time.Time) (PeriodReport, error) {
+	return c.periodReport(ctx, "/api/v1/report/weekly", childID, date)
+}
+
+// MonthlyReport 查询 date 所在月的月报，date 为零值时查询本月。
+func (c *Client) MonthlyReport(ctx context.Context, childID string, date time.Time) (PeriodReport, error) {
+	return c.periodReport(ctx, "/api/v1/report/monthly", childID, date)
+}
+
+func (c *Client) periodReport(ctx context.Context, path string, childID string, date time.Time) (PeriodReport, error) {
+	values := childQuery(childID)
+	if !date.IsZero() {
+		values.Set("date", date.Format(dateLayout))
+	}
+	var resp PeriodReport
+	err := c.do(ctx, request{method: http.MethodGet, path: path, query: values}, &resp)
+	return resp, err
+}
+
+// DueReviews 查询到期的复习题，limit <= 0 时使用服务端默认值。
+func (c *Client) DueReviews(ctx context.Context, childID string, limit int) (ReviewDueResponse, error) {
+	values := childQuery(childID)
+	setLimit(values, limit)
+	var resp ReviewDueResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/review/due", query: values}, &resp)
+	return resp, err
+}
+
+func (c *Client) SubmitReview(ctx context.Context, req ReviewAnswerRequest) (ReviewAnswerResponse, error) {
+	var resp ReviewAnswerResponse
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/review/answer", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) Progress(ctx context.Context, childID string) (ProgressProfile, error) {
+	var resp ProgressProfile
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/progress", query: childQuery(childID)}, &resp)
+	return resp, err
+}
+
+func (c *Client) Profile(ctx context.Context, childID string) (ChildProfile, error) {
+	var resp ChildProfile
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/profile", query: childQuery(childID)}, &resp)
+	return resp, err
+}
+
+func (c *Client) UpdateProfile(ctx context.Context, req ProfileRequest) (ChildProfile, error) {
+	var resp ChildProfile
+	err := c.do(ctx, request{method: http.MethodPut, path: "/api/v1/profile", body: req}, &resp)
+	return resp, err
+}
+
+// CaptureMap 查询探索地图：设置 BBox 或 Center+RadiusM 之一；Zoom 为 0 时使用服务端默认值。
+func (c *Client) CaptureMap(ctx context.Context, childID string, query MapQuery) (GeoJSONFeatureCollection, error) {
+	values := childQuery(childID)
+	if len(query.BBox) > 0 {
+		parts := make([]string, len(query.BBox))
+		for i, v := range query.BBox {
+			parts[i] = formatFloat(v)
+		}
+		values.Set("bbox", strings.Join(parts, ","))
+	}
+	if query.Center != nil {
+		values.Set("lat", formatFloat(query.Center.Latitude))
+		values.Set("lng", formatFloat(query.Center.Longitude))
+		values.Set("radius_m", formatFloat(query.RadiusM))
+	}
+	if query.Cluster {
+		values.Set("cluster", "true")
+	}
+	if query.Zoom > 0 {
+		values.Set("zoom", strconv.Itoa(query.Zoom))
+	}
+	var resp GeoJSONFeatureCollection
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/v1/map/captures",
+		query:  values,
+		accept: "application/geo+json",
+	}, &resp)
+	return resp, err
+}
+
+// NearbyHints 查询附近可以找的对象，limit <= 0 时使用服务端默认值。
+func (c *Client) NearbyHints(ctx context.Context, childID string, lat float64, lng float64, limit int) (NearbyHints, error) {
+	values := childQuery(childID)
+	values.Set("lat", formatFloat(lat))
+	values.Set("lng", formatFloat(lng))
+	setLimit(values, limit)
+	var resp NearbyHints
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/hints/nearby", query: values}, &resp)
+	return resp, err
+}
+
+func (c *Client) Quests(ctx context.Context, childID string) (QuestBoard, error) {
+	var resp QuestBoard
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/quests", query: childQuery(childID)}, &resp)
+	return resp, err
+}
+
+// ClaimQuest 领取任务奖励；childID 非空时服务端会校验任务归属。
+func (c *Client) ClaimQuest(ctx context.Context, questID string, childID string) (QuestClaimResponse, error) {
+	var resp QuestClaimResponse
+	err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/api/v1/quests/{id}/claim",
+		pathParams: []string{questID},
+		body:       QuestClaimRequest{ChildID: childID},
+	}, &resp)
+	return resp, err
+}
+
+func (c *Client) Groups(ctx context.Context, viewer GroupViewer) (GroupListResponse, error) {
+	var resp GroupListResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/groups", query: viewerQuery(viewer)}, &resp)
+	return resp, err
+}
+
+func (c *Client) CreateGroup(ctx context.Context, req GroupRequest) (Group, error) {
+	var resp Group
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/groups", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) JoinGroup(ctx context.Context, req GroupJoinRequest) (GroupDetail, error) {
+	var resp GroupDetail
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/groups/join", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) Group(ctx context.Context, groupID string, viewer GroupViewer) (GroupDetail, error) {
+	var resp GroupDetail
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/groups/{id}",
+		pathParams: []string{groupID},
+		query:      viewerQuery(viewer),
+	}, &resp)
+	return resp, err
+}
+
+func (c *Client) UpdateGroup(ctx context.Context, groupID string, req GroupRequest) (Group, error) {
+	var resp Group
+	err := c.do(ctx, request{
+		method:     http.MethodPut,
+		path:       "/api/v1/groups/{id}",
+		pathParams: []string{groupID},
+		body:       req,
+	}, &resp)
+	return resp, err
+}
+
+func (c *Client) RegenerateInviteCode(ctx context.Context, groupID string, ownerID string) (Group, error) {
+	var resp Group
+	err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/api/v1/groups/{id}/invite-code",
+		pathParams: []string{groupID},
+		body:       InviteCodeRequest{OwnerID: ownerID},
+	}, &resp)
+	return resp, err
+}
+
+// RemoveGroupMember 由孩子本人（viewer.ChildID）退出，或由群主（viewer.OwnerID）移除成员。
+func (c *Client) RemoveGroupMember(ctx context.Context, groupID string, memberID string, viewer GroupViewer) error {
+	return c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       "/api/v1/groups/{id}/members/{child_id}",
+		pathParams: []string{groupID, memberID},
+		query:      viewerQuery(viewer),
+	}, nil)
+}
+
+// LeaderboardQuery 的字段为空时使用服务端默认值（captures、7d）。
+type LeaderboardQuery struct {
+	Metric string
+	Window string
+}
+
+func (c *Client) GroupLeaderboard(ctx context.Context, groupID string, query LeaderboardQuery, viewer GroupViewer) (GroupLeaderboard, error) {
+	values := viewerQuery(viewer)
+	if query.Metric != "" {
+		values.Set("metric", query.Metric)
+	}
+	if query.Window != "" {
+		values.Set("window", query.Window)
+	}
+	var resp GroupLeaderboard
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/groups/{id}/leaderboard",
+		pathParams: []string{groupID},
+		query:      values,
+	}, &resp)
+	return resp, err
+}
+
+func (c *Client) GroupFeed(ctx context.Context, groupID string, limit int, viewer GroupViewer) (GroupFeed, error) {
+	values := viewerQuery(viewer)
+	setLimit(values, limit)
+	var resp GroupFeed
+	err := c.do(ctx, request{
+		method:     http.MethodGet,
+		path:       "/api/v1/groups/{id}/feed",
+		pathParams: []string{groupID},
+		query:      values,
+	}, &resp)
+	return resp, err
+}
+
+func (c *Client) CreateSpiritShare(ctx context.Context, req SpiritShareRequest) (SpiritShare, error) {
+	var resp SpiritShare
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/shares", body: req}, &resp)
+	return resp, err
+}
+
+func (c *Client) SpiritCard(ctx context.Context, token string) (SpiritCard, error) {
+	var resp SpiritCard
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/shares/{token}", pathParams: []string{token}}, &resp)
+	return resp, err
+}
+
+func (c *Client) RevokeSpiritShare(ctx context.Context, token string, childID string) error {
+	return c.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       "/api/v1/shares/{token}",
+		pathParams: []string{token},
+		query:      childQuery(childID),
+	}, nil)
+}
+
+// Trades 查看孩子发起或收到的交换，status 为空时返回全部。
+func (c *Client) Trades(ctx context.Context, childID string, status string) (TradeListResponse, error) {
+	values := childQuery(childID)
+	if status != "" {
+		values.Set("status", status)
+	}
+	var resp TradeListResponse
+	err := c.do(ctx, request{method: http.MethodGet, path: "/api/v1/trades", query: values}, &resp)
+	return resp, err
+}
+
+func (c *Client) ProposeTrade(ctx context.Context, req TradeProposal) (TradeDetail, error) {
+	var resp TradeDetail
+	err := c.do(ctx, request{method: http.MethodPost, path: "/api/v1/trades", body: req}, &resp)
+	return resp, err
+}
+
+// RespondTrade 的 action 为 TradeActionAccept、TradeActionDecline 或 TradeActionCancel。
+func (c *Client) RespondTrade(ctx context.Context, tradeID string, action string, childID string) (TradeDetail, error) {
+	var resp TradeDetail
+	err := c.do(ctx, request{
+		method:     http.MethodPost,
+		path:       "/api/v1/trades/{id}/{action}",
+		pathParams: []string{tradeID, action},
+		body:       TradeActionRequest{ChildID: childID},
+	}, &resp)
+	return resp, err
+}
+
+func childQuery(childID string) url.Values {
+	values := url.Values{}
+	if childID != "" {
+		values.Set("child_id", childID)
+	}
+	return values
+}
+
+func viewerQuery(viewer GroupViewer) url.Values {
+	values := childQuery(viewer.ChildID)
+	if viewer.OwnerID != "" {
+		values.Set("owner_id", viewer.OwnerID)
+	}
+	return values
+}
+
+func setLimit(values url.Values, limit int) {
+	if limit > 0 {
+		values.Set("limit", strconv.Itoa(limit))
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,323 @@
+// Package client 是 City Ling API 的 Go SDK，供压测工具、管理脚本与小程序 BFF 调用 /api/v1。
+//
+// 请求与响应类型是 pkg/api 的别名（见 types.go），与服务端共用同一份定义，不需要各自重复声明；
+// pkg/api 只依赖标准库，引入 SDK 不会链接服务端的存储与追踪依赖。
+// 所有方法都接收 context；GET/PUT/DELETE 在网络错误、429 与 502/503/504 时按 Config 重试，
+// POST 不自动重试，避免重复扫描或重复发起交换。非 2xx 响应解析为 *Error，可按 Code 分支。
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultMaxRetries   = 2
+	defaultRetryBackoff = 200 * time.Millisecond
+	maxRetryBackoff     = 5 * time.Second
+)
+
+type Config struct {
+	// BaseURL 为服务地址，如 http://localhost:8080。
+	BaseURL string
+	// AdminToken 为 CITYLING_ADMIN_TOKEN，设置后每个请求都带上 Authorization: Bearer。
+	AdminToken string
+	// AcceptLanguage 为默认的 Accept-Language，决定错误文案与生成内容的语言。
+	AcceptLanguage string
+	UserAgent      string
+	// HTTPClient 为空时使用 Timeout 构造的默认客户端。
+	HTTPClient *http.Client
+	Timeout    time.Duration
+	// MaxRetries 为幂等请求的最大重试次数，0 使用默认值 2，负数表示不重试。
+	MaxRetries int
+	// RetryBackoff 为首次重试前的等待时间，之后逐次翻倍；服务端返回 Retry-After 时以其为准。
+	RetryBackoff time.Duration
+}
+
+type Client struct {
+	baseURL        *url.URL
+	adminToken     string
+	acceptLanguage string
+	userAgent      string
+	httpClient     *http.Client
+	maxRetries     int
+	retryBackoff   time.Duration
+}
+
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("client: invalid base url %q", cfg.BaseURL)
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		httpClient = &http.Client{Timeout: timeout}
+	}
+	maxRetries := cfg.MaxRetries
+	switch {
+	case maxRetries == 0:
+		maxRetries = defaultMaxRetries
+	case maxRetries < 0:
+		maxRetries = 0
+	}
+	backoff := cfg.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	userAgent := strings.TrimSpace(cfg.UserAgent)
+	if userAgent == "" {
+		userAgent = "cityling-go-client"
+	}
+	return &Client{
+		baseURL:        base,
+		adminToken:     strings.TrimSpace(cfg.AdminToken),
+		acceptLanguage: strings.TrimSpace(cfg.AcceptLanguage),
+		userAgent:      userAgent,
+		httpClient:     httpClient,
+		maxRetries:     maxRetries,
+		retryBackoff:   backoff,
+	}, nil
+}
+
+// Error 是服务端的结构化错误响应。Code 稳定，可用于分支；Message 已按 Accept-Language 本地化。
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	Details    map[string]any
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("cityling: http %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("cityling: %s (http %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// ErrorCode 返回 err 中服务端错误的 Code，不是服务端错误时返回空字符串。
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// request 描述一次调用；path 里的 {name} 由 pathParams 按顺序替换并转义。
+type request struct {
+	method      string
+	path        string
+	pathParams  []string
+	query       url.Values
+	body        any
+	contentType string
+	accept      string
+}
+
+// do 发送请求并把 2xx 响应体解码到 out（out 为 nil 时丢弃），其他状态返回 *Error。
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	raw, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("cityling: decode %s %s response: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+func (c *Client) send(ctx context.Context, req request) ([]byte, error) {
+	target, err := c.resolve(req)
+	if err != nil {
+		return nil, err
+	}
+	var payload []byte
+	contentType := req.contentType
+	switch body := req.body.(type) {
+	case nil:
+	case []byte:
+		payload = body
+	default:
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("cityling: encode %s %s request: %w", req.method, req.path, err)
+		}
+		contentType = "application/json"
+	}
+
+	attempts := 1
+	if idempotent(req.method) {
+		attempts += c.maxRetries
+	}
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoff(attempt, lastErr)); err != nil {
+				return nil, err
+			}
+		}
+		var body io.Reader
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+		if err != nil {
+			return nil, fmt.Errorf("cityling: build request: %w", err)
+		}
+		c.setHeaders(httpReq, contentType, req.accept)
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		raw, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			lastErr = readErr
+			continue
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return raw, nil
+		}
+		apiErr := decodeError(resp, raw)
+		if !retryable(resp.StatusCode) {
+			return nil, apiErr
+		}
+		lastErr = &retryError{err: apiErr, retryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+	}
+	var wrapped *retryError
+	if errors.As(lastErr, &wrapped) {
+		return nil, wrapped.err
+	}
+	return nil, fmt.Errorf("cityling: %s %s: %w", req.method, req.path, lastErr)
+}
+
+func (c *Client) resolve(req request) (string, error) {
+	path := req.path
+	for _, value := range req.pathParams {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			return "", fmt.Errorf("cityling: too many path params for %s", req.path)
+		}
+		if strings.TrimSpace(value) == "" {
+			return "", fmt.Errorf("cityling: empty path param %s for %s", path[start:end+1], req.path)
+		}
+		path = path[:start] + url.PathEscape(value) + path[end+1:]
+	}
+	if strings.Contains(path, "{") {
+		return "", fmt.Errorf("cityling: missing path params for %s", req.path)
+	}
+	target := *c.baseURL
+	target.Path = strings.TrimRight(target.Path, "/") + path
+	target.RawPath = ""
+	if len(req.query) > 0 {
+		target.RawQuery = req.query.Encode()
+	}
+	return target.String(), nil
+}
+
+func (c *Client) setHeaders(req *http.Request, contentType string, accept string) {
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if accept == "" {
+		accept = "application/json"
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.acceptLanguage != "" {
+		req.Header.Set("Accept-Language", c.acceptLanguage)
+	}
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+}
+
+func (c *Client) backoff(attempt int, lastErr error) time.Duration {
+	var wrapped *retryError
+	if errors.As(lastErr, &wrapped) && wrapped.retryAfter > 0 {
+		return min(wrapped.retryAfter, maxRetryBackoff)
+	}
+	return min(c.retryBackoff<<(attempt-1), maxRetryBackoff)
+}
+
+// retryError 记录可重试的服务端错误，重试用尽后返回其中的 *Error。
+type retryError struct {
+	err        *Error
+	retryAfter time.Duration
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+
+func decodeError(resp *http.Response, raw []byte) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var body ErrorResponse
+	if err := json.Unmarshal(raw, &body); err == nil && body.Code != "" {
+		apiErr.Code = body.Code
+		apiErr.Message = body.Error
+		apiErr.Details = body.Details
+		return apiErr
+	}
+	apiErr.Message = strings.TrimSpace(string(raw))
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter 只支持秒数形式的 Retry-After。
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
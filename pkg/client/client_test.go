@@ -0,0 +1,322 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ling/internal/httpapi"
+	"ling/internal/knowledge"
+	"ling/internal/service"
+	"ling/internal/store"
+	"ling/pkg/client"
+)
+
+func newTestServer(t *testing.T, record func(r *http.Request)) *httptest.Server {
+	t.Helper()
+	st, err := store.NewJSONStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewJSONStore() error = %v", err)
+	}
+	handler := httpapi.NewHandler(service.New(st, knowledge.BaseKnowledge))
+	handler.SetAdminToken("secret")
+	router := httpapi.NewRouter(handler)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if record != nil {
+			record(r)
+		}
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(t *testing.T, cfg client.Config) *client.Client {
+	t.Helper()
+	c, err := client.New(cfg)
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+	return c
+}
+
+func TestClientCoversEveryDocumentedRoute(t *testing.T) {
+	var mu sync.Mutex
+	var requests []*http.Request
+	server := newTestServer(t, func(r *http.Request) {
+		mu.Lock()
+		requests = append(requests, httptest.NewRequest(r.Method, r.URL.Path, nil))
+		mu.Unlock()
+	})
+	c := newTestClient(t, client.Config{BaseURL: server.URL, AdminToken: "secret", MaxRetries: -1})
+	ctx := context.Background()
+	viewer := client.GroupViewer{ChildID: "kid_1"}
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+
+	// 逐个调用 SDK 方法；这里只关心请求是否命中路由，业务错误（如 404）可以忽略，但不能是网络或解码错误。
+	calls := []func() error{
+		func() error { _, err := c.Health(ctx); return err },
+		func() error {
+			_, err := c.Scan(ctx, client.ScanRequest{ChildID: "kid_1", ChildAge: 8, DetectedLabel: "路灯"})
+			return err
+		},
+		func() error {
+			_, err := c.ScanImage(ctx, client.ScanImageRequest{ChildID: "kid_1", ChildAge: 8})
+			return err
+		},
+		func() error { _, err := c.UploadImage(ctx, "photo.jpg", strings.NewReader("jpeg")); return err },
+		func() error {
+			_, err := c.CompanionScene(ctx, client.CompanionSceneRequest{ChildID: "kid_1", ChildAge: 8})
+			return err
+		},
+		func() error {
+			_, err := c.CompanionChat(ctx, client.CompanionChatRequest{ChildID: "kid_1", ChildAge: 8})
+			return err
+		},
+		func() error {
+			_, err := c.CompanionVoice(ctx, client.CompanionVoiceRequest{ChildID: "kid_1", ChildAge: 8})
+			return err
+		},
+		func() error {
+			_, err := c.Answer(ctx, client.AnswerRequest{SessionID: "ses_x", ChildID: "kid_1", Answer: "晚上"})
+			return err
+		},
+		func() error { _, err := c.Pokedex(ctx, "kid_1"); return err },
+		func() error { _, err := c.PokedexBadges(ctx, "kid_1"); return err },
+		func() error { _, err := c.UnseenBadgeUnlocks(ctx, "kid_1"); return err },
+		func() error {
+			_, err := c.MarkBadgeUnlocksSeen(ctx, client.BadgeSeenRequest{ChildID: "kid_1"})
+			return err
+		},
+		func() error {
+			_, err := c.DailyReport(ctx, client.DailyReportQuery{ChildID: "kid_1", Date: day})
+			return err
+		},
+		func() error { _, err := c.WeeklyReport(ctx, "kid_1", day); return err },
+		func() error { _, err := c.MonthlyReport(ctx, "kid_1", time.Time{}); return err },
+		func() error { _, err := c.DueReviews(ctx, "kid_1", 5); return err },
+		func() error {
+			_, err := c.SubmitReview(ctx, client.ReviewAnswerRequest{ReviewID: "rev_x", ChildID: "kid_1", Answer: "a"})
+			return err
+		},
+		func() error { _, err := c.Progress(ctx, "kid_1"); return err },
+		func() error { _, err := c.Profile(ctx, "kid_1"); return err },
+		func() error {
+			_, err := c.UpdateProfile(ctx, client.ProfileRequest{ChildID: "kid_1", Locale: "en"})
+			return err
+		},
+		func() error {
+			_, err := c.CaptureMap(ctx, "kid_1", client.MapQuery{BBox: []float64{116, 39, 117, 40}, Cluster: true, Zoom: 10})
+			return err
+		},
+		func() error { _, err := c.NearbyHints(ctx, "kid_1", 39.9, 116.4, 3); return err },
+		func() error { _, err := c.Quests(ctx, "kid_1"); return err },
+		func() error { _, err := c.ClaimQuest(ctx, "quest_x", "kid_1"); return err },
+		func() error { _, err := c.Groups(ctx, viewer); return err },
+		func() error {
+			_, err := c.CreateGroup(ctx, client.GroupRequest{Name: "三年二班", Kind: "classroom", OwnerID: "teacher_1"})
+			return err
+		},
+		func() error {
+			_, err := c.JoinGroup(ctx, client.GroupJoinRequest{InviteCode: "NOPE0000", ChildID: "kid_1"})
+			return err
+		},
+		func() error { _, err := c.Group(ctx, "grp_x", viewer); return err },
+		func() error {
+			_, err := c.UpdateGroup(ctx, "grp_x", client.GroupRequest{Name: "x", OwnerID: "teacher_1"})
+			return err
+		},
+		func() error { _, err := c.RegenerateInviteCode(ctx, "grp_x", "teacher_1"); return err },
+		func() error {
+			return c.RemoveGroupMember(ctx, "grp_x", "kid_2", client.GroupViewer{OwnerID: "teacher_1"})
+		},
+		func() error {
+			_, err := c.GroupLeaderboard(ctx, "grp_x", client.LeaderboardQuery{Metric: "captures", Window: "7d"}, viewer)
+			return err
+		},
+		func() error { _, err := c.GroupFeed(ctx, "grp_x", 10, viewer); return err },
+		func() error {
+			_, err := c.CreateSpiritShare(ctx, client.SpiritShareRequest{ChildID: "kid_1", CaptureID: "cap_x"})
+			return err
+		},
+		func() error { _, err := c.SpiritCard(ctx, "token_x"); return err },
+		func() error { return c.RevokeSpiritShare(ctx, "token_x", "kid_1") },
+		func() error { _, err := c.Trades(ctx, "kid_1", ""); return err },
+		func() error {
+			_, err := c.ProposeTrade(ctx, client.TradeProposal{GroupID: "grp_x", ChildID: "kid_1", CaptureID: "cap_x", RecipientID: "kid_2", RecipientCaptureID: "cap_y"})
+			return err
+		},
+		func() error { _, err := c.RespondTrade(ctx, "trade_x", client.TradeActionAccept, "kid_2"); return err },
+		func() error { _, err := c.AdminBadges(ctx); return err },
+		func() error { _, err := c.AdminBadge(ctx, "badge_x"); return err },
+		func() error { _, err := c.AdminCreateBadge(ctx, client.BadgeRule{ID: "badge_x"}); return err },
+		func() error {
+			_, err := c.AdminUpdateBadge(ctx, "badge_x", client.BadgeRule{ID: "badge_x"})
+			return err
+		},
+		func() error { return c.AdminDeleteBadge(ctx, "badge_x") },
+		func() error { _, err := c.AdminReloadBadges(ctx); return err },
+		func() error { _, err := c.AdminDeliverySubscriptions(ctx); return err },
+		func() error {
+			_, err := c.AdminCreateDeliverySubscription(ctx, client.DeliverySubscriptionRequest{ChildID: "kid_1", Channel: "email"})
+			return err
+		},
+		func() error {
+			_, err := c.AdminUpdateDeliverySubscription(ctx, "sub_x", client.DeliverySubscriptionRequest{ChildID: "kid_1"})
+			return err
+		},
+		func() error { return c.AdminDeleteDeliverySubscription(ctx, "sub_x") },
+		func() error { _, err := c.AdminSendDelivery(ctx, "sub_x"); return err },
+		func() error { _, err := c.AdminDeliveryAttempts(ctx, "sub_x", "failed", 10); return err },
+	}
+	for i, call := range calls {
+		if err := call(); err != nil {
+			var apiErr *client.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("call %d returned a non-API error: %v", i, err)
+			}
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/docs/openapi.json")
+	if err != nil {
+		t.Fatalf("fetch spec error = %v", err)
+	}
+	defer resp.Body.Close()
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("decode spec error = %v", err)
+	}
+	// 按文档中的路径模式重新匹配记录下的请求，判断每个接口是否被调用到。
+	mux := http.NewServeMux()
+	for path, item := range spec.Paths {
+		for method := range item {
+			mux.HandleFunc(strings.ToUpper(method)+" "+path, func(http.ResponseWriter, *http.Request) {})
+		}
+	}
+	hit := make(map[string]bool)
+	for _, r := range requests {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			hit[pattern] = true
+		}
+	}
+	for path, item := range spec.Paths {
+		for method := range item {
+			pattern := strings.ToUpper(method) + " " + path
+			if !hit[pattern] {
+				t.Errorf("documented route %s has no client method exercised by this test", pattern)
+			}
+		}
+	}
+}
+
+func TestClientRoundTripsSharedTypes(t *testing.T) {
+	server := newTestServer(t, nil)
+	c := newTestClient(t, client.Config{BaseURL: server.URL})
+	ctx := context.Background()
+
+	scan, err := c.Scan(ctx, client.ScanRequest{ChildID: "kid_1", ChildAge: 8, DetectedLabel: "路灯"})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if scan.SessionID == "" || scan.ObjectType == "" {
+		t.Fatalf("expected scan session, got %+v", scan)
+	}
+	group, err := c.CreateGroup(ctx, client.GroupRequest{Name: "小区探险队", Kind: "family", OwnerID: "parent_1"})
+	if err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+	detail, err := c.JoinGroup(ctx, client.GroupJoinRequest{InviteCode: group.InviteCode, ChildID: "kid_1", DisplayName: "小明", ChildAge: 8})
+	if err != nil {
+		t.Fatalf("JoinGroup() error = %v", err)
+	}
+	if detail.ID != group.ID || len(detail.Members) != 1 {
+		t.Fatalf("expected joined group with one member, got %+v", detail)
+	}
+	groups, err := c.Groups(ctx, client.GroupViewer{ChildID: "kid_1"})
+	if err != nil {
+		t.Fatalf("Groups() error = %v", err)
+	}
+	if len(groups.Groups) != 1 || groups.Groups[0].Name != "小区探险队" {
+		t.Fatalf("expected one group, got %+v", groups)
+	}
+}
+
+func TestClientDecodesStructuredErrors(t *testing.T) {
+	server := newTestServer(t, nil)
+	ctx := context.Background()
+
+	c := newTestClient(t, client.Config{BaseURL: server.URL, AcceptLanguage: "en"})
+	_, err := c.Scan(ctx, client.ScanRequest{ChildID: "kid_1", ChildAge: 1, DetectedLabel: "路灯"})
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *client.Error, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != service.CodeChildAgeInvalid {
+		t.Fatalf("expected 400 %s, got %+v", service.CodeChildAgeInvalid, apiErr)
+	}
+	if apiErr.Details["min"] == nil || strings.ContainsAny(apiErr.Message, "必须") {
+		t.Fatalf("expected english message with details, got %+v", apiErr)
+	}
+
+	if _, err := c.AdminBadges(ctx); client.ErrorCode(err) != service.CodeAdminUnauthorized {
+		t.Fatalf("expected %s without token, got %v", service.CodeAdminUnauthorized, err)
+	}
+	admin := newTestClient(t, client.Config{BaseURL: server.URL, AdminToken: "secret"})
+	if _, err := admin.AdminBadges(ctx); err != nil {
+		t.Fatalf("AdminBadges() with token error = %v", err)
+	}
+}
+
+func TestClientRetriesIdempotentRequests(t *testing.T) {
+	var gets, posts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			posts.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"busy","code":"LLM_UNAVAILABLE"}`))
+			return
+		}
+		if gets.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"busy","code":"INTERNAL"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(client.ChildProfile{ChildID: "kid_1", Locale: "en"})
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, client.Config{BaseURL: server.URL, AdminToken: "secret", RetryBackoff: time.Millisecond})
+	profile, err := c.Profile(context.Background(), "kid_1")
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	if profile.Locale != "en" || gets.Load() != 3 {
+		t.Fatalf("expected success on third attempt, got %+v after %d attempts", profile, gets.Load())
+	}
+
+	_, err = c.Scan(context.Background(), client.ScanRequest{ChildID: "kid_1", ChildAge: 8})
+	if client.ErrorCode(err) != service.CodeLLMUnavailable || posts.Load() != 1 {
+		t.Fatalf("expected a single POST attempt with %s, got %v after %d attempts", service.CodeLLMUnavailable, err, posts.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Profile(ctx, "kid_1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import "ling/pkg/api"
+
+// 以下类型都是 pkg/api 的别名，服务端使用同一份定义：服务端新增字段时 SDK 自动跟上，调用方也不必再声明一份请求结构。
+
+// 扫描、作答与角色剧情。
+type (
+	ScanRequest            = api.ScanRequest
+	ScanResponse           = api.ScanResponse
+	ScanImageRequest       = api.ScanImageRequest
+	ScanImageResponse      = api.ScanImageResponse
+	UploadImageResponse    = api.UploadImageResponse
+	AnswerRequest          = api.AnswerRequest
+	AnswerResponse         = api.AnswerResponse
+	CompanionSceneRequest  = api.CompanionSceneRequest
+	CompanionSceneResponse = api.CompanionSceneResponse
+	CompanionChatRequest   = api.CompanionChatRequest
+	CompanionChatResponse  = api.CompanionChatResponse
+	CompanionVoiceRequest  = api.CompanionVoiceRequest
+	CompanionVoiceResponse = api.CompanionVoiceResponse
+)
+
+// 图鉴、勋章、复习、成长与任务。
+type (
+	PokedexResponse         = api.PokedexResponse
+	PokedexBadgeResponse    = api.PokedexBadgeResponse
+	BadgeUnlockListResponse = api.BadgeUnlockListResponse
+	BadgeSeenRequest        = api.BadgeSeenRequest
+	BadgeSeenResponse       = api.BadgeSeenResponse
+	ReviewDueResponse       = api.ReviewDueResponse
+	ReviewAnswerRequest     = api.ReviewAnswerRequest
+	ReviewAnswerResponse    = api.ReviewAnswerResponse
+	ProgressProfile         = api.ProgressProfile
+	QuestBoard              = api.QuestBoard
+	QuestClaimRequest       = api.QuestClaimRequest
+	QuestClaimResponse      = api.QuestClaimResponse
+	ProfileRequest          = api.ProfileRequest
+	ChildProfile            = api.ChildProfile
+)
+
+// 报告、地图与附近提示。
+type (
+	DailyReport              = api.DailyReport
+	PeriodReport             = api.PeriodReport
+	MapQuery                 = api.MapQuery
+	GeoPoint                 = api.GeoPoint
+	GeoJSONFeatureCollection = api.GeoJSONFeatureCollection
+	NearbyHints              = api.NearbyHints
+)
+
+// 群组、分享与交换。
+type (
+	Group              = api.Group
+	GroupRequest       = api.GroupRequest
+	GroupJoinRequest   = api.GroupJoinRequest
+	GroupDetail        = api.GroupDetail
+	GroupViewer        = api.GroupViewer
+	GroupListResponse  = api.GroupListResponse
+	InviteCodeRequest  = api.InviteCodeRequest
+	GroupLeaderboard   = api.GroupLeaderboard
+	GroupFeed          = api.GroupFeed
+	SpiritShare        = api.SpiritShare
+	SpiritCard         = api.SpiritCard
+	SpiritShareRequest = api.SpiritShareRequest
+	TradeProposal      = api.TradeProposal
+	TradeActionRequest = api.TradeActionRequest
+	TradeDetail        = api.TradeDetail
+	TradeListResponse  = api.TradeListResponse
+)
+
+// 管理接口。
+type (
+	BadgeRule                        = api.BadgeRule
+	BadgeRuleListResponse            = api.BadgeRuleListResponse
+	BadgeCatalogStatus               = api.BadgeCatalogStatus
+	DeliverySubscription             = api.DeliverySubscription
+	DeliverySubscriptionRequest      = api.DeliverySubscriptionRequest
+	DeliverySubscriptionListResponse = api.DeliverySubscriptionListResponse
+	DeliveryAttempt                  = api.DeliveryAttempt
+	DeliveryAttemptListResponse      = api.DeliveryAttemptListResponse
+)
+
+type (
+	HealthResponse = api.HealthResponse
+	ErrorResponse  = api.ErrorResponse
+)
+
+// 交换操作，用于 RespondTrade。
+const (
+	TradeActionAccept  = api.TradeActionAccept
+	TradeActionDecline = api.TradeActionDecline
+	TradeActionCancel  = api.TradeActionCancel
+)